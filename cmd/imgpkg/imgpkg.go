@@ -31,6 +31,10 @@ func main() {
 	err := command.Execute()
 	if err != nil {
 		confUI.ErrorLinef("imgpkg: Error: %v", uierrs.NewMultiLineError(err))
+		confUI.Flush()
+		if exitErr, ok := err.(cmd.ExitCodeError); ok {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 
@@ -4,6 +4,7 @@
 package bundle
 
 import (
+	"bytes"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -22,6 +23,9 @@ import (
 
 const (
 	BundleConfigLabel = "dev.carvel.imgpkg.bundle"
+
+	// defaultPullConcurrency number of nested bundles pulled in parallel
+	defaultPullConcurrency = 5
 )
 
 // Logger Interface used for logging
@@ -53,20 +57,41 @@ type Bundle struct {
 	// discovered as part of reading the bundle.
 	// Includes refs only directly referenced by the bundle.
 	cachedImageRefs imageRefCache
+
+	pullConcurrency  int
+	fallbackToOrigin bool
+
+	// existenceCache shares image existence lookups between the bundle and its
+	// nested bundles during a single pull
+	existenceCache *ImagesExistenceCache
 }
 
 func NewBundle(ref string, imagesMetadata ImagesMetadata) *Bundle {
-	return NewBundleWithReader(ref, imagesMetadata, &singleLayerReader{})
+	return NewBundleWithReader(ref, imagesMetadata, NewCachedImagesLockReader(&singleLayerReader{}))
 }
 
 func NewBundleFromPlainImage(plainImg *plainimg.PlainImage, imagesMetadata ImagesMetadata) *Bundle {
 	return &Bundle{plainImg: plainImg, imgRetriever: imagesMetadata,
-		imagesLockReader: &singleLayerReader{}}
+		imagesLockReader: NewCachedImagesLockReader(&singleLayerReader{}), pullConcurrency: defaultPullConcurrency}
 }
 
 func NewBundleWithReader(ref string, imagesMetadata ImagesMetadata, imagesLockReader ImagesLockReader) *Bundle {
 	return &Bundle{plainImg: plainimg.NewPlainImage(ref, imagesMetadata),
-		imgRetriever: imagesMetadata, imagesLockReader: imagesLockReader}
+		imgRetriever: imagesMetadata, imagesLockReader: imagesLockReader, pullConcurrency: defaultPullConcurrency}
+}
+
+// SetPullConcurrency configures the number of nested bundles pulled in parallel
+func (o *Bundle) SetPullConcurrency(concurrency int) {
+	if concurrency > 0 {
+		o.pullConcurrency = concurrency
+	}
+}
+
+// SetFallbackToOrigin when enabled, images missing from the bundle's repository
+// keep their original reference during pull instead of the bundle being treated
+// as not relocated
+func (o *Bundle) SetFallbackToOrigin(fallback bool) {
+	o.fallbackToOrigin = fallback
 }
 
 // DigestRef Bundle full location including registry, repository and digest
@@ -148,8 +173,19 @@ func (o *Bundle) NoteCopy(processedImages *imageset.ProcessedImages, reg ImagesM
 	return NewLocations(ui).Save(reg, destinationRef, locationsCfg, goui.NewNoopUI())
 }
 
-func (o *Bundle) Pull(outputPath string, ui goui.UI, pullNestedBundles bool) error {
-	isRootBundleRelocated, err := o.pull(outputPath, ui, pullNestedBundles, "", map[string]bool{}, 0)
+func (o *Bundle) Pull(outputPath string, ui goui.UI, pullNestedBundles bool, imgOpts ...ctlimg.DirImageOpt) error {
+	return o.pullBundle(outputPath, ui, pullNestedBundles, false, imgOpts)
+}
+
+// PullRewritingManifests behaves like Pull and additionally rewrites image
+// references inside the bundle's YAML manifests to their relocated locations,
+// using the same resolution as the ImagesLock rewrite
+func (o *Bundle) PullRewritingManifests(outputPath string, ui goui.UI, pullNestedBundles bool, imgOpts ...ctlimg.DirImageOpt) error {
+	return o.pullBundle(outputPath, ui, pullNestedBundles, true, imgOpts)
+}
+
+func (o *Bundle) pullBundle(outputPath string, ui goui.UI, pullNestedBundles bool, rewriteManifests bool, imgOpts []ctlimg.DirImageOpt) error {
+	isRootBundleRelocated, err := o.pull(outputPath, ui, pullNestedBundles, rewriteManifests, "", newProcessedBundles(), 0, imgOpts)
 	if err != nil {
 		return err
 	}
@@ -163,7 +199,7 @@ func (o *Bundle) Pull(outputPath string, ui goui.UI, pullNestedBundles bool) err
 	return nil
 }
 
-func (o *Bundle) pull(baseOutputPath string, ui goui.UI, pullNestedBundles bool, bundlePath string, imagesProcessed map[string]bool, numSubBundles int) (bool, error) {
+func (o *Bundle) pull(baseOutputPath string, ui goui.UI, pullNestedBundles bool, rewriteManifests bool, bundlePath string, imagesProcessed *processedBundles, numSubBundles int, imgOpts []ctlimg.DirImageOpt) (bool, error) {
 	img, err := o.checkedImage()
 	if err != nil {
 		return false, err
@@ -180,7 +216,7 @@ func (o *Bundle) pull(baseOutputPath string, ui goui.UI, pullNestedBundles bool,
 		return false, err
 	}
 
-	err = ctlimg.NewDirImage(filepath.Join(baseOutputPath, bundlePath), img, goui.NewIndentingUI(ui)).AsDirectory()
+	err = ctlimg.NewDirImage(filepath.Join(baseOutputPath, bundlePath), img, goui.NewIndentingUI(ui), imgOpts...).AsDirectory()
 	if err != nil {
 		return false, fmt.Errorf("Extracting bundle into directory: %s", err)
 	}
@@ -199,22 +235,30 @@ func (o *Bundle) pull(baseOutputPath string, ui goui.UI, pullNestedBundles bool,
 		return false, err
 	}
 
-	isRelocatedToBundle, err := bundleImageRefs.UpdateRelativeToRepo(o.imgRetriever, o.Repo())
+	if o.existenceCache == nil {
+		o.existenceCache = NewImagesExistenceCache()
+	}
+	bundleImageRefs.SetExistenceCache(o.existenceCache)
+
+	isRelocatedToBundle, err := o.updateRefsRelativeToRepo(bundleImageRefs, ui)
 	if err != nil {
 		return false, err
 	}
 
 	if pullNestedBundles {
+		var subBundles []nestedBundle
 		for _, bundleImgRef := range bundleImageRefs.ImageRefs() {
-			if isBundle, alreadyProcessedImage := imagesProcessed[bundleImgRef.Image]; alreadyProcessedImage {
+			if isBundle, alreadyProcessedImage := imagesProcessed.Processed(bundleImgRef.Image); alreadyProcessedImage {
 				if isBundle {
-					goui.NewIndentingUI(ui).BeginLinef("Pulling nested bundle '%s'\n", bundleImgRef.Image)
-					goui.NewIndentingUI(ui).BeginLinef("Skipped, already downloaded\n")
+					subBundles = append(subBundles, nestedBundle{imageRef: bundleImgRef.Image, skipped: true})
 				}
 				continue
 			}
 
 			subBundle := NewBundle(bundleImgRef.PrimaryLocation(), o.imgRetriever)
+			subBundle.pullConcurrency = o.pullConcurrency
+			subBundle.fallbackToOrigin = o.fallbackToOrigin
+			subBundle.existenceCache = o.existenceCache
 
 			var isBundle bool
 			if bundleImgRef.IsBundle != nil {
@@ -226,7 +270,9 @@ func (o *Bundle) pull(baseOutputPath string, ui goui.UI, pullNestedBundles bool,
 				}
 			}
 
-			imagesProcessed[bundleImgRef.Image] = isBundle
+			if !imagesProcessed.MarkProcessed(bundleImgRef.Image, isBundle) {
+				continue
+			}
 
 			if !isBundle {
 				continue
@@ -241,10 +287,20 @@ func (o *Bundle) pull(baseOutputPath string, ui goui.UI, pullNestedBundles bool,
 			if err != nil {
 				return false, err
 			}
-			_, err = subBundle.pull(baseOutputPath, goui.NewIndentingUI(ui), pullNestedBundles, o.subBundlePath(bundleDigest), imagesProcessed, numSubBundles)
-			if err != nil {
-				return false, err
+
+			subBundlePath := o.subBundlePath(bundleDigest)
+			if !imagesProcessed.MarkProcessed(subBundlePath, true) {
+				// Same bundle digest already extracted under a different reference
+				subBundles = append(subBundles, nestedBundle{imageRef: bundleImgRef.Image, skipped: true})
+				continue
 			}
+
+			subBundles = append(subBundles, nestedBundle{bundle: subBundle, imageRef: bundleImgRef.Image, path: subBundlePath})
+		}
+
+		err = o.pullNestedBundles(baseOutputPath, ui, subBundles, rewriteManifests, imagesProcessed, numSubBundles, imgOpts)
+		if err != nil {
+			return false, err
 		}
 	}
 
@@ -253,13 +309,161 @@ func (o *Bundle) pull(baseOutputPath string, ui goui.UI, pullNestedBundles bool,
 		if err != nil {
 			return false, fmt.Errorf("Rewriting image lock file: %s", err)
 		}
+
+		if rewriteManifests {
+			err := rewriteManifestRefs(filepath.Join(baseOutputPath, bundlePath), bundleImageRefs)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		err = writeRelocatedValuesOverlay(filepath.Join(baseOutputPath, bundlePath), bundleImageRefs, ui)
+		if err != nil {
+			return false, err
+		}
 	}
 
 	return isRelocatedToBundle, nil
 }
 
+// updateRefsRelativeToRepo resolves bundleImageRefs against the bundle's
+// repository. With fallback to origin enabled, images the repository lacks keep
+// their original reference and are reported, instead of the whole bundle being
+// treated as not relocated
+func (o *Bundle) updateRefsRelativeToRepo(bundleImageRefs ImageRefs, ui goui.UI) (bool, error) {
+	if !o.fallbackToOrigin {
+		return bundleImageRefs.UpdateRelativeToRepo(o.imgRetriever, o.Repo())
+	}
+
+	localized, missingRefs, err := bundleImageRefs.LocalizeFoundToRepo(o.imgRetriever, o.Repo())
+	if err != nil {
+		return false, err
+	}
+	if !localized && len(missingRefs) > 0 {
+		return false, nil
+	}
+
+	for _, missingRef := range missingRefs {
+		ui.BeginLinef("Warning: Image '%s' is not present in repository '%s', keeping its original reference\n", missingRef, o.Repo())
+	}
+	return true, nil
+}
+
+// nestedBundle nested bundle selected to be pulled and the directory, relative
+// to the root bundle's output path, it is extracted into. Bundles that were
+// already downloaded are only reported as skipped
+type nestedBundle struct {
+	bundle   *Bundle
+	imageRef string
+	path     string
+	skipped  bool
+}
+
+// pullNestedBundles pulls subBundles, in parallel when more than one was
+// selected. Each parallel pull buffers its output and the buffers are printed
+// in selection order once every pull finished, keeping the output and the
+// directory layout deterministic. Each recursion level throttles its own
+// nested bundles, so a deep chain of bundles cannot deadlock on a shared limit
+func (o *Bundle) pullNestedBundles(baseOutputPath string, ui goui.UI, subBundles []nestedBundle, rewriteManifests bool, imagesProcessed *processedBundles, numSubBundles int, imgOpts []ctlimg.DirImageOpt) error {
+	if len(subBundles) == 0 {
+		return nil
+	}
+
+	if o.pullConcurrency <= 1 || len(subBundles) == 1 {
+		for _, subBundle := range subBundles {
+			if subBundle.skipped {
+				o.printSkippedSubBundle(ui, subBundle)
+				continue
+			}
+			_, err := subBundle.bundle.pull(baseOutputPath, goui.NewIndentingUI(ui), true, rewriteManifests, subBundle.path, imagesProcessed, numSubBundles, imgOpts)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	pullThrottle := util.NewThrottle(o.pullConcurrency)
+
+	outputBuffers := make([]*bytes.Buffer, len(subBundles))
+	errCh := make(chan error, len(subBundles))
+	for i, subBundle := range subBundles {
+		i, subBundle := i, subBundle // copy
+		outputBuffers[i] = &bytes.Buffer{}
+
+		go func() {
+			pullThrottle.Take()
+			defer pullThrottle.Done()
+
+			bufferedUI := goui.NewWriterUI(outputBuffers[i], outputBuffers[i], nil)
+			if subBundle.skipped {
+				o.printSkippedSubBundle(bufferedUI, subBundle)
+				errCh <- nil
+				return
+			}
+			_, err := subBundle.bundle.pull(baseOutputPath, goui.NewIndentingUI(bufferedUI), true, rewriteManifests, subBundle.path, imagesProcessed, numSubBundles, imgOpts)
+			errCh <- err
+		}()
+	}
+
+	var firstErr error
+	for range subBundles {
+		if err := <-errCh; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for _, buffer := range outputBuffers {
+		if buffer.Len() > 0 {
+			ui.PrintBlock(buffer.Bytes())
+		}
+	}
+	return firstErr
+}
+
+func (o *Bundle) printSkippedSubBundle(ui goui.UI, subBundle nestedBundle) {
+	goui.NewIndentingUI(ui).BeginLinef("Pulling nested bundle '%s'\n", subBundle.imageRef)
+	goui.NewIndentingUI(ui).BeginLinef("Skipped, already downloaded\n")
+}
+
+// processedBundles tracks the images visited while pulling nested bundles, so
+// a bundle referenced from multiple parents is only downloaded once even when
+// parents are pulled in parallel
+type processedBundles struct {
+	processed map[string]bool
+	mutex     *sync.Mutex
+}
+
+func newProcessedBundles() *processedBundles {
+	return &processedBundles{
+		processed: map[string]bool{},
+		mutex:     &sync.Mutex{},
+	}
+}
+
+// Processed whether imageRef was visited already and, if so, whether it is a bundle
+func (t *processedBundles) Processed(imageRef string) (bool, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	isBundle, found := t.processed[imageRef]
+	return isBundle, found
+}
+
+// MarkProcessed marks imageRef as visited, returning false when another caller
+// already claimed it
+func (t *processedBundles) MarkProcessed(imageRef string, isBundle bool) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if _, found := t.processed[imageRef]; found {
+		return false
+	}
+	t.processed[imageRef] = isBundle
+	return true
+}
+
 func (*Bundle) subBundlePath(bundleDigest regname.Digest) string {
-	return filepath.Join(ImgpkgDir, BundlesDir, strings.ReplaceAll(bundleDigest.DigestStr(), "sha256:", "sha256-"))
+	// sha256:... -> sha256-..., preserving whichever digest algorithm is used
+	return filepath.Join(ImgpkgDir, BundlesDir, strings.Replace(bundleDigest.DigestStr(), ":", "-", 1))
 }
 
 func (o *Bundle) shouldPrintNestedBundlesHeader(bundlePath string, bundlesProcessed int) bool {
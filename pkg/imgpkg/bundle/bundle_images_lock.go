@@ -25,9 +25,18 @@ func (o *Bundle) ImagesRefs() []ImageRef {
 
 // AllImagesLockRefs returns a flat list of nested bundles and every image reference for a specific bundle
 func (o *Bundle) AllImagesLockRefs(concurrency int, ui util.UIWithLevels) ([]*Bundle, ImageRefs, error) {
+	return o.AllImagesLockRefsWithDepth(concurrency, ui, -1)
+}
+
+// AllImagesLockRefsWithDepth is AllImagesLockRefs limited to depth levels of
+// image references: 1 includes only the images directly referenced by the
+// bundle (nested bundles are not expanded), negative values do not limit the
+// traversal
+func (o *Bundle) AllImagesLockRefsWithDepth(concurrency int, ui util.UIWithLevels, depth int) ([]*Bundle, ImageRefs, error) {
 	throttleReq := util.NewThrottle(concurrency)
+	regThrottle := util.NewRegistryThrottle(concurrency)
 
-	bundles, allImageRefs, err := o.buildAllImagesLock(&throttleReq, &processedImages{processedImgs: map[string]struct{}{}}, ui)
+	bundles, allImageRefs, err := o.buildAllImagesLock(&throttleReq, regThrottle, &processedImages{processedImgs: map[string]struct{}{}}, ui, depth)
 	if err != nil {
 		return nil, ImageRefs{}, err
 	}
@@ -82,7 +91,7 @@ func (o *Bundle) UpdateImageRefs(bundles []*Bundle) error {
 	return nil
 }
 
-func (o *Bundle) buildAllImagesLock(throttleReq *util.Throttle, processedImgs *processedImages, ui util.UIWithLevels) ([]*Bundle, ImageRefs, error) {
+func (o *Bundle) buildAllImagesLock(throttleReq *util.Throttle, regThrottle *util.RegistryThrottle, processedImgs *processedImages, ui util.UIWithLevels, levelsLeft int) ([]*Bundle, ImageRefs, error) {
 	o.cachedImageRefs = newImageRefCache()
 
 	img, err := o.checkedImage()
@@ -128,9 +137,19 @@ func (o *Bundle) buildAllImagesLock(throttleReq *util.Throttle, processedImgs *p
 			continue
 		}
 
+		// At the traversal depth limit images are recorded as plain content
+		// without expanding nested bundles
+		if levelsLeft == 1 {
+			typedImageRef := NewContentImageRef(image.ImageRef).DeepCopy()
+			processedImageRefs.AddImagesRef(typedImageRef)
+			o.cachedImageRefs.StoreImageRef(typedImageRef)
+			errChan <- nil
+			continue
+		}
+
 		image := image.DeepCopy()
 		go func() {
-			nestedBundles, nestedBundlesProcessedImageRefs, imgRef, err := o.imagesLockIfIsBundle(throttleReq, image, processedImgs, ui)
+			nestedBundles, nestedBundlesProcessedImageRefs, imgRef, err := o.imagesLockIfIsBundle(throttleReq, regThrottle, image, processedImgs, ui, nextTraversalLevel(levelsLeft))
 			if err != nil {
 				errChan <- err
 				return
@@ -184,12 +203,15 @@ func (o *Bundle) fetchImagesRef(img regv1.Image, locationsConfig ImageRefLocatio
 	return refs, nil
 }
 
-func (o *Bundle) imagesLockIfIsBundle(throttleReq *util.Throttle, imgRef ImageRef, processedImgs *processedImages, ui util.UIWithLevels) ([]*Bundle, ImageRefs, lockconfig.ImageRef, error) {
+func (o *Bundle) imagesLockIfIsBundle(throttleReq *util.Throttle, regThrottle *util.RegistryThrottle, imgRef ImageRef, processedImgs *processedImages, ui util.UIWithLevels, levelsLeft int) ([]*Bundle, ImageRefs, lockconfig.ImageRef, error) {
+	registry := registryHostname(imgRef.PrimaryLocation())
 	throttleReq.Take()
+	regThrottle.Take(registry)
 	// We need to check where we can find the image we are looking for.
 	// First checks the current bundle repository and if it cannot be found there
 	// it will check in the original location of the image
 	imgURL, err := o.imgRetriever.FirstImageExists(imgRef.Locations())
+	regThrottle.Done(registry)
 	throttleReq.Done()
 	if err != nil {
 		return nil, ImageRefs{}, lockconfig.ImageRef{}, err
@@ -198,8 +220,11 @@ func (o *Bundle) imagesLockIfIsBundle(throttleReq *util.Throttle, imgRef ImageRe
 
 	bundle := NewBundleWithReader(newImgRef.PrimaryLocation(), o.imgRetriever, o.imagesLockReader)
 
+	registry = registryHostname(newImgRef.PrimaryLocation())
 	throttleReq.Take()
+	regThrottle.Take(registry)
 	isBundle, err := bundle.IsBundle()
+	regThrottle.Done(registry)
 	throttleReq.Done()
 	if err != nil {
 		return nil, ImageRefs{}, lockconfig.ImageRef{}, fmt.Errorf("Checking if '%s' is a bundle: %s", imgRef.Image, err)
@@ -208,7 +233,7 @@ func (o *Bundle) imagesLockIfIsBundle(throttleReq *util.Throttle, imgRef ImageRe
 	var processedImageRefs ImageRefs
 	var nestedBundles []*Bundle
 	if isBundle {
-		nestedBundles, processedImageRefs, err = bundle.buildAllImagesLock(throttleReq, processedImgs, ui)
+		nestedBundles, processedImageRefs, err = bundle.buildAllImagesLock(throttleReq, regThrottle, processedImgs, ui, levelsLeft)
 		if err != nil {
 			return nil, ImageRefs{}, lockconfig.ImageRef{}, fmt.Errorf("Retrieving images for bundle '%s': %s", imgRef.Image, err)
 		}
@@ -216,6 +241,25 @@ func (o *Bundle) imagesLockIfIsBundle(throttleReq *util.Throttle, imgRef ImageRe
 	return nestedBundles, processedImageRefs, newImgRef, nil
 }
 
+// nextTraversalLevel decrements the remaining traversal levels, leaving
+// negative (unlimited) values untouched
+func nextTraversalLevel(levelsLeft int) int {
+	if levelsLeft < 0 {
+		return levelsLeft
+	}
+	return levelsLeft - 1
+}
+
+// registryHostname returns the registry of the provided image reference,
+// used to key the per-registry request limiter
+func registryHostname(ref string) string {
+	parsedRef, err := regname.ParseReference(ref, regname.WeakValidation)
+	if err != nil {
+		return ""
+	}
+	return parsedRef.Context().RegistryStr()
+}
+
 type processedImages struct {
 	lock          sync.Mutex
 	processedImgs map[string]struct{}
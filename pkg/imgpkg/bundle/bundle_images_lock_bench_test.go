@@ -0,0 +1,51 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle_test
+
+import (
+	"fmt"
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+// BenchmarkBundleAllImagesLockRefs measures resolution of every image
+// referenced by a bundle at different concurrency settings
+// (ex: go test -bench=AllImagesLockRefs -run=XXX ./pkg/imgpkg/bundle/)
+func BenchmarkBundleAllImagesLockRefs(b *testing.B) {
+	logger := &helpers.Logger{LogLevel: helpers.LogDebug}
+
+	fakeRegistry := helpers.NewFakeRegistry(b, logger)
+	defer fakeRegistry.CleanUp()
+
+	var imageRefs []lockconfig.ImageRef
+	for i := 0; i < 20; i++ {
+		img := fakeRegistry.WithRandomImage(fmt.Sprintf("repo/benchmark-img-%d", i))
+		imageRefs = append(imageRefs, lockconfig.ImageRef{Image: img.RefDigest})
+	}
+	bundleInfo := fakeRegistry.WithRandomBundle("repo/benchmark-bundle").WithImageRefs(imageRefs)
+	reg := fakeRegistry.Build()
+
+	confUI := goui.NewConfUI(goui.NewNoopLogger())
+	defer confUI.Flush()
+	uiLogger := util.NewUILevelLogger(util.LogWarn, confUI)
+
+	for _, concurrency := range []int{1, 5, 10} {
+		concurrency := concurrency
+
+		b.Run(fmt.Sprintf("concurrency-%d", concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				subject := bundle.NewBundle(bundleInfo.RefDigest, reg)
+				_, _, err := subject.AllImagesLockRefs(concurrency, uiLogger)
+				if err != nil {
+					b.Fatalf("not expecting resolution to fail: %s", err)
+				}
+			}
+		})
+	}
+}
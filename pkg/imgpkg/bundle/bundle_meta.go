@@ -0,0 +1,83 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// BundleMetaFile name of the optional metadata file inside the .imgpkg directory
+	BundleMetaFile = "bundle.yml"
+
+	BundleMetaKind       = "Bundle"
+	BundleMetaAPIVersion = "imgpkg.carvel.dev/v1alpha1"
+)
+
+// BundleMeta metadata provided by bundle authors in .imgpkg/bundle.yml
+type BundleMeta struct {
+	APIVersion string            `json:"apiVersion"` // This generated yaml, but due to lib we need to use `json`
+	Kind       string            `json:"kind"`       // This generated yaml, but due to lib we need to use `json`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Authors    []Author          `json:"authors,omitempty"`
+	Websites   []Website         `json:"websites,omitempty"`
+}
+
+// NewBundleMetaFromPath reads and validates the bundle metadata file at path.
+// When allowUnknownFields is set, fields this version of imgpkg does not know
+// about are tolerated instead of rejected.
+func NewBundleMetaFromPath(path string, allowUnknownFields bool) (BundleMeta, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return BundleMeta{}, fmt.Errorf("Reading path %s: %s", path, err)
+	}
+
+	return NewBundleMetaFromBytes(bs, allowUnknownFields)
+}
+
+// NewBundleMetaFromBytes parses and validates bundle metadata
+func NewBundleMetaFromBytes(data []byte, allowUnknownFields bool) (BundleMeta, error) {
+	var meta BundleMeta
+
+	unmarshal := yaml.UnmarshalStrict
+	if allowUnknownFields {
+		unmarshal = yaml.Unmarshal
+	}
+
+	err := unmarshal(data, &meta)
+	if err != nil {
+		return meta, fmt.Errorf("Unmarshaling bundle metadata: %s (hint: to accept fields introduced by newer imgpkg versions use --allow-unknown-bundle-fields)", err)
+	}
+
+	err = meta.Validate()
+	if err != nil {
+		return meta, fmt.Errorf("Validating bundle metadata: %s", err)
+	}
+
+	return meta, nil
+}
+
+// Validate checks the bundle metadata for required fields
+func (m BundleMeta) Validate() error {
+	if m.APIVersion != BundleMetaAPIVersion {
+		return fmt.Errorf("Validating apiVersion: Unknown version (known: %s)", BundleMetaAPIVersion)
+	}
+	if m.Kind != BundleMetaKind {
+		return fmt.Errorf("Validating kind: Unknown kind (known: %s)", BundleMetaKind)
+	}
+	for i, author := range m.Authors {
+		if author.Name == "" && author.Email == "" {
+			return fmt.Errorf("Validating authors[%d]: Expected name or email to be provided", i)
+		}
+	}
+	for i, website := range m.Websites {
+		if website.URL == "" {
+			return fmt.Errorf("Validating websites[%d]: Expected url to be provided", i)
+		}
+	}
+	return nil
+}
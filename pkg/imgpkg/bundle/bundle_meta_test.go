@@ -0,0 +1,85 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+)
+
+func TestNewBundleMetaFromBytes(t *testing.T) {
+	t.Run("parses valid metadata", func(t *testing.T) {
+		data := `---
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: Bundle
+metadata:
+  name: my-app
+authors:
+- name: blah
+  email: blah@blah.com
+websites:
+- url: blah.com
+`
+		meta, err := bundle.NewBundleMetaFromBytes([]byte(data), false)
+		require.NoError(t, err)
+		assert.Equal(t, "my-app", meta.Metadata["name"])
+		require.Len(t, meta.Authors, 1)
+		assert.Equal(t, "blah", meta.Authors[0].Name)
+		require.Len(t, meta.Websites, 1)
+		assert.Equal(t, "blah.com", meta.Websites[0].URL)
+	})
+
+	t.Run("when apiVersion is unknown, it errors", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v2
+kind: Bundle
+`
+		_, err := bundle.NewBundleMetaFromBytes([]byte(data), false)
+		require.EqualError(t, err, "Validating bundle metadata: Validating apiVersion: Unknown version (known: imgpkg.carvel.dev/v1alpha1)")
+	})
+
+	t.Run("when kind is unknown, it errors", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: NotABundle
+`
+		_, err := bundle.NewBundleMetaFromBytes([]byte(data), false)
+		require.EqualError(t, err, "Validating bundle metadata: Validating kind: Unknown kind (known: Bundle)")
+	})
+
+	t.Run("when author has no name or email, it errors", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: Bundle
+authors:
+- {}
+`
+		_, err := bundle.NewBundleMetaFromBytes([]byte(data), false)
+		require.EqualError(t, err, "Validating bundle metadata: Validating authors[0]: Expected name or email to be provided")
+	})
+
+	t.Run("when yaml contains keys that are unknown, it errors", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: Bundle
+another-key: invalid
+`
+		_, err := bundle.NewBundleMetaFromBytes([]byte(data), false)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `unknown field "another-key"`)
+	})
+
+	t.Run("when unknown fields are allowed, unknown keys are tolerated", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: Bundle
+another-key: from-the-future
+`
+		_, err := bundle.NewBundleMetaFromBytes([]byte(data), true)
+		require.NoError(t, err)
+	})
+}
@@ -288,6 +288,83 @@ kind: ImagesLock
 	})
 }
 
+func TestPullBundleFallbackToOrigin(t *testing.T) {
+	fakeUI := &bundlefakes.FakeUI{}
+	pullNestedBundles := false
+
+	t.Run("partially relocated bundle keeps original references for missing images", func(t *testing.T) {
+		fakePublicRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+		defer fakePublicRegistry.CleanUp()
+
+		fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+		defer fakeRegistry.CleanUp()
+
+		relocatedImage := fakeRegistry.WithRandomImage("repo/partial-bundle")
+		originalImage := fakePublicRegistry.WithImage("library/image1", relocatedImage.Image)
+		missingImage := fakePublicRegistry.WithRandomImage("library/image2")
+
+		partialBundle := fakeRegistry.WithBundleFromPath("repo/partial-bundle", "test_assets/bundle_with_mult_images").WithImageRefs([]lockconfig.ImageRef{
+			{Image: originalImage.RefDigest},
+			{Image: missingImage.RefDigest},
+		})
+
+		fakePublicRegistry.Build()
+		subject := bundle.NewBundle(partialBundle.RefDigest, fakeRegistry.Build())
+		subject.SetFallbackToOrigin(true)
+		outputPath, err := os.MkdirTemp(os.TempDir(), "test-output-bundle-path")
+		assert.NoError(t, err)
+		defer os.Remove(outputPath)
+
+		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		assert.NoError(t, err)
+
+		imagesYmlFile := filepath.Join(outputPath, ".imgpkg", "images.yml")
+		assert.FileExists(t, imagesYmlFile)
+		actualImagesYmlFile, err := os.ReadFile(imagesYmlFile)
+		assert.NoError(t, err)
+
+		// the written lock is sorted by image ref, and the fake registries use
+		// random ports, so compare the parsed entries instead of the raw yaml
+		actualLock, err := lockconfig.NewImagesLockFromBytes(actualImagesYmlFile)
+		assert.NoError(t, err)
+		var actualRefs []string
+		for _, image := range actualLock.Images {
+			actualRefs = append(actualRefs, image.Image)
+		}
+		assert.ElementsMatch(t, []string{relocatedImage.RefDigest, missingImage.RefDigest}, actualRefs)
+	})
+
+	t.Run("bundle with no relocated images is left untouched", func(t *testing.T) {
+		fakePublicRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+		defer fakePublicRegistry.CleanUp()
+
+		fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+		defer fakeRegistry.CleanUp()
+
+		missingImage := fakePublicRegistry.WithRandomImage("library/image1")
+
+		fakeRegistry.WithBundleFromPath("repo/not-relocated-bundle", "test_assets/bundle_with_mult_images").WithImageRefs([]lockconfig.ImageRef{
+			{Image: missingImage.RefDigest},
+		})
+
+		fakePublicRegistry.Build()
+		subject := bundle.NewBundle(fakeRegistry.ReferenceOnTestServer("repo/not-relocated-bundle"), fakeRegistry.Build())
+		subject.SetFallbackToOrigin(true)
+		outputPath, err := os.MkdirTemp(os.TempDir(), "test-output-bundle-path")
+		assert.NoError(t, err)
+		defer os.Remove(outputPath)
+
+		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		assert.NoError(t, err)
+
+		imagesYmlFile := filepath.Join(outputPath, ".imgpkg", "images.yml")
+		assert.FileExists(t, imagesYmlFile)
+		actualImagesYmlFile, err := os.ReadFile(imagesYmlFile)
+		assert.NoError(t, err)
+		assert.Contains(t, string(actualImagesYmlFile), missingImage.RefDigest)
+	})
+}
+
 func TestPullNestedBundlesLocalizesImagesLockFileWithLocationOCI(t *testing.T) {
 	fakeUI := &bundlefakes.FakeUI{}
 	pullNestedBundles := true
@@ -560,6 +637,79 @@ kind: ImagesLock
 	})
 }
 
+func TestPullBundleRewritingManifests(t *testing.T) {
+	fakeUI := &bundlefakes.FakeUI{}
+	pullNestedBundles := false
+
+	t.Run("relocated bundle's manifests reference images in the bundle repo", func(t *testing.T) {
+		fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+		defer fakeRegistry.CleanUp()
+
+		randomImage := fakeRegistry.WithRandomImage("library/image1")
+
+		bundleDir, err := os.MkdirTemp(os.TempDir(), "test-bundle-with-manifests")
+		assert.NoError(t, err)
+		defer os.RemoveAll(bundleDir)
+		assert.NoError(t, os.Mkdir(filepath.Join(bundleDir, ".imgpkg"), 0700))
+		manifest := fmt.Sprintf(`---
+apiVersion: v1
+kind: Pod
+spec:
+  containers:
+  - image: %s
+`, randomImage.RefDigest)
+		assert.NoError(t, os.WriteFile(filepath.Join(bundleDir, "config.yml"), []byte(manifest), 0600))
+
+		bundleInfo := fakeRegistry.WithBundleFromPath("repo/app-bundle", bundleDir).WithImageRefs([]lockconfig.ImageRef{
+			{Image: randomImage.RefDigest},
+		})
+		relocatedImage := fakeRegistry.WithImage("repo/app-bundle", randomImage.Image)
+
+		subject := bundle.NewBundle(bundleInfo.RefDigest, fakeRegistry.Build())
+		outputPath, err := os.MkdirTemp(os.TempDir(), "test-output-bundle-path")
+		assert.NoError(t, err)
+		defer os.RemoveAll(outputPath)
+
+		err = subject.PullRewritingManifests(outputPath, fakeUI, pullNestedBundles)
+		assert.NoError(t, err)
+
+		rewrittenManifest, err := os.ReadFile(filepath.Join(outputPath, "config.yml"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(rewrittenManifest), relocatedImage.RefDigest)
+		assert.NotContains(t, string(rewrittenManifest), randomImage.RefDigest)
+	})
+
+	t.Run("bundle that was not relocated keeps its manifests untouched", func(t *testing.T) {
+		fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+		defer fakeRegistry.CleanUp()
+
+		randomImage := fakeRegistry.WithRandomImage("library/image1")
+
+		bundleDir, err := os.MkdirTemp(os.TempDir(), "test-bundle-with-manifests")
+		assert.NoError(t, err)
+		defer os.RemoveAll(bundleDir)
+		assert.NoError(t, os.Mkdir(filepath.Join(bundleDir, ".imgpkg"), 0700))
+		manifest := fmt.Sprintf("---\nimage: %s\n", randomImage.RefDigest)
+		assert.NoError(t, os.WriteFile(filepath.Join(bundleDir, "config.yml"), []byte(manifest), 0600))
+
+		bundleInfo := fakeRegistry.WithBundleFromPath("repo/app-bundle", bundleDir).WithImageRefs([]lockconfig.ImageRef{
+			{Image: randomImage.RefDigest},
+		})
+
+		subject := bundle.NewBundle(bundleInfo.RefDigest, fakeRegistry.Build())
+		outputPath, err := os.MkdirTemp(os.TempDir(), "test-output-bundle-path")
+		assert.NoError(t, err)
+		defer os.RemoveAll(outputPath)
+
+		err = subject.PullRewritingManifests(outputPath, fakeUI, pullNestedBundles)
+		assert.NoError(t, err)
+
+		pulledManifest, err := os.ReadFile(filepath.Join(outputPath, "config.yml"))
+		assert.NoError(t, err)
+		assert.Equal(t, manifest, string(pulledManifest))
+	})
+}
+
 func TestPullBundleOutputToUser(t *testing.T) {
 	pullNestedBundles := false
 
@@ -738,16 +888,15 @@ Nested bundles
     Pulling nested bundle '%s'
       Extracting layer 'sha256:.*' \(1/1\)
   Pulling nested bundle '%s'
-    Extracting layer 'sha256:.*' \(1/1\)
-    Pulling nested bundle '%s'
-    Skipped, already downloaded
+  Skipped, already downloaded
 
 Locating image lock file images...
 One or more images not found in bundle repo; skipping lock file update`, bundleWithMultipleBundles.RefDigest,
-				ImageWithNonDistLayer.RefDigest,
-				applesBundle.RefDigest,
+				// the bundle's images.yml is sorted by image ref when written,
+				// so the frown bundle is processed before the non-distributable one
 				imageWithFrown.RefDigest,
-				applesBundle.RefDigest), output.String())
+				applesBundle.RefDigest,
+				ImageWithNonDistLayer.RefDigest), output.String())
 	})
 
 	t.Run("bundle referencing another bundle that references another bundle", func(t *testing.T) {
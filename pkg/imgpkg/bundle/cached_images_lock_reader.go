@@ -0,0 +1,62 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"sync"
+
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+)
+
+// NewCachedImagesLockReader wraps reader with a per-digest memo, so each
+// bundle's ImagesLock layer is read only once even when the same bundle is
+// referenced from several parents of the bundle graph or the graph is
+// resolved more than once during a single invocation
+func NewCachedImagesLockReader(reader ImagesLockReader) ImagesLockReader {
+	return &cachedImagesLockReader{delegate: reader, cache: map[string]lockconfig.ImagesLock{}}
+}
+
+type cachedImagesLockReader struct {
+	delegate ImagesLockReader
+	mutex    sync.Mutex
+	cache    map[string]lockconfig.ImagesLock
+}
+
+// Read returns a copy of the memoized ImagesLock for the image's digest,
+// delegating on the first request for each digest
+func (r *cachedImagesLockReader) Read(img regv1.Image) (lockconfig.ImagesLock, error) {
+	digest, err := img.Digest()
+	if err != nil {
+		return r.delegate.Read(img)
+	}
+
+	r.mutex.Lock()
+	imagesLock, found := r.cache[digest.String()]
+	r.mutex.Unlock()
+	if found {
+		return copyImagesLock(imagesLock), nil
+	}
+
+	imagesLock, err = r.delegate.Read(img)
+	if err != nil {
+		return lockconfig.ImagesLock{}, err
+	}
+
+	r.mutex.Lock()
+	r.cache[digest.String()] = copyImagesLock(imagesLock)
+	r.mutex.Unlock()
+
+	return imagesLock, nil
+}
+
+// copyImagesLock copies the lock so callers cannot mutate the memoized entry
+func copyImagesLock(imagesLock lockconfig.ImagesLock) lockconfig.ImagesLock {
+	lockCopy := imagesLock
+	lockCopy.Images = nil
+	for _, imgRef := range imagesLock.Images {
+		lockCopy.Images = append(lockCopy.Images, imgRef.DeepCopy())
+	}
+	return lockCopy
+}
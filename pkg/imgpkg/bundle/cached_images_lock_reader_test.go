@@ -0,0 +1,45 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle_test
+
+import (
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+)
+
+func TestCachedImagesLockReaderReadsEachDigestOnce(t *testing.T) {
+	img, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	otherImg, err := random.Image(1024, 1)
+	require.NoError(t, err)
+
+	fakeReader := &bundlefakes.FakeImagesLockReader{}
+	fakeReader.ReadReturns(lockconfig.ImagesLock{
+		Images: []lockconfig.ImageRef{{Image: "repo.io/img@sha256:aaaa", Annotations: map[string]string{"some": "annotation"}}},
+	}, nil)
+
+	reader := bundle.NewCachedImagesLockReader(fakeReader)
+
+	imagesLock, err := reader.Read(img)
+	require.NoError(t, err)
+	require.Len(t, imagesLock.Images, 1)
+
+	// Mutating the returned lock must not affect later reads
+	imagesLock.Images[0].Annotations["some"] = "changed"
+
+	imagesLock, err = reader.Read(img)
+	require.NoError(t, err)
+	assert.Equal(t, "annotation", imagesLock.Images[0].Annotations["some"])
+	assert.Equal(t, 1, fakeReader.ReadCallCount())
+
+	_, err = reader.Read(otherImg)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fakeReader.ReadCallCount())
+}
@@ -25,6 +25,34 @@ const (
 type Contents struct {
 	paths         []string
 	excludedPaths []string
+	opts          ContentsOpts
+}
+
+// ContentsOpts policies applied when validating and pushing bundle contents
+type ContentsOpts struct {
+	// AllowUnknownBundleFields tolerate unknown fields in .imgpkg/bundle.yml
+	AllowUnknownBundleFields bool
+	// FailOnConflict fail when multiple inputs provide the same path instead
+	// of overlaying (later inputs win)
+	FailOnConflict bool
+	// Reproducible normalize file modes and zero timestamps in the pushed
+	// layer so identical content always produces identical digests
+	Reproducible bool
+	// Estargz compress the pushed layer in the eStargz format so the bundle
+	// stays lazily pullable by stargz snapshotters
+	Estargz bool
+	// IncludeVCS keep VCS and editor artifacts (.git, .svn, .DS_Store, swap
+	// files) that are excluded by default
+	IncludeVCS bool
+	// DerefSymlinks follow symlinks and embed the target's contents instead of
+	// failing the push
+	DerefSymlinks bool
+	// StrictSymlinks error when a followed symlink points outside the pushed
+	// directory
+	StrictSymlinks bool
+	// Labels additional config labels recorded on the pushed bundle image
+	// (ex: provenance information)
+	Labels map[string]string
 }
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . ImagesMetadataWriter
@@ -38,6 +66,16 @@ func NewContents(paths []string, excludedPaths []string) Contents {
 	return Contents{paths: paths, excludedPaths: excludedPaths}
 }
 
+// NewContentsWithOpts constructor for Contents with explicit policies
+func NewContentsWithOpts(paths []string, excludedPaths []string, opts ContentsOpts) Contents {
+	return Contents{paths: paths, excludedPaths: excludedPaths, opts: opts}
+}
+
+// Validate checks the provided directories for a well formed bundle without pushing it
+func (b Contents) Validate() error {
+	return b.validate()
+}
+
 func (b Contents) Push(uploadRef regname.Tag, registry ImagesMetadataWriter, ui ui.UI) (string, error) {
 	err := b.validate()
 	if err != nil {
@@ -45,7 +83,18 @@ func (b Contents) Push(uploadRef regname.Tag, registry ImagesMetadataWriter, ui
 	}
 
 	labels := map[string]string{BundleConfigLabel: "true"}
-	return plainimage.NewContents(b.paths, b.excludedPaths).Push(uploadRef, labels, registry, ui)
+	for key, value := range b.opts.Labels {
+		labels[key] = value
+	}
+	contentsOpts := plainimage.ContentsOpts{
+		FailOnConflict: b.opts.FailOnConflict,
+		Reproducible:   b.opts.Reproducible,
+		Estargz:        b.opts.Estargz,
+		IncludeVCS:     b.opts.IncludeVCS,
+		DerefSymlinks:  b.opts.DerefSymlinks,
+		StrictSymlinks: b.opts.StrictSymlinks,
+	}
+	return plainimage.NewContentsWithOpts(b.paths, b.excludedPaths, contentsOpts).Push(uploadRef, labels, registry, ui)
 }
 
 func (b Contents) PresentsAsBundle() (bool, error) {
@@ -137,6 +186,13 @@ func (b Contents) validateImgpkgDirs(imgpkgDirs []string) error {
 				return bundleValidationError{msg}
 			}
 
+			metaPath := filepath.Join(path, BundleMetaFile)
+			if _, err := os.Stat(metaPath); err == nil {
+				if _, err := NewBundleMetaFromPath(metaPath, b.opts.AllowUnknownBundleFields); err != nil {
+					return fmt.Errorf("Validating %s: %s", metaPath, err)
+				}
+			}
+
 			return nil
 		}
 	}
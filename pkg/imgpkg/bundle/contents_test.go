@@ -4,6 +4,7 @@
 package bundle_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/google/go-containerregistry/pkg/name"
@@ -51,6 +52,39 @@ images:
 	})
 }
 
+func TestNewContentsBundleWithInvalidBundleMeta(t *testing.T) {
+	imagesLockYAML := `---
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+`
+	bundleYAML := `---
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: NotABundle
+`
+	fakeUI := &bundlefakes.FakeUI{}
+	fakeRegistry := &bundlefakes.FakeImagesMetadataWriter{}
+	assets := &helpers.Assets{T: t}
+	defer assets.CleanCreatedFolders()
+	bundleBuilder := helpers.NewBundleDir(t, assets)
+	bundleDir := bundleBuilder.CreateBundleDir(bundleYAML, imagesLockYAML)
+
+	t.Run("push fails with actionable error", func(t *testing.T) {
+		subject := bundle.NewContents([]string{bundleDir}, nil)
+		imgTag, err := name.NewTag("my.registry.io/new-bundle:tag")
+		if err != nil {
+			t.Fatalf("failed to read tag: %s", err)
+		}
+
+		_, err = subject.Push(imgTag, fakeRegistry, fakeUI)
+		if err == nil {
+			t.Fatalf("expected push to fail")
+		}
+		if !strings.Contains(err.Error(), "Validating kind: Unknown kind (known: Bundle)") {
+			t.Fatalf("expected bundle metadata validation error, got: %s", err)
+		}
+	})
+}
+
 func TestNewContentsBundleWithImages(t *testing.T) {
 	imagesLockYAML := `---
 apiVersion: imgpkg.carvel.dev/v1alpha1
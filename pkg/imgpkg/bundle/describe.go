@@ -51,6 +51,7 @@ type Description struct {
 	Origin      string            `json:"origin"`
 	Annotations map[string]string `json:"annotations,omitempty"`
 	Metadata    Metadata          `json:"metadata,omitempty"`
+	Provenance  map[string]string `json:"provenance,omitempty"`
 	Content     Content           `json:"content"`
 }
 
@@ -94,7 +95,17 @@ func DescribeWithRegistryAndSignatureFetcher(bundleImage string, opts DescribeOp
 	topBundle := refWithDescription{
 		imgRef: NewBundleImageRef(lockconfig.ImageRef{Image: bundle.DigestRef()}),
 	}
-	return topBundle.DescribeBundle(allBundles), nil
+	description := topBundle.DescribeBundle(allBundles)
+
+	provenance, err := bundle.ProvenanceLabels()
+	if err != nil {
+		return Description{}, fmt.Errorf("Reading provenance labels: %s", err)
+	}
+	if len(provenance) > 0 {
+		description.Provenance = provenance
+	}
+
+	return description, nil
 }
 
 type refWithDescription struct {
@@ -0,0 +1,157 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// LayerSizeInfo Sizes of a single layer of an image
+type LayerSizeInfo struct {
+	Digest           string `json:"digest"`
+	CompressedSize   int64  `json:"compressedSize"`
+	UncompressedSize int64  `json:"uncompressedSize"`
+	Shared           bool   `json:"shared,omitempty"`
+}
+
+// ImageSizeInfo Sizes of a single image of a Bundle, including its layers
+type ImageSizeInfo struct {
+	Image            string          `json:"image"`
+	CompressedSize   int64           `json:"compressedSize"`
+	UncompressedSize int64           `json:"uncompressedSize"`
+	Layers           []LayerSizeInfo `json:"layers"`
+}
+
+// SizeBreakdown Registry storage needed by a Bundle and all the images it
+// references. Layers shared between images are only counted once in the
+// totals, since registries store each blob a single time per repository
+type SizeBreakdown struct {
+	TotalCompressedSize   int64           `json:"totalCompressedSize"`
+	TotalUncompressedSize int64           `json:"totalUncompressedSize"`
+	SharedLayersSavings   int64           `json:"sharedLayersSavings"`
+	Images                []ImageSizeInfo `json:"images"`
+}
+
+// CalculateSizeBreakdown Computes the per-image and per-layer sizes of every
+// image present in description. Images that cannot be retrieved as a single
+// image (e.g. image indexes) are skipped
+func CalculateSizeBreakdown(description Description, imagesMetadata ImagesMetadata) (SizeBreakdown, error) {
+	breakdown := SizeBreakdown{}
+
+	layerImageCount := map[string]int{}
+	layerCompressedSizes := map[string]int64{}
+	layerUncompressedSizes := map[string]int64{}
+
+	for _, imageRef := range collectImageRefs(description) {
+		ref, err := regname.NewDigest(imageRef, regname.WeakValidation)
+		if err != nil {
+			return SizeBreakdown{}, fmt.Errorf("Parsing '%s': %s", imageRef, err)
+		}
+
+		img, err := imagesMetadata.Image(ref)
+		if err != nil {
+			continue
+		}
+
+		layers, err := img.Layers()
+		if err != nil {
+			return SizeBreakdown{}, fmt.Errorf("Retrieving layers of '%s': %s", imageRef, err)
+		}
+
+		imageInfo := ImageSizeInfo{Image: imageRef}
+		for _, layer := range layers {
+			layerDigest, err := layer.Digest()
+			if err != nil {
+				return SizeBreakdown{}, fmt.Errorf("Retrieving layer digest of '%s': %s", imageRef, err)
+			}
+
+			compressedSize, err := layer.Size()
+			if err != nil {
+				return SizeBreakdown{}, fmt.Errorf("Retrieving layer size of '%s': %s", imageRef, err)
+			}
+
+			uncompressedSize, found := layerUncompressedSizes[layerDigest.String()]
+			if !found {
+				uncompressedSize, err = measureUncompressedSize(layer)
+				if err != nil {
+					return SizeBreakdown{}, fmt.Errorf("Reading layer %s of '%s': %s", layerDigest, imageRef, err)
+				}
+				layerUncompressedSizes[layerDigest.String()] = uncompressedSize
+			}
+
+			layerImageCount[layerDigest.String()]++
+			layerCompressedSizes[layerDigest.String()] = compressedSize
+
+			imageInfo.CompressedSize += compressedSize
+			imageInfo.UncompressedSize += uncompressedSize
+			imageInfo.Layers = append(imageInfo.Layers, LayerSizeInfo{
+				Digest:           layerDigest.String(),
+				CompressedSize:   compressedSize,
+				UncompressedSize: uncompressedSize,
+			})
+		}
+
+		breakdown.Images = append(breakdown.Images, imageInfo)
+	}
+
+	for digest, count := range layerImageCount {
+		breakdown.TotalCompressedSize += layerCompressedSizes[digest]
+		breakdown.TotalUncompressedSize += layerUncompressedSizes[digest]
+		breakdown.SharedLayersSavings += int64(count-1) * layerCompressedSizes[digest]
+	}
+
+	for i, imageInfo := range breakdown.Images {
+		for j, layer := range imageInfo.Layers {
+			breakdown.Images[i].Layers[j].Shared = layerImageCount[layer.Digest] > 1
+		}
+	}
+
+	return breakdown, nil
+}
+
+// collectImageRefs flattens the description into the deduplicated and sorted
+// list of image references it contains, including the bundle images themselves
+func collectImageRefs(description Description) []string {
+	refs := map[string]struct{}{}
+	collectImageRefsRec(description, refs)
+
+	var result []string
+	for ref := range refs {
+		result = append(result, ref)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func collectImageRefsRec(description Description, refs map[string]struct{}) {
+	if _, visited := refs[description.Image]; visited {
+		return
+	}
+	refs[description.Image] = struct{}{}
+
+	for _, imageInfo := range description.Content.Images {
+		refs[imageInfo.Image] = struct{}{}
+	}
+	for _, bundleDesc := range description.Content.Bundles {
+		collectImageRefsRec(bundleDesc, refs)
+	}
+}
+
+// measureUncompressedSize streams the layer contents to determine its size
+// after decompression, as manifests only record compressed sizes
+func measureUncompressedSize(layer regv1.Layer) (int64, error) {
+	contents, err := layer.Uncompressed()
+	if err != nil {
+		return 0, err
+	}
+	defer contents.Close()
+
+	return io.Copy(ioutil.Discard, contents)
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctlbundle "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+)
+
+func TestCalculateSizeBreakdown(t *testing.T) {
+	bundleImg, err := random.Image(512, 1)
+	require.NoError(t, err)
+	contentImg, err := random.Image(1024, 2)
+	require.NoError(t, err)
+
+	bundleRef := "registry.io/bundle@" + describeSizeTestDigest(t, bundleImg)
+	contentRef := "registry.io/img@" + describeSizeTestDigest(t, contentImg)
+
+	t.Run("sums compressed and uncompressed sizes per image and layer", func(t *testing.T) {
+		metadata := describeSizeTestMetadata(map[string]regv1.Image{
+			bundleRef:  bundleImg,
+			contentRef: contentImg,
+		})
+
+		description := ctlbundle.Description{
+			Image: bundleRef,
+			Content: ctlbundle.Content{
+				Images: map[string]ctlbundle.ImageInfo{"some-digest": {Image: contentRef}},
+			},
+		}
+
+		breakdown, err := ctlbundle.CalculateSizeBreakdown(description, metadata)
+		require.NoError(t, err)
+
+		require.Len(t, breakdown.Images, 2)
+		expectedCompressed := int64(0)
+		for _, img := range []regv1.Image{bundleImg, contentImg} {
+			layers, err := img.Layers()
+			require.NoError(t, err)
+			for _, layer := range layers {
+				size, err := layer.Size()
+				require.NoError(t, err)
+				expectedCompressed += size
+			}
+		}
+
+		assert.Equal(t, expectedCompressed, breakdown.TotalCompressedSize)
+		assert.Greater(t, breakdown.TotalUncompressedSize, int64(0))
+		assert.Zero(t, breakdown.SharedLayersSavings)
+		for _, imageInfo := range breakdown.Images {
+			for _, layer := range imageInfo.Layers {
+				assert.False(t, layer.Shared)
+			}
+		}
+	})
+
+	t.Run("counts layers shared between images only once", func(t *testing.T) {
+		otherRef := "registry.io/img-copy@" + describeSizeTestDigest(t, contentImg)
+		metadata := describeSizeTestMetadata(map[string]regv1.Image{
+			bundleRef:  bundleImg,
+			contentRef: contentImg,
+			otherRef:   contentImg,
+		})
+
+		description := ctlbundle.Description{
+			Image: bundleRef,
+			Content: ctlbundle.Content{
+				Images: map[string]ctlbundle.ImageInfo{
+					"some-digest":  {Image: contentRef},
+					"other-digest": {Image: otherRef},
+				},
+			},
+		}
+
+		breakdown, err := ctlbundle.CalculateSizeBreakdown(description, metadata)
+		require.NoError(t, err)
+
+		var contentImgCompressed int64
+		layers, err := contentImg.Layers()
+		require.NoError(t, err)
+		for _, layer := range layers {
+			size, err := layer.Size()
+			require.NoError(t, err)
+			contentImgCompressed += size
+		}
+
+		assert.Equal(t, contentImgCompressed, breakdown.SharedLayersSavings)
+		for _, imageInfo := range breakdown.Images {
+			for _, layer := range imageInfo.Layers {
+				assert.Equal(t, imageInfo.Image != bundleRef, layer.Shared)
+			}
+		}
+	})
+
+	t.Run("skips images that cannot be retrieved as a single image", func(t *testing.T) {
+		metadata := describeSizeTestMetadata(map[string]regv1.Image{bundleRef: bundleImg})
+
+		description := ctlbundle.Description{
+			Image: bundleRef,
+			Content: ctlbundle.Content{
+				Images: map[string]ctlbundle.ImageInfo{"some-digest": {Image: contentRef}},
+			},
+		}
+
+		breakdown, err := ctlbundle.CalculateSizeBreakdown(description, metadata)
+		require.NoError(t, err)
+
+		require.Len(t, breakdown.Images, 1)
+		assert.Equal(t, bundleRef, breakdown.Images[0].Image)
+	})
+}
+
+func describeSizeTestDigest(t *testing.T, img regv1.Image) string {
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	return digest.String()
+}
+
+func describeSizeTestMetadata(images map[string]regv1.Image) *bundlefakes.FakeImagesMetadataWriter {
+	metadata := &bundlefakes.FakeImagesMetadataWriter{}
+	metadata.ImageStub = func(ref name.Reference) (regv1.Image, error) {
+		img, found := images[ref.Name()]
+		if !found {
+			return nil, fmt.Errorf("image '%s' not found", ref.Name())
+		}
+		return img, nil
+	}
+	return metadata
+}
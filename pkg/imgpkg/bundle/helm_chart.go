@@ -0,0 +1,38 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/helmchart"
+)
+
+// writeRelocatedValuesOverlay writes a values overlay next to a relocated
+// Helm chart bundle's values file so the chart can be installed with the
+// relocated image references. Bundles that do not contain a chart are left
+// untouched
+func writeRelocatedValuesOverlay(bundleDirPath string, imageRefs ImageRefs, ui goui.UI) error {
+	if !helmchart.IsChartDir(bundleDirPath) {
+		return nil
+	}
+
+	replacements := map[string]string{}
+	for _, ref := range imageRefs.ImageRefs() {
+		if ref.PrimaryLocation() != ref.Image {
+			replacements[ref.Image] = ref.PrimaryLocation()
+		}
+	}
+	if len(replacements) == 0 {
+		return nil
+	}
+
+	overlayPath, err := helmchart.WriteRelocatedValuesOverlay(bundleDirPath, replacements)
+	if err != nil {
+		return err
+	}
+	if overlayPath != "" {
+		ui.BeginLinef("Wrote relocated Helm values overlay '%s'\n", overlayPath)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@
 package bundle
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"sort"
@@ -74,6 +75,21 @@ func (c ImageLocationsConfig) AsBytes() ([]byte, error) {
 	return []byte(fmt.Sprintf("---\n%s", bs)), nil
 }
 
+// Equals checks if both configurations contain the same images, ignoring order
+func (c ImageLocationsConfig) Equals(other ImageLocationsConfig) bool {
+	bs, err := c.AsBytes()
+	if err != nil {
+		return false
+	}
+
+	otherBs, err := other.AsBytes()
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(bs, otherBs)
+}
+
 func (c ImageLocationsConfig) WriteToPath(path string) error {
 	bs, err := c.AsBytes()
 	if err != nil {
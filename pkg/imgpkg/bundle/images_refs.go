@@ -10,9 +10,42 @@ import (
 
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
 )
 
+// existenceCheckConcurrency number of parallel lookups done while determining
+// if a bundle's images are colocated in its repository
+const existenceCheckConcurrency = 5
+
+// ImagesExistenceCache caches which images a repository contains, keyed by
+// repository and digest, so bundles sharing images within a single invocation
+// only look them up once
+type ImagesExistenceCache struct {
+	lock  sync.Mutex
+	found map[string]bool
+}
+
+// NewImagesExistenceCache constructor for ImagesExistenceCache
+func NewImagesExistenceCache() *ImagesExistenceCache {
+	return &ImagesExistenceCache{found: map[string]bool{}}
+}
+
+func (c *ImagesExistenceCache) lookup(ref string) (bool, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	exists, cached := c.found[ref]
+	return exists, cached
+}
+
+func (c *ImagesExistenceCache) store(ref string, exists bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.found[ref] = exists
+}
+
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 github.com/cppforlife/go-cli-ui/ui.UI
 
 type ImageRef struct {
@@ -88,6 +121,7 @@ type ImageRefs struct {
 	refs                 []ImageRef
 	imageLocationsConfig *ImageLocationsConfig
 	originalImagesLock   *lockconfig.ImagesLock
+	existenceCache       *ImagesExistenceCache
 
 	refsLock *sync.Mutex
 }
@@ -128,31 +162,138 @@ func (i *ImageRefs) LocalizeToRepo(relativeToRepo string) {
 	}
 }
 
+// SetExistenceCache shares cached image existence lookups with other bundles
+// processed during the same invocation
+func (i *ImageRefs) SetExistenceCache(cache *ImagesExistenceCache) {
+	i.existenceCache = cache
+}
+
 func (i *ImageRefs) UpdateRelativeToRepo(imgRetriever ImagesMetadata, relativeToRepo string) (bool, error) {
 	if i.imageLocationsConfig != nil {
 		i.LocalizeToRepo(relativeToRepo)
 		return true, nil
 	}
 
+	found, err := i.checkImagesExist(imgRetriever, relativeToRepo)
+	if err != nil {
+		return false, err
+	}
+	for _, exists := range found {
+		if !exists {
+			return false, nil
+		}
+	}
+
+	i.LocalizeToRepo(relativeToRepo)
+
+	return true, nil
+}
+
+// LocalizeFoundToRepo adds the repository relative location to every image present
+// in relativeToRepo, leaving images the repository lacks at their original
+// location. It checks each image individually, even when a locations configuration
+// claims the bundle was fully relocated. Returns whether any image was localized
+// and the references that were left at their origin
+func (i *ImageRefs) LocalizeFoundToRepo(imgRetriever ImagesMetadata, relativeToRepo string) (bool, []string, error) {
+	found, err := i.checkImagesExist(imgRetriever, relativeToRepo)
+	if err != nil {
+		return false, nil, err
+	}
+
+	i.refsLock.Lock()
+	defer i.refsLock.Unlock()
+
+	localized := false
+	var missingRefs []string
+	for j, ref := range i.refs {
+		if found[ref.Image] {
+			i.refs[j].AddLocation(replaceImageRepo(ref.Image, relativeToRepo))
+			localized = true
+		} else {
+			missingRefs = append(missingRefs, ref.Image)
+		}
+	}
+
+	return localized, missingRefs, nil
+}
+
+// checkImagesExist looks up every image in relativeToRepo, in parallel, and
+// reports per original reference whether the repository contains it. Lookups
+// are deduplicated by repository and digest and shared through the existence
+// cache when one was provided
+func (i *ImageRefs) checkImagesExist(imgRetriever ImagesMetadata, relativeToRepo string) (map[string]bool, error) {
+	i.refsLock.Lock()
+	seen := map[string]struct{}{}
+	var images []string
 	for _, ref := range i.refs {
-		image, err := name.ParseReference(replaceImageRepo(ref.Image, relativeToRepo))
-		if err != nil {
-			return false, err
+		if _, ok := seen[ref.Image]; ok {
+			continue
 		}
-		_, err = imgRetriever.Digest(image)
-		if err != nil {
-			if terr, ok := err.(*transport.Error); ok {
-				if i.imageIsNotFound(terr) {
-					return false, nil
+		seen[ref.Image] = struct{}{}
+		images = append(images, ref.Image)
+	}
+	i.refsLock.Unlock()
+
+	found := map[string]bool{}
+	resultLock := &sync.Mutex{}
+	throttle := util.NewThrottle(existenceCheckConcurrency)
+
+	errCh := make(chan error, len(images))
+	for _, img := range images {
+		img := img // copy
+
+		go func() {
+			throttle.Take()
+			defer throttle.Done()
+
+			localizedRef := replaceImageRepo(img, relativeToRepo)
+			if i.existenceCache != nil {
+				if exists, cached := i.existenceCache.lookup(localizedRef); cached {
+					resultLock.Lock()
+					found[img] = exists
+					resultLock.Unlock()
+					errCh <- nil
+					return
 				}
 			}
-			return false, err
-		}
+
+			imageRef, err := name.ParseReference(localizedRef)
+			if err != nil {
+				errCh <- err
+				return
+			}
+
+			_, err = imgRetriever.Digest(imageRef)
+			exists := err == nil
+			if err != nil {
+				terr, ok := err.(*transport.Error)
+				if !ok || !i.imageIsNotFound(terr) {
+					errCh <- err
+					return
+				}
+			}
+
+			if i.existenceCache != nil {
+				i.existenceCache.store(localizedRef, exists)
+			}
+			resultLock.Lock()
+			found[img] = exists
+			resultLock.Unlock()
+			errCh <- nil
+		}()
 	}
 
-	i.LocalizeToRepo(relativeToRepo)
+	var firstErr error
+	for range images {
+		if err := <-errCh; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
 
-	return true, nil
+	return found, nil
 }
 
 func (i *ImageRefs) AddImagesRef(refs ...ImageRef) {
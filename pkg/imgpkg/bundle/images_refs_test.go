@@ -141,6 +141,41 @@ func TestImagesRefs_LocalizeImagesLock(t *testing.T) {
 		assert.Equal(t, "2", subject.ImagesLock().Images[1].Annotations["annot"])
 	})
 
+	t.Run("When an existence cache is shared, repeated checks reuse previous lookups", func(t *testing.T) {
+		imagesLock := lockconfig.ImagesLock{
+			Images: []lockconfig.ImageRef{
+				{
+					Image: "some.repo.io/img1@sha256:27fde5fa39e3c97cb1e5dabfb664784b605a592d5d2df5482d744742efebba80",
+				},
+				{
+					Image: "some.repo.io/img2@sha256:45f3926bca9fc42adb650fef2a41250d77841dde49afc8adc7c0c633b3d5f27a",
+				},
+			},
+		}
+
+		fakeImagesMetadata := &registryfakes.FakeImagesReader{}
+		cache := ctlbundle.NewImagesExistenceCache()
+
+		subject, err := ctlbundle.NewImageRefsFromImagesLock(imagesLock, config)
+		require.NoError(t, err)
+		subject.SetExistenceCache(cache)
+
+		colocated, err := subject.UpdateRelativeToRepo(fakeImagesMetadata, "some.repo.io/bundle")
+		require.NoError(t, err)
+		assert.True(t, colocated)
+		lookupsAfterFirstCheck := fakeImagesMetadata.DigestCallCount()
+		assert.Equal(t, 2, lookupsAfterFirstCheck)
+
+		otherRefs, err := ctlbundle.NewImageRefsFromImagesLock(imagesLock, config)
+		require.NoError(t, err)
+		otherRefs.SetExistenceCache(cache)
+
+		colocated, err = otherRefs.UpdateRelativeToRepo(fakeImagesMetadata, "some.repo.io/bundle")
+		require.NoError(t, err)
+		assert.True(t, colocated)
+		assert.Equal(t, lookupsAfterFirstCheck, fakeImagesMetadata.DigestCallCount())
+	})
+
 	t.Run("When one images is present twice with locations OCI Image, it returns the ImagesLock with both images", func(t *testing.T) {
 		imagesLock := lockconfig.ImagesLock{
 			Images: []lockconfig.ImageRef{
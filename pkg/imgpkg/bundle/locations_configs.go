@@ -19,6 +19,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/plainimage"
 )
 
@@ -111,6 +112,11 @@ func (r LocationsConfigs) LocationsImageDigest(registry ImagesMetadata, bundleRe
 	return bundleRef.Digest(digest.String()), nil
 }
 
+// Save writes the Locations OCI Image for bundleRef. Generation is
+// deterministic: images are sorted and timestamps are fixed, so the same
+// configuration always produces the same image digest. When the registry
+// already contains a locations image with the same configuration the push
+// is skipped
 func (r LocationsConfigs) Save(reg ImagesMetadataWriter, bundleRef name.Digest, config ImageLocationsConfig, ui ui.UI) error {
 	r.ui.Tracef("saving Locations OCI Image for bundle: %s\n", bundleRef.Name())
 
@@ -119,6 +125,13 @@ func (r LocationsConfigs) Save(reg ImagesMetadataWriter, bundleRef name.Digest,
 		return fmt.Errorf("Calculating locations image tag: %s", err)
 	}
 
+	if existingConfig, err := r.Fetch(reg, bundleRef); err == nil {
+		if existingConfig.Equals(config) {
+			r.ui.Debugf("Locations OCI Image for bundle '%s' is already up to date\n", bundleRef.Name())
+			return nil
+		}
+	}
+
 	tmpDir, err := os.MkdirTemp("", "imgpkg-bundle-locations")
 	if err != nil {
 		return err
@@ -132,7 +145,9 @@ func (r LocationsConfigs) Save(reg ImagesMetadataWriter, bundleRef name.Digest,
 
 	r.ui.Tracef("Pushing image\n")
 
-	_, err = plainimage.NewContents([]string{tmpDir}, nil).Push(locRef, nil, reg, ui)
+	// Reproducible contents normalize file modes and timestamps, keeping the
+	// locations image digest stable across invocations
+	_, err = plainimage.NewContentsWithOpts([]string{tmpDir}, nil, plainimage.ContentsOpts{Reproducible: true}).Push(locRef, nil, reg, ui)
 	if err != nil {
 		// Immutable tag errors within registries are not standardized.
 		// Assume word "immutable" would be present in most cases.
@@ -160,7 +175,13 @@ func (r LocationsConfigs) Save(reg ImagesMetadataWriter, bundleRef name.Digest,
 }
 
 func (r LocationsConfigs) locationsRefFromBundleRef(bundleRef name.Digest) (name.Tag, error) {
-	hash, err := regv1.NewHash(bundleRef.DigestStr())
+	return LocationsImageTagRef(bundleRef)
+}
+
+// LocationsImageTagRef returns the tag reference where the locations OCI Image
+// associated with bundleRef is kept
+func LocationsImageTagRef(bundleRef name.Digest) (name.Tag, error) {
+	algorithm, hex, err := lockconfig.ParseDigest(bundleRef.DigestStr())
 	if err != nil {
 		return name.Tag{}, err
 	}
@@ -170,7 +191,7 @@ func (r LocationsConfigs) locationsRefFromBundleRef(bundleRef name.Digest) (name
 		return name.Tag{}, err
 	}
 
-	return tag.Tag(fmt.Sprintf(locationsTagFmt, hash.Algorithm, hash.Hex)), nil
+	return tag.Tag(fmt.Sprintf(locationsTagFmt, algorithm, hex)), nil
 }
 
 type locationsSingleLayerReader struct{}
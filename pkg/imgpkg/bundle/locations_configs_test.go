@@ -8,12 +8,24 @@ import (
 
 	goui "github.com/cppforlife/go-cli-ui/ui"
 	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/stretchr/testify/require"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
 	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
 )
 
+// countingLocationsWriter counts image pushes, delegating all calls
+type countingLocationsWriter struct {
+	bundle.ImagesMetadataWriter
+	writeImageCalls int
+}
+
+func (c *countingLocationsWriter) WriteImage(ref regname.Reference, img regv1.Image) error {
+	c.writeImageCalls++
+	return c.ImagesMetadataWriter.WriteImage(ref, img)
+}
+
 func TestLocations(t *testing.T) {
 	t.Run("when creates a locations Images it can fetch the configuration", func(t *testing.T) {
 		fakeRegistryBuilder := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
@@ -56,6 +68,47 @@ func TestLocations(t *testing.T) {
 		require.Equal(t, expectedConfig, cfg)
 	})
 
+	t.Run("when saving the same configuration twice, the digest is stable and the re-push is skipped", func(t *testing.T) {
+		fakeRegistryBuilder := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+		writer := &countingLocationsWriter{ImagesMetadataWriter: fakeRegistryBuilder.Build()}
+
+		confUI := goui.NewConfUI(goui.NewNoopLogger())
+		defer confUI.Flush()
+		uiLogger := util.NewUILevelLogger(util.LogWarn, confUI)
+
+		subject := bundle.NewLocations(uiLogger)
+
+		bundleRef := fakeRegistryBuilder.ReferenceOnTestServer("some/testing@sha256:cf31af331f38d1d7158470e095b132acd126a7180a54f263d386da88eb681d93")
+		bundleDigestRef, err := regname.NewDigest(bundleRef)
+		require.NoError(t, err)
+
+		config := bundle.ImageLocationsConfig{
+			APIVersion: "imgpkg.carvel.dev/v1alpha1",
+			Kind:       "ImageLocations",
+			Images: []bundle.ImageLocation{
+				{
+					Image:    "gcr.io/img1@sha256:acf7795dc91df17e10effee064bd229580a9c34213b4dba578d64768af5d8c51",
+					IsBundle: false,
+				},
+			},
+		}
+
+		err = subject.Save(writer, bundleDigestRef, config, goui.NewConfUI(goui.NewNoopLogger()))
+		require.NoError(t, err)
+		require.Equal(t, 1, writer.writeImageCalls)
+
+		digestAfterFirstSave, err := subject.LocationsImageDigest(writer, bundleDigestRef)
+		require.NoError(t, err)
+
+		err = subject.Save(writer, bundleDigestRef, config, goui.NewConfUI(goui.NewNoopLogger()))
+		require.NoError(t, err)
+		require.Equal(t, 1, writer.writeImageCalls)
+
+		digestAfterSecondSave, err := subject.LocationsImageDigest(writer, bundleDigestRef)
+		require.NoError(t, err)
+		require.Equal(t, digestAfterFirstSave, digestAfterSecondSave)
+	})
+
 	t.Run("when locations Image is not present it returns LocationsNotFound error", func(t *testing.T) {
 		fakeRegistryBuilder := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
 		fakeRegistry := fakeRegistryBuilder.Build()
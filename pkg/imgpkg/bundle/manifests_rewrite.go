@@ -0,0 +1,62 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// rewriteManifestRefs replaces original image references inside the bundle's
+// YAML manifests with their relocated locations. The .imgpkg directory is
+// skipped since the ImagesLock file is rewritten separately
+func rewriteManifestRefs(bundleDirPath string, imageRefs ImageRefs) error {
+	replacements := map[string]string{}
+	for _, ref := range imageRefs.ImageRefs() {
+		if ref.PrimaryLocation() != ref.Image {
+			replacements[ref.Image] = ref.PrimaryLocation()
+		}
+	}
+	if len(replacements) == 0 {
+		return nil
+	}
+
+	return filepath.Walk(bundleDirPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path == filepath.Join(bundleDirPath, ImgpkgDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if ext != ".yml" && ext != ".yaml" {
+			return nil
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		updated := string(contents)
+		for origRef, relocatedRef := range replacements {
+			updated = strings.ReplaceAll(updated, origRef, relocatedRef)
+		}
+		if updated == string(contents) {
+			return nil
+		}
+
+		err = ioutil.WriteFile(path, []byte(updated), info.Mode())
+		if err != nil {
+			return fmt.Errorf("Rewriting manifest '%s': %s", path, err)
+		}
+		return nil
+	})
+}
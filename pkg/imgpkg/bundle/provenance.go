@@ -0,0 +1,34 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"strings"
+)
+
+// ProvenanceLabelPrefix prefix of the config labels that record provenance
+// information on bundles at push time
+const ProvenanceLabelPrefix = "dev.carvel.imgpkg.provenance."
+
+// ProvenanceLabels returns the provenance labels recorded on the bundle's
+// config when it was pushed, with the common prefix stripped
+func (o *Bundle) ProvenanceLabels() (map[string]string, error) {
+	img, err := o.checkedImage()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{}
+	for key, value := range cfg.Config.Labels {
+		if strings.HasPrefix(key, ProvenanceLabelPrefix) {
+			labels[strings.TrimPrefix(key, ProvenanceLabelPrefix)] = value
+		}
+	}
+	return labels, nil
+}
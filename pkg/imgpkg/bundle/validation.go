@@ -22,6 +22,43 @@ func IsNotBundleError(err error) bool {
 	return ok
 }
 
+// RefKind is the classification of a registry reference returned by Kind
+type RefKind string
+
+const (
+	// RefKindBundle the reference points at an imgpkg bundle
+	RefKindBundle RefKind = "bundle"
+	// RefKindImage the reference points at a plain image
+	RefKindImage RefKind = "image"
+	// RefKindIndex the reference points at an image index
+	RefKindIndex RefKind = "index"
+)
+
+// Kind classifies ref as a bundle, plain image, or index by fetching only the
+// manifest and config blob (never layers), so callers can branch cheaply.
+// Also returns the digest the reference resolved to
+func Kind(ref string, imagesMetadata ImagesMetadata) (RefKind, string, error) {
+	plainImg := plainimg.NewPlainImage(ref, imagesMetadata)
+
+	img, err := plainImg.Fetch()
+	if err != nil {
+		if plainimg.IsNotAnImageError(err) {
+			return RefKindIndex, plainImg.Digest(), nil
+		}
+		return "", "", err
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return "", "", err
+	}
+
+	if _, present := cfg.Config.Labels[BundleConfigLabel]; present {
+		return RefKindBundle, plainImg.Digest(), nil
+	}
+	return RefKindImage, plainImg.Digest(), nil
+}
+
 func (o *Bundle) IsBundle() (bool, error) {
 	img, err := o.plainImg.Fetch()
 	if err != nil {
@@ -0,0 +1,174 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	regauthn "github.com/google/go-containerregistry/pkg/authn"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry/auth"
+)
+
+// NewAuthCmd constructor for the auth command group
+func NewAuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Auth",
+	}
+	return cmd
+}
+
+// AuthCheckOptions Command Line options that can be provided to the auth check command
+type AuthCheckOptions struct {
+	ui goui.UI
+
+	RegistryFlags RegistryFlags
+}
+
+// NewAuthCheckOptions constructor for building an AuthCheckOptions, holding values derived via flags
+func NewAuthCheckOptions(ui *goui.ConfUI) *AuthCheckOptions {
+	return &AuthCheckOptions{ui: ui}
+}
+
+// NewAuthCheckCmd constructor for the auth check command
+func NewAuthCheckCmd(o *AuthCheckOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check REF",
+		Short: "Report which credential source authenticates a reference and whether anonymous access works",
+		Args:  cobra.ExactArgs(1),
+		RunE:  func(_ *cobra.Command, args []string) error { return o.Run(args[0]) },
+		Example: `
+  # Check which credentials imgpkg would pick for an image
+  imgpkg auth check registry.corp.com/app1-bundle`,
+	}
+
+	o.RegistryFlags.Set(cmd)
+	return cmd
+}
+
+// Run functions called when the auth check command is provided in the command line
+func (a *AuthCheckOptions) Run(refStr string) error {
+	var refOpts []regname.Option
+	if a.RegistryFlags.Insecure {
+		refOpts = append(refOpts, regname.Insecure)
+	}
+	ref, err := regname.ParseReference(refStr, refOpts...)
+	if err != nil {
+		return fmt.Errorf("Parsing '%s': %s", refStr, err)
+	}
+
+	a.ui.BeginLinef("Target: %s\n", ref.Name())
+	a.ui.BeginLinef("Registry: %s\n", ref.Context().RegistryStr())
+	a.ui.BeginLinef("Scopes:\n")
+	a.ui.BeginLinef("  Pull: %s\n", ref.Scope(transport.PullScope))
+	a.ui.BeginLinef("  Push: %s\n", ref.Scope(transport.PushScope))
+
+	opts := a.RegistryFlags.AsRegistryOpts()
+	selected := a.reportCredSources(ref, opts)
+	if selected == "" {
+		a.ui.BeginLinef("Selected source: none (anonymous)\n")
+	} else {
+		a.ui.BeginLinef("Selected source: %s\n", selected)
+	}
+
+	a.reportAnonymousAccess(ref, opts)
+	return nil
+}
+
+// reportCredSources resolves the reference against every credential source in
+// the same order the combined keychain consults them, printing what each one
+// provides, and returns the name of the source whose credentials win
+func (a *AuthCheckOptions) reportCredSources(ref regname.Reference, opts registry.Opts) string {
+	type credSource struct {
+		name     string
+		keychain regauthn.Keychain
+	}
+
+	var sources []credSource
+	if opts.CredExecPath != "" {
+		sources = append(sources, credSource{"credential exec (--registry-cred-exec)", auth.NewExecKeychain(opts.CredExecPath)})
+	}
+	sources = append(sources, credSource{"environment (IMGPKG_REGISTRY_*)", auth.NewEnvKeychain(opts.EnvironFunc)})
+	iaasKeychain, err := auth.NewIaasKeychain(context.Background(), opts.EnvironFunc)
+	if err == nil {
+		sources = append(sources, credSource{"IaaS metadata providers", iaasKeychain})
+	}
+	sources = append(sources, credSource{"flags / docker config", auth.CustomRegistryKeychain{Opts: auth.KeychainOpts{
+		Username:     opts.Username,
+		Password:     opts.Password,
+		Token:        opts.Token,
+		Anon:         opts.Anon,
+		CredExecPath: opts.CredExecPath,
+	}}})
+
+	selected := ""
+	a.ui.BeginLinef("Credential sources:\n")
+	for _, source := range sources {
+		resolvedAuth, err := source.keychain.Resolve(ref.Context())
+		if err != nil {
+			a.ui.BeginLinef("  %s: error: %s\n", source.name, err)
+			continue
+		}
+
+		description, supplied := describeAuth(resolvedAuth)
+		a.ui.BeginLinef("  %s: %s\n", source.name, description)
+		if supplied && selected == "" {
+			selected = source.name
+		}
+	}
+
+	return selected
+}
+
+// reportAnonymousAccess probes the registry without credentials to tell
+// whether the reference is pullable anonymously
+func (a *AuthCheckOptions) reportAnonymousAccess(ref regname.Reference, opts registry.Opts) {
+	opts.Username = ""
+	opts.Password = ""
+	opts.Token = ""
+	opts.CredExecPath = ""
+	opts.Anon = true
+	opts.EnvironFunc = func() []string { return []string{"IMGPKG_ENABLE_IAAS_AUTH=false"} }
+
+	reg, err := registry.NewSimpleRegistry(opts)
+	if err != nil {
+		a.ui.BeginLinef("Anonymous access: check failed: %s\n", err)
+		return
+	}
+
+	_, err = reg.Digest(ref)
+	if err != nil {
+		a.ui.BeginLinef("Anonymous access: denied (%s)\n", err)
+		return
+	}
+	a.ui.BeginLinef("Anonymous access: allowed\n")
+}
+
+// describeAuth renders an authenticator without leaking secrets, reporting
+// whether it supplies any credentials
+func describeAuth(resolvedAuth regauthn.Authenticator) (string, bool) {
+	authorization, err := resolvedAuth.Authorization()
+	if err != nil {
+		return fmt.Sprintf("error: %s", err), false
+	}
+
+	switch {
+	case authorization.Username != "" && authorization.Username != "_token":
+		return fmt.Sprintf("username '%s'", authorization.Username), true
+	case authorization.RegistryToken != "":
+		return fmt.Sprintf("registry token (%d chars)", len(authorization.RegistryToken)), true
+	case authorization.IdentityToken != "":
+		return fmt.Sprintf("identity token (%d chars)", len(authorization.IdentityToken)), true
+	case authorization.Password != "":
+		return fmt.Sprintf("password only (%d chars)", len(authorization.Password)), true
+	default:
+		return "no credentials", false
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	regauthn "github.com/google/go-containerregistry/pkg/authn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+)
+
+func TestAuthCheckInvalidRef(t *testing.T) {
+	authCheck := NewAuthCheckOptions(nil)
+	authCheck.ui = &bundlefakes.FakeUI{}
+
+	err := authCheck.Run("not a valid ref")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Parsing 'not a valid ref'")
+}
+
+func TestDescribeAuth(t *testing.T) {
+	t.Run("anonymous reports no credentials", func(t *testing.T) {
+		description, supplied := describeAuth(regauthn.Anonymous)
+		assert.Equal(t, "no credentials", description)
+		assert.False(t, supplied)
+	})
+
+	t.Run("basic auth reports the username but never the password", func(t *testing.T) {
+		description, supplied := describeAuth(&regauthn.Basic{Username: "user", Password: "hunter2"})
+		assert.Equal(t, "username 'user'", description)
+		assert.True(t, supplied)
+		assert.NotContains(t, description, "hunter2")
+	})
+
+	t.Run("bearer token reports only its length", func(t *testing.T) {
+		description, supplied := describeAuth(&regauthn.Bearer{Token: "secret-token"})
+		assert.True(t, supplied)
+		assert.NotContains(t, description, "secret-token")
+		assert.Contains(t, description, "12 chars")
+	})
+}
@@ -0,0 +1,284 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+// BenchOptions options for the bench command
+type BenchOptions struct {
+	ui ui.UI
+
+	RegistryFlags RegistryFlags
+
+	Repo        string
+	Size        string
+	Concurrency int
+}
+
+// NewBenchOptions constructor for building a BenchOptions, holding values derived via flags
+func NewBenchOptions(ui *ui.ConfUI) *BenchOptions {
+	return &BenchOptions{ui: ui}
+}
+
+func NewBenchCmd(o *BenchOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Measure a registry's throughput and latency by pushing and pulling synthetic blobs",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+    # Measure push/pull throughput of a repository using 5 concurrent uploads
+    imgpkg bench --repo registry.corp.com/scratch/bench --size 1GB --concurrency 5`,
+	}
+	o.RegistryFlags.Set(cmd)
+	cmd.Flags().StringVar(&o.Repo, "repo", "", "Repository to push benchmark blobs to (will be written to)")
+	cmd.Flags().StringVar(&o.Size, "size", "100MB", "Total size of synthetic blob data to transfer (ex: 512MB, 1GB)")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", 5, "Concurrency")
+	return cmd
+}
+
+// Run pushes synthetic blobs to the repository, pulls them back, and reports
+// throughput and manifest latency as seen through imgpkg's transport settings
+func (b *BenchOptions) Run() error {
+	if b.Repo == "" {
+		return fmt.Errorf("Expected --repo to be provided")
+	}
+	if b.Concurrency < 1 {
+		return fmt.Errorf("Expected --concurrency to be greater than zero")
+	}
+
+	totalSize, err := parseDataSize(b.Size)
+	if err != nil {
+		return err
+	}
+
+	repo, err := regname.NewRepository(b.Repo, regname.WeakValidation)
+	if err != nil {
+		return fmt.Errorf("Building repository ref: %s", err)
+	}
+
+	reg, err := registry.NewSimpleRegistry(b.RegistryFlags.AsRegistryOpts())
+	if err != nil {
+		return err
+	}
+
+	levelLogger := newLevelLogger(b.ui)
+
+	layerSize := totalSize / int64(b.Concurrency)
+	if layerSize < 1 {
+		layerSize = 1
+	}
+
+	b.ui.BeginLinef("generating %d synthetic blob(s) of %s each...\n",
+		b.Concurrency, formatDataSize(layerSize))
+
+	img, err := random.Image(layerSize, int64(b.Concurrency))
+	if err != nil {
+		return fmt.Errorf("Generating synthetic image: %s", err)
+	}
+
+	imgDigest, err := img.Digest()
+	if err != nil {
+		return err
+	}
+	uploadRef, err := regname.NewTag(fmt.Sprintf("%s:imgpkg-bench-%d", repo.Name(), time.Now().Unix()), regname.WeakValidation)
+	if err != nil {
+		return err
+	}
+
+	pushStart := time.Now()
+	err = b.pushLayers(img, repo, reg)
+	if err != nil {
+		return err
+	}
+	err = reg.WriteImage(uploadRef, img)
+	if err != nil {
+		return err
+	}
+	pushDuration := time.Since(pushStart)
+
+	b.ui.BeginLinef("pushed %s in %s (%s/s)\n",
+		formatDataSize(totalSize), pushDuration.Round(time.Millisecond), formatDataSize(bytesPerSec(totalSize, pushDuration)))
+
+	digestRef, err := regname.NewDigest(fmt.Sprintf("%s@%s", repo.Name(), imgDigest))
+	if err != nil {
+		return err
+	}
+
+	pullStart := time.Now()
+	err = b.pullLayers(digestRef, reg)
+	if err != nil {
+		return err
+	}
+	pullDuration := time.Since(pullStart)
+
+	b.ui.BeginLinef("pulled %s in %s (%s/s)\n",
+		formatDataSize(totalSize), pullDuration.Round(time.Millisecond), formatDataSize(bytesPerSec(totalSize, pullDuration)))
+
+	latency, err := b.manifestLatency(digestRef, reg)
+	if err != nil {
+		return err
+	}
+	b.ui.BeginLinef("manifest latency: %s (avg over %d requests)\n", latency.Round(time.Millisecond), benchLatencyRequests)
+
+	err = reg.Delete(digestRef)
+	if err != nil {
+		levelLogger.Warnf("Unable to delete benchmark image %s: %s\n", digestRef.Name(), err)
+	}
+
+	return nil
+}
+
+// benchLatencyRequests number of manifest requests averaged for the latency figure
+const benchLatencyRequests = 5
+
+// pushLayers uploads every layer of the synthetic image in parallel
+func (b *BenchOptions) pushLayers(img regv1.Image, repo regname.Repository, reg registry.Registry) error {
+	layers, err := img.Layers()
+	if err != nil {
+		return err
+	}
+
+	errCh := make(chan error, len(layers))
+	throttle := util.NewThrottle(b.Concurrency)
+
+	for _, layer := range layers {
+		layer := layer // copy
+
+		go func() {
+			throttle.Take()
+			defer throttle.Done()
+
+			errCh <- reg.WriteLayer(repo, layer)
+		}()
+	}
+
+	for i := 0; i < len(layers); i++ {
+		err := <-errCh
+		if err != nil {
+			return fmt.Errorf("Pushing synthetic blob: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// pullLayers reads every layer of the pushed image back in parallel
+func (b *BenchOptions) pullLayers(digestRef regname.Digest, reg registry.Registry) error {
+	img, err := reg.Image(digestRef)
+	if err != nil {
+		return fmt.Errorf("Fetching benchmark image: %s", err)
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return err
+	}
+
+	errCh := make(chan error, len(layers))
+	throttle := util.NewThrottle(b.Concurrency)
+
+	for _, layer := range layers {
+		layer := layer // copy
+
+		go func() {
+			throttle.Take()
+			defer throttle.Done()
+
+			stream, err := layer.Compressed()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			defer stream.Close()
+
+			_, err = io.Copy(ioutil.Discard, stream)
+			errCh <- err
+		}()
+	}
+
+	for i := 0; i < len(layers); i++ {
+		err := <-errCh
+		if err != nil {
+			return fmt.Errorf("Pulling synthetic blob: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// manifestLatency averages the time taken by sequential manifest requests
+func (b *BenchOptions) manifestLatency(digestRef regname.Digest, reg registry.Registry) (time.Duration, error) {
+	start := time.Now()
+	for i := 0; i < benchLatencyRequests; i++ {
+		_, err := reg.Digest(digestRef)
+		if err != nil {
+			return 0, fmt.Errorf("Resolving manifest: %s", err)
+		}
+	}
+	return time.Since(start) / benchLatencyRequests, nil
+}
+
+// parseDataSize parses a human readable size such as 512MB or 1GB into bytes
+func parseDataSize(size string) (int64, error) {
+	sizeStr := strings.ToUpper(strings.TrimSpace(size))
+
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(sizeStr, "GB"):
+		multiplier = 1024 * 1024 * 1024
+		sizeStr = strings.TrimSuffix(sizeStr, "GB")
+	case strings.HasSuffix(sizeStr, "MB"):
+		multiplier = 1024 * 1024
+		sizeStr = strings.TrimSuffix(sizeStr, "MB")
+	case strings.HasSuffix(sizeStr, "KB"):
+		multiplier = 1024
+		sizeStr = strings.TrimSuffix(sizeStr, "KB")
+	case strings.HasSuffix(sizeStr, "B"):
+		sizeStr = strings.TrimSuffix(sizeStr, "B")
+	}
+
+	value, err := strconv.ParseInt(strings.TrimSpace(sizeStr), 10, 64)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("Expected --size to be a positive size such as 512MB or 1GB, got '%s'", size)
+	}
+
+	return value * multiplier, nil
+}
+
+// formatDataSize renders a byte count using the largest fitting unit
+func formatDataSize(bytes int64) string {
+	switch {
+	case bytes >= 1024*1024*1024:
+		return fmt.Sprintf("%.1fGB", float64(bytes)/(1024*1024*1024))
+	case bytes >= 1024*1024:
+		return fmt.Sprintf("%.1fMB", float64(bytes)/(1024*1024))
+	case bytes >= 1024:
+		return fmt.Sprintf("%.1fKB", float64(bytes)/1024)
+	default:
+		return fmt.Sprintf("%dB", bytes)
+	}
+}
+
+// bytesPerSec computes throughput guarding against a zero duration
+func bytesPerSec(bytes int64, duration time.Duration) int64 {
+	if duration <= 0 {
+		return bytes
+	}
+	return int64(float64(bytes) / duration.Seconds())
+}
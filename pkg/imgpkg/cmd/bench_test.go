@@ -0,0 +1,53 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBenchNoRepoError(t *testing.T) {
+	bench := BenchOptions{Size: "1MB", Concurrency: 5}
+
+	err := bench.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected --repo to be provided")
+}
+
+func TestBenchInvalidSizeError(t *testing.T) {
+	bench := BenchOptions{Repo: "registry.io/scratch", Size: "lots", Concurrency: 5}
+
+	err := bench.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected --size to be a positive size")
+}
+
+func TestParseDataSize(t *testing.T) {
+	for input, expected := range map[string]int64{
+		"1GB":   1024 * 1024 * 1024,
+		"512MB": 512 * 1024 * 1024,
+		"100kb": 100 * 1024,
+		"42B":   42,
+		"1000":  1000,
+	} {
+		size, err := parseDataSize(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, size, input)
+	}
+
+	for _, input := range []string{"", "-5MB", "0", "MB"} {
+		_, err := parseDataSize(input)
+		require.Error(t, err, input)
+	}
+}
+
+func TestFormatDataSize(t *testing.T) {
+	assert.Equal(t, "1.0GB", formatDataSize(1024*1024*1024))
+	assert.Equal(t, "2.5MB", formatDataSize(5*1024*1024/2))
+	assert.Equal(t, "1.0KB", formatDataSize(1024))
+	assert.Equal(t, "17B", formatDataSize(17))
+}
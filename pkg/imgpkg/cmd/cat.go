@@ -0,0 +1,138 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/plainimage"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+// CatOptions Command Line options that can be provided to the cat command
+type CatOptions struct {
+	ui goui.UI
+
+	ImageFlags    ImageFlags
+	BundleFlags   BundleFlags
+	RegistryFlags RegistryFlags
+
+	Path string
+}
+
+// NewCatOptions constructor for building a CatOptions, holding values derived via flags
+func NewCatOptions(ui *goui.ConfUI) *CatOptions {
+	return &CatOptions{ui: ui}
+}
+
+// NewCatCmd constructor for the cat command
+func NewCatCmd(o *CatOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cat",
+		Short: "Print a single file from a bundle or image without pulling it",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Print the ImagesLock of a bundle
+  imgpkg cat -b repo/app1-bundle --path .imgpkg/images.yml
+
+  # Print a configuration file of an image
+  imgpkg cat -i repo/app1-image --path config/deployment.yml`,
+	}
+
+	o.ImageFlags.Set(cmd)
+	o.BundleFlags.Set(cmd)
+	o.RegistryFlags.Set(cmd)
+	cmd.Flags().StringVar(&o.Path, "path", "", "Path of the file to print")
+	return cmd
+}
+
+// Run functions called when the cat command is provided in the command line
+func (c *CatOptions) Run() error {
+	var refStr string
+	switch {
+	case len(c.BundleFlags.Bundle) > 0 && len(c.ImageFlags.Image) > 0:
+		return fmt.Errorf("Expected only one of image or bundle")
+	case len(c.BundleFlags.Bundle) > 0:
+		refStr = c.BundleFlags.Bundle
+	case len(c.ImageFlags.Image) > 0:
+		refStr = c.ImageFlags.Image
+	default:
+		return fmt.Errorf("Expected either image or bundle reference")
+	}
+
+	if c.Path == "" {
+		return fmt.Errorf("Expected --path to be none empty")
+	}
+
+	reg, err := registry.NewSimpleRegistry(c.RegistryFlags.AsRegistryOpts())
+	if err != nil {
+		return err
+	}
+
+	img, err := plainimage.NewPlainImage(refStr, reg).Fetch()
+	if err != nil {
+		return err
+	}
+
+	contents, err := catImage(img, strings.TrimPrefix(c.Path, "./"))
+	if err != nil {
+		return fmt.Errorf("Reading '%s' from '%s': %s", c.Path, refStr, err)
+	}
+
+	c.ui.PrintBlock(contents)
+	return nil
+}
+
+// catImage returns the contents of the file at path inside img. Layers are
+// visited newest first so the contents come from the layer that last wrote
+// the file
+func catImage(img regv1.Image, path string) ([]byte, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		layerStream, err := layers[i].Uncompressed()
+		if err != nil {
+			return nil, err
+		}
+
+		tarReader := tar.NewReader(layerStream)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				layerStream.Close()
+				return nil, err
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+
+			if strings.TrimPrefix(header.Name, "./") != path {
+				continue
+			}
+
+			contents, err := ioutil.ReadAll(tarReader)
+			layerStream.Close()
+			if err != nil {
+				return nil, err
+			}
+			return contents, nil
+		}
+		layerStream.Close()
+	}
+
+	return nil, fmt.Errorf("File not found")
+}
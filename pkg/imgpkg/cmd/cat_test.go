@@ -0,0 +1,60 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestCatValidations(t *testing.T) {
+	err := (&CatOptions{Path: "config.yml"}).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected either image or bundle reference")
+
+	noPath := CatOptions{}
+	noPath.ImageFlags.Image = "repo/img"
+	err = noPath.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected --path")
+}
+
+func TestCatImage(t *testing.T) {
+	bundleDir, err := ioutil.TempDir("", "imgpkg-cat-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(bundleDir)
+
+	require.NoError(t, os.Mkdir(filepath.Join(bundleDir, ".imgpkg"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bundleDir, "config.yml"),
+		[]byte("replicas: 3\n"), 0600))
+
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+
+	bundleInfo := fakeRegistry.WithBundleFromPath("repo/cat-bundle", bundleDir).WithImageRefs(nil)
+
+	t.Run("prints the file contents", func(t *testing.T) {
+		contents, err := catImage(bundleInfo.Image, "config.yml")
+		require.NoError(t, err)
+		assert.Equal(t, "replicas: 3\n", string(contents))
+	})
+
+	t.Run("finds the bundle's ImagesLock", func(t *testing.T) {
+		contents, err := catImage(bundleInfo.Image, ".imgpkg/images.yml")
+		require.NoError(t, err)
+		assert.Contains(t, string(contents), "ImagesLock")
+	})
+
+	t.Run("errors for a missing file", func(t *testing.T) {
+		_, err := catImage(bundleInfo.Image, "missing.yml")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "File not found")
+	})
+}
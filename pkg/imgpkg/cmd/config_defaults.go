@@ -0,0 +1,52 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/config"
+)
+
+// applyConfigDefaults sets flag values from the imgpkg configuration file
+// (~/.imgpkg/config.yml or $IMGPKG_CONFIG) for flags that were not provided
+// on the command line, including the defaults of the profile selected via
+// --profile (or $IMGPKG_PROFILE). Flags given explicitly always win over
+// configured defaults
+func applyConfigDefaults(cmd *cobra.Command, _ []string) error {
+	conf, found, err := config.NewConfigFromDefaultPath()
+	if err != nil {
+		return err
+	}
+
+	profileName := os.Getenv("IMGPKG_PROFILE")
+	if flag := cmd.Flags().Lookup("profile"); flag != nil && flag.Value.String() != "" {
+		profileName = flag.Value.String()
+	}
+	if !found {
+		if profileName != "" {
+			return fmt.Errorf("Expected a configuration file defining profile '%s' (hint: Profiles are defined in ~/.imgpkg/config.yml or $IMGPKG_CONFIG)", profileName)
+		}
+		return nil
+	}
+
+	defaults, err := conf.FlagDefaults(profileName)
+	if err != nil {
+		return err
+	}
+
+	for name, val := range defaults {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		err := cmd.Flags().Set(name, val)
+		if err != nil {
+			return fmt.Errorf("Applying configured default for flag '%s': %s", name, err)
+		}
+	}
+	return nil
+}
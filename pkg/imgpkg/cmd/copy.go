@@ -4,14 +4,22 @@
 package cmd
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
 	regv1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/dockerdaemon"
 	ctlimgset "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	ctlimgtar "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagetar"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/plainimage"
@@ -31,12 +39,60 @@ type CopyOptions struct {
 	TarFlags        TarFlags
 	RegistryFlags   RegistryFlags
 	SignatureFlags  SignatureFlags
+	PolicyFlags     PolicyFlags
 
-	RepoDst string
+	NotificationFlags NotificationFlags
+
+	RepoDst      string
+	S3Dst        string
+	GcsDst       string
+	AzureBlobDst string
 
 	Concurrency             int
+	HostConcurrency         []string
 	IncludeNonDistributable bool
 	UseRepoBasedTags        bool
+	UploadTagTemplate       string
+	Output                  string
+	MaxMemoryMB             int64
+	SkipDiskCheck           bool
+
+	IncludeImages []string
+	ExcludeImages []string
+	Platforms     []string
+	OnlyImages    []string
+
+	SkipOptionalImages bool
+	NoRecursive        bool
+	Depth              int
+	LockOutputOrigins  bool
+	FailOnTagConflict  bool
+	PerBundleSubRepos  bool
+	RepoRenames        []string
+
+	Watch           bool
+	WatchInterval   time.Duration
+	VerifyAfterCopy bool
+	ZstdToGzip      bool
+	NormalizeToOCI  bool
+
+	From string
+	To   string
+
+	DockerDaemon   string
+	ToDockerDaemon bool
+	ToContainerd   string
+
+	AssumeYes bool
+
+	ExpectedDigest string
+	Tofu           bool
+	TofuStrict     bool
+
+	MappingOutput          string
+	ProvenanceOutput       string
+	ScanExec               string
+	TransferManifestOutput string
 }
 
 // NewCopyOptions constructor for building a CopyOptions, holding values derived via flags
@@ -80,21 +136,232 @@ func NewCopyCmd(o *CopyOptions) *cobra.Command {
 	o.TarFlags.Set(cmd)
 	o.RegistryFlags.Set(cmd)
 	o.SignatureFlags.Set(cmd)
+	o.PolicyFlags.Set(cmd)
+	o.NotificationFlags.Set(cmd)
 	cmd.Flags().StringVar(&o.RepoDst, "to-repo", "", "Location to upload assets")
+	cmd.Flags().StringVar(&o.S3Dst, "to-s3", "",
+		"Location to upload a tar of the images in an S3 compatible bucket (format: s3://bucket/key; credentials via AWS_* environment variables)")
+	cmd.Flags().StringVar(&o.GcsDst, "to-gcs", "",
+		"Location to upload a tar of the images in a Google Cloud Storage bucket (format: gs://bucket/key; token via GOOGLE_OAUTH_ACCESS_TOKEN)")
+	cmd.Flags().StringVar(&o.AzureBlobDst, "to-azblob", "",
+		"Location to upload a tar of the images in an Azure Blob Storage container (format: azblob://container/key; credentials via AZURE_STORAGE_* environment variables)")
 	cmd.Flags().IntVar(&o.Concurrency, "concurrency", 5, "Concurrency")
+	cmd.Flags().StringSliceVar(&o.HostConcurrency, "host-concurrency", nil,
+		"Override --concurrency for specific registry hostnames (format: host=limit, ex: docker.io=2) (can be specified multiple times)")
 	cmd.Flags().BoolVar(&o.IncludeNonDistributable, "include-non-distributable-layers", false,
 		"Include non-distributable layers when copying an image/bundle")
 	cmd.Flags().BoolVar(&o.UseRepoBasedTags, "repo-based-tags", false,
 		"Allow imgpkg to use repository-based tags for convenience")
+	cmd.Flags().StringVar(&o.UploadTagTemplate, "upload-tag-template", "",
+		"Customize the tag applied to each relocated image (placeholders: %algorithm%, %hex%, %repo%; 'none' disables tags and uploads by digest)")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "",
+		"Output the processed image set in a machine readable format (json|yaml)")
+	cmd.Flags().Int64Var(&o.MaxMemoryMB, "max-memory", 0,
+		"Maximum memory in megabytes used for layer copy buffers when writing a tar (default: one buffer per concurrent write)")
+	cmd.Flags().BoolVar(&o.SkipDiskCheck, "skip-disk-check", false,
+		"Skip the available disk space check done before writing a tar")
+	cmd.Flags().StringSliceVar(&o.IncludeImages, "include-images", nil,
+		"Only keep the given image references when copying between tars (format: repo or repo@digest)")
+	cmd.Flags().StringSliceVar(&o.ExcludeImages, "exclude-images", nil,
+		"Drop the given image references when copying between tars (format: repo or repo@digest)")
+	cmd.Flags().StringSliceVar(&o.Platforms, "platforms", nil,
+		"Only keep images matching the given platforms when copying between tars (format: os/arch, ex: linux/amd64)")
+	cmd.Flags().StringSliceVar(&o.OnlyImages, "only", nil,
+		"Only import the given images when copying a tar to a repository (format: digest or repo@digest) (can be specified multiple times)")
+	cmd.Flags().BoolVar(&o.SkipOptionalImages, "skip-optional-images", false,
+		"Skip images whose ImagesLock entry carries the 'imgpkg.carvel.dev/copy: skip' annotation")
+	cmd.Flags().BoolVar(&o.NoRecursive, "no-recursive", false,
+		"Copy only the bundle image itself, leaving the images it references in the source registry")
+	cmd.Flags().IntVar(&o.Depth, "depth", 0,
+		"Limit how many levels of image references are copied from a bundle source (1 copies only directly referenced images; default: unlimited)")
+	cmd.Flags().BoolVar(&o.LockOutputOrigins, "lock-output-origins", false,
+		"Annotate each image in the lock output with the reference it was copied from ('imgpkg.carvel.dev/original-location')")
+	cmd.Flags().BoolVar(&o.FailOnTagConflict, "fail-on-tag-conflict", false,
+		"Fail if a tag in the destination repository already points at a different digest instead of overwriting it")
+	cmd.Flags().BoolVar(&o.PerBundleSubRepos, "per-bundle-sub-repositories", false,
+		"Copy each bundle and the images it references to its own sub-repository of --to-repo (REPO/<bundle-name>) instead of flattening everything into a single repository")
+	cmd.Flags().StringSliceVar(&o.RepoRenames, "repo-rename", nil,
+		"Rewrite source repositories when computing sub-repository names with --per-bundle-sub-repositories (format: regex=replacement) (can be specified multiple times)")
+	cmd.Flags().StringVar(&o.MappingOutput, "mapping-output", "",
+		"Location to write a relocation mapping of original to relocated references (JSON when the path ends in .json, YAML otherwise)")
+	cmd.Flags().StringVar(&o.ProvenanceOutput, "provenance-output", "",
+		"Location to write an in-toto provenance statement describing the relocation, usable as a cosign predicate")
+	cmd.Flags().StringVar(&o.ScanExec, "scan-exec", "",
+		"Command to run against each image being copied ('{}' is replaced with the image reference; a non-zero exit fails the copy)")
+	cmd.Flags().StringVar(&o.TransferManifestOutput, "transfer-manifest-output", "",
+		"Location to write a summary of the created tar intended to accompany the physical media (JSON when the path ends in .json, markdown otherwise)")
+	cmd.Flags().BoolVar(&o.Watch, "watch", false,
+		"Keep running, periodically re-resolving the source and copying again whenever it points at new content")
+	cmd.Flags().DurationVar(&o.WatchInterval, "interval", 5*time.Minute,
+		"Polling interval used with --watch (ex: 30s, 10m)")
+	cmd.Flags().BoolVar(&o.VerifyAfterCopy, "verify-after-copy", false,
+		"Read every relocated manifest back from the destination and spot-check blob digests after copying to a repository")
+	cmd.Flags().StringVar(&o.From, "from", "",
+		"URI-style source location (schemes: bundle://, image://, lock://, tar://); alternative to the individual source flags")
+	cmd.Flags().StringVar(&o.To, "to", "",
+		"URI-style destination location (schemes: registry://, tar://, s3://, gs://, azblob://); alternative to the individual destination flags")
+	cmd.Flags().BoolVarP(&o.AssumeYes, "yes", "y", false,
+		"Skip the interactive transfer plan confirmation shown before copying to a repository")
+	cmd.Flags().StringVar(&o.ExpectedDigest, "expect-digest", "",
+		"Fail unless the source bundle/image reference still resolves to this digest (format: sha256:...)")
+	cmd.Flags().BoolVar(&o.Tofu, "tofu", false,
+		"Record the resolved source digest on first use and warn when the reference later resolves to a different one")
+	cmd.Flags().BoolVar(&o.TofuStrict, "tofu-strict", false,
+		"Like --tofu, but fail instead of warning when the resolved digest changed")
+	cmd.Flags().BoolVar(&o.ZstdToGzip, "zstd-to-gzip", false,
+		"Recompress zstd image layers with gzip for destination registries that cannot serve zstd (changes image digests)")
+	cmd.Flags().BoolVar(&o.NormalizeToOCI, "normalize-to-oci", false,
+		"Convert Docker manifest and layer media types to their OCI equivalents for destination registries that require OCI (changes image digests)")
+	cmd.Flags().StringVar(&o.DockerDaemon, "docker-daemon", "",
+		"Image reference to read from the local Docker/Podman daemon and use as the copy source (requires --to-repo)")
+	cmd.Flags().BoolVar(&o.ToDockerDaemon, "to-docker-daemon", false,
+		"Load the source image into the local Docker/Podman daemon instead of a registry")
+	cmd.Flags().StringVar(&o.ToContainerd, "to-containerd", "",
+		"Import the source image into containerd under this namespace (uses the ctr binary)")
 	return cmd
 }
 
 func (c *CopyOptions) Run() error {
+	err := c.applyURIFlags()
+	if err != nil {
+		return err
+	}
+
+	if c.Watch {
+		if c.TarFlags.IsSrc() || c.TarFlags.IsDst() {
+			return fmt.Errorf("Expected --watch to only be used when copying from a registry source to a repository (--to-repo)")
+		}
+		if c.WatchInterval <= 0 {
+			return fmt.Errorf("Expected --interval to be greater than zero")
+		}
+		return c.watch()
+	}
+
+	return c.copy()
+}
+
+// watch runs the copy whenever the source resolves to new content, polling at
+// the configured interval until the process is stopped. Transient resolution
+// or copy failures are reported and retried on the next tick
+func (c *CopyOptions) watch() error {
+	reg, err := registry.NewSimpleRegistry(c.RegistryFlags.AsRegistryOpts())
+	if err != nil {
+		return err
+	}
+
+	levelLogger := newLevelLogger(util.NewUIPrefixedWriter("watch | ", c.ui))
+
+	lastFingerprint := ""
+	for {
+		fingerprint, err := c.sourceFingerprint(reg)
+		switch {
+		case err != nil:
+			levelLogger.Warnf("Resolving source: %s\n", err)
+		case fingerprint == lastFingerprint:
+			levelLogger.Debugf("Source unchanged, skipping copy\n")
+		default:
+			err := c.copy()
+			if err != nil {
+				levelLogger.Warnf("Copying: %s\n", err)
+			} else {
+				lastFingerprint = fingerprint
+			}
+		}
+
+		time.Sleep(c.WatchInterval)
+	}
+}
+
+// sourceFingerprint returns a value that changes when the source would copy
+// different content: resolved digests for registry references, a checksum of
+// the file contents for lock file sources
+func (c *CopyOptions) sourceFingerprint(reg registry.Registry) (string, error) {
+	if c.LockInputFlags.LockFilePath != "" {
+		bs, err := os.ReadFile(c.LockInputFlags.LockFilePath)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%x", sha256.Sum256(bs)), nil
+	}
+	if c.ImageFlags.ImagesFilePath != "" {
+		bs, err := os.ReadFile(c.ImageFlags.ImagesFilePath)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%x", sha256.Sum256(bs)), nil
+	}
+
+	var refs []string
+	if c.BundleFlags.Bundle != "" {
+		refs = append(refs, c.BundleFlags.Bundle)
+	}
+	if c.ImageFlags.Image != "" {
+		refs = append(refs, c.ImageFlags.Image)
+	}
+	refs = append(refs, c.ImageFlags.Images...)
+
+	var resolvedRefs []string
+	for _, ref := range refs {
+		parsedRef, err := regname.ParseReference(ref, regname.WeakValidation)
+		if err != nil {
+			return "", fmt.Errorf("Parsing '%s': %s", ref, err)
+		}
+		digest, err := reg.Digest(parsedRef)
+		if err != nil {
+			return "", fmt.Errorf("Resolving '%s': %s", ref, err)
+		}
+		resolvedRefs = append(resolvedRefs, parsedRef.Context().Name()+"@"+digest.String())
+	}
+	return strings.Join(resolvedRefs, "\n"), nil
+}
+
+func (c *CopyOptions) copy() error {
 	if !c.hasOneSrc() {
 		return fmt.Errorf("Expected either --lock, --bundle (-b), --image (-i), or --tar as a source")
 	}
 	if !c.hasOneDst() {
-		return fmt.Errorf("Expected either --to-tar or --to-repo")
+		return fmt.Errorf("Expected either --to-tar, --to-repo, --to-s3, --to-gcs, --to-azblob, --to-docker-daemon or --to-containerd")
+	}
+	if c.Output != "" && c.Output != "json" && c.Output != "yaml" {
+		return fmt.Errorf("Unsupported output format '%s' (allowed: json, yaml)", c.Output)
+	}
+	if c.hasTarFilters() && !(c.TarFlags.IsSrc() && c.TarFlags.IsDst()) {
+		return fmt.Errorf("Expected --include-images, --exclude-images and --platforms to only be used when copying between tars (--tar with --to-tar)")
+	}
+	if len(c.OnlyImages) > 0 && !(c.TarFlags.IsSrc() && c.isRepoDst()) {
+		return fmt.Errorf("Expected --only to only be used when copying a tar to a repository (--tar with --to-repo)")
+	}
+	if c.ScanExec != "" && c.TarFlags.IsSrc() {
+		return fmt.Errorf("Cannot use --scan-exec with tar source (images are scanned as they leave a registry)")
+	}
+	if c.TransferManifestOutput != "" && (!c.TarFlags.IsDst() || c.TarFlags.IsSrc()) {
+		return fmt.Errorf("Expected --transfer-manifest-output to only be used when creating a tar from a registry source (--to-tar)")
+	}
+	if c.PerBundleSubRepos && (c.TarFlags.IsSrc() || c.TarFlags.IsDst()) {
+		return fmt.Errorf("Expected --per-bundle-sub-repositories to only be used when copying from a registry source to a repository (--to-repo)")
+	}
+	if len(c.RepoRenames) > 0 && !c.PerBundleSubRepos {
+		return fmt.Errorf("Expected --repo-rename to only be used together with --per-bundle-sub-repositories")
+	}
+	if c.DockerDaemon != "" && !c.isRepoDst() {
+		return fmt.Errorf("Expected --docker-daemon to only be used when copying to a repository (--to-repo)")
+	}
+	if c.NoRecursive && c.Depth != 0 {
+		return fmt.Errorf("Expected only one of --no-recursive or --depth")
+	}
+	if c.Depth < 0 {
+		return fmt.Errorf("Expected --depth to be greater than zero")
+	}
+	if (c.NoRecursive || c.Depth > 0) && c.BundleFlags.Bundle == "" && c.LockInputFlags.LockFilePath == "" {
+		return fmt.Errorf("Expected --no-recursive and --depth to only be used with --bundle or --lock sources")
+	}
+	repoRenameRules, err := parseRepoRenameRules(c.RepoRenames)
+	if err != nil {
+		return err
+	}
+	hostConcurrency, err := parseHostConcurrency(c.HostConcurrency)
+	if err != nil {
+		return err
 	}
 
 	registryOpts := c.RegistryFlags.AsRegistryOpts()
@@ -105,9 +372,37 @@ func (c *CopyOptions) Run() error {
 		return err
 	}
 
+	if c.ExpectedDigest != "" {
+		srcRef := c.BundleFlags.Bundle
+		if srcRef == "" {
+			srcRef = c.ImageFlags.Image
+		}
+		if srcRef == "" {
+			return fmt.Errorf("Expected --expect-digest to only be used with --bundle or --image sources")
+		}
+		err := verifyExpectedDigest(srcRef, c.ExpectedDigest, reg)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.Tofu || c.TofuStrict {
+		srcRef := c.BundleFlags.Bundle
+		if srcRef == "" {
+			srcRef = c.ImageFlags.Image
+		}
+		if srcRef == "" {
+			return fmt.Errorf("Expected --tofu to only be used with --bundle or --image sources")
+		}
+		err := checkTrustOnFirstUse(srcRef, c.TofuStrict, reg, c.ui)
+		if err != nil {
+			return err
+		}
+	}
+
 	prefixedLogger := util.NewUIPrefixedWriter("copy | ", c.ui)
-	levelLogger := util.NewUILevelLogger(util.LogWarn, prefixedLogger)
-	imagesUploaderLogger := util.NewProgressBar(levelLogger, "done uploading images", "Error uploading images")
+	levelLogger := newLevelLogger(prefixedLogger)
+	imagesUploaderLogger := util.NewProgressBar(prefixedLogger, "done uploading images", "Error uploading images")
 
 	var tagGen util.TagGenerator
 	tagGen = util.DefaultTagGenerator{}
@@ -115,16 +410,43 @@ func (c *CopyOptions) Run() error {
 		tagGen = util.RepoBasedTagGenerator{}
 	}
 
+	uploadTagsDisabled := false
+	switch {
+	case c.UploadTagTemplate == "":
+		// keep the generator selected above
+	case c.UseRepoBasedTags:
+		return fmt.Errorf("Expected only one of --repo-based-tags or --upload-tag-template")
+	case c.UploadTagTemplate == "none":
+		uploadTagsDisabled = true
+	default:
+		tagGen = util.TemplateTagGenerator{Template: c.UploadTagTemplate}
+	}
+
 	imageSet := ctlimgset.NewImageSet(c.Concurrency, prefixedLogger, tagGen)
-	tarImageSet := ctlimgset.NewTarImageSet(imageSet, c.Concurrency, prefixedLogger)
+	imageSet.SetZstdToGzip(c.ZstdToGzip)
+	imageSet.SetNormalizeToOCI(c.NormalizeToOCI)
+	imageSet.SetHostConcurrency(hostConcurrency)
+	imageSet.SetUploadTagsDisabled(uploadTagsDisabled)
+	tarImageSet := ctlimgset.NewTarImageSet(imageSet, c.Concurrency, c.MaxMemoryMB*1024*1024, c.SkipDiskCheck, prefixedLogger)
+	tarImageSet.SetImportOnlyRefs(c.OnlyImages)
+	tarImageSet.SetHostConcurrency(hostConcurrency)
 
 	var signatureRetriever SignatureRetriever
-	if c.SignatureFlags.CopyCosignSignatures {
+	switch {
+	case c.SignatureFlags.CopySignatureArtifacts:
+		signatureRetriever = signature.NewAttachedArtifacts(reg, c.Concurrency)
+	case c.SignatureFlags.CopyCosignSignatures:
 		signatureRetriever = signature.NewSignatures(signature.NewCosign(reg), c.Concurrency)
-	} else {
+	default:
 		signatureRetriever = signature.NewNoop()
 	}
 
+	var scanner ImageScanner
+	scanner = NewNoopScanner()
+	if c.ScanExec != "" {
+		scanner = NewExecScanner(c.ScanExec, c.Concurrency, levelLogger)
+	}
+
 	repoSrc := CopyRepoSrc{
 		ImageFlags:              c.ImageFlags,
 		BundleFlags:             c.BundleFlags,
@@ -132,36 +454,214 @@ func (c *CopyOptions) Run() error {
 		TarFlags:                c.TarFlags,
 		IncludeNonDistributable: c.IncludeNonDistributable,
 		Concurrency:             c.Concurrency,
+		SkipOptionalImages:      c.SkipOptionalImages,
+		NoRecursive:             c.NoRecursive,
+		Depth:                   c.Depth,
+		FailOnTagConflict:       c.FailOnTagConflict,
+		TransferManifestOutput:  c.TransferManifestOutput,
+		PerBundleSubRepos:       c.PerBundleSubRepos,
+		RepoRenameRules:         repoRenameRules,
 
 		ui:                 levelLogger,
-		registry:           registry.NewRegistryWithProgress(reg, imagesUploaderLogger),
+		registry:           registry.NewRegistryWithManifestCache(registry.NewRegistryWithProgress(reg, imagesUploaderLogger), levelLogger),
 		imageSet:           imageSet,
 		tarImageSet:        tarImageSet,
 		signatureRetriever: signatureRetriever,
+		scanner:            scanner,
+		policyFile:         c.PolicyFlags.PolicyFile,
 	}
 
 	switch {
-	case c.TarFlags.IsDst():
+	case c.ToDockerDaemon || c.ToContainerd != "":
+		return c.copyToDaemon(reg)
+
+	case c.hasObjectStorageDst():
 		if c.TarFlags.IsSrc() {
-			return fmt.Errorf("Cannot use tar source (--tar) with tar destination (--to-tar)")
+			return fmt.Errorf("Cannot use tar source (--tar) with an object storage destination")
 		}
+		if c.LockOutputFlags.LockFilePath != "" {
+			return fmt.Errorf("Cannot output lock file with an object storage destination")
+		}
+		if c.Output != "" || c.MappingOutput != "" || c.ProvenanceOutput != "" {
+			return fmt.Errorf("Cannot use --output, --mapping-output or --provenance-output with an object storage destination")
+		}
+
+		dst, err := c.objectStorageDst()
+		if err != nil {
+			return err
+		}
+		return repoSrc.CopyToTarDestination(dst)
+
+	case c.TarFlags.IsDst():
 		if c.LockOutputFlags.LockFilePath != "" {
 			return fmt.Errorf("Cannot output lock file with tar destination")
 		}
+		if c.Output != "" {
+			return fmt.Errorf("Cannot use --output with tar destination")
+		}
+		if c.MappingOutput != "" {
+			return fmt.Errorf("Cannot use --mapping-output with tar destination")
+		}
+		if c.ProvenanceOutput != "" {
+			return fmt.Errorf("Cannot use --provenance-output with tar destination")
+		}
+		if c.NotificationFlags.AnySet() {
+			return fmt.Errorf("Cannot use --notify-webhook or --notify-exec with tar destination")
+		}
+		if c.TarFlags.IsSrc() {
+			return c.repackTar(prefixedLogger)
+		}
 		return repoSrc.CopyToTar(c.TarFlags.TarDst)
 
 	case c.isRepoDst():
+		if c.DockerDaemon != "" {
+			return c.copyFromDockerDaemon(reg, levelLogger)
+		}
+
+		if !c.AssumeYes && c.ui.IsInteractive() {
+			err := c.confirmTransferPlan(repoSrc, reg)
+			if err != nil {
+				return err
+			}
+		}
+
 		processedImages, err := repoSrc.CopyToRepo(c.RepoDst)
 		if err != nil {
 			return err
 		}
-		return c.writeLockOutput(processedImages, reg)
+		if stats := reg.BlobUploadRetryStats(); stats.RetriedUploads > 0 {
+			levelLogger.Warnf("Retried %d blob upload(s) (%d resumed from a partial offset, %d attempt(s) total)\n",
+				stats.RetriedUploads, stats.ResumedUploads, stats.TotalAttempts)
+		}
+		if c.VerifyAfterCopy {
+			err = newCopyVerifier(reg, c.Concurrency, levelLogger).Verify(processedImages)
+			if err != nil {
+				return err
+			}
+		}
+		err = c.writeLockOutput(processedImages, reg)
+		if err != nil {
+			return err
+		}
+		err = c.writeMappingOutput(processedImages)
+		if err != nil {
+			return err
+		}
+		err = c.writeProvenanceOutput(processedImages)
+		if err != nil {
+			return err
+		}
+		err = c.writeCopyOutput(processedImages)
+		if err != nil {
+			return err
+		}
+		return c.NotificationFlags.Notify("copy", notifiedImages(processedImages))
 
 	default:
 		panic("Unreachable")
 	}
 }
 
+// copyFromDockerDaemon exports the image from the local Docker/Podman daemon
+// and uploads it to the destination repository, so locally built images can be
+// relocated without an intermediate registry push
+func (c *CopyOptions) copyFromDockerDaemon(reg registry.Registry, logger util.UIWithLevels) error {
+	if c.LockOutputFlags.LockFilePath != "" || c.Output != "" || c.MappingOutput != "" || c.ProvenanceOutput != "" {
+		return fmt.Errorf("Cannot use --lock-output, --output, --mapping-output or --provenance-output with a docker daemon source")
+	}
+
+	importRepo, err := regname.NewRepository(c.RepoDst)
+	if err != nil {
+		return fmt.Errorf("Building destination repository ref: %s", err)
+	}
+
+	client, err := dockerdaemon.NewClient()
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("exporting '%s' from the docker daemon\n", c.DockerDaemon)
+	img, cleanup, err := client.Image(c.DockerDaemon)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	digest, err := img.Digest()
+	if err != nil {
+		return err
+	}
+
+	uploadTagRef, err := util.BuildDefaultUploadTagRef(img, importRepo)
+	if err != nil {
+		return err
+	}
+
+	err = reg.WriteImage(uploadTagRef, img)
+	if err != nil {
+		return fmt.Errorf("Writing '%s': %s", uploadTagRef.Name(), err)
+	}
+
+	// Keep the daemon-side tag (if any) on the relocated image for convenience
+	srcTag, err := regname.NewTag(c.DockerDaemon, regname.WeakValidation)
+	if err == nil {
+		err = reg.WriteTag(importRepo.Tag(srcTag.TagStr()), img)
+		if err != nil {
+			return fmt.Errorf("Tagging '%s': %s", importRepo.Tag(srcTag.TagStr()).Name(), err)
+		}
+	}
+
+	copiedRef := fmt.Sprintf("%s@%s", importRepo.Name(), digest)
+	c.ui.BeginLinef("copied '%s' to '%s'\n", c.DockerDaemon, copiedRef)
+
+	return c.NotificationFlags.Notify("copy", []NotificationImage{{Image: copiedRef, Origin: c.DockerDaemon}})
+}
+
+// notifiedImages builds the notification payload entries for a finished copy
+func notifiedImages(processedImages *ctlimgset.ProcessedImages) []NotificationImage {
+	var images []NotificationImage
+	for _, img := range processedImages.All() {
+		origRef := img.UnprocessedImageRef.OrigRef
+		if origRef == "" {
+			origRef = img.UnprocessedImageRef.DigestRef
+		}
+		images = append(images, NotificationImage{
+			Image:  img.DigestRef,
+			Tag:    img.UnprocessedImageRef.Tag,
+			Origin: origRef,
+		})
+	}
+	return images
+}
+
+func (c *CopyOptions) writeMappingOutput(processedImages *ctlimgset.ProcessedImages) error {
+	if c.MappingOutput == "" {
+		return nil
+	}
+	return NewRelocationMapping(processedImages).WriteToPath(c.MappingOutput)
+}
+
+func (c *CopyOptions) writeProvenanceOutput(processedImages *ctlimgset.ProcessedImages) error {
+	if c.ProvenanceOutput == "" {
+		return nil
+	}
+	return NewProvenanceStatement(processedImages).WriteToPath(c.ProvenanceOutput)
+}
+
+func (c *CopyOptions) writeCopyOutput(processedImages *ctlimgset.ProcessedImages) error {
+	if c.Output == "" {
+		return nil
+	}
+
+	bs, err := NewCopyOutput(processedImages).AsBytes(c.Output)
+	if err != nil {
+		return err
+	}
+
+	c.ui.PrintBlock(bs)
+	return nil
+}
+
 func (c *CopyOptions) writeLockOutput(processedImages *ctlimgset.ProcessedImages, registry registry.Registry) error {
 	if c.LockOutputFlags.LockFilePath == "" {
 		return nil
@@ -240,19 +740,126 @@ func (c *CopyOptions) informUserIfTarballNeedsToBeRecreated(processedImages *ctl
 	return nil
 }
 
+// repackTar copies the tar source into the tar destination, applying the
+// image filter flags, without contacting any registry
+func (c *CopyOptions) repackTar(logger ui.UI) error {
+	opts := ctlimgtar.RepackOpts{
+		IncludeRefs: c.IncludeImages,
+		ExcludeRefs: c.ExcludeImages,
+		Platforms:   c.Platforms,
+	}
+	writerOpts := ctlimgtar.TarWriterOpts{
+		Concurrency:    c.Concurrency,
+		MaxMemoryBytes: c.MaxMemoryMB * 1024 * 1024,
+	}
+
+	return ctlimgtar.Repack(c.TarFlags.TarSrc, c.TarFlags.TarDst, opts, writerOpts, logger,
+		ctlimgtar.NewImageLayerWriterCheck(c.IncludeNonDistributable))
+}
+
+// parseHostConcurrency parses host=limit entries from --host-concurrency
+func parseHostConcurrency(entries []string) (map[string]int, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	limits := map[string]int{}
+	for _, entry := range entries {
+		pieces := strings.SplitN(entry, "=", 2)
+		if len(pieces) != 2 || pieces[0] == "" {
+			return nil, fmt.Errorf("Expected --host-concurrency to be in 'host=limit' format, got '%s'", entry)
+		}
+		limit, err := strconv.Atoi(pieces[1])
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("Expected --host-concurrency limit for '%s' to be a positive number, got '%s'", pieces[0], pieces[1])
+		}
+		limits[pieces[0]] = limit
+	}
+	return limits, nil
+}
+
+func (c *CopyOptions) hasTarFilters() bool {
+	return len(c.IncludeImages) > 0 || len(c.ExcludeImages) > 0 || len(c.Platforms) > 0
+}
+
 func (c *CopyOptions) isRepoDst() bool { return c.RepoDst != "" }
 
 func (c *CopyOptions) hasOneDst() bool {
-	repoSet := c.isRepoDst()
-	tarSet := c.TarFlags.IsDst()
-	return (repoSet || tarSet) && !(repoSet && tarSet)
+	dstCount := 0
+	for _, dstSet := range []bool{c.isRepoDst(), c.TarFlags.IsDst(), c.S3Dst != "", c.GcsDst != "", c.AzureBlobDst != "",
+		c.ToDockerDaemon, c.ToContainerd != ""} {
+		if dstSet {
+			dstCount++
+		}
+	}
+	return dstCount == 1
+}
+
+func (c *CopyOptions) hasObjectStorageDst() bool {
+	return c.S3Dst != "" || c.GcsDst != "" || c.AzureBlobDst != ""
+}
+
+// objectStorageDst builds the destination for whichever object storage flag
+// was provided
+func (c *CopyOptions) objectStorageDst() (ctlimgtar.Destination, error) {
+	switch {
+	case c.S3Dst != "":
+		return ctlimgtar.NewS3Destination(c.S3Dst)
+	case c.GcsDst != "":
+		return ctlimgtar.NewGCSDestination(c.GcsDst)
+	default:
+		return ctlimgtar.NewAzureBlobDestination(c.AzureBlobDst)
+	}
+}
+
+// confirmTransferPlan shows the computed transfer plan (image count,
+// estimated size and destination) and asks the user to confirm before the
+// copy starts. Only called when attached to a terminal and --yes is not given
+func (c *CopyOptions) confirmTransferPlan(repoSrc CopyRepoSrc, reg registry.Registry) error {
+	if c.TarFlags.IsSrc() {
+		c.ui.BeginLinef("copy plan: contents of tar '%s' to repository '%s'\n", c.TarFlags.TarSrc, c.RepoDst)
+		return c.ui.AskForConfirmation()
+	}
+
+	unprocessedImageRefs, _, err := repoSrc.getAllSourceImages()
+	if err != nil {
+		return err
+	}
+
+	var totalSize int64
+	for _, img := range unprocessedImageRefs.All() {
+		ref, err := regname.NewDigest(img.DigestRef)
+		if err != nil {
+			continue
+		}
+		foundImg, err := reg.Image(ref)
+		if err != nil {
+			// image indexes do not contribute layers of their own
+			continue
+		}
+		layers, err := foundImg.Layers()
+		if err != nil {
+			continue
+		}
+		for _, layer := range layers {
+			size, err := layer.Size()
+			if err == nil {
+				totalSize += size
+			}
+		}
+	}
+
+	c.ui.BeginLinef("copy plan: %d image(s), ~%s, to repository '%s'\n",
+		len(unprocessedImageRefs.All()), formatDataSize(totalSize), c.RepoDst)
+
+	return c.ui.AskForConfirmation()
 }
 
 func (c *CopyOptions) hasOneSrc() bool {
 	var seen bool
-	for _, ref := range []string{c.LockInputFlags.LockFilePath, c.TarFlags.TarSrc,
-		c.BundleFlags.Bundle, c.ImageFlags.Image} {
-		if ref != "" {
+	for _, srcSet := range []bool{c.LockInputFlags.LockFilePath != "", c.TarFlags.TarSrc != "",
+		c.BundleFlags.Bundle != "", c.hasImagesSrc(), c.DockerDaemon != ""} {
+		if srcSet {
 			if seen {
 				return false
 			}
@@ -262,6 +869,10 @@ func (c *CopyOptions) hasOneSrc() bool {
 	return seen
 }
 
+func (c *CopyOptions) hasImagesSrc() bool {
+	return c.ImageFlags.Image != "" || len(c.ImageFlags.Images) > 0 || c.ImageFlags.ImagesFilePath != ""
+}
+
 func (c *CopyOptions) writeImagesLockOutput(processedImages *ctlimgset.ProcessedImages) error {
 	imagesLock := lockconfig.ImagesLock{
 		LockVersion: lockconfig.LockVersion{
@@ -282,16 +893,38 @@ func (c *CopyOptions) writeImagesLockOutput(processedImages *ctlimgset.Processed
 				return fmt.Errorf("Expected image '%s' to have been copied but was not", image.Image)
 			}
 			imagesLock.Images[i].Image = img.DigestRef
+			c.annotateOrigin(&imagesLock.Images[i], image.Image)
 		}
 	} else {
 		for _, img := range processedImages.All() {
-			imagesLock.Images = append(imagesLock.Images, lockconfig.ImageRef{
+			imageRef := lockconfig.ImageRef{
 				Image: img.DigestRef,
-			})
+			}
+
+			origRef := img.UnprocessedImageRef.OrigRef
+			if origRef == "" {
+				origRef = img.UnprocessedImageRef.DigestRef
+			}
+			c.annotateOrigin(&imageRef, origRef)
+
+			imagesLock.Images = append(imagesLock.Images, imageRef)
 		}
 	}
 
-	return imagesLock.WriteToPath(c.LockOutputFlags.LockFilePath)
+	return c.LockOutputFlags.WriteLock(imagesLock, c.ui)
+}
+
+// annotateOrigin records the reference the image was copied from, when
+// --lock-output-origins was provided and the image was relocated
+func (c *CopyOptions) annotateOrigin(imageRef *lockconfig.ImageRef, origRef string) {
+	if !c.LockOutputOrigins || origRef == "" || origRef == imageRef.Image {
+		return
+	}
+
+	if imageRef.Annotations == nil {
+		imageRef.Annotations = map[string]string{}
+	}
+	imageRef.Annotations[lockconfig.OriginalLocationAnnotationKey] = origRef
 }
 
 func (c *CopyOptions) writeBundleLockOutput(bundle *bundle.Bundle) error {
@@ -306,7 +939,7 @@ func (c *CopyOptions) writeBundleLockOutput(bundle *bundle.Bundle) error {
 		},
 	}
 
-	return bundleLock.WriteToPath(c.LockOutputFlags.LockFilePath)
+	return c.LockOutputFlags.WriteLock(bundleLock, c.ui)
 }
 
 func processedImagesMediaType(processedImages *ctlimgset.ProcessedImages) []string {
@@ -0,0 +1,56 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+)
+
+// ImportReport summary of how importing a tar changes the tags of a
+// destination repository that may already hold other relocated content
+type ImportReport struct {
+	NewTags         []string
+	UnchangedTags   []string
+	OverwrittenTags []ImportTagCollision
+}
+
+// ImportTagCollision tag that already pointed at different content before the
+// import
+type ImportTagCollision struct {
+	Tag            string
+	ExistingDigest string
+	NewDigest      string
+}
+
+// NewImportReport categorizes the tags about to be written against the
+// current state of the destination repository
+func NewImportReport(tags []existingTag) ImportReport {
+	report := ImportReport{}
+	for _, tag := range tags {
+		switch {
+		case !tag.Found:
+			report.NewTags = append(report.NewTags, tag.TagRef)
+		case tag.ExistingDigest == tag.NewDigest:
+			report.UnchangedTags = append(report.UnchangedTags, tag.TagRef)
+		default:
+			report.OverwrittenTags = append(report.OverwrittenTags, ImportTagCollision{
+				Tag:            tag.TagRef,
+				ExistingDigest: tag.ExistingDigest,
+				NewDigest:      tag.NewDigest,
+			})
+		}
+	}
+	return report
+}
+
+// Print reports collisions as warnings and the overall counts at debug level
+func (r ImportReport) Print(ui util.UIWithLevels) {
+	ui.Debugf("Import summary: %d tag(s) newly added, %d tag(s) unchanged, %d tag(s) overwritten\n",
+		len(r.NewTags), len(r.UnchangedTags), len(r.OverwrittenTags))
+
+	for _, collision := range r.OverwrittenTags {
+		ui.Warnf("Tag '%s' already points at %s, overwriting it with %s\n",
+			collision.Tag, collision.ExistingDigest, collision.NewDigest)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	ctlimgset "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+	"sigs.k8s.io/yaml"
+)
+
+// RelocationMapping original reference to relocated reference for every
+// copied image, including images referenced by nested bundles
+type RelocationMapping map[string]string
+
+// NewRelocationMapping builds the mapping from the set of processed images
+func NewRelocationMapping(processedImages *ctlimgset.ProcessedImages) RelocationMapping {
+	mapping := RelocationMapping{}
+	for _, img := range processedImages.All() {
+		mapping[img.UnprocessedImageRef.DigestRef] = img.DigestRef
+	}
+	return mapping
+}
+
+// WriteToPath serializes the mapping to path (JSON when the path ends in
+// .json, YAML otherwise)
+func (m RelocationMapping) WriteToPath(path string) error {
+	var bs []byte
+	var err error
+
+	if strings.HasSuffix(path, ".json") {
+		bs, err = json.MarshalIndent(m, "", "  ")
+		if err == nil {
+			bs = append(bs, '\n')
+		}
+	} else {
+		bs, err = yaml.Marshal(m)
+	}
+	if err != nil {
+		return fmt.Errorf("Marshaling relocation mapping: %s", err)
+	}
+
+	err = util.WriteFileAtomically(path, bs, 0600)
+	if err != nil {
+		return fmt.Errorf("Writing relocation mapping: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/stretchr/testify/require"
+	ctlimgset "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"sigs.k8s.io/yaml"
+)
+
+func TestRelocationMapping(t *testing.T) {
+	img, err := random.Image(512, 1)
+	require.NoError(t, err)
+
+	processedImages := ctlimgset.NewProcessedImages()
+	processedImages.Add(ctlimgset.ProcessedImage{
+		UnprocessedImageRef: ctlimgset.UnprocessedImageRef{DigestRef: "origin.io/app1@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
+		DigestRef:           "dest.io/app1@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		Image:               img,
+	})
+	processedImages.Add(ctlimgset.ProcessedImage{
+		UnprocessedImageRef: ctlimgset.UnprocessedImageRef{DigestRef: "origin.io/app2@sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"},
+		DigestRef:           "dest.io/app2@sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		Image:               img,
+	})
+
+	expectedMapping := map[string]string{
+		"origin.io/app1@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa": "dest.io/app1@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"origin.io/app2@sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb": "dest.io/app2@sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+	}
+
+	t.Run("writes a yaml mapping by default", func(t *testing.T) {
+		mappingPath := filepath.Join(os.TempDir(), "mapping.yml")
+		defer os.Remove(mappingPath)
+
+		require.NoError(t, NewRelocationMapping(processedImages).WriteToPath(mappingPath))
+
+		bs, err := ioutil.ReadFile(mappingPath)
+		require.NoError(t, err)
+
+		var mapping map[string]string
+		require.NoError(t, yaml.Unmarshal(bs, &mapping))
+		require.Equal(t, expectedMapping, mapping)
+	})
+
+	t.Run("writes a json mapping when the path ends in .json", func(t *testing.T) {
+		mappingPath := filepath.Join(os.TempDir(), "mapping.json")
+		defer os.Remove(mappingPath)
+
+		require.NoError(t, NewRelocationMapping(processedImages).WriteToPath(mappingPath))
+
+		bs, err := ioutil.ReadFile(mappingPath)
+		require.NoError(t, err)
+
+		var mapping map[string]string
+		require.NoError(t, json.Unmarshal(bs, &mapping))
+		require.Equal(t, expectedMapping, mapping)
+	})
+}
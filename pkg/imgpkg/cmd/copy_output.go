@@ -0,0 +1,81 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ctlimgset "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"sigs.k8s.io/yaml"
+)
+
+// CopyOutput machine readable result of a copy to a repository
+type CopyOutput struct {
+	Images     []CopiedImage          `json:"images"`
+	ImagesLock *lockconfig.ImagesLock `json:"imagesLock,omitempty"`
+}
+
+// CopiedImage source to destination mapping for a single copied image
+type CopiedImage struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Digest      string `json:"digest"`
+	Tag         string `json:"tag,omitempty"`
+}
+
+// NewCopyOutput builds the output report from the set of processed images
+func NewCopyOutput(processedImages *ctlimgset.ProcessedImages) CopyOutput {
+	output := CopyOutput{Images: []CopiedImage{}}
+	imagesLock := lockconfig.NewEmptyImagesLock()
+
+	for _, img := range processedImages.All() {
+		digest := ""
+		if idx := lastIndexOfDigestSeparator(img.DigestRef); idx >= 0 {
+			digest = img.DigestRef[idx+1:]
+		}
+
+		output.Images = append(output.Images, CopiedImage{
+			Source:      img.UnprocessedImageRef.DigestRef,
+			Destination: img.DigestRef,
+			Digest:      digest,
+			Tag:         img.Tag,
+		})
+
+		imagesLock.AddImageRef(lockconfig.ImageRef{Image: img.DigestRef})
+	}
+
+	output.ImagesLock = &imagesLock
+	return output
+}
+
+// AsBytes serializes the report in the given format (json or yaml)
+func (c CopyOutput) AsBytes(format string) ([]byte, error) {
+	switch format {
+	case "json":
+		bs, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("Marshaling copy output: %s", err)
+		}
+		return append(bs, '\n'), nil
+	case "yaml":
+		bs, err := yaml.Marshal(c)
+		if err != nil {
+			return nil, fmt.Errorf("Marshaling copy output: %s", err)
+		}
+		return bs, nil
+	default:
+		return nil, fmt.Errorf("Unsupported output format '%s' (allowed: json, yaml)", format)
+	}
+}
+
+func lastIndexOfDigestSeparator(ref string) int {
+	for i := len(ref) - 1; i >= 0; i-- {
+		if ref[i] == '@' {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	ctlimgset "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+)
+
+const (
+	// inTotoStatementType type of the generated in-toto statement
+	inTotoStatementType = "https://in-toto.io/Statement/v0.1"
+	// relocationPredicateType predicate type describing an imgpkg relocation
+	relocationPredicateType = "https://carvel.dev/imgpkg/relocation/v1"
+)
+
+// ProvenanceStatement in-toto statement describing a relocation, usable as a
+// cosign-compatible predicate for supply-chain audits
+type ProvenanceStatement struct {
+	Type          string              `json:"_type"`
+	PredicateType string              `json:"predicateType"`
+	Subject       []ProvenanceSubject `json:"subject"`
+	Predicate     RelocationPredicate `json:"predicate"`
+}
+
+// ProvenanceSubject relocated image the statement refers to
+type ProvenanceSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// RelocationPredicate details of the relocation that produced the subjects
+type RelocationPredicate struct {
+	Invocation RelocationInvocation `json:"invocation"`
+	Images     []RelocatedImage     `json:"images"`
+}
+
+// RelocationInvocation tool and time of the relocation
+type RelocationInvocation struct {
+	Tool      string `json:"tool"`
+	Version   string `json:"version"`
+	Timestamp string `json:"timestamp"`
+}
+
+// RelocatedImage source to destination provenance for a single image
+type RelocatedImage struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+// NewProvenanceStatement builds a provenance statement from the set of
+// processed images
+func NewProvenanceStatement(processedImages *ctlimgset.ProcessedImages) ProvenanceStatement {
+	statement := ProvenanceStatement{
+		Type:          inTotoStatementType,
+		PredicateType: relocationPredicateType,
+		Subject:       []ProvenanceSubject{},
+		Predicate: RelocationPredicate{
+			Invocation: RelocationInvocation{
+				Tool:      "imgpkg",
+				Version:   Version,
+				Timestamp: time.Now().UTC().Format(time.RFC3339),
+			},
+			Images: []RelocatedImage{},
+		},
+	}
+
+	for _, img := range processedImages.All() {
+		if idx := strings.Index(img.DigestRef, "@"); idx >= 0 {
+			digestParts := strings.SplitN(img.DigestRef[idx+1:], ":", 2)
+			if len(digestParts) == 2 {
+				statement.Subject = append(statement.Subject, ProvenanceSubject{
+					Name:   img.DigestRef[:idx],
+					Digest: map[string]string{digestParts[0]: digestParts[1]},
+				})
+			}
+		}
+
+		statement.Predicate.Images = append(statement.Predicate.Images, RelocatedImage{
+			Source:      img.UnprocessedImageRef.DigestRef,
+			Destination: img.DigestRef,
+		})
+	}
+
+	return statement
+}
+
+// WriteToPath serializes the statement as JSON to path
+func (s ProvenanceStatement) WriteToPath(path string) error {
+	bs, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Marshaling provenance statement: %s", err)
+	}
+
+	err = ioutil.WriteFile(path, append(bs, '\n'), 0600)
+	if err != nil {
+		return fmt.Errorf("Writing provenance statement: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctlimgset "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+)
+
+func TestProvenanceStatement(t *testing.T) {
+	img, err := random.Image(512, 1)
+	require.NoError(t, err)
+
+	digest := "sha256:cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc"
+	processedImages := ctlimgset.NewProcessedImages()
+	processedImages.Add(ctlimgset.ProcessedImage{
+		UnprocessedImageRef: ctlimgset.UnprocessedImageRef{DigestRef: "origin.io/app1@" + digest},
+		DigestRef:           "dest.io/app1@" + digest,
+		Image:               img,
+	})
+
+	statement := NewProvenanceStatement(processedImages)
+
+	require.Len(t, statement.Subject, 1)
+	assert.Equal(t, "dest.io/app1", statement.Subject[0].Name)
+	assert.Equal(t, map[string]string{"sha256": "cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc"}, statement.Subject[0].Digest)
+
+	require.Len(t, statement.Predicate.Images, 1)
+	assert.Equal(t, "origin.io/app1@"+digest, statement.Predicate.Images[0].Source)
+	assert.Equal(t, "dest.io/app1@"+digest, statement.Predicate.Images[0].Destination)
+	assert.Equal(t, "imgpkg", statement.Predicate.Invocation.Tool)
+	assert.NotEmpty(t, statement.Predicate.Invocation.Timestamp)
+
+	statementPath := filepath.Join(os.TempDir(), "provenance.json")
+	defer os.Remove(statementPath)
+	require.NoError(t, statement.WriteToPath(statementPath))
+
+	bs, err := os.ReadFile(statementPath)
+	require.NoError(t, err)
+
+	var parsed ProvenanceStatement
+	require.NoError(t, json.Unmarshal(bs, &parsed))
+	assert.Equal(t, statement, parsed)
+}
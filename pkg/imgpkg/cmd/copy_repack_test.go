@@ -0,0 +1,104 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagetar"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestRepackTar(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+
+	image1 := fakeRegistry.WithRandomImage("library/image-1")
+	image2 := fakeRegistry.WithRandomImage("library/image-2")
+
+	subject := subject
+	subject.ImageFlags = ImageFlags{Images: []string{image1.RefDigest, image2.RefDigest}}
+	subject.registry = fakeRegistry.Build()
+
+	srcTarPath := filepath.Join(os.TempDir(), "repack-src.tar")
+	defer os.Remove(srcTarPath)
+
+	require.NoError(t, subject.CopyToTar(srcTarPath))
+
+	t.Run("keeps only included images", func(t *testing.T) {
+		dstTarPath := filepath.Join(os.TempDir(), "repack-include.tar")
+		defer os.Remove(dstTarPath)
+
+		opts := imagetar.RepackOpts{IncludeRefs: []string{image1.RefDigest}}
+		err := imagetar.Repack(srcTarPath, dstTarPath, opts, imagetar.TarWriterOpts{Concurrency: 1},
+			goui.NewNoopUI(), imagetar.NewImageLayerWriterCheck(false))
+		require.NoError(t, err)
+
+		assertTarRefs(t, dstTarPath, []string{image1.RefDigest})
+	})
+
+	t.Run("drops excluded images", func(t *testing.T) {
+		dstTarPath := filepath.Join(os.TempDir(), "repack-exclude.tar")
+		defer os.Remove(dstTarPath)
+
+		opts := imagetar.RepackOpts{ExcludeRefs: []string{image1.RefDigest}}
+		err := imagetar.Repack(srcTarPath, dstTarPath, opts, imagetar.TarWriterOpts{Concurrency: 1},
+			goui.NewNoopUI(), imagetar.NewImageLayerWriterCheck(false))
+		require.NoError(t, err)
+
+		assertTarRefs(t, dstTarPath, []string{image2.RefDigest})
+	})
+
+	t.Run("matches references by repository", func(t *testing.T) {
+		dstTarPath := filepath.Join(os.TempDir(), "repack-repo.tar")
+		defer os.Remove(dstTarPath)
+
+		repo := fakeRegistry.ReferenceOnTestServer("library/image-2")
+		opts := imagetar.RepackOpts{IncludeRefs: []string{repo}}
+		err := imagetar.Repack(srcTarPath, dstTarPath, opts, imagetar.TarWriterOpts{Concurrency: 1},
+			goui.NewNoopUI(), imagetar.NewImageLayerWriterCheck(false))
+		require.NoError(t, err)
+
+		assertTarRefs(t, dstTarPath, []string{image2.RefDigest})
+	})
+
+	t.Run("errors when filters select no images", func(t *testing.T) {
+		dstTarPath := filepath.Join(os.TempDir(), "repack-empty.tar")
+		defer os.Remove(dstTarPath)
+
+		opts := imagetar.RepackOpts{IncludeRefs: []string{"library/does-not-exist"}}
+		err := imagetar.Repack(srcTarPath, dstTarPath, opts, imagetar.TarWriterOpts{Concurrency: 1},
+			goui.NewNoopUI(), imagetar.NewImageLayerWriterCheck(false))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected filters to select at least one image")
+	})
+}
+
+func TestCopyRejectsTarFiltersWithoutTarToTar(t *testing.T) {
+	copyOpts := NewCopyOptions(nil)
+	copyOpts.ui = goui.NewNoopUI()
+	copyOpts.ImageFlags = ImageFlags{Image: "some/image"}
+	copyOpts.RepoDst = "some/other-repo"
+	copyOpts.IncludeImages = []string{"some/image"}
+
+	err := copyOpts.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only be used when copying between tars")
+}
+
+func assertTarRefs(t *testing.T, tarPath string, expectedRefs []string) {
+	descs, err := imagetar.NewTarReader(tarPath).Read()
+	require.NoError(t, err)
+
+	var refs []string
+	for _, desc := range descs {
+		refs = append(refs, desc.Ref())
+	}
+	require.ElementsMatch(t, expectedRefs, refs)
+}
@@ -5,8 +5,14 @@ package cmd
 
 import (
 	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
 
 	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	ctlbundle "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
@@ -29,12 +35,21 @@ type CopyRepoSrc struct {
 	TarFlags                TarFlags
 	IncludeNonDistributable bool
 	Concurrency             int
+	SkipOptionalImages      bool
+	NoRecursive             bool
+	Depth                   int
+	FailOnTagConflict       bool
+	TransferManifestOutput  string
+	PerBundleSubRepos       bool
+	RepoRenameRules         []repoRenameRule
 
 	ui                 util.UIWithLevels
 	imageSet           ctlimgset.ImageSet
 	tarImageSet        ctlimgset.TarImageSet
 	registry           registry.ImagesReaderWriter
 	signatureRetriever SignatureRetriever
+	scanner            ImageScanner
+	policyFile         string
 }
 
 func (c CopyRepoSrc) CopyToTar(dstPath string) error {
@@ -51,6 +66,41 @@ func (c CopyRepoSrc) CopyToTar(dstPath string) error {
 		return err
 	}
 
+	informUserToUseTheNonDistributableFlagWithDescriptors(
+		c.ui, c.IncludeNonDistributable, imageRefDescriptorsMediaTypes(ids))
+
+	if c.TransferManifestOutput != "" {
+		manifest, err := NewTransferManifest(unprocessedImageRefs, ids, dstPath)
+		if err != nil {
+			return err
+		}
+
+		err = manifest.WriteToPath(c.TransferManifestOutput)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CopyToTarDestination writes the source images into a tar at the given
+// destination, allowing sinks other than the local filesystem (ex: object
+// storage) to be plugged in
+func (c CopyRepoSrc) CopyToTarDestination(dst imagetar.Destination) error {
+	c.ui.Tracef("CopyToTarDestination(%s)\n", dst.Description())
+
+	unprocessedImageRefs, _, err := c.getAllSourceImages()
+	if err != nil {
+		return err
+	}
+
+	ids, err := c.tarImageSet.ExportTo(unprocessedImageRefs, dst, c.registry,
+		imagetar.NewImageLayerWriterCheck(c.IncludeNonDistributable))
+	if err != nil {
+		return err
+	}
+
 	informUserToUseTheNonDistributableFlagWithDescriptors(
 		c.ui, c.IncludeNonDistributable, imageRefDescriptorsMediaTypes(ids))
 
@@ -71,7 +121,12 @@ func (c CopyRepoSrc) CopyToRepo(repo string) (*ctlimgset.ProcessedImages, error)
 			return nil, fmt.Errorf("Cannot use tar source (--tar) with tar destination (--to-tar)")
 		}
 
-		processedImages, err = c.tarImageSet.Import(c.TarFlags.TarSrc, importRepo, c.registry)
+		tarSrc, err := imagetar.NewSource(c.TarFlags.TarSrc)
+		if err != nil {
+			return nil, err
+		}
+
+		processedImages, err = c.tarImageSet.ImportFrom(tarSrc, importRepo, c.registry)
 		if err != nil {
 			return nil, err
 		}
@@ -110,7 +165,11 @@ func (c CopyRepoSrc) CopyToRepo(repo string) (*ctlimgset.ProcessedImages, error)
 			return nil, err
 		}
 
-		processedImages, err = c.imageSet.Relocate(unprocessedImageRefs, importRepo, c.registry)
+		if c.PerBundleSubRepos && len(bundles) > 0 {
+			processedImages, err = c.relocatePerBundle(unprocessedImageRefs, importRepo, bundles)
+		} else {
+			processedImages, err = c.imageSet.Relocate(unprocessedImageRefs, importRepo, c.registry)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -125,6 +184,21 @@ func (c CopyRepoSrc) CopyToRepo(repo string) (*ctlimgset.ProcessedImages, error)
 	informUserToUseTheNonDistributableFlagWithDescriptors(
 		c.ui, c.IncludeNonDistributable, processedImagesMediaType(processedImages))
 
+	if c.TarFlags.IsSrc() {
+		tags, err := c.lookupExistingTags(processedImages)
+		if err != nil {
+			return nil, err
+		}
+		NewImportReport(tags).Print(c.ui)
+	}
+
+	if c.FailOnTagConflict {
+		err = c.checkTagConflicts(processedImages)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	err = c.tagAllImages(processedImages)
 	if err != nil {
 		return nil, fmt.Errorf("Tagging images: %s", err)
@@ -139,6 +213,26 @@ func (c CopyRepoSrc) getAllSourceImages() (*ctlimgset.UnprocessedImageRefs, []*c
 		return nil, nil, err
 	}
 
+	if c.policyFile != "" {
+		c.ui.Debugf("Checking policy\n")
+
+		var refs []string
+		for _, ref := range unprocessedImageRefs.All() {
+			refs = append(refs, ref.DigestRef)
+		}
+
+		violations, err := checkPolicy(c.policyFile, refs, c.registry)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, violation := range violations {
+			c.ui.Errorf("Policy violation: %s\n", violation)
+		}
+		if len(violations) > 0 {
+			return nil, nil, policyViolationsError(c.policyFile, len(violations))
+		}
+	}
+
 	c.ui.Debugf("Fetching signatures\n")
 
 	signatures, err := c.signatureRetriever.Fetch(unprocessedImageRefs)
@@ -150,6 +244,19 @@ func (c CopyRepoSrc) getAllSourceImages() (*ctlimgset.UnprocessedImageRefs, []*c
 		unprocessedImageRefs.Add(signature)
 	}
 
+	if c.scanner != nil {
+		c.ui.Debugf("Scanning images\n")
+
+		err = c.scanner.Scan(unprocessedImageRefs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if dups := unprocessedImageRefs.DuplicateCount(); dups > 0 {
+		c.ui.Debugf("Deduplicated %d reference(s) to digests already in the transfer set\n", dups)
+	}
+
 	return unprocessedImageRefs, bundles, nil
 }
 
@@ -171,6 +278,10 @@ func (c CopyRepoSrc) getProvidedSourceImages() (*ctlimgset.UnprocessedImageRefs,
 			}
 
 			for _, img := range imagesRef.ImageRefs() {
+				if c.skipOptionalImage(img.ImageRef) {
+					continue
+				}
+
 				unprocessedImageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: img.PrimaryLocation()})
 			}
 
@@ -187,6 +298,10 @@ func (c CopyRepoSrc) getProvidedSourceImages() (*ctlimgset.UnprocessedImageRefs,
 		case imagesLock != nil:
 			c.ui.Tracef("get images from ImagesLock file\n")
 			for _, img := range imagesLock.Images {
+				if c.skipOptionalImage(img) {
+					continue
+				}
+
 				plainImg := plainimage.NewPlainImage(img.Image, c.registry)
 
 				ok, err := ctlbundle.NewBundleFromPlainImage(plainImg, c.registry).IsBundle()
@@ -205,19 +320,26 @@ func (c CopyRepoSrc) getProvidedSourceImages() (*ctlimgset.UnprocessedImageRefs,
 			panic("Unreachable")
 		}
 
-	case c.ImageFlags.Image != "":
-		c.ui.Tracef("copy single image\n")
-		plainImg := plainimage.NewPlainImage(c.ImageFlags.Image, c.registry)
-
-		ok, err := ctlbundle.NewBundleFromPlainImage(plainImg, c.registry).IsBundle()
+	case c.ImageFlags.Image != "" || len(c.ImageFlags.Images) > 0 || c.ImageFlags.ImagesFilePath != "":
+		c.ui.Tracef("copy plain images\n")
+		imageRefs, err := c.ImageFlags.AllImages()
 		if err != nil {
 			return nil, nil, err
 		}
-		if ok {
-			return nil, nil, fmt.Errorf("Expected bundle flag when copying a bundle (hint: Use -b instead of -i for bundles)")
-		}
 
-		unprocessedImageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: plainImg.DigestRef(), Tag: plainImg.Tag()})
+		for _, imageRef := range imageRefs {
+			plainImg := plainimage.NewPlainImage(imageRef, c.registry)
+
+			ok, err := ctlbundle.NewBundleFromPlainImage(plainImg, c.registry).IsBundle()
+			if err != nil {
+				return nil, nil, err
+			}
+			if ok {
+				return nil, nil, fmt.Errorf("Expected bundle flag when copying a bundle (hint: Use -b instead of -i for bundles)")
+			}
+
+			unprocessedImageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: plainImg.DigestRef(), Tag: plainImg.Tag()})
+		}
 		return unprocessedImageRefs, nil, nil
 
 	default:
@@ -228,6 +350,10 @@ func (c CopyRepoSrc) getProvidedSourceImages() (*ctlimgset.UnprocessedImageRefs,
 		}
 
 		for _, img := range imagesRef.ImageRefs() {
+			if c.skipOptionalImage(img.ImageRef) {
+				continue
+			}
+
 			unprocessedImageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: img.PrimaryLocation(), OrigRef: img.Image})
 		}
 
@@ -243,6 +369,109 @@ func (c CopyRepoSrc) getProvidedSourceImages() (*ctlimgset.UnprocessedImageRefs,
 	}
 }
 
+// skipOptionalImage checks if the image was marked as optional via the
+// 'imgpkg.carvel.dev/copy: skip' annotation and --skip-optional-images was
+// provided, reporting each image being left out
+func (c CopyRepoSrc) skipOptionalImage(img lockconfig.ImageRef) bool {
+	if !c.SkipOptionalImages || !img.SkipOnCopy() {
+		return false
+	}
+
+	c.ui.Warnf("Skipping optional image '%s' (annotated '%s: %s')\n", img.Image, lockconfig.CopyModeAnnotationKey, lockconfig.CopyModeSkip)
+	return true
+}
+
+// relocatePerBundle relocates the root bundle together with every image into
+// a sub-repository of importRepo named after the root bundle, and copies each
+// nested bundle along with the images it references into its own
+// sub-repository, so every bundle stays pullable from a repository of its own
+func (c CopyRepoSrc) relocatePerBundle(unprocessedImageRefs *ctlimgset.UnprocessedImageRefs, importRepo regname.Repository, bundles []*ctlbundle.Bundle) (*ctlimgset.ProcessedImages, error) {
+	rootBundleRef := ""
+	for _, ref := range unprocessedImageRefs.All() {
+		if _, ok := ref.Labels[rootBundleLabelKey]; ok {
+			rootBundleRef = ref.DigestRef
+		}
+	}
+
+	processedImages := ctlimgset.NewProcessedImages()
+	for _, bundle := range bundles {
+		if bundle.DigestRef() == rootBundleRef {
+			continue
+		}
+
+		subRepo, err := bundleSubRepository(importRepo, bundle.DigestRef(), c.RepoRenameRules)
+		if err != nil {
+			return nil, err
+		}
+
+		_, imageRefs, err := bundle.AllImagesLockRefs(c.Concurrency, c.ui)
+		if err != nil {
+			return nil, fmt.Errorf("Reading Images from Bundle: %s", err)
+		}
+
+		bundleImageRefs := ctlimgset.NewUnprocessedImageRefs()
+		bundleImageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: bundle.DigestRef(), OrigRef: bundle.DigestRef()})
+		for _, img := range imageRefs.ImageRefs() {
+			if c.skipOptionalImage(img.ImageRef) {
+				continue
+			}
+
+			bundleImageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: img.PrimaryLocation(), OrigRef: img.Image})
+		}
+
+		bundleProcessedImages, err := c.imageSet.Relocate(bundleImageRefs, subRepo, c.registry)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := bundle.NoteCopy(bundleProcessedImages, c.registry, c.ui); err != nil {
+			return nil, fmt.Errorf("Creating copy information for bundle %s: %s", bundle.DigestRef(), err)
+		}
+
+		for _, img := range bundleProcessedImages.All() {
+			processedImages.Add(img)
+		}
+	}
+
+	rootRepo, err := bundleSubRepository(importRepo, rootBundleRef, c.RepoRenameRules)
+	if err != nil {
+		return nil, err
+	}
+
+	rootProcessedImages, err := c.imageSet.Relocate(unprocessedImageRefs, rootRepo, c.registry)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, img := range rootProcessedImages.All() {
+		processedImages.Add(img)
+	}
+
+	return processedImages, nil
+}
+
+// bundleSubRepository returns the sub-repository of importRepo named after
+// the last path segment of the bundle's original repository. Rename rules are
+// matched against the bundle's repository as provided (registry included);
+// the first matching rule's replacement becomes the sub-repository path
+func bundleSubRepository(importRepo regname.Repository, bundleRef string, renameRules []repoRenameRule) (regname.Repository, error) {
+	digest, err := regname.NewDigest(bundleRef)
+	if err != nil {
+		return regname.Repository{}, fmt.Errorf("Parsing bundle reference '%s': %s", bundleRef, err)
+	}
+
+	srcRepo := strings.Split(bundleRef, "@")[0]
+	if renamed, matched := renameRepository(renameRules, srcRepo); matched {
+		subRepo, err := regname.NewRepository(importRepo.Name() + "/" + renamed)
+		if err != nil {
+			return regname.Repository{}, fmt.Errorf("Building renamed repository '%s': %s", renamed, err)
+		}
+		return subRepo, nil
+	}
+
+	return regname.NewRepository(importRepo.Name() + "/" + path.Base(digest.Context().RepositoryStr()))
+}
+
 func (c CopyRepoSrc) getBundleImageRefs(bundleRef string) (*ctlbundle.Bundle, []*ctlbundle.Bundle, ctlbundle.ImageRefs, error) {
 	bundle := ctlbundle.NewBundle(bundleRef, c.registry)
 	isBundle, err := bundle.IsBundle()
@@ -253,7 +482,18 @@ func (c CopyRepoSrc) getBundleImageRefs(bundleRef string) (*ctlbundle.Bundle, []
 		return nil, nil, ctlbundle.ImageRefs{}, fmt.Errorf("Expected bundle image but found plain image (hint: Did you use -i instead of -b?)")
 	}
 
-	nestedBundles, imageRefs, err := bundle.AllImagesLockRefs(c.Concurrency, c.ui)
+	if c.NoRecursive {
+		c.ui.Warnf("--no-recursive: only the bundle image is copied; images it references are not relocated and remain in the source registry\n")
+		return bundle, nil, ctlbundle.ImageRefs{}, nil
+	}
+
+	depth := -1
+	if c.Depth > 0 {
+		depth = c.Depth
+		c.ui.Warnf("--depth %d: image references nested beyond the depth limit are not relocated and remain in the source registry\n", c.Depth)
+	}
+
+	nestedBundles, imageRefs, err := bundle.AllImagesLockRefsWithDepth(c.Concurrency, c.ui, depth)
 	if err != nil {
 		return nil, nil, ctlbundle.ImageRefs{}, fmt.Errorf("Reading Images from Bundle: %s", err)
 	}
@@ -328,3 +568,106 @@ func (c CopyRepoSrc) tagAllImages(processedImages *ctlimgset.ProcessedImages) er
 	}
 	return nil
 }
+
+var tagNotFoundStatusCode = map[int]struct{}{
+	http.StatusNotFound:     {},
+	http.StatusUnauthorized: {},
+	http.StatusForbidden:    {},
+}
+
+// existingTag state of a destination tag that is about to be written
+type existingTag struct {
+	TagRef         string
+	NewDigest      string
+	ExistingDigest string
+	Found          bool
+}
+
+// lookupExistingTags resolves the current digest of every tag that is about
+// to be written to the destination repository
+func (c CopyRepoSrc) lookupExistingTags(processedImages *ctlimgset.ProcessedImages) ([]existingTag, error) {
+	throttle := util.NewThrottle(c.Concurrency)
+
+	var tagsLock sync.Mutex
+	var tags []existingTag
+
+	totalThreads := 0
+	errCh := make(chan error, processedImages.Len())
+	for _, item := range processedImages.All() {
+		item := item // copy
+
+		if item.Tag == "" {
+			continue
+		}
+
+		totalThreads++
+		go func() {
+			throttle.Take()
+			defer throttle.Done()
+
+			digest, err := regname.NewDigest(item.DigestRef)
+			if err != nil {
+				panic(fmt.Sprintf("Internal consistency: %s should be a digest", item.DigestRef))
+			}
+
+			customTagRef := digest.Tag(item.Tag)
+
+			existingDigest, err := c.registry.Digest(customTagRef)
+			if err != nil {
+				if terr, ok := err.(*transport.Error); ok {
+					if _, found := tagNotFoundStatusCode[terr.StatusCode]; found {
+						tagsLock.Lock()
+						tags = append(tags, existingTag{TagRef: customTagRef.Name(), NewDigest: digest.DigestStr()})
+						tagsLock.Unlock()
+						errCh <- nil
+						return
+					}
+				}
+				errCh <- fmt.Errorf("Checking existing tag '%s': %s", customTagRef.Name(), err)
+				return
+			}
+
+			tagsLock.Lock()
+			tags = append(tags, existingTag{
+				TagRef:         customTagRef.Name(),
+				NewDigest:      digest.DigestStr(),
+				ExistingDigest: existingDigest.String(),
+				Found:          true,
+			})
+			tagsLock.Unlock()
+			errCh <- nil
+		}()
+	}
+
+	for i := 0; i < totalThreads; i++ {
+		err := <-errCh
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].TagRef < tags[j].TagRef })
+	return tags, nil
+}
+
+// checkTagConflicts verifies that none of the tags about to be written already
+// point at a different digest in the destination repository
+func (c CopyRepoSrc) checkTagConflicts(processedImages *ctlimgset.ProcessedImages) error {
+	tags, err := c.lookupExistingTags(processedImages)
+	if err != nil {
+		return err
+	}
+
+	var conflicts []string
+	for _, tag := range tags {
+		if tag.Found && tag.ExistingDigest != tag.NewDigest {
+			conflicts = append(conflicts, fmt.Sprintf("tag '%s' points at %s, refusing to move it to %s",
+				tag.TagRef, tag.ExistingDigest, tag.NewDigest))
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("Found %d tag conflict(s) in the destination repository:\n- %s", len(conflicts), strings.Join(conflicts, "\n- "))
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ package cmd
 import (
 	"archive/tar"
 	"bytes"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -55,7 +57,7 @@ func TestMain(m *testing.M) {
 	subject = CopyRepoSrc{
 		ui:                 uiLogger,
 		imageSet:           imageSet,
-		tarImageSet:        imageset.NewTarImageSet(imageSet, 1, confUI),
+		tarImageSet:        imageset.NewTarImageSet(imageSet, 1, 0, false, confUI),
 		Concurrency:        1,
 		signatureRetriever: &fakeSignatureRetriever{},
 	}
@@ -126,6 +128,117 @@ bundle:
 	})
 }
 
+func TestToTarTransferManifest(t *testing.T) {
+	bundleName := "library/bundle"
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	bundleWithImages := fakeRegistry.WithBundleFromPath(bundleName, "test_assets/bundle").
+		WithEveryImageFromPath("test_assets/image_with_config", map[string]string{})
+
+	defer fakeRegistry.CleanUp()
+
+	subject := subject
+	subject.BundleFlags = BundleFlags{fakeRegistry.ReferenceOnTestServer(bundleName)}
+	subject.registry = fakeRegistry.Build()
+
+	t.Run("when a json output is requested, it describes the tar contents", func(t *testing.T) {
+		assets := &helpers.Assets{T: t}
+		defer assets.CleanCreatedFolders()
+
+		tempFolder := assets.CreateTempFolder("transfer-manifest")
+		bundleTarPath := filepath.Join(tempFolder, "bundle.tar")
+		manifestPath := filepath.Join(tempFolder, "transfer.json")
+
+		subject := subject
+		subject.TransferManifestOutput = manifestPath
+
+		err := subject.CopyToTar(bundleTarPath)
+		require.NoError(t, err)
+
+		bs, err := ioutil.ReadFile(manifestPath)
+		require.NoError(t, err)
+
+		var manifest TransferManifest
+		require.NoError(t, json.Unmarshal(bs, &manifest))
+
+		assert.Equal(t, bundleWithImages.RefDigest, manifest.Bundle)
+		require.Len(t, manifest.Images, 2)
+		assert.Greater(t, manifest.TotalLayersSizeBytes, int64(0))
+
+		tarBs, err := ioutil.ReadFile(bundleTarPath)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(tarBs)), manifest.TarSizeBytes)
+		assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256(tarBs)), manifest.TarChecksumSHA256)
+	})
+
+	t.Run("when no json extension is used, it writes a markdown summary", func(t *testing.T) {
+		assets := &helpers.Assets{T: t}
+		defer assets.CleanCreatedFolders()
+
+		tempFolder := assets.CreateTempFolder("transfer-manifest")
+		bundleTarPath := filepath.Join(tempFolder, "bundle.tar")
+		manifestPath := filepath.Join(tempFolder, "TRANSFER.md")
+
+		subject := subject
+		subject.TransferManifestOutput = manifestPath
+
+		err := subject.CopyToTar(bundleTarPath)
+		require.NoError(t, err)
+
+		bs, err := ioutil.ReadFile(manifestPath)
+		require.NoError(t, err)
+
+		assert.Contains(t, string(bs), "# Transfer Manifest")
+		assert.Contains(t, string(bs), bundleWithImages.RefDigest)
+	})
+}
+
+func TestToTarBundleSkipsOptionalImages(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+
+	requiredImage := fakeRegistry.WithRandomImage("library/required-image")
+	optionalImage := fakeRegistry.WithRandomImage("library/optional-image")
+
+	fakeRegistry.WithBundleFromPath("library/bundle-with-optional", "test_assets/bundle").
+		WithImageRefs([]lockconfig.ImageRef{
+			{Image: requiredImage.RefDigest},
+			{Image: optionalImage.RefDigest, Annotations: map[string]string{
+				lockconfig.CopyModeAnnotationKey: lockconfig.CopyModeSkip,
+			}},
+		})
+
+	subject := subject
+	subject.BundleFlags = BundleFlags{fakeRegistry.ReferenceOnTestServer("library/bundle-with-optional")}
+	subject.registry = fakeRegistry.Build()
+
+	t.Run("with --skip-optional-images, annotated images are left out", func(t *testing.T) {
+		subject := subject
+		subject.SkipOptionalImages = true
+
+		bundleTarPath := filepath.Join(os.TempDir(), "bundle-skip-optional.tar")
+		defer os.Remove(bundleTarPath)
+
+		err := subject.CopyToTar(bundleTarPath)
+		require.NoError(t, err)
+
+		refs := tarballImageRefs(t, bundleTarPath)
+		assert.Contains(t, refs, requiredImage.RefDigest)
+		assert.NotContains(t, refs, optionalImage.RefDigest)
+	})
+
+	t.Run("without the flag, annotated images are copied", func(t *testing.T) {
+		bundleTarPath := filepath.Join(os.TempDir(), "bundle-with-optional.tar")
+		defer os.Remove(bundleTarPath)
+
+		err := subject.CopyToTar(bundleTarPath)
+		require.NoError(t, err)
+
+		refs := tarballImageRefs(t, bundleTarPath)
+		assert.Contains(t, refs, requiredImage.RefDigest)
+		assert.Contains(t, refs, optionalImage.RefDigest)
+	})
+}
+
 func TestToTarBundleContainingNonDistributableLayers(t *testing.T) {
 	bundleName := "library/bundle"
 	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
@@ -232,7 +345,7 @@ func TestToTarImage(t *testing.T) {
 
 	subject := subject
 	subject.ImageFlags = ImageFlags{
-		fakeRegistry.ReferenceOnTestServer(imageName),
+		Image: fakeRegistry.ReferenceOnTestServer(imageName),
 	}
 	subject.registry = fakeRegistry.Build()
 
@@ -282,7 +395,7 @@ func TestToTarImageContainingNonDistributableLayers(t *testing.T) {
 	defer fakeRegistry.CleanUp()
 	subject := subject
 	subject.ImageFlags = ImageFlags{
-		fakeRegistry.ReferenceOnTestServer(imageName),
+		Image: fakeRegistry.ReferenceOnTestServer(imageName),
 	}
 	subject.registry = fakeRegistry.Build()
 
@@ -321,7 +434,7 @@ func TestToTarImageIndex(t *testing.T) {
 
 	subject := subject
 	subject.ImageFlags = ImageFlags{
-		imageIndex.RefDigest,
+		Image: imageIndex.RefDigest,
 	}
 	subject.registry = fakeRegistry.Build()
 
@@ -343,7 +456,7 @@ func TestToRepoImageIndex(t *testing.T) {
 	defer fakeRegistry.CleanUp()
 	subject := subject
 	subject.ImageFlags = ImageFlags{
-		randomImageIndex.RefDigest,
+		Image: randomImageIndex.RefDigest,
 	}
 	destinationImageName := "library/copied-img"
 
@@ -530,6 +643,63 @@ images:
 	})
 }
 
+func TestToRepoBundlePerBundleSubRepositories(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+	randomImage := fakeRegistry.WithRandomImage("library/image_with_config")
+
+	nestedBundle := fakeRegistry.WithBundleFromPath("library/nested-bundle", "test_assets/bundle_with_mult_images").
+		WithImageRefs([]lockconfig.ImageRef{
+			{Image: randomImage.RefDigest},
+		})
+
+	outerBundle := fakeRegistry.WithBundleFromPath("library/outer-bundle", "test_assets/bundle_with_mult_images").
+		WithImageRefs([]lockconfig.ImageRef{
+			{Image: nestedBundle.RefDigest},
+		})
+
+	subject := subject
+	subject.BundleFlags.Bundle = outerBundle.RefDigest
+	subject.PerBundleSubRepos = true
+	subject.registry = fakeRegistry.Build()
+
+	destRepo := fakeRegistry.ReferenceOnTestServer("library/bundle-copy")
+	processedImages, err := subject.CopyToRepo(destRepo)
+	require.NoError(t, err)
+
+	require.Len(t, processedImages.All(), 3)
+
+	var processedBundle imageset.ProcessedImage
+	processedImageDigest := []string{}
+	for _, processedImage := range processedImages.All() {
+		processedImageDigest = append(processedImageDigest, processedImage.DigestRef)
+		if _, ok := processedImage.Labels[rootBundleLabelKey]; ok {
+			processedBundle = processedImage
+		}
+	}
+
+	// every image ends up in the root bundle's sub-repository
+	assert.ElementsMatch(t, processedImageDigest, []string{
+		destRepo + "/outer-bundle@" + outerBundle.Digest,
+		destRepo + "/outer-bundle@" + nestedBundle.Digest,
+		destRepo + "/outer-bundle@" + randomImage.Digest,
+	})
+	assert.Equal(t, destRepo+"/outer-bundle@"+outerBundle.Digest, processedBundle.DigestRef)
+
+	// the nested bundle and the images it references are also copied to the
+	// nested bundle's own sub-repository
+	reg := fakeRegistry.Build()
+	for _, ref := range []string{
+		destRepo + "/nested-bundle@" + nestedBundle.Digest,
+		destRepo + "/nested-bundle@" + randomImage.Digest,
+	} {
+		digestRef, err := name.NewDigest(ref)
+		require.NoError(t, err)
+		_, err = reg.Digest(digestRef)
+		require.NoError(t, err, "expected '%s' to exist", ref)
+	}
+}
+
 func TestToRepoBundleCreatesValidLocationOCI(t *testing.T) {
 	logger := &helpers.Logger{LogLevel: helpers.LogDebug}
 	fakeRegistry := helpers.NewFakeRegistry(t, logger)
@@ -968,7 +1138,7 @@ func TestToRepoImage(t *testing.T) {
 	defer fakeRegistry.CleanUp()
 	subject := subject
 	subject.ImageFlags = ImageFlags{
-		fakeRegistry.ReferenceOnTestServer(imageName),
+		Image: fakeRegistry.ReferenceOnTestServer(imageName),
 	}
 
 	t.Run("When Include-non-distributable-layers flag is provided a warning message should be printed", func(t *testing.T) {
@@ -1124,6 +1294,80 @@ images:
 	})
 }
 
+func TestToRepoFromTarImportReport(t *testing.T) {
+	assets := &helpers.Assets{T: t}
+	defer assets.CleanCreatedFolders()
+
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+
+	fakeRegistry.WithRandomImage("library/image-to-copy:release-1.0")
+	fakeRegistry.WithRandomImage("library/collision-dest:release-1.0")
+
+	subject := subject
+	subject.ImageFlags = ImageFlags{
+		Image: fakeRegistry.ReferenceOnTestServer("library/image-to-copy:release-1.0"),
+	}
+	subject.registry = fakeRegistry.Build()
+
+	imageTarPath := filepath.Join(assets.CreateTempFolder("tar-import"), "image.tar")
+	require.NoError(t, subject.CopyToTar(imageTarPath))
+
+	subject.ImageFlags = ImageFlags{}
+	subject.TarFlags.TarSrc = imageTarPath
+
+	t.Run("when a destination tag points at different content, it warns about the collision", func(t *testing.T) {
+		stdOut.Reset()
+
+		_, err := subject.CopyToRepo(fakeRegistry.ReferenceOnTestServer("library/collision-dest"))
+		require.NoError(t, err)
+
+		assert.Contains(t, stdOut.String(), "Warning: Tag")
+		assert.Contains(t, stdOut.String(), "overwriting it with")
+	})
+
+	t.Run("when the destination repository is empty, no collision is reported", func(t *testing.T) {
+		stdOut.Reset()
+
+		_, err := subject.CopyToRepo(fakeRegistry.ReferenceOnTestServer("library/fresh-dest"))
+		require.NoError(t, err)
+
+		assert.NotContains(t, stdOut.String(), "overwriting it with")
+	})
+}
+
+func TestToRepoImageFailOnTagConflict(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+
+	fakeRegistry.WithRandomImage("library/image-to-copy:release-1.0")
+	fakeRegistry.WithRandomImage("library/conflicting-dest:release-1.0")
+
+	subject := subject
+	subject.ImageFlags = ImageFlags{
+		Image: fakeRegistry.ReferenceOnTestServer("library/image-to-copy:release-1.0"),
+	}
+	subject.FailOnTagConflict = true
+	subject.registry = fakeRegistry.Build()
+
+	t.Run("when the destination tag points at a different digest, it fails listing the conflicting tag", func(t *testing.T) {
+		_, err := subject.CopyToRepo(fakeRegistry.ReferenceOnTestServer("library/conflicting-dest"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tag conflict")
+		assert.Contains(t, err.Error(), "release-1.0")
+	})
+
+	t.Run("when the destination tag already points at the same digest, copying again succeeds", func(t *testing.T) {
+		destination := fakeRegistry.ReferenceOnTestServer("library/copied-dest")
+
+		_, err := subject.CopyToRepo(destination)
+		require.NoError(t, err)
+
+		_, err = subject.CopyToRepo(destination)
+		require.NoError(t, err)
+	})
+}
+
 type fakeSignatureRetriever struct {
 }
 
@@ -1133,6 +1377,17 @@ func (f fakeSignatureRetriever) Fetch(images *imageset.UnprocessedImageRefs) (*i
 
 var _ SignatureRetriever = new(fakeSignatureRetriever)
 
+func tarballImageRefs(t *testing.T, imageTarPath string) []string {
+	imageOrIndices, err := imagetar.NewTarReader(imageTarPath).Read()
+	require.NoError(t, err)
+
+	var refs []string
+	for _, imageOrIndex := range imageOrIndices {
+		refs = append(refs, imageOrIndex.Ref())
+	}
+	return refs
+}
+
 func assertTarballContainsEveryLayer(t *testing.T, imageTarPath string) {
 	path := imagetar.NewTarReader(imageTarPath)
 	imageOrIndex, err := path.Read()
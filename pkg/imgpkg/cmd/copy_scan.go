@@ -0,0 +1,108 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	ctlimgset "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+)
+
+// ImageScanner gates the images entering the destination of a copy
+type ImageScanner interface {
+	Scan(refs *ctlimgset.UnprocessedImageRefs) error
+}
+
+// NewNoopScanner returns a scanner that approves every image
+func NewNoopScanner() *noopScanner { return &noopScanner{} }
+
+type noopScanner struct{}
+
+func (s *noopScanner) Scan(*ctlimgset.UnprocessedImageRefs) error { return nil }
+
+// execScanner runs a user provided command against every image being copied
+// and fails the copy when the command exits non-zero for any image
+type execScanner struct {
+	cmdTemplate string
+	concurrency int
+	ui          util.UIWithLevels
+}
+
+// NewExecScanner constructor for a scanner executing cmdTemplate per image
+// ('{}' in the template is replaced with the image reference; when absent the
+// reference is appended as the last argument)
+func NewExecScanner(cmdTemplate string, concurrency int, ui util.UIWithLevels) *execScanner {
+	return &execScanner{cmdTemplate: cmdTemplate, concurrency: concurrency, ui: ui}
+}
+
+type scanResult struct {
+	ref    string
+	output string
+	err    error
+}
+
+func (s *execScanner) Scan(refs *ctlimgset.UnprocessedImageRefs) error {
+	allRefs := refs.All()
+
+	throttle := util.NewThrottle(s.concurrency)
+	resultCh := make(chan scanResult, len(allRefs))
+
+	for _, ref := range allRefs {
+		ref := ref // copy
+
+		go func() {
+			throttle.Take()
+			defer throttle.Done()
+
+			output, err := s.scanImage(ref.DigestRef)
+			resultCh <- scanResult{ref: ref.DigestRef, output: output, err: err}
+		}()
+	}
+
+	var violations []scanResult
+	for i := 0; i < len(allRefs); i++ {
+		result := <-resultCh
+		if result.err != nil {
+			violations = append(violations, result)
+		} else {
+			s.ui.Debugf("scan ok: %s\n", result.ref)
+		}
+	}
+
+	for _, violation := range violations {
+		s.ui.Errorf("scan failed: %s: %s\n", violation.ref, violation.err)
+		if violation.output != "" {
+			s.ui.Errorf("%s\n", violation.output)
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("Expected all images to pass the scan, but %d of %d image(s) failed", len(violations), len(allRefs))
+	}
+	return nil
+}
+
+func (s *execScanner) scanImage(ref string) (string, error) {
+	args := strings.Fields(s.cmdTemplate)
+	if len(args) == 0 {
+		return "", fmt.Errorf("Expected scan command to be non empty")
+	}
+
+	replaced := false
+	for i, arg := range args {
+		if strings.Contains(arg, "{}") {
+			args[i] = strings.ReplaceAll(arg, "{}", ref)
+			replaced = true
+		}
+	}
+	if !replaced {
+		args = append(args, ref)
+	}
+
+	output, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	return strings.TrimSpace(string(output)), err
+}
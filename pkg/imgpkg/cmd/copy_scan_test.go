@@ -0,0 +1,47 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctlimgset "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+)
+
+func TestExecScanner(t *testing.T) {
+	refs := ctlimgset.NewUnprocessedImageRefs()
+	refs.Add(ctlimgset.UnprocessedImageRef{DigestRef: "registry.io/app1@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"})
+	refs.Add(ctlimgset.UnprocessedImageRef{DigestRef: "registry.io/app2@sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"})
+
+	t.Run("passes when the command succeeds for every image", func(t *testing.T) {
+		scanner := NewExecScanner("true", 1, newScanTestUI(&bytes.Buffer{}))
+		require.NoError(t, scanner.Scan(refs))
+	})
+
+	t.Run("fails with an aggregate report when the command fails", func(t *testing.T) {
+		output := &bytes.Buffer{}
+		scanner := NewExecScanner("false", 1, newScanTestUI(output))
+
+		err := scanner.Scan(refs)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "2 of 2 image(s) failed")
+		assert.Contains(t, output.String(), "scan failed: registry.io/app1@")
+		assert.Contains(t, output.String(), "scan failed: registry.io/app2@")
+	})
+
+	t.Run("errors when the command is empty", func(t *testing.T) {
+		scanner := NewExecScanner("   ", 1, newScanTestUI(&bytes.Buffer{}))
+		err := scanner.Scan(refs)
+		require.Error(t, err)
+	})
+}
+
+func newScanTestUI(buf *bytes.Buffer) util.UIWithLevels {
+	return util.NewUILevelLogger(util.LogWarn, goui.NewWriterUI(buf, buf, nil))
+}
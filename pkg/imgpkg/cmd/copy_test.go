@@ -4,8 +4,17 @@
 package cmd
 
 import (
+	"fmt"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+	ctlimgset "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
 )
 
 func TestMultiDest(t *testing.T) {
@@ -14,7 +23,7 @@ func TestMultiDest(t *testing.T) {
 		t.Fatalf("Expected Run() to err")
 	}
 
-	if !strings.Contains(err.Error(), "Expected either --to-tar or --to-repo") {
+	if !strings.Contains(err.Error(), "Expected either --to-tar, --to-repo, --to-s3, --to-gcs, --to-azblob, --to-docker-daemon or --to-containerd") {
 		t.Fatalf("Expected error message related to destinations, got: %s", err)
 	}
 }
@@ -25,7 +34,7 @@ func TestNoDest(t *testing.T) {
 		t.Fatalf("Expected Run() to err")
 	}
 
-	if !strings.Contains(err.Error(), "Expected either --to-tar or --to-repo") {
+	if !strings.Contains(err.Error(), "Expected either --to-tar, --to-repo, --to-s3, --to-gcs, --to-azblob, --to-docker-daemon or --to-containerd") {
 		t.Fatalf("Expected error message related to destinations, got: %s", err)
 	}
 }
@@ -52,13 +61,151 @@ func TestNoSrc(t *testing.T) {
 	}
 }
 
+func TestImagesLockOutputOrigins(t *testing.T) {
+	origRef := "index.docker.io/library/some-image@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	relocatedRef := "registry.example.com/mirror/some-image@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+	img, err := random.Image(1024, 1)
+	require.NoError(t, err)
+
+	processedImages := ctlimgset.NewProcessedImages()
+	processedImages.Add(ctlimgset.ProcessedImage{
+		UnprocessedImageRef: ctlimgset.UnprocessedImageRef{DigestRef: origRef},
+		DigestRef:           relocatedRef,
+		Image:               img,
+	})
+
+	t.Run("with --lock-output-origins, the original reference is annotated", func(t *testing.T) {
+		lockPath := filepath.Join(t.TempDir(), "images.lock.yml")
+		subject := &CopyOptions{LockOutputFlags: LockOutputFlags{LockFilePath: lockPath}, LockOutputOrigins: true}
+		require.NoError(t, subject.writeImagesLockOutput(processedImages))
+
+		imagesLock, err := lockconfig.NewImagesLockFromPath(lockPath)
+		require.NoError(t, err)
+		require.Len(t, imagesLock.Images, 1)
+		assert.Equal(t, relocatedRef, imagesLock.Images[0].Image)
+		assert.Equal(t, origRef, imagesLock.Images[0].Annotations[lockconfig.OriginalLocationAnnotationKey])
+	})
+
+	t.Run("without the flag, no annotation is added", func(t *testing.T) {
+		lockPath := filepath.Join(t.TempDir(), "images.lock.yml")
+		subject := &CopyOptions{LockOutputFlags: LockOutputFlags{LockFilePath: lockPath}}
+		require.NoError(t, subject.writeImagesLockOutput(processedImages))
+
+		imagesLock, err := lockconfig.NewImagesLockFromPath(lockPath)
+		require.NoError(t, err)
+		require.Len(t, imagesLock.Images, 1)
+		assert.NotContains(t, imagesLock.Images[0].Annotations, lockconfig.OriginalLocationAnnotationKey)
+	})
+}
+
+func TestNoRecursiveWithDepthError(t *testing.T) {
+	err := (&CopyOptions{NoRecursive: true, Depth: 1, BundleFlags: BundleFlags{Bundle: "foo"}, RepoDst: "bar"}).Run()
+	if err == nil {
+		t.Fatalf("Expected Run() to err")
+	}
+
+	if !strings.Contains(err.Error(), "Expected only one of --no-recursive or --depth") {
+		t.Fatalf("Expected error message related to recursion flags, got: %s", err)
+	}
+}
+
+func TestDepthNegativeError(t *testing.T) {
+	err := (&CopyOptions{Depth: -1, BundleFlags: BundleFlags{Bundle: "foo"}, RepoDst: "bar"}).Run()
+	if err == nil {
+		t.Fatalf("Expected Run() to err")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --depth to be greater than zero") {
+		t.Fatalf("Expected error message related to the depth value, got: %s", err)
+	}
+}
+
+func TestNoRecursiveWithImageSrcError(t *testing.T) {
+	err := (&CopyOptions{NoRecursive: true, ImageFlags: ImageFlags{Image: "foo"}, RepoDst: "bar"}).Run()
+	if err == nil {
+		t.Fatalf("Expected Run() to err")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --no-recursive and --depth to only be used with --bundle or --lock sources") {
+		t.Fatalf("Expected error message related to the source flags, got: %s", err)
+	}
+}
+
+func TestWatchWithTarError(t *testing.T) {
+	err := (&CopyOptions{Watch: true, TarFlags: TarFlags{TarSrc: "foo"}, RepoDst: "bar"}).Run()
+	if err == nil {
+		t.Fatalf("Expected Run() to err")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --watch to only be used when copying from a registry source") {
+		t.Fatalf("Expected error message related to watch mode, got: %s", err)
+	}
+}
+
+func TestWatchWithoutIntervalError(t *testing.T) {
+	err := (&CopyOptions{Watch: true, ImageFlags: ImageFlags{Image: "foo"}, RepoDst: "bar"}).Run()
+	if err == nil {
+		t.Fatalf("Expected Run() to err")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --interval to be greater than zero") {
+		t.Fatalf("Expected error message related to the polling interval, got: %s", err)
+	}
+}
+
 func TestTarSrcWithTarDst(t *testing.T) {
 	err := (&CopyOptions{TarFlags: TarFlags{TarDst: "bar", TarSrc: "foo"}}).Run()
 	if err == nil {
 		t.Fatalf("Expected Run() to err")
 	}
 
-	if !strings.Contains(err.Error(), "Cannot use tar source (--tar) with tar destination (--to-tar)") {
-		t.Fatalf("Expected error message related to destinations, got: %s", err)
+	// Tar to tar copying repacks the source archive; 'foo' does not exist
+	if !strings.Contains(err.Error(), "foo") {
+		t.Fatalf("Expected error message related to the source tar, got: %s", err)
 	}
 }
+
+func TestConfirmTransferPlanCancelled(t *testing.T) {
+	confUI := &bundlefakes.FakeUI{}
+	confUI.AskForConfirmationReturns(fmt.Errorf("stopped"))
+
+	copy := CopyOptions{ui: confUI, RepoDst: "registry.io/dst"}
+	copy.TarFlags.TarSrc = "some.tar"
+
+	err := copy.confirmTransferPlan(CopyRepoSrc{}, nil)
+	require.Error(t, err)
+	assert.Equal(t, "stopped", err.Error())
+	assert.Equal(t, 1, confUI.AskForConfirmationCallCount())
+}
+
+func TestConfirmTransferPlanAccepted(t *testing.T) {
+	confUI := &bundlefakes.FakeUI{}
+
+	copy := CopyOptions{ui: confUI, RepoDst: "registry.io/dst"}
+	copy.TarFlags.TarSrc = "some.tar"
+
+	require.NoError(t, copy.confirmTransferPlan(CopyRepoSrc{}, nil))
+	assert.Equal(t, 1, confUI.AskForConfirmationCallCount())
+}
+
+func TestParseHostConcurrency(t *testing.T) {
+	limits, err := parseHostConcurrency(nil)
+	require.NoError(t, err)
+	assert.Nil(t, limits)
+
+	limits, err = parseHostConcurrency([]string{"docker.io=2", "harbor.corp=16"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"docker.io": 2, "harbor.corp": 16}, limits)
+
+	_, err = parseHostConcurrency([]string{"no-separator"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected --host-concurrency to be in 'host=limit' format, got 'no-separator'")
+
+	_, err = parseHostConcurrency([]string{"docker.io=zero"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected --host-concurrency limit for 'docker.io' to be a positive number, got 'zero'")
+
+	_, err = parseHostConcurrency([]string{"docker.io=0"})
+	require.Error(t, err)
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/dockerdaemon"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+// copyToDaemon loads the source image into a local container runtime, so
+// air-gapped hosts without a registry can receive relocated images directly
+func (c *CopyOptions) copyToDaemon(reg registry.Registry) error {
+	if c.ImageFlags.Image == "" {
+		return fmt.Errorf("Expected --image (-i) source when copying to a container runtime")
+	}
+	if c.LockOutputFlags.LockFilePath != "" || c.Output != "" || c.MappingOutput != "" || c.ProvenanceOutput != "" {
+		return fmt.Errorf("Cannot use --lock-output, --output, --mapping-output or --provenance-output with a container runtime destination")
+	}
+
+	ref, err := regname.ParseReference(c.ImageFlags.Image, regname.WeakValidation)
+	if err != nil {
+		return fmt.Errorf("Parsing '%s': %s", c.ImageFlags.Image, err)
+	}
+
+	img, err := reg.Image(ref)
+	if err != nil {
+		return fmt.Errorf("Fetching '%s': %s", c.ImageFlags.Image, err)
+	}
+
+	tagRef, err := daemonTagRef(ref)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case c.ToDockerDaemon:
+		client, err := dockerdaemon.NewClient()
+		if err != nil {
+			return err
+		}
+		err = client.LoadImage(tagRef, img)
+		if err != nil {
+			return err
+		}
+
+	case c.ToContainerd != "":
+		err = importIntoContainerd(c.ToContainerd, tagRef, img)
+		if err != nil {
+			return err
+		}
+
+	default:
+		panic("Unreachable code")
+	}
+
+	c.ui.BeginLinef("loaded '%s' as '%s'\n", c.ImageFlags.Image, tagRef.Name())
+
+	return c.NotificationFlags.Notify("copy", []NotificationImage{{Image: tagRef.Name(), Origin: c.ImageFlags.Image}})
+}
+
+// daemonTagRef returns the name the image is loaded under: the source
+// reference itself when it carries a tag, otherwise a tag derived from the
+// digest, since daemon image names cannot contain '@'
+func daemonTagRef(ref regname.Reference) (regname.Tag, error) {
+	if tagRef, ok := ref.(regname.Tag); ok {
+		return tagRef, nil
+	}
+
+	digestRef, ok := ref.(regname.Digest)
+	if !ok {
+		return regname.Tag{}, fmt.Errorf("Unexpected reference type for '%s'", ref.String())
+	}
+
+	tag := strings.ReplaceAll(digestRef.DigestStr(), ":", "-") + ".imgpkg"
+	return regname.NewTag(fmt.Sprintf("%s:%s", digestRef.Context().Name(), tag))
+}
+
+// importIntoContainerd pipes the image in docker save format to
+// 'ctr -n NAMESPACE images import', since containerd only exposes its image
+// store over gRPC
+func importIntoContainerd(namespace string, tagRef regname.Tag, img regv1.Image) error {
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(tarball.Write(tagRef, img, pipeWriter))
+	}()
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("ctr", "-n", namespace, "images", "import", "-")
+	cmd.Stdin = pipeReader
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("Importing '%s' into containerd: %s (%s)", tagRef.Name(), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyToDaemonRequiresImageSrc(t *testing.T) {
+	copyOptions := NewCopyOptions(nil)
+	copyOptions.BundleFlags.Bundle = "repo/bundle:tag"
+	copyOptions.ToDockerDaemon = true
+
+	err := copyOptions.copyToDaemon(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected --image (-i) source when copying to a container runtime")
+}
+
+func TestCopyToDaemonRejectsOutputFlags(t *testing.T) {
+	copyOptions := NewCopyOptions(nil)
+	copyOptions.ImageFlags.Image = "repo/img:tag"
+	copyOptions.ToDockerDaemon = true
+	copyOptions.LockOutputFlags.LockFilePath = "lock.yml"
+
+	err := copyOptions.copyToDaemon(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Cannot use --lock-output, --output, --mapping-output or --provenance-output with a container runtime destination")
+}
+
+func TestDaemonTagRef(t *testing.T) {
+	tagRef, err := regname.NewTag("repo.io/img:v1")
+	require.NoError(t, err)
+
+	ref, err := daemonTagRef(tagRef)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", ref.TagStr())
+
+	digestRef, err := regname.NewDigest("repo.io/img@sha256:aaaabbbbccccddddaaaabbbbccccddddaaaabbbbccccddddaaaabbbbccccdddd")
+	require.NoError(t, err)
+
+	ref, err = daemonTagRef(digestRef)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256-aaaabbbbccccddddaaaabbbbccccddddaaaabbbbccccddddaaaabbbbccccdddd.imgpkg", ref.TagStr())
+}
@@ -0,0 +1,151 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
+	ctlimgset "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+)
+
+// TransferManifest summary of a tar archive intended to accompany the
+// physical media in air-gapped transfers, so the receiving side knows
+// exactly what they are importing
+type TransferManifest struct {
+	ImgpkgVersion        string                  `json:"imgpkgVersion"`
+	CreatedAt            string                  `json:"createdAt"`
+	Bundle               string                  `json:"bundle,omitempty"`
+	Images               []TransferManifestImage `json:"images"`
+	TotalLayersSizeBytes int64                   `json:"totalLayersSizeBytes"`
+	TarSizeBytes         int64                   `json:"tarSizeBytes"`
+	TarChecksumSHA256    string                  `json:"tarChecksumSha256"`
+}
+
+// TransferManifestImage single image carried in the tar archive
+type TransferManifestImage struct {
+	Image string `json:"image"`
+	Tag   string `json:"tag,omitempty"`
+}
+
+// NewTransferManifest builds the transfer summary for the image set that was
+// written to the tar archive at tarPath
+func NewTransferManifest(unprocessedImageRefs *ctlimgset.UnprocessedImageRefs, ids *imagedesc.ImageRefDescriptors, tarPath string) (TransferManifest, error) {
+	manifest := TransferManifest{
+		ImgpkgVersion: Version,
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+		Images:        []TransferManifestImage{},
+	}
+
+	for _, ref := range unprocessedImageRefs.All() {
+		if _, ok := ref.Labels[rootBundleLabelKey]; ok {
+			manifest.Bundle = ref.DigestRef
+		}
+		manifest.Images = append(manifest.Images, TransferManifestImage{Image: ref.DigestRef, Tag: ref.Tag})
+	}
+	sort.Slice(manifest.Images, func(i, j int) bool {
+		return manifest.Images[i].Image < manifest.Images[j].Image
+	})
+
+	seenLayers := map[string]struct{}{}
+	for _, descriptor := range ids.Descriptors() {
+		if descriptor.Image != nil {
+			manifest.TotalLayersSizeBytes += imageLayersSize(*descriptor.Image, seenLayers)
+		}
+		if descriptor.ImageIndex != nil {
+			manifest.TotalLayersSizeBytes += imageIndexLayersSize(*descriptor.ImageIndex, seenLayers)
+		}
+	}
+
+	tarFile, err := os.Open(tarPath)
+	if err != nil {
+		return TransferManifest{}, fmt.Errorf("Opening tar for checksumming: %s", err)
+	}
+	defer tarFile.Close()
+
+	digest := sha256.New()
+	size, err := io.Copy(digest, tarFile)
+	if err != nil {
+		return TransferManifest{}, fmt.Errorf("Checksumming tar: %s", err)
+	}
+	manifest.TarSizeBytes = size
+	manifest.TarChecksumSHA256 = fmt.Sprintf("%x", digest.Sum(nil))
+
+	return manifest, nil
+}
+
+// WriteToPath serializes the manifest to path (JSON when the path ends in
+// .json, markdown otherwise)
+func (t TransferManifest) WriteToPath(path string) error {
+	var bs []byte
+	var err error
+
+	if strings.HasSuffix(path, ".json") {
+		bs, err = json.MarshalIndent(t, "", "  ")
+		if err != nil {
+			return fmt.Errorf("Marshaling transfer manifest: %s", err)
+		}
+		bs = append(bs, '\n')
+	} else {
+		bs = t.asMarkdown()
+	}
+
+	err = ioutil.WriteFile(path, bs, 0600)
+	if err != nil {
+		return fmt.Errorf("Writing transfer manifest: %s", err)
+	}
+	return nil
+}
+
+func (t TransferManifest) asMarkdown() []byte {
+	var sb strings.Builder
+	sb.WriteString("# Transfer Manifest\n\n")
+	sb.WriteString(fmt.Sprintf("- imgpkg version: %s\n", t.ImgpkgVersion))
+	sb.WriteString(fmt.Sprintf("- created at: %s\n", t.CreatedAt))
+	if t.Bundle != "" {
+		sb.WriteString(fmt.Sprintf("- bundle: %s\n", t.Bundle))
+	}
+	sb.WriteString(fmt.Sprintf("- tar size: %d bytes (sha256:%s)\n", t.TarSizeBytes, t.TarChecksumSHA256))
+	sb.WriteString(fmt.Sprintf("- total layers size: %d bytes\n", t.TotalLayersSizeBytes))
+	sb.WriteString("\n## Images\n\n")
+	for _, img := range t.Images {
+		if img.Tag != "" {
+			sb.WriteString(fmt.Sprintf("- %s (tag: %s)\n", img.Image, img.Tag))
+		} else {
+			sb.WriteString(fmt.Sprintf("- %s\n", img.Image))
+		}
+	}
+	return []byte(sb.String())
+}
+
+func imageLayersSize(descriptor imagedesc.ImageDescriptor, seenLayers map[string]struct{}) int64 {
+	var total int64
+	for _, layer := range descriptor.Layers {
+		if _, ok := seenLayers[layer.Digest]; ok {
+			continue
+		}
+		seenLayers[layer.Digest] = struct{}{}
+		total += layer.Size
+	}
+	return total
+}
+
+func imageIndexLayersSize(descriptor imagedesc.ImageIndexDescriptor, seenLayers map[string]struct{}) int64 {
+	var total int64
+	for _, img := range descriptor.Images {
+		total += imageLayersSize(img, seenLayers)
+	}
+	for _, idx := range descriptor.Indexes {
+		total += imageIndexLayersSize(idx, seenLayers)
+	}
+	return total
+}
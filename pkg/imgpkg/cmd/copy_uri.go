@@ -0,0 +1,93 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// copySourceSchemes schemes accepted by --from, mapping onto the individual
+// source flags
+var copySourceSchemes = []string{"bundle", "image", "lock", "tar"}
+
+// copyDestinationSchemes schemes accepted by --to, mapping onto the individual
+// destination flags
+var copyDestinationSchemes = []string{"registry", "tar", "s3", "gs", "azblob"}
+
+// parseCopyURI splits a URI-style location into its scheme and value,
+// validating the scheme against the allowed set
+func parseCopyURI(uri string, allowedSchemes []string) (string, string, error) {
+	parts := strings.SplitN(uri, "://", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("Expected '%s' to be in the form scheme://value (supported schemes: %s)",
+			uri, strings.Join(allowedSchemes, ", "))
+	}
+
+	for _, scheme := range allowedSchemes {
+		if parts[0] == scheme {
+			return parts[0], parts[1], nil
+		}
+	}
+
+	return "", "", fmt.Errorf("Unknown scheme '%s' in '%s' (supported schemes: %s)",
+		parts[0], uri, strings.Join(allowedSchemes, ", "))
+}
+
+// applyURIFlags translates the URI-style --from/--to flags into the
+// individual source and destination flags, so the rest of the copy logic only
+// deals with one representation
+func (c *CopyOptions) applyURIFlags() error {
+	if c.From != "" {
+		anySrcSet := c.hasImagesSrc() || c.BundleFlags.Bundle != "" ||
+			c.LockInputFlags.LockFilePath != "" || c.TarFlags.TarSrc != ""
+		if anySrcSet {
+			return fmt.Errorf("Expected either --from or one of --image, --bundle, --lock, --tar to be provided, not both")
+		}
+
+		scheme, value, err := parseCopyURI(c.From, copySourceSchemes)
+		if err != nil {
+			return fmt.Errorf("Parsing --from: %s", err)
+		}
+
+		switch scheme {
+		case "bundle":
+			c.BundleFlags.Bundle = value
+		case "image":
+			c.ImageFlags.Image = value
+		case "lock":
+			c.LockInputFlags.LockFilePath = value
+		case "tar":
+			c.TarFlags.TarSrc = value
+		}
+	}
+
+	if c.To != "" {
+		anyDstSet := c.RepoDst != "" || c.TarFlags.TarDst != "" ||
+			c.S3Dst != "" || c.GcsDst != "" || c.AzureBlobDst != ""
+		if anyDstSet {
+			return fmt.Errorf("Expected either --to or one of --to-repo, --to-tar, --to-s3, --to-gcs, --to-azblob to be provided, not both")
+		}
+
+		scheme, value, err := parseCopyURI(c.To, copyDestinationSchemes)
+		if err != nil {
+			return fmt.Errorf("Parsing --to: %s", err)
+		}
+
+		switch scheme {
+		case "registry":
+			c.RepoDst = value
+		case "tar":
+			c.TarFlags.TarDst = value
+		case "s3":
+			c.S3Dst = c.To
+		case "gs":
+			c.GcsDst = c.To
+		case "azblob":
+			c.AzureBlobDst = c.To
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyURIFlagsMapsSourcesAndDestinations(t *testing.T) {
+	copy := CopyOptions{From: "bundle://registry.io/some-bundle", To: "registry://registry.io/dst-repo"}
+
+	require.NoError(t, copy.applyURIFlags())
+	assert.Equal(t, "registry.io/some-bundle", copy.BundleFlags.Bundle)
+	assert.Equal(t, "registry.io/dst-repo", copy.RepoDst)
+
+	copy = CopyOptions{From: "tar://my.tar", To: "tar://out.tar"}
+	require.NoError(t, copy.applyURIFlags())
+	assert.Equal(t, "my.tar", copy.TarFlags.TarSrc)
+	assert.Equal(t, "out.tar", copy.TarFlags.TarDst)
+
+	copy = CopyOptions{From: "lock://images.lock.yml", To: "s3://bucket/key"}
+	require.NoError(t, copy.applyURIFlags())
+	assert.Equal(t, "images.lock.yml", copy.LockInputFlags.LockFilePath)
+	assert.Equal(t, "s3://bucket/key", copy.S3Dst)
+}
+
+func TestApplyURIFlagsUnknownSchemeError(t *testing.T) {
+	copy := CopyOptions{From: "ftp://somewhere"}
+
+	err := copy.applyURIFlags()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Parsing --from: Unknown scheme 'ftp' in 'ftp://somewhere'")
+	assert.Contains(t, err.Error(), "supported schemes: bundle, image, lock, tar")
+}
+
+func TestApplyURIFlagsMissingSchemeError(t *testing.T) {
+	copy := CopyOptions{To: "just-a-repo"}
+
+	err := copy.applyURIFlags()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Parsing --to: Expected 'just-a-repo' to be in the form scheme://value")
+}
+
+func TestApplyURIFlagsConflictingFlagsError(t *testing.T) {
+	copy := CopyOptions{From: "image://registry.io/img"}
+	copy.BundleFlags.Bundle = "registry.io/some-bundle"
+
+	err := copy.applyURIFlags()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected either --from or one of --image, --bundle, --lock, --tar to be provided, not both")
+
+	copy = CopyOptions{To: "registry://registry.io/dst"}
+	copy.RepoDst = "registry.io/other-dst"
+
+	err = copy.applyURIFlags()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected either --to or one of --to-repo, --to-tar, --to-s3, --to-gcs, --to-azblob to be provided, not both")
+}
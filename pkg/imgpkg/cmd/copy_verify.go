@@ -0,0 +1,144 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	ctlimgset "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+// copyVerifier reads every relocated manifest back from the destination and
+// spot-checks one blob per image, so operators can prove the mirror is
+// correct before deleting staging media
+type copyVerifier struct {
+	registry    registry.ImagesReader
+	concurrency int
+	ui          util.UIWithLevels
+}
+
+// newCopyVerifier constructor for the read-back verification done after a copy
+func newCopyVerifier(reg registry.ImagesReader, concurrency int, ui util.UIWithLevels) copyVerifier {
+	return copyVerifier{registry: reg, concurrency: concurrency, ui: ui}
+}
+
+// Verify checks every processed image against the destination registry,
+// returning an error listing all mismatches when verification fails
+func (v copyVerifier) Verify(processedImages *ctlimgset.ProcessedImages) error {
+	allImages := processedImages.All()
+
+	type verifyResult struct {
+		blobChecked bool
+		failure     string
+	}
+
+	throttle := util.NewThrottle(v.concurrency)
+	resultCh := make(chan verifyResult, len(allImages))
+
+	for _, img := range allImages {
+		img := img // copy
+
+		go func() {
+			throttle.Take()
+			defer throttle.Done()
+
+			blobChecked, err := v.verifyImage(img)
+			result := verifyResult{blobChecked: blobChecked}
+			if err != nil {
+				result.failure = fmt.Sprintf("%s: %s", img.DigestRef, err)
+			}
+			resultCh <- result
+		}()
+	}
+
+	verifiedBlobs := 0
+	var failures []string
+	for i := 0; i < len(allImages); i++ {
+		result := <-resultCh
+		if result.blobChecked {
+			verifiedBlobs++
+		}
+		if result.failure != "" {
+			failures = append(failures, result.failure)
+		}
+	}
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return fmt.Errorf("Verification failed for %d of %d image(s):\n- %s",
+			len(failures), len(allImages), strings.Join(failures, "\n- "))
+	}
+
+	v.ui.Debugf("Verified %d manifest(s) and spot-checked %d blob(s) in the destination\n",
+		len(allImages), verifiedBlobs)
+	return nil
+}
+
+// verifyImage confirms the destination serves the expected manifest digest
+// and, for images, re-reads the first layer comparing its content digest
+func (v copyVerifier) verifyImage(img ctlimgset.ProcessedImage) (bool, error) {
+	ref, err := regname.NewDigest(img.DigestRef)
+	if err != nil {
+		return false, err
+	}
+
+	foundDigest, err := v.registry.Digest(ref)
+	if err != nil {
+		return false, fmt.Errorf("Reading manifest: %s", err)
+	}
+	if foundDigest.String() != ref.DigestStr() {
+		return false, fmt.Errorf("Expected manifest digest '%s', found '%s'", ref.DigestStr(), foundDigest)
+	}
+
+	// indexes carry no layers of their own; their manifest check is enough
+	if img.Image == nil {
+		return false, nil
+	}
+
+	dstImg, err := v.registry.Image(ref)
+	if err != nil {
+		return false, fmt.Errorf("Fetching image: %s", err)
+	}
+
+	layers, err := dstImg.Layers()
+	if err != nil {
+		return false, fmt.Errorf("Reading layers: %s", err)
+	}
+	if len(layers) == 0 {
+		return false, nil
+	}
+
+	layer := layers[0]
+	expectedDigest, err := layer.Digest()
+	if err != nil {
+		return false, err
+	}
+
+	stream, err := layer.Compressed()
+	if err != nil {
+		return false, fmt.Errorf("Reading blob %s: %s", expectedDigest, err)
+	}
+	defer stream.Close()
+
+	digest := sha256.New()
+	_, err = io.Copy(digest, stream)
+	if err != nil {
+		return false, fmt.Errorf("Reading blob %s: %s", expectedDigest, err)
+	}
+
+	foundHex := hex.EncodeToString(digest.Sum(nil))
+	if "sha256:"+foundHex != expectedDigest.String() {
+		return false, fmt.Errorf("Expected blob digest '%s', found 'sha256:%s'", expectedDigest, foundHex)
+	}
+
+	return true, nil
+}
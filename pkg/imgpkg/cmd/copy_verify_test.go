@@ -0,0 +1,64 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctlimgset "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestCopyVerifierSucceedsForCopiedImage(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+	randomImage := fakeRegistry.WithRandomImage("some/repo")
+	reg := fakeRegistry.Build()
+
+	ref, err := regname.NewDigest(randomImage.RefDigest)
+	require.NoError(t, err)
+	img, err := reg.Image(ref)
+	require.NoError(t, err)
+
+	processedImages := ctlimgset.NewProcessedImages()
+	processedImages.Add(ctlimgset.ProcessedImage{
+		UnprocessedImageRef: ctlimgset.UnprocessedImageRef{DigestRef: randomImage.RefDigest},
+		DigestRef:           randomImage.RefDigest,
+		Image:               img,
+	})
+
+	verifier := newCopyVerifier(reg, 1, util.NewUILevelLogger(util.LogWarn, goui.NewNoopUI()))
+	require.NoError(t, verifier.Verify(processedImages))
+}
+
+func TestCopyVerifierErrsWhenManifestIsMissing(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+	randomImage := fakeRegistry.WithRandomImage("some/repo")
+	reg := fakeRegistry.Build()
+
+	ref, err := regname.NewDigest(randomImage.RefDigest)
+	require.NoError(t, err)
+	img, err := reg.Image(ref)
+	require.NoError(t, err)
+
+	missingRef := ref.Repository.Name() + "@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	processedImages := ctlimgset.NewProcessedImages()
+	processedImages.Add(ctlimgset.ProcessedImage{
+		UnprocessedImageRef: ctlimgset.UnprocessedImageRef{DigestRef: missingRef},
+		DigestRef:           missingRef,
+		Image:               img,
+	})
+
+	verifier := newCopyVerifier(reg, 1, util.NewUILevelLogger(util.LogWarn, goui.NewNoopUI()))
+	err = verifier.Verify(processedImages)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Verification failed for 1 of 1 image(s)")
+	assert.Contains(t, err.Error(), missingRef)
+}
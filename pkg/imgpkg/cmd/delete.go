@@ -0,0 +1,185 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+type DeleteOptions struct {
+	ui ui.UI
+
+	ImageFlags         ImageFlags
+	BundleFlags        BundleFlags
+	RegistryFlags      RegistryFlags
+	PruneOrphanedBlobs bool
+}
+
+func NewDeleteOptions(ui ui.UI) *DeleteOptions {
+	return &DeleteOptions{ui: ui}
+}
+
+func NewDeleteCmd(o *DeleteOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a bundle or image from a registry",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Delete image repo/app1-image from the registry
+  imgpkg delete -i repo/app1-image@sha256:...
+
+  # Delete bundle repo/app1-bundle and its locations image
+  imgpkg delete -b repo/app1-bundle@sha256:...`,
+	}
+	o.ImageFlags.Set(cmd)
+	o.BundleFlags.Set(cmd)
+	o.RegistryFlags.Set(cmd)
+	cmd.Flags().BoolVar(&o.PruneOrphanedBlobs, "prune-orphaned-blobs", false,
+		"Trigger registry garbage collection after deleting, where the registry exposes an API for it (best-effort)")
+	return cmd
+}
+
+func (d *DeleteOptions) Run() error {
+	isBundle := len(d.BundleFlags.Bundle) > 0
+
+	var refString string
+	switch {
+	case len(d.ImageFlags.Image) > 0 && isBundle:
+		return fmt.Errorf("Expected only one of image or bundle")
+	case len(d.ImageFlags.Image) > 0:
+		refString = d.ImageFlags.Image
+	case isBundle:
+		refString = d.BundleFlags.Bundle
+	default:
+		return fmt.Errorf("Expected either image or bundle")
+	}
+
+	reg, err := registry.NewSimpleRegistry(d.RegistryFlags.AsRegistryOpts())
+	if err != nil {
+		return err
+	}
+
+	ref, err := regname.ParseReference(refString, regname.WeakValidation)
+	if err != nil {
+		return err
+	}
+
+	// Most registries only accept deletes by digest
+	hash, err := reg.Digest(ref)
+	if err != nil {
+		return err
+	}
+
+	digestRef, err := regname.NewDigest(fmt.Sprintf("%s@%s", ref.Context().Name(), hash.String()))
+	if err != nil {
+		return err
+	}
+
+	if isBundle {
+		d.deleteLocationsImage(reg, digestRef)
+	}
+
+	err = d.deleteRef(reg, digestRef)
+	if err != nil {
+		return fmt.Errorf("Deleting '%s' (hint: not every registry supports the delete API): %s", digestRef.Name(), err)
+	}
+	d.ui.BeginLinef("Deleted '%s'\n", digestRef.Name())
+
+	if d.PruneOrphanedBlobs {
+		d.pruneOrphanedBlobs(ref.Context().RegistryStr())
+	}
+
+	return nil
+}
+
+// deleteLocationsImage removes the locations OCI Image associated with a
+// bundle. This is best-effort: the locations image is only a cache and may
+// not exist
+func (d *DeleteOptions) deleteLocationsImage(reg registry.Registry, bundleRef regname.Digest) {
+	locRef, err := bundle.LocationsImageTagRef(bundleRef)
+	if err != nil {
+		return
+	}
+
+	locHash, err := reg.Digest(locRef)
+	if err != nil {
+		return
+	}
+
+	locDigestRef, err := regname.NewDigest(fmt.Sprintf("%s@%s", locRef.Context().Name(), locHash.String()))
+	if err != nil {
+		return
+	}
+
+	err = d.deleteRef(reg, locDigestRef)
+	if err != nil {
+		d.ui.BeginLinef("Unable to delete locations image '%s': %s\n", locDigestRef.Name(), err)
+		return
+	}
+	d.ui.BeginLinef("Deleted locations image '%s'\n", locDigestRef.Name())
+}
+
+func (d *DeleteOptions) deleteRef(reg registry.Registry, ref regname.Reference) error {
+	return reg.Delete(ref)
+}
+
+// pruneOrphanedBlobs triggers registry specific garbage collection where an
+// API for it exists. This is best-effort: failures only produce warnings
+func (d *DeleteOptions) pruneOrphanedBlobs(registryHost string) {
+	if strings.HasSuffix(registryHost, ".amazonaws.com") {
+		d.ui.BeginLinef("ECR prunes orphaned blobs automatically; no garbage collection was triggered\n")
+		return
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	// Harbor exposes a garbage collection API
+	resp, err := client.Get(fmt.Sprintf("https://%s/api/v2.0/systeminfo", registryHost))
+	if err == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			d.triggerHarborGC(client, registryHost)
+			return
+		}
+	}
+
+	d.ui.BeginLinef("Registry '%s' does not expose a supported garbage collection API; blobs may be pruned by the registry later\n", registryHost)
+}
+
+func (d *DeleteOptions) triggerHarborGC(client *http.Client, registryHost string) {
+	opts := d.RegistryFlags.AsRegistryOpts()
+
+	body := bytes.NewBufferString(`{"schedule":{"type":"Manual"}}`)
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("https://%s/api/v2.0/system/gc/schedule", registryHost), body)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if opts.Username != "" {
+		req.SetBasicAuth(opts.Username, opts.Password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		d.ui.BeginLinef("Unable to trigger Harbor garbage collection: %s\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		d.ui.BeginLinef("Harbor refused to schedule garbage collection (status %d)\n", resp.StatusCode)
+		return
+	}
+	d.ui.BeginLinef("Scheduled Harbor garbage collection\n")
+}
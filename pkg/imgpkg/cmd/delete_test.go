@@ -0,0 +1,64 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/ociregistry"
+)
+
+func TestDelete(t *testing.T) {
+	handler, err := ociregistry.NewHandler(t.TempDir())
+	require.NoError(t, err)
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	t.Run("deletes the image manifest from the registry", func(t *testing.T) {
+		img, err := random.Image(512, 1)
+		require.NoError(t, err)
+
+		tagRef, err := regname.NewTag(fmt.Sprintf("%s/repo/delete-me:latest", serverURL.Host))
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(tagRef, img))
+
+		deleteOpts := NewDeleteOptions(goui.NewNoopUI())
+		deleteOpts.ImageFlags = ImageFlags{Image: tagRef.Name()}
+		deleteOpts.RegistryFlags = RegistryFlags{Insecure: true}
+
+		require.NoError(t, deleteOpts.Run())
+
+		_, err = remote.Image(tagRef)
+		require.Error(t, err)
+	})
+
+	t.Run("errors when neither image nor bundle is provided", func(t *testing.T) {
+		deleteOpts := NewDeleteOptions(goui.NewNoopUI())
+		err := deleteOpts.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected either image or bundle")
+	})
+
+	t.Run("errors when both image and bundle are provided", func(t *testing.T) {
+		deleteOpts := NewDeleteOptions(goui.NewNoopUI())
+		deleteOpts.ImageFlags = ImageFlags{Image: "some/image"}
+		deleteOpts.BundleFlags = BundleFlags{Bundle: "some/bundle"}
+		err := deleteOpts.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected only one of image or bundle")
+	})
+}
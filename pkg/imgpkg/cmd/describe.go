@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
 	"sigs.k8s.io/yaml"
 )
 
@@ -30,6 +31,7 @@ type DescribeOptions struct {
 	Concurrency            int
 	OutputType             string
 	IncludeCosignArtifacts bool
+	SizeBreakdown          bool
 }
 
 // NewDescribeOptions constructor for building a DescribeOptions, holding values derived via flags
@@ -53,6 +55,7 @@ func NewDescribeCmd(o *DescribeOptions) *cobra.Command {
 	cmd.Flags().IntVar(&o.Concurrency, "concurrency", 5, "Concurrency")
 	cmd.Flags().StringVarP(&o.OutputType, "output-type", "o", "text", "Type of output possible values: [text, yaml]")
 	cmd.Flags().BoolVar(&o.IncludeCosignArtifacts, "cosign-artifacts", true, "Retrieve cosign artifact information (Default: true)")
+	cmd.Flags().BoolVar(&o.SizeBreakdown, "size-breakdown", false, "Include per-image and per-layer sizes with shared layer analysis (downloads layer contents to measure uncompressed sizes)")
 	return cmd
 }
 
@@ -63,7 +66,7 @@ func (d *DescribeOptions) Run() error {
 		return err
 	}
 
-	levelLogger := util.NewUILevelLogger(util.LogWarn, d.ui)
+	levelLogger := newLevelLogger(d.ui)
 	description, err := bundle.Describe(
 		d.BundleFlags.Bundle,
 		bundle.DescribeOpts{
@@ -76,12 +79,35 @@ func (d *DescribeOptions) Run() error {
 		return err
 	}
 
+	var sizeBreakdown *bundle.SizeBreakdown
+	if d.SizeBreakdown {
+		reg, err := registry.NewSimpleRegistry(d.RegistryFlags.AsRegistryOpts())
+		if err != nil {
+			return err
+		}
+
+		breakdown, err := bundle.CalculateSizeBreakdown(description, reg)
+		if err != nil {
+			return err
+		}
+		sizeBreakdown = &breakdown
+	}
+
 	if d.OutputType == "text" {
 		p := bundleTextPrinter{ui: d.ui}
 		p.Print(description)
+		if sizeBreakdown != nil {
+			p.PrintSizeBreakdown(*sizeBreakdown)
+		}
 	} else if d.OutputType == "yaml" {
 		p := bundleYAMLPrinter{ui: d.ui}
-		return p.Print(description)
+		err := p.Print(description)
+		if err != nil {
+			return err
+		}
+		if sizeBreakdown != nil {
+			return p.PrintSizeBreakdown(*sizeBreakdown)
+		}
 	}
 	return nil
 }
@@ -112,6 +138,21 @@ func (p bundleTextPrinter) Print(description bundle.Description) {
 	}
 	logger.BeginLinef("Bundle SHA: %s\n", bundleRef.Identifier())
 
+	if len(description.Provenance) > 0 {
+		logger.BeginLinef("\n")
+		logger.BeginLinef("Provenance:\n")
+		indentLogger := goui.NewIndentingUI(logger)
+
+		var provenanceKeys []string
+		for key := range description.Provenance {
+			provenanceKeys = append(provenanceKeys, key)
+		}
+		sort.Strings(provenanceKeys)
+		for _, key := range provenanceKeys {
+			indentLogger.BeginLinef("%s: %s\n", key, description.Provenance[key])
+		}
+	}
+
 	logger.BeginLinef("\n")
 	p.printerRec(description, logger, logger)
 }
@@ -163,6 +204,50 @@ func (p bundleTextPrinter) printerRec(description bundle.Description, originalLo
 	}
 }
 
+// PrintSizeBreakdown prints the per-image and per-layer sizes of the bundle
+func (p bundleTextPrinter) PrintSizeBreakdown(breakdown bundle.SizeBreakdown) {
+	logger := util.NewUIPrefixedWriter("", p.ui)
+	indentLogger := goui.NewIndentingUI(logger)
+
+	logger.BeginLinef("\n")
+	logger.BeginLinef("Size Breakdown:\n")
+	indentLogger.BeginLinef("Total compressed size: %s\n", humanReadableSize(breakdown.TotalCompressedSize))
+	indentLogger.BeginLinef("Total uncompressed size: %s\n", humanReadableSize(breakdown.TotalUncompressedSize))
+	indentLogger.BeginLinef("Shared layer savings: %s\n", humanReadableSize(breakdown.SharedLayersSavings))
+
+	indentLogger.BeginLinef("\n")
+	indentLogger.BeginLinef("Images:\n")
+	for _, imageInfo := range breakdown.Images {
+		indentLogger.BeginLinef("- Image: %s\n", imageInfo.Image)
+		indentLogger.BeginLinef("  Compressed size: %s\n", humanReadableSize(imageInfo.CompressedSize))
+		indentLogger.BeginLinef("  Uncompressed size: %s\n", humanReadableSize(imageInfo.UncompressedSize))
+		indentLogger.BeginLinef("  Layers:\n")
+		for _, layer := range imageInfo.Layers {
+			shared := ""
+			if layer.Shared {
+				shared = " (shared)"
+			}
+			indentLogger.BeginLinef("  - %s: %s compressed, %s uncompressed%s\n",
+				layer.Digest, humanReadableSize(layer.CompressedSize), humanReadableSize(layer.UncompressedSize), shared)
+		}
+	}
+}
+
+// humanReadableSize formats sizeBytes using binary prefixes
+func humanReadableSize(sizeBytes int64) string {
+	const unit = 1024
+	if sizeBytes < unit {
+		return fmt.Sprintf("%d B", sizeBytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := sizeBytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(sizeBytes)/float64(div), "KMGTPE"[exp])
+}
+
 func (p bundleTextPrinter) printAnnotations(annotations map[string]string, indentLogger *goui.IndentingUI) {
 	if len(annotations) > 0 {
 		indentLogger.BeginLinef("Annotations:\n")
@@ -200,3 +285,16 @@ func (p bundleYAMLPrinter) Print(description bundle.Description) error {
 
 	return nil
 }
+
+// PrintSizeBreakdown prints the per-image and per-layer sizes of the bundle
+func (p bundleYAMLPrinter) PrintSizeBreakdown(breakdown bundle.SizeBreakdown) error {
+	yamlBreakdown, err := yaml.Marshal(struct {
+		SizeBreakdown bundle.SizeBreakdown `json:"sizeBreakdown"`
+	}{breakdown})
+	if err != nil {
+		return err
+	}
+
+	util.NewUIPrefixedWriter("", p.ui).BeginLinef(string(yamlBreakdown))
+	return nil
+}
@@ -0,0 +1,320 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+// DiffOptions Command Line options that can be provided to the diff command
+type DiffOptions struct {
+	ui goui.UI
+
+	RegistryFlags RegistryFlags
+
+	Bundles      []string
+	LockFilePath string
+}
+
+// NewDiffOptions constructor for building a DiffOptions, holding values derived via flags
+func NewDiffOptions(ui *goui.ConfUI) *DiffOptions {
+	return &DiffOptions{ui: ui}
+}
+
+// NewDiffCmd constructor for the diff command
+func NewDiffCmd(o *DiffOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Diff the files and image references of two bundles",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Diff new bundle against the previous release
+  imgpkg diff -b repo/app1-bundle:v2 -b repo/app1-bundle:v1
+
+  # Diff new bundle against a lock file from the previous release
+  imgpkg diff -b repo/app1-bundle:v2 --lock bundle.lock.yml`,
+	}
+
+	cmd.Flags().StringSliceVarP(&o.Bundles, "bundle", "b", nil,
+		"Bundle references to compare, new bundle first (can be specified twice)")
+	cmd.Flags().StringVar(&o.LockFilePath, "lock", "",
+		"Lock file with the bundle or image references to compare the bundle against")
+	o.RegistryFlags.Set(cmd)
+	return cmd
+}
+
+// Run functions called when the diff command is provided in the command line
+func (d *DiffOptions) Run() error {
+	err := d.validate()
+	if err != nil {
+		return err
+	}
+
+	reg, err := registry.NewSimpleRegistry(d.RegistryFlags.AsRegistryOpts())
+	if err != nil {
+		return err
+	}
+
+	newDir, err := d.pullToTempDir(d.Bundles[0], reg)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(newDir)
+
+	switch {
+	case len(d.Bundles) == 2:
+		oldDir, err := d.pullToTempDir(d.Bundles[1], reg)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(oldDir)
+
+		return d.printDiff(diffBundleDirs(newDir, oldDir))
+
+	default:
+		bundleLock, imagesLock, err := lockconfig.NewLockFromPath(d.LockFilePath)
+		if err != nil {
+			return err
+		}
+
+		if bundleLock != nil {
+			oldDir, err := d.pullToTempDir(bundleLock.Bundle.Image, reg)
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(oldDir)
+
+			return d.printDiff(diffBundleDirs(newDir, oldDir))
+		}
+
+		newLock, err := lockconfig.NewImagesLockFromPath(filepath.Join(newDir, bundle.ImgpkgDir, bundle.ImagesLockFile))
+		if err != nil {
+			return err
+		}
+
+		diff := bundleDiff{}
+		diff.ImagesAdded, diff.ImagesRemoved = diffImageRefs(newLock, *imagesLock)
+		return d.printDiff(diff, nil)
+	}
+}
+
+func (d *DiffOptions) validate() error {
+	switch {
+	case len(d.Bundles) == 2 && d.LockFilePath == "":
+		return nil
+	case len(d.Bundles) == 1 && d.LockFilePath != "":
+		return nil
+	}
+	return fmt.Errorf("Expected either two bundles (-b NEW -b OLD) or one bundle and a lock file (-b NEW --lock OLD)")
+}
+
+// pullToTempDir pulls the bundle into a temporary directory for comparison
+func (d *DiffOptions) pullToTempDir(bundleRef string, reg registry.Registry) (string, error) {
+	outputDir, err := ioutil.TempDir("", "imgpkg-diff")
+	if err != nil {
+		return "", err
+	}
+
+	err = bundle.NewBundle(bundleRef, reg).Pull(outputDir, d.ui, false)
+	if err != nil {
+		os.RemoveAll(outputDir)
+		if bundle.IsNotBundleError(err) {
+			return "", fmt.Errorf("Expected bundle image but found plain image '%s'", bundleRef)
+		}
+		return "", err
+	}
+	return outputDir, nil
+}
+
+func (d *DiffOptions) printDiff(diff bundleDiff, err error) error {
+	if err != nil {
+		return err
+	}
+
+	if diff.isEmpty() {
+		d.ui.BeginLinef("No differences found\n")
+		return nil
+	}
+
+	if len(diff.FilesAdded)+len(diff.FilesRemoved)+len(diff.FilesChanged) > 0 {
+		d.ui.BeginLinef("Files:\n")
+		for _, path := range diff.FilesAdded {
+			d.ui.BeginLinef("  + %s\n", path)
+		}
+		for _, path := range diff.FilesRemoved {
+			d.ui.BeginLinef("  - %s\n", path)
+		}
+		for _, path := range diff.FilesChanged {
+			d.ui.BeginLinef("  ~ %s\n", path)
+		}
+	}
+
+	if len(diff.ImagesAdded)+len(diff.ImagesRemoved) > 0 {
+		d.ui.BeginLinef("Images:\n")
+		for _, ref := range diff.ImagesAdded {
+			d.ui.BeginLinef("  + %s\n", ref)
+		}
+		for _, ref := range diff.ImagesRemoved {
+			d.ui.BeginLinef("  - %s\n", ref)
+		}
+	}
+	return nil
+}
+
+// bundleDiff differences between the contents of two bundles
+type bundleDiff struct {
+	FilesAdded   []string
+	FilesRemoved []string
+	FilesChanged []string
+
+	ImagesAdded   []string
+	ImagesRemoved []string
+}
+
+func (d bundleDiff) isEmpty() bool {
+	return len(d.FilesAdded)+len(d.FilesRemoved)+len(d.FilesChanged)+
+		len(d.ImagesAdded)+len(d.ImagesRemoved) == 0
+}
+
+// diffBundleDirs compares the files and ImagesLocks of two pulled bundles.
+// The .imgpkg directory is excluded from the file diff since its contents
+// are compared separately via the ImagesLock
+func diffBundleDirs(newDir, oldDir string) (bundleDiff, error) {
+	diff := bundleDiff{}
+
+	newChecksums, err := fileChecksums(newDir)
+	if err != nil {
+		return diff, err
+	}
+	oldChecksums, err := fileChecksums(oldDir)
+	if err != nil {
+		return diff, err
+	}
+
+	for path, newSum := range newChecksums {
+		oldSum, found := oldChecksums[path]
+		switch {
+		case !found:
+			diff.FilesAdded = append(diff.FilesAdded, path)
+		case oldSum != newSum:
+			diff.FilesChanged = append(diff.FilesChanged, path)
+		}
+	}
+	for path := range oldChecksums {
+		if _, found := newChecksums[path]; !found {
+			diff.FilesRemoved = append(diff.FilesRemoved, path)
+		}
+	}
+
+	sort.Strings(diff.FilesAdded)
+	sort.Strings(diff.FilesRemoved)
+	sort.Strings(diff.FilesChanged)
+
+	newLock, err := lockconfig.NewImagesLockFromPath(filepath.Join(newDir, bundle.ImgpkgDir, bundle.ImagesLockFile))
+	if err != nil {
+		return diff, err
+	}
+	oldLock, err := lockconfig.NewImagesLockFromPath(filepath.Join(oldDir, bundle.ImgpkgDir, bundle.ImagesLockFile))
+	if err != nil {
+		return diff, err
+	}
+
+	diff.ImagesAdded, diff.ImagesRemoved = diffImageRefs(newLock, oldLock)
+	return diff, nil
+}
+
+// diffImageRefs compares ImagesLocks by image digest, so relocating images
+// to a different repository does not show up as a difference
+func diffImageRefs(newLock, oldLock lockconfig.ImagesLock) ([]string, []string) {
+	newDigests := imageRefsByDigest(newLock)
+	oldDigests := imageRefsByDigest(oldLock)
+
+	var added, removed []string
+	for digest, ref := range newDigests {
+		if _, found := oldDigests[digest]; !found {
+			added = append(added, ref)
+		}
+	}
+	for digest, ref := range oldDigests {
+		if _, found := newDigests[digest]; !found {
+			removed = append(removed, ref)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+func imageRefsByDigest(imagesLock lockconfig.ImagesLock) map[string]string {
+	refs := map[string]string{}
+	for _, imgRef := range imagesLock.Images {
+		digestRef, err := regname.NewDigest(imgRef.Image, regname.WeakValidation)
+		if err != nil {
+			// ImagesLock validation guarantees digest references
+			refs[imgRef.Image] = imgRef.Image
+			continue
+		}
+		refs[digestRef.DigestStr()] = imgRef.Image
+	}
+	return refs
+}
+
+// fileChecksums maps the bundle's files, keyed by slash separated path
+// relative to dir, to the sha256 of their contents
+func fileChecksums(dir string) (map[string]string, error) {
+	checksums := map[string]string{}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path == filepath.Join(dir, bundle.ImgpkgDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		digest := sha256.New()
+		_, err = io.Copy(digest, file)
+		if err != nil {
+			return err
+		}
+
+		checksums[filepath.ToSlash(relPath)] = fmt.Sprintf("%x", digest.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Comparing bundle contents: %s", err)
+	}
+	return checksums, nil
+}
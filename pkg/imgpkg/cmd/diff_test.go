@@ -0,0 +1,109 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffRequiresTwoBundlesOrLock(t *testing.T) {
+	err := (&DiffOptions{Bundles: []string{"repo/bundle:v2"}}).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected either two bundles")
+
+	err = (&DiffOptions{Bundles: []string{"repo/bundle:v2", "repo/bundle:v1"}, LockFilePath: "lock.yml"}).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected either two bundles")
+}
+
+func TestDiffBundleDirs(t *testing.T) {
+	imagesLock := `apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: registry.io/img@sha256:%s
+`
+
+	newDir := writeDiffTestBundle(t, map[string]string{
+		"config.yml":          "key: new-value",
+		"added.yml":           "added: true",
+		"unchanged.yml":       "same: true",
+		".imgpkg/images.yml":  stringsRepeatDigest(imagesLock, "a"),
+		".imgpkg/ignored.yml": "changed: true",
+	})
+	defer os.RemoveAll(newDir)
+
+	oldDir := writeDiffTestBundle(t, map[string]string{
+		"config.yml":          "key: old-value",
+		"removed.yml":         "removed: true",
+		"unchanged.yml":       "same: true",
+		".imgpkg/images.yml":  stringsRepeatDigest(imagesLock, "b"),
+		".imgpkg/ignored.yml": "changed: false",
+	})
+	defer os.RemoveAll(oldDir)
+
+	diff, err := diffBundleDirs(newDir, oldDir)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"added.yml"}, diff.FilesAdded)
+	assert.Equal(t, []string{"removed.yml"}, diff.FilesRemoved)
+	assert.Equal(t, []string{"config.yml"}, diff.FilesChanged)
+	require.Len(t, diff.ImagesAdded, 1)
+	assert.Contains(t, diff.ImagesAdded[0], "sha256:aaaa")
+	require.Len(t, diff.ImagesRemoved, 1)
+	assert.Contains(t, diff.ImagesRemoved[0], "sha256:bbbb")
+}
+
+func TestDiffImageRefsIgnoresRelocation(t *testing.T) {
+	newDir := writeDiffTestBundle(t, map[string]string{
+		".imgpkg/images.yml": stringsRepeatDigest(`apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: relocated.io/repo@sha256:%s
+`, "a"),
+	})
+	defer os.RemoveAll(newDir)
+
+	oldDir := writeDiffTestBundle(t, map[string]string{
+		".imgpkg/images.yml": stringsRepeatDigest(`apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: original.io/img@sha256:%s
+`, "a"),
+	})
+	defer os.RemoveAll(oldDir)
+
+	diff, err := diffBundleDirs(newDir, oldDir)
+	require.NoError(t, err)
+
+	assert.Empty(t, diff.ImagesAdded)
+	assert.Empty(t, diff.ImagesRemoved)
+}
+
+func writeDiffTestBundle(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "imgpkg-diff-test")
+	require.NoError(t, err)
+
+	for path, contents := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0700)
+		require.NoError(t, err)
+		err = ioutil.WriteFile(filepath.Join(dir, path), []byte(contents), 0600)
+		require.NoError(t, err)
+	}
+	return dir
+}
+
+func stringsRepeatDigest(format, char string) string {
+	digest := ""
+	for i := 0; i < 64; i++ {
+		digest += char
+	}
+	return fmt.Sprintf(format, digest)
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/plainimage"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+const (
+	// existsExitCodeNotFound exit code when the reference does not exist in the registry
+	existsExitCodeNotFound = 3
+	// existsExitCodeNotABundle exit code when the reference exists but is not a bundle (-b only)
+	existsExitCodeNotABundle = 4
+)
+
+// ExitCodeError wraps an error with the exit code the process should
+// terminate with, so commands like exists can report distinct outcomes to
+// scripts
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+// Error delegates to the wrapped error
+func (e ExitCodeError) Error() string { return e.Err.Error() }
+
+type ExistsOptions struct {
+	ui ui.UI
+
+	ImageFlags    ImageFlags
+	BundleFlags   BundleFlags
+	RegistryFlags RegistryFlags
+}
+
+func NewExistsOptions(ui ui.UI) *ExistsOptions {
+	return &ExistsOptions{ui: ui}
+}
+
+func NewExistsCmd(o *ExistsOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exists",
+		Short: "Check if an image or bundle exists in a registry",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Check that image repo/app1-image exists (exit code 0 when found, 3 when not)
+  imgpkg exists -i repo/app1-image
+
+  # Check that repo/app1-bundle exists and is a bundle (exit code 4 when it exists but is a plain image)
+  imgpkg exists -b repo/app1-bundle`,
+	}
+	o.ImageFlags.Set(cmd)
+	o.BundleFlags.Set(cmd)
+	o.RegistryFlags.Set(cmd)
+	return cmd
+}
+
+func (e *ExistsOptions) Run() error {
+	refStr, err := e.sourceRef()
+	if err != nil {
+		return err
+	}
+
+	reg, err := registry.NewSimpleRegistry(e.RegistryFlags.AsRegistryOpts())
+	if err != nil {
+		return err
+	}
+
+	ref, err := regname.ParseReference(refStr, regname.WeakValidation)
+	if err != nil {
+		return err
+	}
+
+	digest, err := reg.Digest(ref)
+	if err != nil {
+		if terr, ok := err.(*transport.Error); ok && terr.StatusCode == http.StatusNotFound {
+			return ExitCodeError{existsExitCodeNotFound, fmt.Errorf("Image '%s' does not exist", refStr)}
+		}
+		return err
+	}
+
+	if len(e.BundleFlags.Bundle) > 0 {
+		isBundle, err := bundle.NewBundleFromPlainImage(plainimage.NewPlainImage(refStr, reg), reg).IsBundle()
+		if err != nil {
+			return err
+		}
+		if !isBundle {
+			return ExitCodeError{existsExitCodeNotABundle, fmt.Errorf("Image '%s' exists but is not a bundle", refStr)}
+		}
+	}
+
+	e.ui.PrintLinef("Exists: %s@%s", ref.Context().Name(), digest)
+
+	return nil
+}
+
+// sourceRef returns the single reference provided via -b or -i
+func (e *ExistsOptions) sourceRef() (string, error) {
+	switch {
+	case len(e.BundleFlags.Bundle) > 0 && len(e.ImageFlags.Image) > 0:
+		return "", fmt.Errorf("Expected only one of image or bundle")
+	case len(e.BundleFlags.Bundle) > 0:
+		return e.BundleFlags.Bundle, nil
+	case len(e.ImageFlags.Image) > 0:
+		return e.ImageFlags.Image, nil
+	default:
+		return "", fmt.Errorf("Expected either image or bundle reference")
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestExistsNoRefError(t *testing.T) {
+	err := (&ExistsOptions{ui: goui.NewNoopUI()}).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected either image or bundle reference")
+}
+
+func TestExistsMultiRefError(t *testing.T) {
+	exists := ExistsOptions{ui: goui.NewNoopUI()}
+	exists.ImageFlags.Image = "foo"
+	exists.BundleFlags.Bundle = "bar"
+
+	err := exists.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected only one of image or bundle")
+}
+
+func TestExistsExitCodes(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+
+	fakeRegistry.WithRandomImage("repo/some-image")
+	fakeRegistry.Build()
+
+	t.Run("exits zero when the image exists", func(t *testing.T) {
+		exists := ExistsOptions{ui: goui.NewNoopUI()}
+		exists.ImageFlags.Image = fakeRegistry.ReferenceOnTestServer("repo/some-image")
+		require.NoError(t, exists.Run())
+	})
+
+	t.Run("returns the not-found exit code when the image is missing", func(t *testing.T) {
+		exists := ExistsOptions{ui: goui.NewNoopUI()}
+		exists.ImageFlags.Image = fakeRegistry.ReferenceOnTestServer("repo/missing-image")
+
+		err := exists.Run()
+		require.Error(t, err)
+		exitErr, ok := err.(ExitCodeError)
+		require.True(t, ok, "expected an ExitCodeError, got: %s", err)
+		assert.Equal(t, existsExitCodeNotFound, exitErr.Code)
+		assert.Contains(t, err.Error(), "does not exist")
+	})
+
+	t.Run("returns the not-a-bundle exit code for -b on a plain image", func(t *testing.T) {
+		exists := ExistsOptions{ui: goui.NewNoopUI()}
+		exists.BundleFlags.Bundle = fakeRegistry.ReferenceOnTestServer("repo/some-image")
+
+		err := exists.Run()
+		require.Error(t, err)
+		exitErr, ok := err.(ExitCodeError)
+		require.True(t, ok, "expected an ExitCodeError, got: %s", err)
+		assert.Equal(t, existsExitCodeNotABundle, exitErr.Code)
+		assert.Contains(t, err.Error(), "exists but is not a bundle")
+	})
+}
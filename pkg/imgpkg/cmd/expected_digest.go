@@ -0,0 +1,38 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+// verifyExpectedDigest resolves refStr in the registry and errors when it
+// does not point at expectedDigest, letting pipelines assert that a tag still
+// points at the digest they approved
+func verifyExpectedDigest(refStr string, expectedDigest string, reg registry.ImagesReader) error {
+	if !strings.HasPrefix(expectedDigest, "sha256:") {
+		return fmt.Errorf("Expected --expect-digest to be in the form sha256:..., got '%s'", expectedDigest)
+	}
+
+	ref, err := regname.ParseReference(refStr, regname.WeakValidation)
+	if err != nil {
+		return fmt.Errorf("Parsing '%s': %s", refStr, err)
+	}
+
+	foundDigest, err := reg.Digest(ref)
+	if err != nil {
+		return fmt.Errorf("Resolving '%s': %s", refStr, err)
+	}
+
+	if foundDigest.String() != expectedDigest {
+		return fmt.Errorf("Expected '%s' to point at digest '%s', but it points at '%s' (hint: The tag may have moved since the digest was approved)",
+			refStr, expectedDigest, foundDigest)
+	}
+
+	return nil
+}
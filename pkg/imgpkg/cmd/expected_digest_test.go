@@ -0,0 +1,52 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestVerifyExpectedDigestMalformedDigest(t *testing.T) {
+	err := verifyExpectedDigest("registry.io/some-image", "aaaa", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected --expect-digest to be in the form sha256:..., got 'aaaa'")
+}
+
+func TestVerifyExpectedDigest(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+
+	imgInfo := fakeRegistry.WithRandomImage("repo/some-image")
+	reg := fakeRegistry.Build()
+
+	tagRef := fakeRegistry.ReferenceOnTestServer("repo/some-image")
+	digest := imgInfo.RefDigest[strings.Index(imgInfo.RefDigest, "@")+1:]
+
+	t.Run("succeeds when the tag still points at the digest", func(t *testing.T) {
+		require.NoError(t, verifyExpectedDigest(tagRef, digest, reg))
+	})
+
+	t.Run("errors when the tag points at a different digest", func(t *testing.T) {
+		otherDigest := "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+		err := verifyExpectedDigest(tagRef, otherDigest, reg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), fmt.Sprintf("Expected '%s' to point at digest '%s'", tagRef, otherDigest))
+		assert.Contains(t, err.Error(), "The tag may have moved since the digest was approved")
+	})
+}
+
+func TestExpectedDigestRequiresBundleOrImage(t *testing.T) {
+	pull := PullOptions{ExpectedDigest: "sha256:aaaa", OutputPath: "/tmp/some-output"}
+	pull.LockInputFlags.LockFilePath = "bundle.lock.yml"
+
+	err := pull.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected --expect-digest to only be used with --bundle or --image sources")
+}
@@ -0,0 +1,264 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/plainimage"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+// GrepOptions Command Line options that can be provided to the grep command
+type GrepOptions struct {
+	ui goui.UI
+
+	BundleFlags          BundleFlags
+	BundleRecursiveFlags BundleRecursiveFlags
+	RegistryFlags        RegistryFlags
+
+	Pattern string
+}
+
+// NewGrepOptions constructor for building a GrepOptions, holding values derived via flags
+func NewGrepOptions(ui *goui.ConfUI) *GrepOptions {
+	return &GrepOptions{ui: ui}
+}
+
+// NewGrepCmd constructor for the grep command
+func NewGrepCmd(o *GrepOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "grep",
+		Short: "Search for a regexp across the files of a bundle without pulling it",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Search for a configuration value in a bundle
+  imgpkg grep -b repo/app1-bundle --pattern 'replicas: \d+'
+
+  # Search the bundle and all of its nested bundles
+  imgpkg grep -b repo/app1-bundle --pattern internal.io --recursive`,
+	}
+
+	o.BundleFlags.Set(cmd)
+	o.BundleRecursiveFlags.Set(cmd)
+	o.RegistryFlags.Set(cmd)
+	cmd.Flags().StringVar(&o.Pattern, "pattern", "", "Regexp to search for in the bundle's files")
+	return cmd
+}
+
+// Run functions called when the grep command is provided in the command line
+func (g *GrepOptions) Run() error {
+	if g.BundleFlags.Bundle == "" {
+		return fmt.Errorf("Expected bundle reference to be provided")
+	}
+	if g.Pattern == "" {
+		return fmt.Errorf("Expected --pattern to be none empty")
+	}
+
+	pattern, err := regexp.Compile(g.Pattern)
+	if err != nil {
+		return fmt.Errorf("Compiling pattern '%s': %s", g.Pattern, err)
+	}
+
+	reg, err := registry.NewSimpleRegistry(g.RegistryFlags.AsRegistryOpts())
+	if err != nil {
+		return err
+	}
+
+	matchesCount, err := g.grepBundle(g.BundleFlags.Bundle, pattern, reg, map[string]struct{}{})
+	if err != nil {
+		return err
+	}
+
+	if matchesCount == 0 {
+		g.ui.BeginLinef("No matches found\n")
+	}
+	return nil
+}
+
+// grepBundle streams the bundle's layers searching each file for pattern and,
+// when recursion was requested, follows the bundles found in its ImagesLock
+func (g *GrepOptions) grepBundle(bundleRef string, pattern *regexp.Regexp, reg registry.Registry, visitedRefs map[string]struct{}) (int, error) {
+	plainImg := plainimage.NewPlainImage(bundleRef, reg)
+
+	isBundle, err := bundle.NewBundleFromPlainImage(plainImg, reg).IsBundle()
+	if err != nil {
+		return 0, err
+	}
+	if !isBundle {
+		return 0, fmt.Errorf("Expected '%s' to be a bundle image", bundleRef)
+	}
+
+	img, err := plainImg.Fetch()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, visited := visitedRefs[plainImg.DigestRef()]; visited {
+		return 0, nil
+	}
+	visitedRefs[plainImg.DigestRef()] = struct{}{}
+
+	matches, imagesLockBytes, err := grepImage(img, pattern)
+	if err != nil {
+		return 0, fmt.Errorf("Searching '%s': %s", bundleRef, err)
+	}
+
+	if len(matches) > 0 {
+		g.ui.BeginLinef("%s\n", plainImg.DigestRef())
+		for _, match := range matches {
+			g.ui.BeginLinef("  %s:%d: %s\n", match.Path, match.Line, match.Text)
+		}
+	}
+
+	matchesCount := len(matches)
+	if g.BundleRecursiveFlags.Recursive && imagesLockBytes != nil {
+		imagesLock, err := lockconfig.NewImagesLockFromBytes(imagesLockBytes)
+		if err != nil {
+			return matchesCount, err
+		}
+
+		for _, imgRef := range imagesLock.Images {
+			nestedIsBundle, err := bundle.NewBundle(imgRef.Image, reg).IsBundle()
+			if err != nil {
+				return matchesCount, err
+			}
+			if !nestedIsBundle {
+				continue
+			}
+
+			nestedCount, err := g.grepBundle(imgRef.Image, pattern, reg, visitedRefs)
+			if err != nil {
+				return matchesCount, err
+			}
+			matchesCount += nestedCount
+		}
+	}
+
+	return matchesCount, nil
+}
+
+// grepMatch single line of a bundle's file that matched the pattern
+type grepMatch struct {
+	Path string
+	Line int
+	Text string
+}
+
+// grepImage searches every file of img for pattern and captures the contents
+// of the bundle's ImagesLock along the way. Layers are visited newest first so
+// files overridden by later layers are only searched once
+func grepImage(img regv1.Image, pattern *regexp.Regexp) ([]grepMatch, []byte, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	imagesLockPath := bundle.ImgpkgDir + "/" + bundle.ImagesLockFile
+
+	var matches []grepMatch
+	var imagesLockBytes []byte
+	seenPaths := map[string]struct{}{}
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		layerStream, err := layers[i].Uncompressed()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tarReader := tar.NewReader(layerStream)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				layerStream.Close()
+				return nil, nil, err
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+
+			path := strings.TrimPrefix(header.Name, "./")
+			if _, seen := seenPaths[path]; seen {
+				continue
+			}
+			seenPaths[path] = struct{}{}
+
+			if path == imagesLockPath {
+				imagesLockBytes, err = ioutil.ReadAll(tarReader)
+				if err != nil {
+					layerStream.Close()
+					return nil, nil, err
+				}
+				matches = append(matches, grepBytes(path, imagesLockBytes, pattern)...)
+				continue
+			}
+
+			fileMatches, err := grepStream(path, tarReader, pattern)
+			if err != nil {
+				layerStream.Close()
+				return nil, nil, err
+			}
+			matches = append(matches, fileMatches...)
+		}
+		layerStream.Close()
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Path != matches[j].Path {
+			return matches[i].Path < matches[j].Path
+		}
+		return matches[i].Line < matches[j].Line
+	})
+
+	return matches, imagesLockBytes, nil
+}
+
+func grepBytes(path string, contents []byte, pattern *regexp.Regexp) []grepMatch {
+	matches, _ := grepStream(path, bytes.NewReader(contents), pattern)
+	return matches
+}
+
+// grepStream scans contents line by line, stopping at the first hint the file
+// is binary (a NUL byte or a line too long to buffer)
+func grepStream(path string, contents io.Reader, pattern *regexp.Regexp) ([]grepMatch, error) {
+	var matches []grepMatch
+
+	scanner := bufio.NewScanner(contents)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Bytes()
+		if bytes.IndexByte(line, 0) >= 0 {
+			return nil, nil
+		}
+		if pattern.Match(line) {
+			matches = append(matches, grepMatch{Path: path, Line: lineNum, Text: string(line)})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return matches, nil
+}
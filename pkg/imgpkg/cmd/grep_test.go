@@ -0,0 +1,73 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestGrepValidations(t *testing.T) {
+	err := (&GrepOptions{Pattern: "foo"}).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected bundle reference")
+
+	err = (&GrepOptions{BundleFlags: BundleFlags{Bundle: "repo/bundle"}}).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected --pattern")
+
+	err = (&GrepOptions{BundleFlags: BundleFlags{Bundle: "repo/bundle"}, Pattern: "["}).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Compiling pattern")
+}
+
+func TestGrepImage(t *testing.T) {
+	bundleDir, err := ioutil.TempDir("", "imgpkg-grep-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(bundleDir)
+
+	require.NoError(t, os.Mkdir(filepath.Join(bundleDir, ".imgpkg"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bundleDir, "config.yml"),
+		[]byte("replicas: 3\nimage: registry.io/app\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bundleDir, "other.yml"),
+		[]byte("replicas: 5\n"), 0600))
+
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+
+	bundleInfo := fakeRegistry.WithBundleFromPath("repo/grep-bundle", bundleDir).WithImageRefs(nil)
+
+	t.Run("finds matching lines with paths and line numbers", func(t *testing.T) {
+		matches, _, err := grepImage(bundleInfo.Image, regexp.MustCompile(`replicas: \d+`))
+		require.NoError(t, err)
+
+		require.Len(t, matches, 2)
+		assert.Equal(t, "config.yml", matches[0].Path)
+		assert.Equal(t, 1, matches[0].Line)
+		assert.Equal(t, "replicas: 3", matches[0].Text)
+		assert.Equal(t, "other.yml", matches[1].Path)
+	})
+
+	t.Run("captures the ImagesLock and searches it as well", func(t *testing.T) {
+		matches, imagesLockBytes, err := grepImage(bundleInfo.Image, regexp.MustCompile("ImagesLock"))
+		require.NoError(t, err)
+
+		require.NotNil(t, imagesLockBytes)
+		require.Len(t, matches, 1)
+		assert.Equal(t, ".imgpkg/images.yml", matches[0].Path)
+	})
+
+	t.Run("reports no matches when pattern is absent", func(t *testing.T) {
+		matches, _, err := grepImage(bundleInfo.Image, regexp.MustCompile("not-in-the-bundle"))
+		require.NoError(t, err)
+		assert.Empty(t, matches)
+	})
+}
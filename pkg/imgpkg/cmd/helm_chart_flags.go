@@ -0,0 +1,21 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/helmchart"
+)
+
+type HelmChartFlags struct {
+	Chart            string
+	ImagesAnnotation string
+}
+
+func (h *HelmChartFlags) Set(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&h.Chart, "helm-chart", "",
+		"Package the given Helm chart directory as a bundle, extracting its image references into the bundle's ImagesLock")
+	cmd.Flags().StringVar(&h.ImagesAnnotation, "helm-chart-images-annotation", helmchart.DefaultImagesAnnotation,
+		"Chart.yaml annotation listing the image references deployed by the chart (a YAML list of strings)")
+}
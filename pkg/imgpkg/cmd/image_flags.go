@@ -4,17 +4,65 @@
 package cmd
 
 import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
 	"github.com/spf13/cobra"
 )
 
 type ImageFlags struct {
 	Image string
+
+	// Images and ImagesFilePath are only used by the copy command, which
+	// accepts multiple plain image references as a source
+	Images         []string
+	ImagesFilePath string
 }
 
 func (i *ImageFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&i.Image, "image", "i", "", "Set image (example: docker.io/dkalinin/test-content)")
 }
 
+// AllImages returns the image references provided via the repeatable -i flag,
+// the single -i flag, and the --images-file file, deduplicated in order
+func (i ImageFlags) AllImages() ([]string, error) {
+	var refs []string
+	seen := map[string]struct{}{}
+
+	addRef := func(ref string) {
+		if _, found := seen[ref]; found {
+			return
+		}
+		seen[ref] = struct{}{}
+		refs = append(refs, ref)
+	}
+
+	if i.Image != "" {
+		addRef(i.Image)
+	}
+	for _, ref := range i.Images {
+		addRef(ref)
+	}
+
+	if i.ImagesFilePath != "" {
+		bs, err := ioutil.ReadFile(i.ImagesFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("Reading path %s: %s", i.ImagesFilePath, err)
+		}
+		for _, line := range strings.Split(string(bs), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			addRef(line)
+		}
+	}
+
+	return refs, nil
+}
+
 func (i *ImageFlags) SetCopy(cmd *cobra.Command) {
-	cmd.Flags().StringVarP(&i.Image, "image", "i", "", "Image reference for copying a generic image (example: docker.io/dkalinin/test-content)")
+	cmd.Flags().StringSliceVarP(&i.Images, "image", "i", nil, "Image reference for copying a generic image (example: docker.io/dkalinin/test-content) (can be specified multiple times)")
+	cmd.Flags().StringVar(&i.ImagesFilePath, "images-file", "", "Path to a file with one image reference per line to copy ('#' lines are ignored)")
 }
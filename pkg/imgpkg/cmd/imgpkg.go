@@ -14,8 +14,9 @@ import (
 type ImgpkgOptions struct {
 	ui *ui.ConfUI
 
-	UIFlags    UIFlags
-	DebugFlags DebugFlags
+	UIFlags     UIFlags
+	DebugFlags  DebugFlags
+	LoggerFlags LoggerFlags
 }
 
 func NewImgpkgOptions(ui *ui.ConfUI) *ImgpkgOptions {
@@ -44,12 +45,38 @@ func NewImgpkgCmd(o *ImgpkgOptions) *cobra.Command {
 
 	o.UIFlags.Set(cmd)
 	o.DebugFlags.Set(cmd)
+	o.LoggerFlags.Set(cmd)
+
+	cmd.PersistentFlags().String("profile", "", "Name of a profile from the imgpkg configuration file whose flag defaults should apply ($IMGPKG_PROFILE)")
 
 	cmd.AddCommand(NewPushCmd(NewPushOptions(o.ui)))
 	cmd.AddCommand(NewPullCmd(NewPullOptions(o.ui)))
 	cmd.AddCommand(NewVersionCmd(NewVersionOptions(o.ui)))
 	cmd.AddCommand(NewCopyCmd(NewCopyOptions(o.ui)))
 	cmd.AddCommand(NewDescribeCmd(NewDescribeOptions(o.ui)))
+	cmd.AddCommand(NewDiffCmd(NewDiffOptions(o.ui)))
+	cmd.AddCommand(NewGrepCmd(NewGrepOptions(o.ui)))
+	cmd.AddCommand(NewCatCmd(NewCatOptions(o.ui)))
+	cmd.AddCommand(NewManifestCmd(NewManifestOptions(o.ui)))
+	cmd.AddCommand(NewLintCmd(NewLintOptions(o.ui)))
+	cmd.AddCommand(NewLsFilesCmd(NewLsFilesOptions(o.ui)))
+	cmd.AddCommand(NewResolveCmd(NewResolveOptions(o.ui)))
+	cmd.AddCommand(NewServeCmd(NewServeOptions(o.ui)))
+	cmd.AddCommand(NewServerCmd(NewServerOptions(o.ui)))
+	cmd.AddCommand(NewDeleteCmd(NewDeleteOptions(o.ui)))
+	cmd.AddCommand(NewPromoteCmd(NewPromoteOptions(o.ui)))
+	cmd.AddCommand(NewBenchCmd(NewBenchOptions(o.ui)))
+	cmd.AddCommand(NewExistsCmd(NewExistsOptions(o.ui)))
+	cmd.AddCommand(NewIsBundleCmd(NewIsBundleOptions(o.ui)))
+	cmd.AddCommand(NewVerifyCmd(NewVerifyOptions(o.ui)))
+
+	repoCmd := NewRepoCmd()
+	repoCmd.AddCommand(NewRepoListCmd(NewRepoListOptions(o.ui)))
+	cmd.AddCommand(repoCmd)
+
+	authCmd := NewAuthCmd()
+	authCmd.AddCommand(NewAuthCheckCmd(NewAuthCheckOptions(o.ui)))
+	cmd.AddCommand(authCmd)
 
 	tagCmd := NewTagCmd()
 	tagCmd.AddCommand(NewTagListCmd(NewTagListOptions(o.ui)))
@@ -63,9 +90,13 @@ func NewImgpkgCmd(o *ImgpkgOptions) *cobra.Command {
 	cobrautil.VisitCommands(cmd, cobrautil.WrapRunEForCmd(func(*cobra.Command, []string) error {
 		o.UIFlags.ConfigureUI(o.ui)
 		o.DebugFlags.ConfigureDebug()
-		return nil
+		return o.LoggerFlags.ConfigureLogger()
 	}))
 
+	cobrautil.VisitCommands(cmd, cobrautil.WrapRunEForCmd(applyConfigDefaults))
+
+	cobrautil.VisitCommands(cmd, cobrautil.WrapRunEForCmd(applyShortNamePolicy))
+
 	cobrautil.VisitCommands(cmd, cobrautil.WrapRunEForCmd(cobrautil.ResolveFlagsForCmd))
 
 	// Completion command have to be added after the VisitCommands
@@ -0,0 +1,64 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+type IsBundleOptions struct {
+	ui ui.UI
+
+	ImageFlags    ImageFlags
+	RegistryFlags RegistryFlags
+}
+
+func NewIsBundleOptions(ui ui.UI) *IsBundleOptions {
+	return &IsBundleOptions{ui: ui}
+}
+
+func NewIsBundleCmd(o *IsBundleOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "is-bundle",
+		Short: "Check whether an image is a bundle by inspecting only its config",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Print whether repo/app1 is a bundle, an image, or an index
+  # (exit code 0 for a bundle, 4 otherwise)
+  imgpkg is-bundle -i repo/app1`,
+	}
+	o.ImageFlags.Set(cmd)
+	o.RegistryFlags.Set(cmd)
+	return cmd
+}
+
+func (b *IsBundleOptions) Run() error {
+	if len(b.ImageFlags.Image) == 0 {
+		return fmt.Errorf("Expected image reference")
+	}
+
+	reg, err := registry.NewSimpleRegistry(b.RegistryFlags.AsRegistryOpts())
+	if err != nil {
+		return err
+	}
+
+	kind, digest, err := bundle.Kind(b.ImageFlags.Image, reg)
+	if err != nil {
+		return err
+	}
+
+	b.ui.PrintLinef("Kind: %s", kind)
+	b.ui.PrintLinef("Digest: %s", digest)
+
+	if kind != bundle.RefKindBundle {
+		return ExitCodeError{existsExitCodeNotABundle, fmt.Errorf("Image '%s' is not a bundle (kind: %s)", b.ImageFlags.Image, kind)}
+	}
+
+	return nil
+}
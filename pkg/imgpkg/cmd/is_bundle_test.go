@@ -0,0 +1,56 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestIsBundleNoRefError(t *testing.T) {
+	err := (&IsBundleOptions{ui: goui.NewNoopUI()}).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected image reference")
+}
+
+func TestIsBundleClassifiesReferences(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+
+	fakeRegistry.WithRandomBundle("repo/some-bundle")
+	fakeRegistry.WithRandomImage("repo/some-image")
+	fakeRegistry.WithARandomImageIndex("repo/some-index", 2)
+	fakeRegistry.Build()
+
+	t.Run("exits zero for a bundle", func(t *testing.T) {
+		isBundle := IsBundleOptions{ui: goui.NewNoopUI()}
+		isBundle.ImageFlags.Image = fakeRegistry.ReferenceOnTestServer("repo/some-bundle")
+		require.NoError(t, isBundle.Run())
+	})
+
+	t.Run("reports kind image for a plain image", func(t *testing.T) {
+		isBundle := IsBundleOptions{ui: goui.NewNoopUI()}
+		isBundle.ImageFlags.Image = fakeRegistry.ReferenceOnTestServer("repo/some-image")
+
+		err := isBundle.Run()
+		require.Error(t, err)
+		exitErr, ok := err.(ExitCodeError)
+		require.True(t, ok, "expected an ExitCodeError, got: %s", err)
+		assert.Equal(t, existsExitCodeNotABundle, exitErr.Code)
+		assert.Contains(t, err.Error(), "kind: image")
+	})
+
+	t.Run("reports kind index for an image index", func(t *testing.T) {
+		isBundle := IsBundleOptions{ui: goui.NewNoopUI()}
+		isBundle.ImageFlags.Image = fakeRegistry.ReferenceOnTestServer("repo/some-index")
+
+		err := isBundle.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "kind: index")
+	})
+}
@@ -0,0 +1,55 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+)
+
+type LintOptions struct {
+	ui ui.UI
+
+	FileFlags FileFlags
+
+	AllowUnknownBundleFields bool
+}
+
+func NewLintOptions(ui ui.UI) *LintOptions {
+	return &LintOptions{ui: ui}
+}
+
+func NewLintCmd(o *LintOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Validate bundle directory contents without pushing",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Validate the bundle in the config/ directory
+  imgpkg lint -f config/`,
+	}
+	o.FileFlags.Set(cmd)
+	cmd.Flags().BoolVar(&o.AllowUnknownBundleFields, "allow-unknown-bundle-fields", false, "Tolerate unknown fields in .imgpkg/bundle.yml (e.g. fields introduced by newer imgpkg versions)")
+	return cmd
+}
+
+func (o *LintOptions) Run() error {
+	if len(o.FileFlags.Files) == 0 {
+		return fmt.Errorf("Expected at least one file flag (-f)")
+	}
+
+	contents := bundle.NewContentsWithOpts(o.FileFlags.Files, o.FileFlags.ExcludedFilePaths, bundle.ContentsOpts{AllowUnknownBundleFields: o.AllowUnknownBundleFields})
+
+	err := contents.Validate()
+	if err != nil {
+		return err
+	}
+
+	o.ui.PrintLinef("Bundle is valid")
+
+	return nil
+}
@@ -4,14 +4,109 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
 	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"sigs.k8s.io/yaml"
 )
 
 type LockOutputFlags struct {
-	LockFilePath string
+	LockFilePath  string
+	Backup        bool
+	Format        string
+	PreserveOrder bool
+}
+
+// lockOutput a lock config that can render itself as YAML
+type lockOutput interface {
+	AsBytes() ([]byte, error)
 }
 
 func (l *LockOutputFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&l.LockFilePath, "lock-output", "",
-		"Location to output the generated lockfile. Option only available when using --bundle or --lock flags")
+		"Location to output the generated lockfile ('-' prints it to stdout). Option only available when using --bundle or --lock flags")
+	cmd.Flags().BoolVar(&l.Backup, "lock-output-backup", false,
+		"Keep a .bak copy of a pre-existing lockfile before overwriting it")
+	cmd.Flags().StringVar(&l.Format, "lock-output-format", "yaml",
+		"Format of the lock output (yaml|json)")
+	cmd.Flags().BoolVar(&l.PreserveOrder, "lock-output-preserve-order", false,
+		"Keep images in their input order in the lock output instead of sorting by image reference")
+}
+
+// WriteLock writes the lock in the configured format to the configured
+// location, backing up a pre-existing lockfile when asked to
+func (l *LockOutputFlags) WriteLock(lock lockOutput, ui goui.UI) error {
+	if imagesLock, ok := lock.(lockconfig.ImagesLock); ok {
+		imagesLock.SetPreserveOrder(l.PreserveOrder)
+		lock = imagesLock
+	}
+
+	bs, err := lock.AsBytes()
+	if err != nil {
+		return err
+	}
+
+	switch l.Format {
+	case "", "yaml":
+	case "json":
+		jsonBs, err := yaml.YAMLToJSON(bs)
+		if err != nil {
+			return fmt.Errorf("Converting lock output to JSON: %s", err)
+		}
+		var indented bytes.Buffer
+		err = json.Indent(&indented, jsonBs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("Converting lock output to JSON: %s", err)
+		}
+		bs = append(indented.Bytes(), '\n')
+	default:
+		return fmt.Errorf("Expected --lock-output-format to be either 'yaml' or 'json', got '%s'", l.Format)
+	}
+
+	if l.LockFilePath == "-" {
+		ui.PrintBlock(bs)
+		return nil
+	}
+
+	err = l.BackupExisting()
+	if err != nil {
+		return err
+	}
+
+	err = util.WriteFileAtomically(l.LockFilePath, bs, 0600)
+	if err != nil {
+		return fmt.Errorf("Writing lock output: %s", err)
+	}
+
+	return nil
+}
+
+// BackupExisting copies a pre-existing lockfile to a '.bak' file alongside it,
+// so the previous contents survive the lockfile being overwritten
+func (l *LockOutputFlags) BackupExisting() error {
+	if !l.Backup || l.LockFilePath == "" {
+		return nil
+	}
+
+	bs, err := ioutil.ReadFile(l.LockFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("Reading existing lockfile: %s", err)
+	}
+
+	err = ioutil.WriteFile(l.LockFilePath+".bak", bs, 0600)
+	if err != nil {
+		return fmt.Errorf("Backing up existing lockfile: %s", err)
+	}
+
+	return nil
 }
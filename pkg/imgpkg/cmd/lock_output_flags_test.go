@@ -0,0 +1,95 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+)
+
+func TestLockOutputBackupKeepsPreExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.lock.yml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("previous contents"), 0600))
+
+	flags := LockOutputFlags{LockFilePath: path, Backup: true}
+	require.NoError(t, flags.BackupExisting())
+
+	backup, err := ioutil.ReadFile(path + ".bak")
+	require.NoError(t, err)
+	assert.Equal(t, "previous contents", string(backup))
+}
+
+func TestLockOutputBackupNoopWhenFileMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.lock.yml")
+
+	flags := LockOutputFlags{LockFilePath: path, Backup: true}
+	require.NoError(t, flags.BackupExisting())
+
+	_, err := os.Stat(path + ".bak")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLockOutputBackupNoopWhenDisabled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.lock.yml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("previous contents"), 0600))
+
+	flags := LockOutputFlags{LockFilePath: path}
+	require.NoError(t, flags.BackupExisting())
+
+	_, err := os.Stat(path + ".bak")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLockOutputWriteLockAsJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.lock.json")
+
+	flags := LockOutputFlags{LockFilePath: path, Format: "json"}
+	require.NoError(t, flags.WriteLock(testBundleLock(), goui.NewNoopUI()))
+
+	bs, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	var parsed map[string]interface{}
+	require.NoError(t, json.Unmarshal(bs, &parsed))
+	assert.Equal(t, "BundleLock", parsed["kind"])
+}
+
+func TestLockOutputWriteLockToStdout(t *testing.T) {
+	stdout := bytes.NewBufferString("")
+	writerUI := goui.NewWriterUI(stdout, stdout, goui.NewNoopLogger())
+
+	flags := LockOutputFlags{LockFilePath: "-"}
+	require.NoError(t, flags.WriteLock(testBundleLock(), writerUI))
+
+	assert.Contains(t, stdout.String(), "kind: BundleLock")
+}
+
+func TestLockOutputWriteLockInvalidFormatError(t *testing.T) {
+	flags := LockOutputFlags{LockFilePath: "-", Format: "xml"}
+
+	err := flags.WriteLock(testBundleLock(), goui.NewNoopUI())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected --lock-output-format to be either 'yaml' or 'json', got 'xml'")
+}
+
+func testBundleLock() lockconfig.BundleLock {
+	return lockconfig.BundleLock{
+		LockVersion: lockconfig.LockVersion{
+			APIVersion: lockconfig.BundleLockAPIVersion,
+			Kind:       lockconfig.BundleLockKind,
+		},
+		Bundle: lockconfig.BundleRef{
+			Image: "registry.io/foo@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		},
+	}
+}
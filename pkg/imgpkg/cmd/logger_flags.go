@@ -0,0 +1,77 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	"github.com/google/go-containerregistry/pkg/logs"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+)
+
+// LoggerFlags indicates the verbosity and format of imgpkg logging
+type LoggerFlags struct {
+	Verbose   bool
+	LogLevel  string
+	LogFormat string
+}
+
+// Set adds the logging flags to the command
+func (f *LoggerFlags) Set(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&f.Verbose, "verbose", false, "Set log level to debug (shorthand for --log-level=debug)")
+	cmd.PersistentFlags().StringVar(&f.LogLevel, "log-level", "warn", "Set logging level (trace|debug|warn)")
+	cmd.PersistentFlags().StringVar(&f.LogFormat, "log-format", "text", "Set logging format (text|json)")
+}
+
+// ConfigureLogger records the selected level and format, and routes
+// go-containerregistry logs to stderr when running at debug or trace level
+func (f *LoggerFlags) ConfigureLogger() error {
+	var level util.LogLevel
+	switch f.LogLevel {
+	case "trace":
+		level = util.LogTrace
+	case "debug":
+		level = util.LogDebug
+	case "warn":
+		level = util.LogWarn
+	default:
+		return fmt.Errorf("Unknown log level '%s' (allowed: trace, debug, warn)", f.LogLevel)
+	}
+	if f.Verbose && level > util.LogDebug {
+		level = util.LogDebug
+	}
+
+	if f.LogFormat != "text" && f.LogFormat != "json" {
+		return fmt.Errorf("Unknown log format '%s' (allowed: text, json)", f.LogFormat)
+	}
+
+	configuredLogLevel = level
+	configuredLogFormat = f.LogFormat
+
+	if level <= util.LogDebug {
+		logs.Warn.SetOutput(os.Stderr)
+	}
+	if level == util.LogTrace {
+		logs.Debug.SetOutput(os.Stderr)
+		logs.Progress.SetOutput(os.Stderr)
+	}
+	return nil
+}
+
+var (
+	configuredLogLevel  = util.LogWarn
+	configuredLogFormat = "text"
+)
+
+// newLevelLogger builds a leveled logger honoring the --verbose, --log-level,
+// and --log-format flags configured on the root command
+func newLevelLogger(ui goui.UI) util.UIWithLevels {
+	if configuredLogFormat == "json" {
+		return util.NewUIJSONLevelLogger(configuredLogLevel, ui)
+	}
+	return util.NewUILevelLogger(configuredLogLevel, ui)
+}
@@ -0,0 +1,165 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/plainimage"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+// LsFilesOptions Command Line options that can be provided to the ls-files command
+type LsFilesOptions struct {
+	ui goui.UI
+
+	ImageFlags    ImageFlags
+	BundleFlags   BundleFlags
+	RegistryFlags RegistryFlags
+
+	Prefix string
+}
+
+// NewLsFilesOptions constructor for building a LsFilesOptions, holding values derived via flags
+func NewLsFilesOptions(ui *goui.ConfUI) *LsFilesOptions {
+	return &LsFilesOptions{ui: ui}
+}
+
+// NewLsFilesCmd constructor for the ls-files command
+func NewLsFilesCmd(o *LsFilesOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ls-files",
+		Short: "List the files of a bundle or image without pulling it",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # List every file in a bundle
+  imgpkg ls-files -b repo/app1-bundle
+
+  # List only the bundle's configuration files
+  imgpkg ls-files -b repo/app1-bundle --prefix config/`,
+	}
+
+	o.ImageFlags.Set(cmd)
+	o.BundleFlags.Set(cmd)
+	o.RegistryFlags.Set(cmd)
+	cmd.Flags().StringVar(&o.Prefix, "prefix", "", "Only list files whose path starts with this prefix")
+	return cmd
+}
+
+// Run functions called when the ls-files command is provided in the command line
+func (l *LsFilesOptions) Run() error {
+	var refStr string
+	switch {
+	case len(l.BundleFlags.Bundle) > 0 && len(l.ImageFlags.Image) > 0:
+		return fmt.Errorf("Expected only one of image or bundle")
+	case len(l.BundleFlags.Bundle) > 0:
+		refStr = l.BundleFlags.Bundle
+	case len(l.ImageFlags.Image) > 0:
+		refStr = l.ImageFlags.Image
+	default:
+		return fmt.Errorf("Expected either image or bundle reference")
+	}
+
+	reg, err := registry.NewSimpleRegistry(l.RegistryFlags.AsRegistryOpts())
+	if err != nil {
+		return err
+	}
+
+	img, err := plainimage.NewPlainImage(refStr, reg).Fetch()
+	if err != nil {
+		return err
+	}
+
+	entries, err := lsFilesImage(img, strings.TrimPrefix(l.Prefix, "./"))
+	if err != nil {
+		return fmt.Errorf("Listing files of '%s': %s", refStr, err)
+	}
+
+	for _, entry := range entries {
+		if entry.LinkTarget != "" {
+			l.ui.BeginLinef("%s  %9d  %s -> %s\n", entry.Mode, entry.Size, entry.Path, entry.LinkTarget)
+		} else {
+			l.ui.BeginLinef("%s  %9d  %s\n", entry.Mode, entry.Size, entry.Path)
+		}
+	}
+
+	if len(entries) == 0 {
+		l.ui.BeginLinef("No files found\n")
+	}
+	return nil
+}
+
+// fileEntry single file of an image, as described by its layer tar header
+type fileEntry struct {
+	Path       string
+	Size       int64
+	Mode       os.FileMode
+	LinkTarget string
+}
+
+// lsFilesImage walks the tar headers of every layer of img collecting the
+// files whose path starts with prefix. Layers are visited newest first so
+// files overridden by later layers are only listed once; file contents are
+// never read
+func lsFilesImage(img regv1.Image, prefix string) ([]fileEntry, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []fileEntry
+	seenPaths := map[string]struct{}{}
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		layerStream, err := layers[i].Uncompressed()
+		if err != nil {
+			return nil, err
+		}
+
+		tarReader := tar.NewReader(layerStream)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				layerStream.Close()
+				return nil, err
+			}
+			if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeSymlink && header.Typeflag != tar.TypeLink {
+				continue
+			}
+
+			path := strings.TrimPrefix(header.Name, "./")
+			if _, seen := seenPaths[path]; seen {
+				continue
+			}
+			seenPaths[path] = struct{}{}
+
+			if !strings.HasPrefix(path, prefix) {
+				continue
+			}
+
+			entries = append(entries, fileEntry{
+				Path:       path,
+				Size:       header.Size,
+				Mode:       header.FileInfo().Mode(),
+				LinkTarget: header.Linkname,
+			})
+		}
+		layerStream.Close()
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	return entries, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestLsFilesValidations(t *testing.T) {
+	err := (&LsFilesOptions{}).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected either image or bundle reference")
+
+	multi := LsFilesOptions{}
+	multi.ImageFlags.Image = "foo"
+	multi.BundleFlags.Bundle = "bar"
+	err = multi.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected only one of image or bundle")
+}
+
+func TestLsFilesImage(t *testing.T) {
+	bundleDir, err := ioutil.TempDir("", "imgpkg-ls-files-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(bundleDir)
+
+	require.NoError(t, os.Mkdir(filepath.Join(bundleDir, ".imgpkg"), 0700))
+	require.NoError(t, os.Mkdir(filepath.Join(bundleDir, "config"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bundleDir, "config", "deployment.yml"),
+		[]byte("replicas: 3\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bundleDir, "README.md"),
+		[]byte("docs\n"), 0600))
+
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+
+	bundleInfo := fakeRegistry.WithBundleFromPath("repo/ls-files-bundle", bundleDir).WithImageRefs(nil)
+
+	t.Run("lists every file with its size", func(t *testing.T) {
+		entries, err := lsFilesImage(bundleInfo.Image, "")
+		require.NoError(t, err)
+
+		var paths []string
+		for _, entry := range entries {
+			paths = append(paths, entry.Path)
+		}
+		assert.Contains(t, paths, "config/deployment.yml")
+		assert.Contains(t, paths, "README.md")
+		assert.Contains(t, paths, ".imgpkg/images.yml")
+
+		for _, entry := range entries {
+			if entry.Path == "config/deployment.yml" {
+				assert.Equal(t, int64(len("replicas: 3\n")), entry.Size)
+			}
+		}
+	})
+
+	t.Run("scopes the listing to a path prefix", func(t *testing.T) {
+		entries, err := lsFilesImage(bundleInfo.Image, "config/")
+		require.NoError(t, err)
+
+		require.Len(t, entries, 1)
+		assert.Equal(t, "config/deployment.yml", entries[0].Path)
+	})
+}
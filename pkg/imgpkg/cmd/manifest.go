@@ -0,0 +1,199 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+// ManifestOptions Command Line options that can be provided to the manifest command
+type ManifestOptions struct {
+	ui goui.UI
+
+	ImageFlags    ImageFlags
+	BundleFlags   BundleFlags
+	RegistryFlags RegistryFlags
+
+	Config   bool
+	Pretty   bool
+	Selector string
+}
+
+// NewManifestOptions constructor for building a ManifestOptions, holding values derived via flags
+func NewManifestOptions(ui *goui.ConfUI) *ManifestOptions {
+	return &ManifestOptions{ui: ui}
+}
+
+// NewManifestCmd constructor for the manifest command
+func NewManifestCmd(o *ManifestOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "manifest",
+		Short: "Print the manifest or config JSON of a bundle or image",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Print the raw manifest of an image
+  imgpkg manifest -i repo/app1-image
+
+  # Print the config of a bundle, pretty-printed
+  imgpkg manifest -b repo/app1-bundle --config --pretty
+
+  # Print just the digest of the config blob
+  imgpkg manifest -i repo/app1-image --selector .config.digest`,
+	}
+
+	o.ImageFlags.Set(cmd)
+	o.BundleFlags.Set(cmd)
+	o.RegistryFlags.Set(cmd)
+	cmd.Flags().BoolVar(&o.Config, "config", false, "Print the image config instead of the manifest")
+	cmd.Flags().BoolVar(&o.Pretty, "pretty", false, "Pretty-print the JSON output")
+	cmd.Flags().StringVar(&o.Selector, "selector", "",
+		"Print only the value at this path of the JSON document (ex: .config.digest, .layers[0].size)")
+	return cmd
+}
+
+// Run functions called when the manifest command is provided in the command line
+func (c *ManifestOptions) Run() error {
+	var refStr string
+	switch {
+	case len(c.BundleFlags.Bundle) > 0 && len(c.ImageFlags.Image) > 0:
+		return fmt.Errorf("Expected only one of image or bundle")
+	case len(c.BundleFlags.Bundle) > 0:
+		refStr = c.BundleFlags.Bundle
+	case len(c.ImageFlags.Image) > 0:
+		refStr = c.ImageFlags.Image
+	default:
+		return fmt.Errorf("Expected either image or bundle reference")
+	}
+
+	ref, err := regname.ParseReference(refStr, regname.WeakValidation)
+	if err != nil {
+		return fmt.Errorf("Parsing '%s': %s", refStr, err)
+	}
+
+	reg, err := registry.NewSimpleRegistry(c.RegistryFlags.AsRegistryOpts())
+	if err != nil {
+		return err
+	}
+
+	desc, err := reg.Get(ref)
+	if err != nil {
+		return fmt.Errorf("Fetching '%s': %s", refStr, err)
+	}
+
+	bs := desc.Manifest
+	if c.Config {
+		if desc.MediaType.IsIndex() {
+			return fmt.Errorf("Expected an image when printing a config, got an index (hint: Pick a specific image from the index)")
+		}
+
+		img, err := desc.Image()
+		if err != nil {
+			return err
+		}
+		bs, err = img.RawConfigFile()
+		if err != nil {
+			return fmt.Errorf("Reading config of '%s': %s", refStr, err)
+		}
+	}
+
+	bs, err = renderJSON(bs, c.Selector, c.Pretty)
+	if err != nil {
+		return err
+	}
+
+	c.ui.PrintBlock(bs)
+	return nil
+}
+
+// renderJSON applies the selector to the raw JSON document and re-encodes the
+// result, pretty-printed when requested. Without a selector or pretty-printing
+// the document is returned untouched
+func renderJSON(bs []byte, selector string, pretty bool) ([]byte, error) {
+	if selector == "" && !pretty {
+		return bs, nil
+	}
+
+	var doc interface{}
+	err := json.Unmarshal(bs, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing JSON document: %s", err)
+	}
+
+	doc, err = selectJSONPath(doc, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scalar selections print as plain values, so they compose with shell pipelines
+	if str, ok := doc.(string); ok {
+		return []byte(str + "\n"), nil
+	}
+
+	if pretty {
+		bs, err = json.MarshalIndent(doc, "", "  ")
+	} else {
+		bs, err = json.Marshal(doc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(bs, '\n'), nil
+}
+
+// selectJSONPath walks doc following a dot separated path of keys with
+// optional indexes (ex: .config.digest, .layers[0].size)
+func selectJSONPath(doc interface{}, selector string) (interface{}, error) {
+	if selector == "" {
+		return doc, nil
+	}
+
+	for _, segment := range strings.Split(strings.Trim(selector, "."), ".") {
+		key := segment
+		indexes := []int{}
+		for strings.HasSuffix(key, "]") {
+			open := strings.LastIndex(key, "[")
+			if open < 0 {
+				return nil, fmt.Errorf("Parsing selector segment '%s': unmatched ']'", segment)
+			}
+			idx, err := strconv.Atoi(key[open+1 : len(key)-1])
+			if err != nil {
+				return nil, fmt.Errorf("Parsing selector segment '%s': %s", segment, err)
+			}
+			indexes = append([]int{idx}, indexes...)
+			key = key[:open]
+		}
+
+		if key != "" {
+			obj, ok := doc.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("Selector '%s': '%s' is not an object", selector, key)
+			}
+			doc, ok = obj[key]
+			if !ok {
+				return nil, fmt.Errorf("Selector '%s': key '%s' not found", selector, key)
+			}
+		}
+
+		for _, idx := range indexes {
+			list, ok := doc.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("Selector '%s': '%s' is not an array", selector, key)
+			}
+			if idx < 0 || idx >= len(list) {
+				return nil, fmt.Errorf("Selector '%s': index %d out of range (array has %d items)", selector, idx, len(list))
+			}
+			doc = list[idx]
+		}
+	}
+
+	return doc, nil
+}
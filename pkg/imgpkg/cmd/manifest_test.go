@@ -0,0 +1,70 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestValidations(t *testing.T) {
+	err := (&ManifestOptions{}).Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected either image or bundle reference")
+
+	both := ManifestOptions{}
+	both.ImageFlags.Image = "repo/img"
+	both.BundleFlags.Bundle = "repo/bundle"
+	err = both.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected only one of image or bundle")
+}
+
+func TestRenderJSON(t *testing.T) {
+	doc := []byte(`{"config":{"digest":"sha256:aaaa","size":3},"layers":[{"size":10},{"size":20}]}`)
+
+	t.Run("without selector or pretty, the document is untouched", func(t *testing.T) {
+		out, err := renderJSON(doc, "", false)
+		require.NoError(t, err)
+		assert.Equal(t, string(doc), string(out))
+	})
+
+	t.Run("pretty-prints the document", func(t *testing.T) {
+		out, err := renderJSON(doc, "", true)
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "  \"config\": {")
+	})
+
+	t.Run("selects a scalar and prints it bare", func(t *testing.T) {
+		out, err := renderJSON(doc, ".config.digest", false)
+		require.NoError(t, err)
+		assert.Equal(t, "sha256:aaaa\n", string(out))
+	})
+
+	t.Run("selects into arrays", func(t *testing.T) {
+		out, err := renderJSON(doc, ".layers[1].size", false)
+		require.NoError(t, err)
+		assert.Equal(t, "20\n", string(out))
+
+		out, err = renderJSON(doc, ".layers[0]", false)
+		require.NoError(t, err)
+		assert.Equal(t, `{"size":10}`+"\n", string(out))
+	})
+
+	t.Run("errors for unknown keys and bad indexes", func(t *testing.T) {
+		_, err := renderJSON(doc, ".missing", false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "key 'missing' not found")
+
+		_, err = renderJSON(doc, ".layers[5]", false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "index 5 out of range")
+
+		_, err = renderJSON(doc, ".config[0]", false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not an array")
+	})
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// NotificationFlags post-operation hooks that run after a successful copy or
+// push, so pipelines can trigger downstream work without wrapping imgpkg in
+// extra scripting
+type NotificationFlags struct {
+	WebhookURL  string
+	ExecCommand string
+}
+
+func (n *NotificationFlags) Set(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&n.WebhookURL, "notify-webhook", "",
+		"URL to POST a JSON payload of resulting digests and destinations to after the operation succeeds")
+	cmd.Flags().StringVar(&n.ExecCommand, "notify-exec", "",
+		"Command to run after the operation succeeds ('{}' is replaced with the path of a file holding the JSON payload)")
+}
+
+// AnySet reports whether at least one hook was configured
+func (n NotificationFlags) AnySet() bool {
+	return n.WebhookURL != "" || n.ExecCommand != ""
+}
+
+// NotificationPayload document sent to the webhook and handed to the exec hook
+type NotificationPayload struct {
+	Operation   string              `json:"operation"`
+	CompletedAt string              `json:"completedAt"`
+	Images      []NotificationImage `json:"images"`
+}
+
+// NotificationImage a single image resulting from the operation
+type NotificationImage struct {
+	Image  string `json:"image"`
+	Tag    string `json:"tag,omitempty"`
+	Origin string `json:"origin,omitempty"`
+}
+
+// Notify runs the configured hooks with a payload describing the operation.
+// A hook failure is reported as an error even though the operation itself
+// already succeeded
+func (n NotificationFlags) Notify(operation string, images []NotificationImage) error {
+	if !n.AnySet() {
+		return nil
+	}
+
+	payload := NotificationPayload{
+		Operation:   operation,
+		CompletedAt: time.Now().UTC().Format(time.RFC3339),
+		Images:      images,
+	}
+	bs, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if n.WebhookURL != "" {
+		resp, err := http.Post(n.WebhookURL, "application/json", bytes.NewReader(bs))
+		if err != nil {
+			return fmt.Errorf("Notifying webhook '%s': %s", n.WebhookURL, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("Notifying webhook '%s': unexpected status code %d", n.WebhookURL, resp.StatusCode)
+		}
+	}
+
+	if n.ExecCommand != "" {
+		err := n.execHook(bs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (n NotificationFlags) execHook(payload []byte) error {
+	payloadFile, err := os.CreateTemp("", "imgpkg-notify-*.json")
+	if err != nil {
+		return fmt.Errorf("Creating notify payload file: %s", err)
+	}
+	defer os.Remove(payloadFile.Name())
+
+	_, err = payloadFile.Write(payload)
+	if err != nil {
+		payloadFile.Close()
+		return fmt.Errorf("Writing notify payload file: %s", err)
+	}
+	err = payloadFile.Close()
+	if err != nil {
+		return fmt.Errorf("Writing notify payload file: %s", err)
+	}
+
+	args := strings.Fields(n.ExecCommand)
+	if len(args) == 0 {
+		return fmt.Errorf("Expected notify command to be non empty")
+	}
+
+	replaced := false
+	for i, arg := range args {
+		if strings.Contains(arg, "{}") {
+			args[i] = strings.ReplaceAll(arg, "{}", payloadFile.Name())
+			replaced = true
+		}
+	}
+	if !replaced {
+		args = append(args, payloadFile.Name())
+	}
+
+	output, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("Running notify command: %s (output: %s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationWebhook(t *testing.T) {
+	var receivedPayload NotificationPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(bs, &receivedPayload))
+	}))
+	defer server.Close()
+
+	hooks := NotificationFlags{WebhookURL: server.URL}
+	err := hooks.Notify("copy", []NotificationImage{
+		{Image: "dest.io/repo@sha256:aaaa", Tag: "v1.0", Origin: "src.io/repo@sha256:aaaa"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "copy", receivedPayload.Operation)
+	assert.NotEmpty(t, receivedPayload.CompletedAt)
+	require.Len(t, receivedPayload.Images, 1)
+	assert.Equal(t, "dest.io/repo@sha256:aaaa", receivedPayload.Images[0].Image)
+	assert.Equal(t, "v1.0", receivedPayload.Images[0].Tag)
+	assert.Equal(t, "src.io/repo@sha256:aaaa", receivedPayload.Images[0].Origin)
+}
+
+func TestNotificationWebhookFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	hooks := NotificationFlags{WebhookURL: server.URL}
+	err := hooks.Notify("copy", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status code 500")
+}
+
+func TestNotificationExec(t *testing.T) {
+	hooks := NotificationFlags{ExecCommand: "cat {}"}
+	err := hooks.Notify("push", []NotificationImage{{Image: "dest.io/repo@sha256:aaaa"}})
+	require.NoError(t, err)
+
+	hooks = NotificationFlags{ExecCommand: "false"}
+	err = hooks.Notify("push", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Running notify command")
+}
+
+func TestNotificationNoopWhenUnset(t *testing.T) {
+	err := NotificationFlags{}.Notify("copy", nil)
+	require.NoError(t, err)
+}
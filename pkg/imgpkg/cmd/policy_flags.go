@@ -0,0 +1,34 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/policy"
+)
+
+type PolicyFlags struct {
+	PolicyFile string
+}
+
+func (p *PolicyFlags) Set(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&p.PolicyFile, "policy-file", "",
+		"Path to a policy file with guardrails images must satisfy (disallowed registries, unsigned images, size limits)")
+}
+
+// checkPolicy evaluates refs against the policy at policyFile
+func checkPolicy(policyFile string, refs []string, metadata policy.ImagesMetadata) ([]policy.Violation, error) {
+	pol, err := policy.NewPolicyFromPath(policyFile)
+	if err != nil {
+		return nil, err
+	}
+	return policy.NewChecker(pol).Check(refs, metadata)
+}
+
+// policyViolationsError error returned when a policy rejects one or more images
+func policyViolationsError(policyFile string, violationsCount int) error {
+	return fmt.Errorf("Expected images to satisfy the policy at '%s', but found %d violation(s)", policyFile, violationsCount)
+}
@@ -0,0 +1,135 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+	ctlimgset "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature"
+)
+
+// PromoteOptions options for the promote command
+type PromoteOptions struct {
+	ui ui.UI
+
+	BundleFlags     BundleFlags
+	RegistryFlags   RegistryFlags
+	LockOutputFlags LockOutputFlags
+
+	FromRepo    string
+	ToRepo      string
+	Concurrency int
+}
+
+// NewPromoteOptions constructor for building a PromoteOptions, holding values derived via flags
+func NewPromoteOptions(ui *ui.ConfUI) *PromoteOptions {
+	return &PromoteOptions{ui: ui}
+}
+
+func NewPromoteCmd(o *PromoteOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "promote",
+		Short: "Promote a bundle from one repository to another",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+    # Promote bundle from a staging repository to the production repository
+    imgpkg promote -b staging.registry.io/apps/app1@sha256:669e010b58baf5beb2836b253c1fd5768333f0d1dbcb834f7c07a4dc93f474be \
+                   --from-repo staging.registry.io/apps/app1 --to-repo prod.registry.io/apps/app1`,
+	}
+	o.BundleFlags.Set(cmd)
+	o.RegistryFlags.Set(cmd)
+	o.LockOutputFlags.Set(cmd)
+	cmd.Flags().StringVar(&o.FromRepo, "from-repo", "",
+		"Repository currently holding the colocated bundle (validated against the bundle reference)")
+	cmd.Flags().StringVar(&o.ToRepo, "to-repo", "", "Repository to promote the bundle to")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", 5, "Concurrency")
+	return cmd
+}
+
+// Run performs the promotion, relocating the bundle and every image it
+// references to the destination repository. Blobs already present in the
+// destination registry are reused via cross repository mounts
+func (p *PromoteOptions) Run() error {
+	if p.BundleFlags.Bundle == "" {
+		return fmt.Errorf("Expected bundle reference (-b) to be provided")
+	}
+	if p.ToRepo == "" {
+		return fmt.Errorf("Expected --to-repo to be provided")
+	}
+
+	bundleRef, err := regname.ParseReference(p.BundleFlags.Bundle, regname.WeakValidation)
+	if err != nil {
+		return fmt.Errorf("Parsing bundle reference '%s': %s", p.BundleFlags.Bundle, err)
+	}
+
+	if p.FromRepo != "" {
+		fromRepo, err := regname.NewRepository(p.FromRepo)
+		if err != nil {
+			return fmt.Errorf("Building source repository ref: %s", err)
+		}
+		if bundleRef.Context().Name() != fromRepo.Name() {
+			return fmt.Errorf("Expected bundle reference to be in repository '%s', but was in '%s'",
+				fromRepo.Name(), bundleRef.Context().Name())
+		}
+	}
+
+	reg, err := registry.NewSimpleRegistry(p.RegistryFlags.AsRegistryOpts())
+	if err != nil {
+		return err
+	}
+
+	prefixedLogger := util.NewUIPrefixedWriter("promote | ", p.ui)
+	levelLogger := newLevelLogger(prefixedLogger)
+	imagesUploaderLogger := util.NewProgressBar(prefixedLogger, "done uploading images", "Error uploading images")
+
+	imageSet := ctlimgset.NewImageSet(p.Concurrency, prefixedLogger, util.DefaultTagGenerator{})
+
+	repoSrc := CopyRepoSrc{
+		BundleFlags: p.BundleFlags,
+		Concurrency: p.Concurrency,
+
+		ui:                 levelLogger,
+		registry:           registry.NewRegistryWithProgress(reg, imagesUploaderLogger),
+		imageSet:           imageSet,
+		signatureRetriever: signature.NewNoop(),
+		scanner:            NewNoopScanner(),
+	}
+
+	processedImages, err := repoSrc.CopyToRepo(p.ToRepo)
+	if err != nil {
+		return err
+	}
+
+	for _, processedImage := range processedImages.All() {
+		if _, ok := processedImage.Labels[rootBundleLabelKey]; !ok {
+			continue
+		}
+
+		p.ui.BeginLinef("promoted bundle to %s\n", processedImage.DigestRef)
+
+		if p.LockOutputFlags.LockFilePath != "" {
+			bundleLock := lockconfig.BundleLock{
+				LockVersion: lockconfig.LockVersion{
+					APIVersion: lockconfig.BundleLockAPIVersion,
+					Kind:       lockconfig.BundleLockKind,
+				},
+				Bundle: lockconfig.BundleRef{
+					Image: processedImage.DigestRef,
+					Tag:   processedImage.Tag,
+				},
+			}
+			return p.LockOutputFlags.WriteLock(bundleLock, p.ui)
+		}
+		return nil
+	}
+
+	return nil
+}
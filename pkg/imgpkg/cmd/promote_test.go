@@ -0,0 +1,49 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPromoteNoBundleError(t *testing.T) {
+	promote := PromoteOptions{ToRepo: "prod.registry.io/apps/app1"}
+	err := promote.Run()
+	if err == nil {
+		t.Fatalf("Expected validations to err, but did not")
+	}
+
+	if !strings.Contains(err.Error(), "Expected bundle reference (-b) to be provided") {
+		t.Fatalf("Expected error to contain message about missing bundle, got: %s", err)
+	}
+}
+
+func TestPromoteNoDestinationRepoError(t *testing.T) {
+	promote := PromoteOptions{BundleFlags: BundleFlags{"staging.registry.io/apps/app1@sha256:669e010b58baf5beb2836b253c1fd5768333f0d1dbcb834f7c07a4dc93f474be"}}
+	err := promote.Run()
+	if err == nil {
+		t.Fatalf("Expected validations to err, but did not")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --to-repo to be provided") {
+		t.Fatalf("Expected error to contain message about missing destination repository, got: %s", err)
+	}
+}
+
+func TestPromoteSourceRepoMismatchError(t *testing.T) {
+	promote := PromoteOptions{
+		BundleFlags: BundleFlags{"staging.registry.io/apps/app1@sha256:669e010b58baf5beb2836b253c1fd5768333f0d1dbcb834f7c07a4dc93f474be"},
+		FromRepo:    "staging.registry.io/apps/other-app",
+		ToRepo:      "prod.registry.io/apps/app1",
+	}
+	err := promote.Run()
+	if err == nil {
+		t.Fatalf("Expected validations to err, but did not")
+	}
+
+	if !strings.Contains(err.Error(), "Expected bundle reference to be in repository") {
+		t.Fatalf("Expected error to contain message about repository mismatch, got: %s", err)
+	}
+}
@@ -5,10 +5,14 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/cppforlife/go-cli-ui/ui"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	ctlimg "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/image"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/plainimage"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
@@ -24,6 +28,14 @@ type PullOptions struct {
 	LockInputFlags       LockInputFlags
 	BundleRecursiveFlags BundleRecursiveFlags
 	OutputPath           string
+	Concurrency          int
+	SkipDiskCheck        bool
+	StrictExtraction     bool
+	RewriteManifests     bool
+	FallbackToOrigin     bool
+	ExpectedDigest       string
+	Tofu                 bool
+	TofuStrict           bool
 }
 
 func NewPullOptions(ui ui.UI) *PullOptions {
@@ -50,6 +62,14 @@ func NewPullCmd(o *PullOptions) *cobra.Command {
 	o.LockInputFlags.Set(cmd)
 	cmd.Flags().StringVarP(&o.OutputPath, "output", "o", "", "Output directory path")
 	cmd.MarkFlagRequired("output")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", 5, "Concurrency")
+	cmd.Flags().BoolVar(&o.SkipDiskCheck, "skip-disk-check", false, "Skip the available disk space check done before extracting")
+	cmd.Flags().BoolVar(&o.StrictExtraction, "strict-extraction", false, "Error on suspicious tar entries (links pointing outside the output directory) instead of skipping them")
+	cmd.Flags().BoolVar(&o.RewriteManifests, "rewrite-manifests", false, "Rewrite image references inside the bundle's YAML manifests to their relocated locations (bundle only)")
+	cmd.Flags().BoolVar(&o.FallbackToOrigin, "fallback-to-origin", false, "Keep original references in the rewritten ImagesLock for images missing from the bundle repository (bundle only)")
+	cmd.Flags().StringVar(&o.ExpectedDigest, "expect-digest", "", "Fail unless the bundle/image reference still resolves to this digest (format: sha256:...)")
+	cmd.Flags().BoolVar(&o.Tofu, "tofu", false, "Record the resolved digest on first use and warn when the reference later resolves to a different one")
+	cmd.Flags().BoolVar(&o.TofuStrict, "tofu-strict", false, "Like --tofu, but fail instead of warning when the resolved digest changed")
 
 	return cmd
 }
@@ -65,6 +85,34 @@ func (po *PullOptions) Run() error {
 		return err
 	}
 
+	if po.ExpectedDigest != "" {
+		srcRef := po.BundleFlags.Bundle
+		if srcRef == "" {
+			srcRef = po.ImageFlags.Image
+		}
+		if srcRef == "" {
+			return fmt.Errorf("Expected --expect-digest to only be used with --bundle or --image sources")
+		}
+		err := verifyExpectedDigest(srcRef, po.ExpectedDigest, reg)
+		if err != nil {
+			return err
+		}
+	}
+
+	if po.Tofu || po.TofuStrict {
+		srcRef := po.BundleFlags.Bundle
+		if srcRef == "" {
+			srcRef = po.ImageFlags.Image
+		}
+		if srcRef == "" {
+			return fmt.Errorf("Expected --tofu to only be used with --bundle or --image sources")
+		}
+		err := checkTrustOnFirstUse(srcRef, po.TofuStrict, reg, po.ui)
+		if err != nil {
+			return err
+		}
+	}
+
 	switch {
 	case len(po.LockInputFlags.LockFilePath) > 0 || len(po.BundleFlags.Bundle) > 0:
 		bundleRef := po.BundleFlags.Bundle
@@ -77,7 +125,19 @@ func (po *PullOptions) Run() error {
 			bundleRef = bundleLock.Bundle.Image
 		}
 
-		err := bundle.NewBundle(bundleRef, reg).Pull(po.OutputPath, po.ui, po.BundleRecursiveFlags.Recursive)
+		err = po.checkDiskSpace(plainimage.NewPlainImage(bundleRef, reg))
+		if err != nil {
+			return err
+		}
+
+		pulledBundle := bundle.NewBundle(bundleRef, reg)
+		pulledBundle.SetPullConcurrency(po.Concurrency)
+		pulledBundle.SetFallbackToOrigin(po.FallbackToOrigin)
+		if po.RewriteManifests {
+			err = pulledBundle.PullRewritingManifests(po.OutputPath, po.ui, po.BundleRecursiveFlags.Recursive, po.imageOpts()...)
+		} else {
+			err = pulledBundle.Pull(po.OutputPath, po.ui, po.BundleRecursiveFlags.Recursive, po.imageOpts()...)
+		}
 		if err != nil {
 			if bundle.IsNotBundleError(err) {
 				return fmt.Errorf("Expected bundle image but found plain image (hint: Did you use -i instead of -b?)")
@@ -107,13 +167,77 @@ func (po *PullOptions) Run() error {
 			}
 		}
 
-		return plainImg.Pull(po.OutputPath, po.ui)
+		err = po.checkDiskSpace(plainImg)
+		if err != nil {
+			return err
+		}
+
+		return plainImg.Pull(po.OutputPath, po.ui, po.imageOpts()...)
 
 	default:
 		panic("Unreachable code")
 	}
 }
 
+// imageOpts builds the extraction options shared by bundle and image pulls
+func (po *PullOptions) imageOpts() []ctlimg.DirImageOpt {
+	var opts []ctlimg.DirImageOpt
+	if po.StrictExtraction {
+		opts = append(opts, ctlimg.StrictExtraction())
+	}
+	return opts
+}
+
+// checkDiskSpace estimates the space needed to extract img from the layer
+// sizes in its manifest and errors early when the output filesystem does not
+// have enough available
+func (po *PullOptions) checkDiskSpace(img *plainimage.PlainImage) error {
+	if po.SkipDiskCheck {
+		return nil
+	}
+
+	v1Img, err := img.Fetch()
+	if err != nil {
+		return err
+	}
+	if v1Img == nil {
+		return nil
+	}
+
+	layers, err := v1Img.Layers()
+	if err != nil {
+		return err
+	}
+
+	var requiredBytes int64
+	for _, imgLayer := range layers {
+		size, err := imgLayer.Size()
+		if err != nil {
+			return err
+		}
+		requiredBytes += size
+	}
+
+	return util.CheckAvailableDiskSpace(closestExistingAncestor(po.OutputPath), requiredBytes)
+}
+
+// closestExistingAncestor walks up from path until it finds a path that
+// exists, as the output directory may not have been created yet
+func closestExistingAncestor(path string) string {
+	for {
+		_, err := os.Stat(path)
+		if err == nil {
+			return path
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
 func (po *PullOptions) validate() error {
 	if po.OutputPath == "" {
 		return fmt.Errorf("Expected --output to be none empty")
@@ -135,5 +259,8 @@ func (po *PullOptions) validate() error {
 	if presentInputParams == 0 {
 		return fmt.Errorf("Expected either image or bundle reference")
 	}
+	if po.RewriteManifests && len(po.ImageFlags.Image) > 0 {
+		return fmt.Errorf("Expected --rewrite-manifests to only be used when pulling a bundle")
+	}
 	return nil
 }
@@ -5,11 +5,16 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/cppforlife/go-cli-ui/ui"
 	regname "github.com/google/go-containerregistry/pkg/name"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/helmchart"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/plainimage"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
@@ -23,6 +28,28 @@ type PushOptions struct {
 	LockOutputFlags LockOutputFlags
 	FileFlags       FileFlags
 	RegistryFlags   RegistryFlags
+	HelmChartFlags  HelmChartFlags
+	PolicyFlags     PolicyFlags
+
+	NotificationFlags NotificationFlags
+
+	AllowUnknownBundleFields bool
+	FailOnConflict           bool
+	Reproducible             bool
+	Estargz                  bool
+	ProvenanceHost           bool
+	AllowSensitiveFiles      bool
+	IncludeVCS               bool
+	DerefSymlinks            bool
+	StrictSymlinks           bool
+	MaxSize                  string
+	MaxFiles                 int
+
+	GitURL  string
+	GitPath string
+
+	BuildIndex     bool
+	IndexPlatforms []string
 }
 
 func NewPushOptions(ui ui.UI) *PushOptions {
@@ -46,6 +73,24 @@ func NewPushCmd(o *PushOptions) *cobra.Command {
 	o.LockOutputFlags.Set(cmd)
 	o.FileFlags.Set(cmd)
 	o.RegistryFlags.Set(cmd)
+	o.HelmChartFlags.Set(cmd)
+	o.PolicyFlags.Set(cmd)
+	o.NotificationFlags.Set(cmd)
+	cmd.Flags().BoolVar(&o.AllowUnknownBundleFields, "allow-unknown-bundle-fields", false, "Tolerate unknown fields in .imgpkg/bundle.yml (e.g. fields introduced by newer imgpkg versions)")
+	cmd.Flags().BoolVar(&o.FailOnConflict, "fail-on-conflict", false, "Fail when multiple -f inputs provide the same path instead of overlaying (later inputs win)")
+	cmd.Flags().BoolVar(&o.Reproducible, "reproducible", false, "Normalize file modes and zero timestamps in pushed layers so identical content produces identical digests")
+	cmd.Flags().BoolVar(&o.ProvenanceHost, "provenance-host", false, "Include hostname and CI job metadata in the provenance labels recorded on pushed bundles")
+	cmd.Flags().BoolVar(&o.AllowSensitiveFiles, "allow-sensitive-files", false, "Push even when the contents include files that look like credentials (private keys, .env files, kubeconfigs, AWS credentials)")
+	cmd.Flags().BoolVar(&o.IncludeVCS, "include-vcs", false, "Include VCS and editor artifacts (.git, .svn, .DS_Store, swap files) that are excluded by default")
+	cmd.Flags().BoolVar(&o.DerefSymlinks, "dereference-symlinks", false, "Follow symlinks and embed the contents of their targets instead of failing the push")
+	cmd.Flags().BoolVar(&o.StrictSymlinks, "strict-symlinks", false, "With --dereference-symlinks, fail when a symlink points outside the pushed directory")
+	cmd.Flags().StringVar(&o.MaxSize, "max-size", "", "Fail the push when the total size of the pushed files exceeds this limit (format: 500KB, 10MB, 1GB)")
+	cmd.Flags().IntVar(&o.MaxFiles, "max-files", 0, "Fail the push when the number of pushed files exceeds this limit")
+	cmd.Flags().BoolVar(&o.Estargz, "estargz", false, "Compress pushed layers in the eStargz format (seekable gzip with a TOC) so images stay lazily pullable by stargz snapshotters")
+	cmd.Flags().StringVar(&o.GitURL, "git", "", "Package the contents of a git repository instead of local files (format: https://repo.git[@ref])")
+	cmd.Flags().StringVar(&o.GitPath, "git-path", "", "Subdirectory of the cloned git repository to package (default: repository root)")
+	cmd.Flags().BoolVar(&o.BuildIndex, "index", false, "Build one image per platform plus an OCI image index referencing them (requires --index-platform)")
+	cmd.Flags().StringSliceVar(&o.IndexPlatforms, "index-platform", nil, "Input directory for one platform of the index (format: linux/amd64=dir/) (can be specified multiple times)")
 	return cmd
 }
 
@@ -55,6 +100,10 @@ func (po *PushOptions) Run() error {
 		return err
 	}
 
+	if po.StrictSymlinks && !po.DerefSymlinks {
+		return fmt.Errorf("Expected --strict-symlinks to only be used together with --dereference-symlinks")
+	}
+
 	var imageURL string
 
 	isBundle := po.BundleFlags.Bundle != ""
@@ -68,13 +117,21 @@ func (po *PushOptions) Run() error {
 		return fmt.Errorf("Expected either image or bundle")
 
 	case isBundle:
+		if po.BuildIndex {
+			return fmt.Errorf("Expected image flag when pushing an index (hint: Use -i instead of -b)")
+		}
+
 		imageURL, err = po.pushBundle(reg)
 		if err != nil {
 			return err
 		}
 
 	case isImage:
-		imageURL, err = po.pushImage(reg)
+		if po.BuildIndex {
+			imageURL, err = po.pushIndex(reg)
+		} else {
+			imageURL, err = po.pushImage(reg)
+		}
 		if err != nil {
 			return err
 		}
@@ -85,7 +142,7 @@ func (po *PushOptions) Run() error {
 
 	po.ui.BeginLinef("Pushed '%s'", imageURL)
 
-	return nil
+	return po.NotificationFlags.Notify("push", []NotificationImage{{Image: imageURL}})
 }
 
 func (po *PushOptions) pushBundle(registry registry.Registry) (string, error) {
@@ -94,7 +151,82 @@ func (po *PushOptions) pushBundle(registry registry.Registry) (string, error) {
 		return "", fmt.Errorf("Parsing '%s': %s", po.BundleFlags.Bundle, err)
 	}
 
-	imageURL, err := bundle.NewContents(po.FileFlags.Files, po.FileFlags.ExcludedFilePaths).Push(uploadRef, registry, po.ui)
+	paths := po.FileFlags.Files
+	if po.HelmChartFlags.Chart != "" {
+		if len(paths) > 0 {
+			return "", fmt.Errorf("Expected either --file or --helm-chart")
+		}
+
+		stagingDir, err := po.stageHelmChart(registry)
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(stagingDir)
+
+		paths = []string{stagingDir}
+	}
+
+	if po.GitPath != "" && po.GitURL == "" {
+		return "", fmt.Errorf("Expected --git-path to only be used together with --git")
+	}
+
+	gitCommit := ""
+	if po.GitURL != "" {
+		if len(paths) > 0 {
+			return "", fmt.Errorf("Expected either --file, --helm-chart or --git")
+		}
+
+		cloneDir, commit, err := stageGitSource(po.GitURL)
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(cloneDir)
+		gitCommit = commit
+
+		contentsDir := cloneDir
+		if po.GitPath != "" {
+			contentsDir = filepath.Join(cloneDir, po.GitPath)
+			if _, err := os.Stat(contentsDir); err != nil {
+				return "", fmt.Errorf("Expected --git-path '%s' to exist in the cloned repository: %s", po.GitPath, err)
+			}
+		}
+		paths = []string{contentsDir}
+	}
+
+	err = po.enforcePolicy(paths, registry)
+	if err != nil {
+		return "", err
+	}
+
+	err = po.checkSensitiveFiles(paths)
+	if err != nil {
+		return "", err
+	}
+
+	err = po.enforceLimits(paths)
+	if err != nil {
+		return "", err
+	}
+
+	provLabels, err := provenanceLabels(Version, paths, po.ProvenanceHost, po.Reproducible)
+	if err != nil {
+		return "", err
+	}
+	if gitCommit != "" {
+		provLabels[bundle.ProvenanceLabelPrefix+"git-commit"] = gitCommit
+	}
+
+	contentsOpts := bundle.ContentsOpts{
+		AllowUnknownBundleFields: po.AllowUnknownBundleFields,
+		FailOnConflict:           po.FailOnConflict,
+		Reproducible:             po.Reproducible,
+		Estargz:                  po.Estargz,
+		IncludeVCS:               po.IncludeVCS,
+		DerefSymlinks:            po.DerefSymlinks,
+		StrictSymlinks:           po.StrictSymlinks,
+		Labels:                   provLabels,
+	}
+	imageURL, err := bundle.NewContentsWithOpts(paths, po.FileFlags.ExcludedFilePaths, contentsOpts).Push(uploadRef, registry, po.ui)
 	if err != nil {
 		return "", err
 	}
@@ -111,7 +243,7 @@ func (po *PushOptions) pushBundle(registry registry.Registry) (string, error) {
 			},
 		}
 
-		err := bundleLock.WriteToPath(po.LockOutputFlags.LockFilePath)
+		err := po.LockOutputFlags.WriteLock(bundleLock, po.ui)
 		if err != nil {
 			return "", err
 		}
@@ -120,10 +252,130 @@ func (po *PushOptions) pushBundle(registry registry.Registry) (string, error) {
 	return imageURL, nil
 }
 
+// stageHelmChart copies the Helm chart into a temporary directory and writes
+// an ImagesLock with the image references extracted from the chart
+func (po *PushOptions) stageHelmChart(registry registry.Registry) (string, error) {
+	chart := helmchart.NewChart(po.HelmChartFlags.Chart, po.HelmChartFlags.ImagesAnnotation)
+	err := chart.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	imagesLock, err := chart.ImagesLock(registry)
+	if err != nil {
+		return "", err
+	}
+
+	stagingDir, err := ioutil.TempDir("", "imgpkg-helm-chart")
+	if err != nil {
+		return "", err
+	}
+
+	err = chart.CopyTo(stagingDir)
+	if err != nil {
+		os.RemoveAll(stagingDir)
+		return "", fmt.Errorf("Copying Helm chart '%s': %s", po.HelmChartFlags.Chart, err)
+	}
+
+	err = os.MkdirAll(filepath.Join(stagingDir, bundle.ImgpkgDir), 0700)
+	if err != nil {
+		os.RemoveAll(stagingDir)
+		return "", err
+	}
+
+	err = imagesLock.WriteToPath(filepath.Join(stagingDir, bundle.ImgpkgDir, bundle.ImagesLockFile))
+	if err != nil {
+		os.RemoveAll(stagingDir)
+		return "", err
+	}
+
+	return stagingDir, nil
+}
+
+// enforcePolicy checks the images referenced by the bundle's ImagesLock
+// against the policy file, when one was provided
+func (po *PushOptions) enforcePolicy(paths []string, registry registry.Registry) error {
+	if po.PolicyFlags.PolicyFile == "" {
+		return nil
+	}
+
+	var refs []string
+	for _, path := range paths {
+		imagesLockPath := filepath.Join(path, bundle.ImgpkgDir, bundle.ImagesLockFile)
+		if _, err := os.Stat(imagesLockPath); err != nil {
+			continue
+		}
+
+		imagesLock, err := lockconfig.NewImagesLockFromPath(imagesLockPath)
+		if err != nil {
+			return err
+		}
+		for _, img := range imagesLock.Images {
+			refs = append(refs, img.Image)
+		}
+	}
+
+	violations, err := checkPolicy(po.PolicyFlags.PolicyFile, refs, registry)
+	if err != nil {
+		return err
+	}
+	for _, violation := range violations {
+		po.ui.BeginLinef("Policy violation: %s\n", violation)
+	}
+	if len(violations) > 0 {
+		return policyViolationsError(po.PolicyFlags.PolicyFile, len(violations))
+	}
+	return nil
+}
+
+// pushIndex builds one image per --index-platform entry and pushes them along
+// with an OCI index referencing all of them
+func (po *PushOptions) pushIndex(registry registry.Registry) (string, error) {
+	if po.LockOutputFlags.LockFilePath != "" {
+		return "", fmt.Errorf("Lock output is not compatible with image, use bundle for lock output")
+	}
+	if po.HelmChartFlags.Chart != "" {
+		return "", fmt.Errorf("Expected bundle flag when pushing a Helm chart (hint: Use -b instead of -i)")
+	}
+	if len(po.FileFlags.Files) > 0 {
+		return "", fmt.Errorf("Expected --index-platform to be used instead of --file (-f) when pushing an index")
+	}
+	if len(po.IndexPlatforms) == 0 {
+		return "", fmt.Errorf("Expected at least one --index-platform to be provided")
+	}
+
+	uploadRef, err := regname.NewTag(po.ImageFlags.Image, regname.WeakValidation)
+	if err != nil {
+		return "", fmt.Errorf("Parsing '%s': %s", po.ImageFlags.Image, err)
+	}
+
+	var platforms []plainimage.PlatformContents
+	platformIdx := map[string]int{}
+	for _, entry := range po.IndexPlatforms {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return "", fmt.Errorf("Expected --index-platform to be in the form os/architecture[/variant]=path, got '%s'", entry)
+		}
+
+		platform, path := parts[0], parts[1]
+		if idx, found := platformIdx[platform]; found {
+			platforms[idx].Paths = append(platforms[idx].Paths, path)
+			continue
+		}
+		platformIdx[platform] = len(platforms)
+		platforms = append(platforms, plainimage.PlatformContents{Platform: platform, Paths: []string{path}})
+	}
+
+	return plainimage.NewIndexContents(platforms, po.FileFlags.ExcludedFilePaths).Push(uploadRef, nil, registry, po.ui)
+}
+
 func (po *PushOptions) pushImage(registry registry.Registry) (string, error) {
 	if po.LockOutputFlags.LockFilePath != "" {
 		return "", fmt.Errorf("Lock output is not compatible with image, use bundle for lock output")
 	}
+	if po.HelmChartFlags.Chart != "" {
+		return "", fmt.Errorf("Expected bundle flag when pushing a Helm chart (hint: Use -b instead of -i)")
+	}
 
 	uploadRef, err := regname.NewTag(po.ImageFlags.Image, regname.WeakValidation)
 	if err != nil {
@@ -138,5 +390,23 @@ func (po *PushOptions) pushImage(registry registry.Registry) (string, error) {
 		return "", fmt.Errorf("Images cannot be pushed with '.imgpkg' directories, consider using --bundle (-b) option")
 	}
 
-	return plainimage.NewContents(po.FileFlags.Files, po.FileFlags.ExcludedFilePaths).Push(uploadRef, nil, registry, po.ui)
+	err = po.checkSensitiveFiles(po.FileFlags.Files)
+	if err != nil {
+		return "", err
+	}
+
+	err = po.enforceLimits(po.FileFlags.Files)
+	if err != nil {
+		return "", err
+	}
+
+	contentsOpts := plainimage.ContentsOpts{
+		FailOnConflict: po.FailOnConflict,
+		Reproducible:   po.Reproducible,
+		Estargz:        po.Estargz,
+		IncludeVCS:     po.IncludeVCS,
+		DerefSymlinks:  po.DerefSymlinks,
+		StrictSymlinks: po.StrictSymlinks,
+	}
+	return plainimage.NewContentsWithOpts(po.FileFlags.Files, po.FileFlags.ExcludedFilePaths, contentsOpts).Push(uploadRef, nil, registry, po.ui)
 }
@@ -0,0 +1,81 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// parseGitRef splits URL@ref, treating the part after the last '@' as the ref
+// when it cannot be part of the URL itself (no '/' or ':')
+func parseGitRef(arg string) (string, string) {
+	idx := strings.LastIndex(arg, "@")
+	if idx < 0 {
+		return arg, ""
+	}
+
+	ref := arg[idx+1:]
+	if ref == "" || strings.ContainsAny(ref, "/:") {
+		return arg, ""
+	}
+	return arg[:idx], ref
+}
+
+// stageGitSource shallow-clones the given git reference (URL optionally
+// suffixed with @ref) into a temporary directory, returning the directory and
+// the commit SHA it resolved to. The caller removes the directory
+func stageGitSource(gitArg string) (string, string, error) {
+	gitURL, gitRef := parseGitRef(gitArg)
+	if gitRef == "" {
+		gitRef = "HEAD"
+	}
+
+	stagingDir, err := ioutil.TempDir("", "imgpkg-git-clone")
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, args := range [][]string{
+		{"init", "--quiet", stagingDir},
+		{"-C", stagingDir, "remote", "add", "origin", gitURL},
+		{"-C", stagingDir, "fetch", "--quiet", "--depth", "1", "origin", gitRef},
+		{"-C", stagingDir, "checkout", "--quiet", "FETCH_HEAD"},
+	} {
+		err := runGit(args, nil)
+		if err != nil {
+			os.RemoveAll(stagingDir)
+			return "", "", fmt.Errorf("Cloning git reference '%s': %s", gitArg, err)
+		}
+	}
+
+	var out bytes.Buffer
+	err = runGit([]string{"-C", stagingDir, "rev-parse", "HEAD"}, &out)
+	if err != nil {
+		os.RemoveAll(stagingDir)
+		return "", "", fmt.Errorf("Resolving cloned commit: %s", err)
+	}
+
+	return stagingDir, strings.TrimSpace(out.String()), nil
+}
+
+// runGit runs the git binary, surfacing its stderr in the returned error
+func runGit(args []string, stdout *bytes.Buffer) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("%s (%s)", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
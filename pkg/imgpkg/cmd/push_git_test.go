@@ -0,0 +1,92 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitRef(t *testing.T) {
+	tests := []struct {
+		arg         string
+		expectedURL string
+		expectedRef string
+	}{
+		{"https://github.com/org/repo.git", "https://github.com/org/repo.git", ""},
+		{"https://github.com/org/repo.git@v1.0.0", "https://github.com/org/repo.git", "v1.0.0"},
+		{"https://github.com/org/repo.git@main", "https://github.com/org/repo.git", "main"},
+		// the '@' in an ssh URL is not a ref separator
+		{"git@github.com:org/repo.git", "git@github.com:org/repo.git", ""},
+		{"git@github.com:org/repo.git@v1.0.0", "git@github.com:org/repo.git", "v1.0.0"},
+		{"https://github.com/org/repo.git@", "https://github.com/org/repo.git@", ""},
+	}
+
+	for _, test := range tests {
+		url, ref := parseGitRef(test.arg)
+		assert.Equal(t, test.expectedURL, url, "url for '%s'", test.arg)
+		assert.Equal(t, test.expectedRef, ref, "ref for '%s'", test.arg)
+	}
+}
+
+func TestStageGitSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+
+	repoDir := t.TempDir()
+	runTestGit(t, repoDir, "init", "--quiet")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, "config.yml"), []byte("key: value"), 0600))
+	runTestGit(t, repoDir, "add", "config.yml")
+	runTestGit(t, repoDir, "commit", "--quiet", "-m", "initial commit")
+	expectedCommit := runTestGit(t, repoDir, "rev-parse", "HEAD")
+
+	t.Run("clones the default branch and reports the commit", func(t *testing.T) {
+		stagingDir, commit, err := stageGitSource("file://" + repoDir)
+		require.NoError(t, err)
+		defer os.RemoveAll(stagingDir)
+
+		assert.Equal(t, expectedCommit, commit)
+		bs, err := ioutil.ReadFile(filepath.Join(stagingDir, "config.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, "key: value", string(bs))
+	})
+
+	t.Run("clones a specific ref", func(t *testing.T) {
+		runTestGit(t, repoDir, "tag", "v1.0.0")
+		require.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, "config.yml"), []byte("key: other"), 0600))
+		runTestGit(t, repoDir, "commit", "--quiet", "-am", "second commit")
+
+		stagingDir, commit, err := stageGitSource("file://" + repoDir + "@v1.0.0")
+		require.NoError(t, err)
+		defer os.RemoveAll(stagingDir)
+
+		assert.Equal(t, expectedCommit, commit)
+		bs, err := ioutil.ReadFile(filepath.Join(stagingDir, "config.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, "key: value", string(bs))
+	})
+
+	t.Run("errors on an unknown ref", func(t *testing.T) {
+		_, _, err := stageGitSource("file://" + repoDir + "@does-not-exist")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Cloning git reference")
+	})
+}
+
+func runTestGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	gitCmd := exec.Command("git", append([]string{"-C", dir,
+		"-c", "user.email=test@example.com", "-c", "user.name=test"}, args...)...)
+	out, err := gitCmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	return strings.TrimSpace(string(out))
+}
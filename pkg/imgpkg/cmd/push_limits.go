@@ -0,0 +1,103 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// largestFilesReported how many of the largest files are listed when a push
+// limit is exceeded
+const largestFilesReported = 10
+
+// fileSize size of a single file about to be pushed
+type fileSize struct {
+	Path string
+	Size int64
+}
+
+// enforceLimits fails the push when the contents exceed --max-size or
+// --max-files, listing the largest files so the culprit (ex: node_modules,
+// .git) is easy to spot
+func (po *PushOptions) enforceLimits(paths []string) error {
+	if po.MaxSize == "" && po.MaxFiles == 0 {
+		return nil
+	}
+
+	var maxSizeBytes int64
+	if po.MaxSize != "" {
+		var err error
+		maxSizeBytes, err = parseDataSize(po.MaxSize)
+		if err != nil {
+			return fmt.Errorf("Expected --max-size to be a positive size such as 512MB or 1GB, got '%s'", po.MaxSize)
+		}
+	}
+
+	files, totalSize, err := collectFileSizes(paths, po.FileFlags.ExcludedFilePaths)
+	if err != nil {
+		return fmt.Errorf("Measuring contents: %s", err)
+	}
+
+	var violations []string
+	if po.MaxFiles > 0 && len(files) > po.MaxFiles {
+		violations = append(violations, fmt.Sprintf("%d files exceed the --max-files limit of %d", len(files), po.MaxFiles))
+	}
+	if maxSizeBytes > 0 && totalSize > maxSizeBytes {
+		violations = append(violations, fmt.Sprintf("total size %s exceeds the --max-size limit of %s", humanReadableSize(totalSize), po.MaxSize))
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Size > files[j].Size })
+	po.ui.BeginLinef("Largest files:\n")
+	for i, file := range files {
+		if i == largestFilesReported {
+			break
+		}
+		po.ui.BeginLinef("  %s: %s\n", file.Path, humanReadableSize(file.Size))
+	}
+
+	return fmt.Errorf("Contents too large to push: %s", strings.Join(violations, "; "))
+}
+
+// collectFileSizes returns the size of every file under paths along with the
+// total, skipping paths excluded from the push
+func collectFileSizes(paths []string, excludedPaths []string) ([]fileSize, int64, error) {
+	var files []fileSize
+	var totalSize int64
+	for _, path := range paths {
+		err := filepath.Walk(path, func(currPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+
+			relPath, err := filepath.Rel(path, currPath)
+			if err != nil {
+				return err
+			}
+			relPath = filepath.ToSlash(relPath)
+			for _, excludedPath := range excludedPaths {
+				if excludedPath == relPath {
+					return nil
+				}
+			}
+
+			files = append(files, fileSize{Path: relPath, Size: info.Size()})
+			totalSize += info.Size()
+			return nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return files, totalSize, nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+)
+
+func TestEnforceLimits(t *testing.T) {
+	bundleDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bundleDir, "big.bin"), make([]byte, 2048), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bundleDir, "small.yml"), []byte("replicas: 3\n"), 0600))
+
+	t.Run("passes without limits", func(t *testing.T) {
+		subject := &PushOptions{ui: &bundlefakes.FakeUI{}}
+		require.NoError(t, subject.enforceLimits([]string{bundleDir}))
+	})
+
+	t.Run("fails above --max-size and lists the largest files", func(t *testing.T) {
+		confUI := &bundlefakes.FakeUI{}
+		subject := &PushOptions{ui: confUI, MaxSize: "1KB"}
+
+		err := subject.enforceLimits([]string{bundleDir})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exceeds the --max-size limit of 1KB")
+
+		_, args := confUI.BeginLinefArgsForCall(1)
+		require.Len(t, args, 2)
+		assert.Equal(t, "big.bin", args[0])
+	})
+
+	t.Run("fails above --max-files", func(t *testing.T) {
+		subject := &PushOptions{ui: &bundlefakes.FakeUI{}, MaxFiles: 1}
+
+		err := subject.enforceLimits([]string{bundleDir})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exceed the --max-files limit of 1")
+	})
+
+	t.Run("passes within the limits", func(t *testing.T) {
+		subject := &PushOptions{ui: &bundlefakes.FakeUI{}, MaxSize: "1MB", MaxFiles: 10}
+		require.NoError(t, subject.enforceLimits([]string{bundleDir}))
+	})
+
+	t.Run("errors for an unparsable --max-size", func(t *testing.T) {
+		subject := &PushOptions{ui: &bundlefakes.FakeUI{}, MaxSize: "lots"}
+		err := subject.enforceLimits([]string{bundleDir})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected --max-size to be a positive size")
+	})
+}
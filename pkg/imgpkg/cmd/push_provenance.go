@@ -0,0 +1,103 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+)
+
+// provenanceLabels builds the config labels recorded on pushed bundles:
+// imgpkg version, a checksum of the source directories and, unless the push
+// is reproducible, the push timestamp. Hostname and CI job metadata are only
+// recorded when includeHost is set, since they may leak environment details
+func provenanceLabels(version string, paths []string, includeHost bool, reproducible bool) (map[string]string, error) {
+	labels := map[string]string{
+		bundle.ProvenanceLabelPrefix + "version": version,
+	}
+
+	sourceHash, err := hashPaths(paths)
+	if err != nil {
+		return nil, fmt.Errorf("Hashing bundle contents: %s", err)
+	}
+	labels[bundle.ProvenanceLabelPrefix+"source-hash"] = sourceHash
+
+	// A timestamp would make every push produce a different digest
+	if !reproducible {
+		labels[bundle.ProvenanceLabelPrefix+"timestamp"] = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	if includeHost {
+		hostname, err := os.Hostname()
+		if err == nil {
+			labels[bundle.ProvenanceLabelPrefix+"hostname"] = hostname
+		}
+		if jobURL := ciJobURL(); jobURL != "" {
+			labels[bundle.ProvenanceLabelPrefix+"ci-job"] = jobURL
+		}
+	}
+
+	return labels, nil
+}
+
+// hashPaths returns a checksum over the relative path and contents of every
+// file under paths, so identical sources always produce the same value
+func hashPaths(paths []string) (string, error) {
+	var entries []string
+	for _, path := range paths {
+		err := filepath.Walk(path, func(currPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+
+			relPath, err := filepath.Rel(path, currPath)
+			if err != nil {
+				return err
+			}
+
+			file, err := os.Open(currPath)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			digest := sha256.New()
+			if _, err := io.Copy(digest, file); err != nil {
+				return err
+			}
+
+			entries = append(entries, fmt.Sprintf("%s %x", filepath.ToSlash(relPath), digest.Sum(nil)))
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	sort.Strings(entries)
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(strings.Join(entries, "\n")))), nil
+}
+
+// ciJobURL detects the URL of the CI job running the push, when one of the
+// common CI environments is present
+func ciJobURL() string {
+	if server, repo, run := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"); server != "" && repo != "" && run != "" {
+		return fmt.Sprintf("%s/%s/actions/runs/%s", server, repo, run)
+	}
+	if jobURL := os.Getenv("CI_JOB_URL"); jobURL != "" {
+		return jobURL
+	}
+	return os.Getenv("BUILD_URL")
+}
@@ -0,0 +1,64 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+)
+
+func TestProvenanceLabels(t *testing.T) {
+	bundleDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bundleDir, "config.yml"), []byte("replicas: 3\n"), 0600))
+
+	t.Run("records version and a deterministic source hash", func(t *testing.T) {
+		labels, err := provenanceLabels("1.2.3", []string{bundleDir}, false, false)
+		require.NoError(t, err)
+		assert.Equal(t, "1.2.3", labels[bundle.ProvenanceLabelPrefix+"version"])
+		assert.Contains(t, labels, bundle.ProvenanceLabelPrefix+"timestamp")
+		assert.NotContains(t, labels, bundle.ProvenanceLabelPrefix+"hostname")
+
+		labelsAgain, err := provenanceLabels("1.2.3", []string{bundleDir}, false, false)
+		require.NoError(t, err)
+		assert.Equal(t, labels[bundle.ProvenanceLabelPrefix+"source-hash"],
+			labelsAgain[bundle.ProvenanceLabelPrefix+"source-hash"])
+	})
+
+	t.Run("source hash changes when the contents change", func(t *testing.T) {
+		labels, err := provenanceLabels("1.2.3", []string{bundleDir}, false, false)
+		require.NoError(t, err)
+
+		require.NoError(t, ioutil.WriteFile(filepath.Join(bundleDir, "config.yml"), []byte("replicas: 5\n"), 0600))
+
+		changedLabels, err := provenanceLabels("1.2.3", []string{bundleDir}, false, false)
+		require.NoError(t, err)
+		assert.NotEqual(t, labels[bundle.ProvenanceLabelPrefix+"source-hash"],
+			changedLabels[bundle.ProvenanceLabelPrefix+"source-hash"])
+	})
+
+	t.Run("skips the timestamp for reproducible pushes", func(t *testing.T) {
+		labels, err := provenanceLabels("1.2.3", []string{bundleDir}, false, true)
+		require.NoError(t, err)
+		assert.NotContains(t, labels, bundle.ProvenanceLabelPrefix+"timestamp")
+	})
+
+	t.Run("records the hostname only when opted in", func(t *testing.T) {
+		labels, err := provenanceLabels("1.2.3", []string{bundleDir}, true, false)
+		require.NoError(t, err)
+		assert.Contains(t, labels, bundle.ProvenanceLabelPrefix+"hostname")
+	})
+}
+
+func TestCIJobURL(t *testing.T) {
+	t.Setenv("GITHUB_SERVER_URL", "https://github.example.com")
+	t.Setenv("GITHUB_REPOSITORY", "org/repo")
+	t.Setenv("GITHUB_RUN_ID", "42")
+
+	assert.Equal(t, "https://github.example.com/org/repo/actions/runs/42", ciJobURL())
+}
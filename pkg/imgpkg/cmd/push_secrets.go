@@ -0,0 +1,138 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxSecretScanBytes how much of each file is read when scanning for secrets
+const maxSecretScanBytes = 64 * 1024
+
+var awsAccessKeyIDRegexp = regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`)
+
+// sensitiveFileFinding a file that likely contains credentials, and why it
+// was flagged
+type sensitiveFileFinding struct {
+	Path   string
+	Reason string
+}
+
+// checkSensitiveFiles scans the files about to be pushed for likely secrets
+// and fails with the list of findings, unless --allow-sensitive-files was
+// passed, in which case the findings are only reported
+func (po *PushOptions) checkSensitiveFiles(paths []string) error {
+	findings, err := findSensitiveFiles(paths, po.FileFlags.ExcludedFilePaths)
+	if err != nil {
+		return fmt.Errorf("Scanning for sensitive files: %s", err)
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	for _, finding := range findings {
+		po.ui.BeginLinef("Sensitive file: %s (%s)\n", finding.Path, finding.Reason)
+	}
+
+	if po.AllowSensitiveFiles {
+		return nil
+	}
+	return fmt.Errorf("Found %d likely sensitive file(s) (hint: Exclude them with --file-exclusion or pass --allow-sensitive-files to push anyway)", len(findings))
+}
+
+// findSensitiveFiles walks paths looking for files that likely hold
+// credentials: private keys, environment files, kubeconfigs and AWS
+// credentials. Paths excluded from the push are not scanned
+func findSensitiveFiles(paths []string, excludedPaths []string) ([]sensitiveFileFinding, error) {
+	var findings []sensitiveFileFinding
+	for _, path := range paths {
+		err := filepath.Walk(path, func(currPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+
+			relPath, err := filepath.Rel(path, currPath)
+			if err != nil {
+				return err
+			}
+			relPath = filepath.ToSlash(relPath)
+			for _, excludedPath := range excludedPaths {
+				if excludedPath == relPath {
+					return nil
+				}
+			}
+
+			if reason := classifySensitiveName(relPath); reason != "" {
+				findings = append(findings, sensitiveFileFinding{Path: relPath, Reason: reason})
+				return nil
+			}
+
+			reason, err := classifySensitiveContents(currPath)
+			if err != nil {
+				return err
+			}
+			if reason != "" {
+				findings = append(findings, sensitiveFileFinding{Path: relPath, Reason: reason})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return findings, nil
+}
+
+// classifySensitiveName flags files whose name alone marks them as likely
+// credentials
+func classifySensitiveName(relPath string) string {
+	base := filepath.Base(relPath)
+	switch {
+	case base == ".env" || strings.HasPrefix(base, ".env."):
+		return "environment file"
+	case base == "kubeconfig" || strings.HasSuffix(relPath, ".kube/config"):
+		return "kubeconfig"
+	case base == "credentials" && strings.Contains(relPath, ".aws/"):
+		return "AWS credentials file"
+	case base == "id_rsa" || base == "id_dsa" || base == "id_ecdsa" || base == "id_ed25519":
+		return "SSH private key"
+	}
+	return ""
+}
+
+// classifySensitiveContents flags files whose leading bytes contain known
+// credential patterns
+func classifySensitiveContents(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	contents := make([]byte, maxSecretScanBytes)
+	n, err := file.Read(contents)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	contents = contents[:n]
+
+	switch {
+	case bytes.Contains(contents, []byte("-----BEGIN ")) && bytes.Contains(contents, []byte("PRIVATE KEY-----")):
+		return "private key", nil
+	case awsAccessKeyIDRegexp.Match(contents):
+		return "AWS access key id", nil
+	case bytes.Contains(contents, []byte("aws_secret_access_key")):
+		return "AWS secret access key", nil
+	}
+	return "", nil
+}
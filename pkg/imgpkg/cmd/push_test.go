@@ -188,7 +188,7 @@ func TestDuplicateFilepathError(t *testing.T) {
 	}
 
 	// duplicate someFile.yaml by including it directly and with the dir fooDir
-	push := PushOptions{FileFlags: FileFlags{Files: []string{someFile, fooDir}}, BundleFlags: BundleFlags{Bundle: "foo"}}
+	push := PushOptions{FileFlags: FileFlags{Files: []string{someFile, fooDir}}, BundleFlags: BundleFlags{Bundle: "foo"}, FailOnConflict: true}
 	err = push.Run()
 	if err == nil {
 		t.Fatalf("Expected validations to err, but did not")
@@ -199,6 +199,36 @@ func TestDuplicateFilepathError(t *testing.T) {
 	}
 }
 
+func TestIndexPushValidations(t *testing.T) {
+	t.Run("when bundle flag is provided, it errors", func(t *testing.T) {
+		push := PushOptions{BundleFlags: BundleFlags{Bundle: "foo"}, BuildIndex: true}
+		err := push.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected image flag when pushing an index")
+	})
+
+	t.Run("when no platforms are provided, it errors", func(t *testing.T) {
+		push := PushOptions{ImageFlags: ImageFlags{Image: "foo"}, BuildIndex: true}
+		err := push.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected at least one --index-platform to be provided")
+	})
+
+	t.Run("when file flags are provided, it errors", func(t *testing.T) {
+		push := PushOptions{ImageFlags: ImageFlags{Image: "foo"}, BuildIndex: true, FileFlags: FileFlags{Files: []string{"dir/"}}}
+		err := push.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected --index-platform to be used instead of --file (-f) when pushing an index")
+	})
+
+	t.Run("when platform entry is malformed, it errors", func(t *testing.T) {
+		push := PushOptions{ImageFlags: ImageFlags{Image: "foo"}, BuildIndex: true, IndexPlatforms: []string{"linux/amd64"}}
+		err := push.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected --index-platform to be in the form os/architecture[/variant]=path")
+	})
+}
+
 func TestNoImageOrBundleError(t *testing.T) {
 	push := PushOptions{}
 	err := push.Run()
@@ -212,7 +242,7 @@ func TestNoImageOrBundleError(t *testing.T) {
 }
 
 func TestImageAndBundleError(t *testing.T) {
-	push := PushOptions{ImageFlags: ImageFlags{"image@123456"}, BundleFlags: BundleFlags{"my-bundle"}}
+	push := PushOptions{ImageFlags: ImageFlags{Image: "image@123456"}, BundleFlags: BundleFlags{"my-bundle"}}
 	err := push.Run()
 	if err == nil {
 		t.Fatalf("Expected validations to err, but did not")
@@ -224,7 +254,7 @@ func TestImageAndBundleError(t *testing.T) {
 }
 
 func TestImageAndBundleLockError(t *testing.T) {
-	push := PushOptions{ImageFlags: ImageFlags{"image@123456"}, LockOutputFlags: LockOutputFlags{LockFilePath: "lock-file"}}
+	push := PushOptions{ImageFlags: ImageFlags{Image: "image@123456"}, LockOutputFlags: LockOutputFlags{LockFilePath: "lock-file"}}
 	err := push.Run()
 	if err == nil {
 		t.Fatalf("Expected validations to err, but did not")
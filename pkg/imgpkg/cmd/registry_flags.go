@@ -13,18 +13,40 @@ import (
 )
 
 type RegistryFlags struct {
-	CACertPaths []string
-	VerifyCerts bool
-	Insecure    bool
-
-	Username string
-	Password string
-	Token    string
-	Anon     bool
+	CACertPaths   []string
+	VerifyCerts   bool
+	Insecure      bool
+	InsecureHosts []string
+	PinnedCerts   []string
+
+	Username     string
+	Password     string
+	Token        string
+	Anon         bool
+	CredExecPath string
 
 	RetryCount int
 
 	ResponseHeaderTimeout time.Duration
+
+	MaxIdleConnsPerHost int
+	KeepAlive           time.Duration
+	DialTimeout         time.Duration
+	EnableHTTP2         bool
+
+	IPFamily      string
+	ResolverAddrs []string
+	HostAliases   []string
+
+	Offline bool
+
+	MaxUploadRate   int64
+	MaxDownloadRate int64
+
+	UseRegistriesConf bool
+
+	ShortNamePolicy string
+	DefaultRegistry string
 }
 
 // Set Registers the flags available to the provided command
@@ -32,14 +54,34 @@ func (r *RegistryFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().StringSliceVar(&r.CACertPaths, "registry-ca-cert-path", nil, "Add CA certificates for registry API (format: /tmp/foo) (can be specified multiple times)")
 	cmd.Flags().BoolVar(&r.VerifyCerts, "registry-verify-certs", true, "Set whether to verify server's certificate chain and host name")
 	cmd.Flags().BoolVar(&r.Insecure, "registry-insecure", false, "Allow the use of http when interacting with registries")
+	cmd.Flags().StringSliceVar(&r.InsecureHosts, "registry-insecure-host", nil,
+		"Skip TLS certificate verification only for the given registry hostname, leaving verification enabled for every other host (can be specified multiple times)")
+	cmd.Flags().StringSliceVar(&r.PinnedCerts, "registry-pinned-cert", nil,
+		"Require the given registry hostname to present a certificate with the same public key as the PEM certificate at path (format: host=/tmp/cert.pem) (can be specified multiple times)")
 
 	cmd.Flags().StringVar(&r.Username, "registry-username", "", "Set username for auth ($IMGPKG_USERNAME)")
 	cmd.Flags().StringVar(&r.Password, "registry-password", "", "Set password for auth ($IMGPKG_PASSWORD)")
 	cmd.Flags().StringVar(&r.Token, "registry-token", "", "Set token for auth ($IMGPKG_TOKEN)")
 	cmd.Flags().BoolVar(&r.Anon, "registry-anon", false, "Set anonymous auth ($IMGPKG_ANON)")
+	cmd.Flags().StringVar(&r.CredExecPath, "registry-cred-exec", "", "Path to an executable invoked per registry host that prints JSON credentials to stdout ($IMGPKG_REGISTRY_CRED_EXEC)")
 
 	cmd.Flags().DurationVar(&r.ResponseHeaderTimeout, "registry-response-header-timeout", 30*time.Second, "Maximum time to allow a request to wait for a server's response headers from the registry (ms|s|m|h)")
 	cmd.Flags().IntVar(&r.RetryCount, "registry-retry-count", 5, "Set the number of times imgpkg retries to send requests to the registry in case of an error")
+	cmd.Flags().IntVar(&r.MaxIdleConnsPerHost, "registry-max-idle-conns-per-host", registry.DefaultMaxIdleConnsPerHost, "Maximum number of idle connections kept per registry host for reuse")
+	cmd.Flags().DurationVar(&r.KeepAlive, "registry-keep-alive", registry.DefaultKeepAlive, "TCP keep-alive period for registry connections (ms|s|m|h)")
+	cmd.Flags().DurationVar(&r.DialTimeout, "registry-dial-timeout", registry.DefaultDialTimeout, "Maximum time to allow establishing a TCP connection to the registry (ms|s|m|h)")
+	cmd.Flags().BoolVar(&r.EnableHTTP2, "registry-http2", false, "Allow the use of HTTP/2 when the registry supports it")
+	cmd.Flags().StringVar(&r.IPFamily, "registry-ip-family", "", "Restrict registry connections to a single IP family: ipv4 or ipv6 (default: both)")
+	cmd.Flags().StringSliceVar(&r.ResolverAddrs, "registry-resolver", nil,
+		"Resolve registry hostnames with the given DNS server instead of the system resolver (format: ip or ip:port) (can be specified multiple times)")
+	cmd.Flags().StringSliceVar(&r.HostAliases, "registry-host-alias", nil,
+		"Map a registry hostname to an explicit IP address, bypassing DNS (format: host=ip) (can be specified multiple times)")
+	cmd.Flags().BoolVar(&r.Offline, "offline", false, "Error on any attempt to reach a registry, for use on disconnected machines ($IMGPKG_OFFLINE)")
+	cmd.Flags().Int64Var(&r.MaxUploadRate, "max-upload-rate", 0, "Maximum upload bandwidth to registries in bytes per second (default: unlimited)")
+	cmd.Flags().Int64Var(&r.MaxDownloadRate, "max-download-rate", 0, "Maximum download bandwidth from registries in bytes per second (default: unlimited)")
+	cmd.Flags().StringVar(&r.ShortNamePolicy, "short-name-policy", ShortNamePolicyDockerHub, "Set how unqualified references are resolved: docker-hub, error, or default-registry")
+	cmd.Flags().StringVar(&r.DefaultRegistry, "default-registry", "", "Registry (and optional namespace) used to qualify short names (implies --short-name-policy=default-registry)")
+	cmd.Flags().BoolVar(&r.UseRegistriesConf, "registries-conf", true, "Honor insecure registries and pull mirrors configured in /etc/containers/registries.conf ($CONTAINERS_REGISTRIES_CONF overrides the path; --registries-conf=false disables)")
 
 	cmd.Flags().String("registry-azure-cr-config", "", "Path to the file containing Azure container registry configuration information. ($IMGPKG_REGISTRY_AZURE_CR_CONFIG)")
 
@@ -71,17 +113,42 @@ func (r *RegistryFlags) AsRegistryOpts() registry.Opts {
 		VerifyCerts: r.VerifyCerts,
 		Insecure:    r.Insecure,
 
-		Username: r.Username,
-		Password: r.Password,
-		Token:    r.Token,
-		Anon:     r.Anon,
+		InsecureSkipVerifyHosts: r.InsecureHosts,
+		PinnedCerts:             r.PinnedCerts,
+
+		Username:     r.Username,
+		Password:     r.Password,
+		Token:        r.Token,
+		Anon:         r.Anon,
+		CredExecPath: r.CredExecPath,
 
 		RetryCount:            r.RetryCount,
 		ResponseHeaderTimeout: r.ResponseHeaderTimeout,
 
+		MaxIdleConnsPerHost: r.MaxIdleConnsPerHost,
+		KeepAlive:           r.KeepAlive,
+		DialTimeout:         r.DialTimeout,
+		EnableHTTP2:         r.EnableHTTP2,
+
+		IPFamily:      r.IPFamily,
+		ResolverAddrs: r.ResolverAddrs,
+		HostAliases:   r.HostAliases,
+
+		Offline: r.Offline,
+
+		MaxUploadRateBytesPerSec:   r.MaxUploadRate,
+		MaxDownloadRateBytesPerSec: r.MaxDownloadRate,
+
 		EnvironFunc: os.Environ,
 	}
 
+	if r.UseRegistriesConf {
+		opts.RegistriesConfPath = os.Getenv("CONTAINERS_REGISTRIES_CONF")
+		if opts.RegistriesConfPath == "" {
+			opts.RegistriesConfPath = registry.DefaultRegistriesConfPath
+		}
+	}
+
 	if len(opts.Username) == 0 {
 		opts.Username = os.Getenv("IMGPKG_USERNAME")
 	}
@@ -94,6 +161,12 @@ func (r *RegistryFlags) AsRegistryOpts() registry.Opts {
 	if os.Getenv("IMGPKG_ANON") == "true" {
 		opts.Anon = true
 	}
+	if len(opts.CredExecPath) == 0 {
+		opts.CredExecPath = os.Getenv("IMGPKG_REGISTRY_CRED_EXEC")
+	}
+	if os.Getenv("IMGPKG_OFFLINE") == "true" {
+		opts.Offline = true
+	}
 
 	return opts
 }
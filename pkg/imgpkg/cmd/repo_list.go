@@ -0,0 +1,170 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	uitable "github.com/cppforlife/go-cli-ui/ui/table"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+type RepoListOptions struct {
+	ui ui.UI
+
+	RegistryFlags RegistryFlags
+	Registry      string
+	OnlyBundles   bool
+	Concurrency   int
+}
+
+func NewRepoListOptions(ui ui.UI) *RepoListOptions {
+	return &RepoListOptions{ui: ui}
+}
+
+func NewRepoListCmd(o *RepoListOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List repositories in a registry",
+		RunE:    func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # List all repositories in registry.corp.com
+  imgpkg repo ls --registry registry.corp.com
+
+  # List only repositories that contain bundles
+  imgpkg repo ls --registry registry.corp.com --bundles`,
+	}
+	o.RegistryFlags.Set(cmd)
+	cmd.Flags().StringVar(&o.Registry, "registry", "", "Registry to list repositories from (example: registry.corp.com)")
+	cmd.Flags().BoolVar(&o.OnlyBundles, "bundles", false, "Only list repositories whose latest image is a bundle (requires fetching image configs)")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", 5, "Concurrency")
+	return cmd
+}
+
+func (r *RepoListOptions) Run() error {
+	if r.Registry == "" {
+		return fmt.Errorf("Expected --registry to be non empty")
+	}
+
+	reg, err := registry.NewSimpleRegistry(r.RegistryFlags.AsRegistryOpts())
+	if err != nil {
+		return err
+	}
+
+	repos, err := reg.CatalogRepositories(r.Registry)
+	if err != nil {
+		return fmt.Errorf("Listing repositories (hint: not every registry supports the _catalog API): %s", err)
+	}
+	sort.Strings(repos)
+
+	bundleRepos := map[string]bool{}
+	if r.OnlyBundles {
+		bundleRepos, err = r.findBundleRepos(reg, repos)
+		if err != nil {
+			return err
+		}
+	}
+
+	table := uitable.Table{
+		Title:   fmt.Sprintf("Repositories in '%s'", r.Registry),
+		Content: "repositories",
+
+		Header: []uitable.Header{
+			uitable.NewHeader("Name"),
+		},
+
+		SortBy: []uitable.ColumnSort{
+			{Column: 0, Asc: true},
+		},
+	}
+
+	for _, repo := range repos {
+		if r.OnlyBundles && !bundleRepos[repo] {
+			continue
+		}
+		table.Rows = append(table.Rows, []uitable.Value{
+			uitable.NewValueString(fmt.Sprintf("%s/%s", r.Registry, repo)),
+		})
+	}
+
+	r.ui.PrintTable(table)
+
+	return nil
+}
+
+// findBundleRepos checks the bundle config label on the newest tag of every
+// repository, reporting the repositories that host bundles
+func (r *RepoListOptions) findBundleRepos(reg registry.Registry, repos []string) (map[string]bool, error) {
+	throttle := util.NewThrottle(r.Concurrency)
+
+	type result struct {
+		repo     string
+		isBundle bool
+		err      error
+	}
+	resultCh := make(chan result, len(repos))
+
+	for _, repo := range repos {
+		repo := repo // copy
+
+		go func() {
+			throttle.Take()
+			defer throttle.Done()
+
+			isBundle, err := r.repoHasBundle(reg, repo)
+			resultCh <- result{repo: repo, isBundle: isBundle, err: err}
+		}()
+	}
+
+	bundleRepos := map[string]bool{}
+	for i := 0; i < len(repos); i++ {
+		res := <-resultCh
+		if res.err != nil {
+			return nil, fmt.Errorf("Checking repository '%s' for bundles: %s", res.repo, res.err)
+		}
+		bundleRepos[res.repo] = res.isBundle
+	}
+
+	return bundleRepos, nil
+}
+
+// repoHasBundle inspects the repository's latest tag, falling back to the
+// last tag the registry reports, and checks whether it points at a bundle
+func (r *RepoListOptions) repoHasBundle(reg registry.Registry, repo string) (bool, error) {
+	ref, err := regname.ParseReference(fmt.Sprintf("%s/%s", r.Registry, repo), regname.WeakValidation)
+	if err != nil {
+		return false, err
+	}
+
+	tags, err := reg.ListTags(ref.Context())
+	if err != nil {
+		return false, err
+	}
+	if len(tags) == 0 {
+		return false, nil
+	}
+
+	tag := tags[len(tags)-1]
+	for _, candidate := range tags {
+		if candidate == "latest" {
+			tag = candidate
+			break
+		}
+	}
+
+	isBundle, err := bundle.NewBundle(fmt.Sprintf("%s/%s:%s", r.Registry, repo, tag), reg).IsBundle()
+	if err != nil {
+		// repositories whose content cannot be inspected (ex: image indexes)
+		// are not bundles
+		return false, nil
+	}
+	return isBundle, nil
+}
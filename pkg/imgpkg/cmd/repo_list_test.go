@@ -0,0 +1,59 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestRepoList(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+
+	fakeRegistry.WithRandomImage("repo/plain-image")
+	fakeRegistry.WithRandomBundle("repo/some-bundle")
+	fakeRegistry.Build()
+
+	t.Run("lists every repository in the registry", func(t *testing.T) {
+		output := bytes.NewBufferString("")
+		writerUI := goui.NewWriterUI(output, output, nil)
+
+		repoListOpts := NewRepoListOptions(writerUI)
+		repoListOpts.Registry = fakeRegistry.Host()
+		repoListOpts.RegistryFlags = RegistryFlags{Insecure: true}
+		repoListOpts.Concurrency = 2
+
+		require.NoError(t, repoListOpts.Run())
+		assert.Contains(t, output.String(), "repo/plain-image")
+		assert.Contains(t, output.String(), "repo/some-bundle")
+	})
+
+	t.Run("lists only bundle repositories when requested", func(t *testing.T) {
+		output := bytes.NewBufferString("")
+		writerUI := goui.NewWriterUI(output, output, nil)
+
+		repoListOpts := NewRepoListOptions(writerUI)
+		repoListOpts.Registry = fakeRegistry.Host()
+		repoListOpts.RegistryFlags = RegistryFlags{Insecure: true}
+		repoListOpts.OnlyBundles = true
+		repoListOpts.Concurrency = 2
+
+		require.NoError(t, repoListOpts.Run())
+		assert.NotContains(t, output.String(), "repo/plain-image")
+		assert.Contains(t, output.String(), "repo/some-bundle")
+	})
+
+	t.Run("errors when --registry is not provided", func(t *testing.T) {
+		repoListOpts := NewRepoListOptions(goui.NewNoopUI())
+		err := repoListOpts.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected --registry to be non empty")
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// repoRenameRule rewrites source repository paths that match regex with
+// replacement when computing destination repository names
+type repoRenameRule struct {
+	regex       *regexp.Regexp
+	replacement string
+}
+
+// parseRepoRenameRules parses 'regex=replacement' entries into rename rules,
+// keeping the provided order
+func parseRepoRenameRules(entries []string) ([]repoRenameRule, error) {
+	var rules []repoRenameRule
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Expected --repo-rename to be in the form regex=replacement, got '%s'", entry)
+		}
+
+		regex, err := regexp.Compile(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("Compiling --repo-rename regex '%s': %s", parts[0], err)
+		}
+
+		rules = append(rules, repoRenameRule{regex: regex, replacement: parts[1]})
+	}
+	return rules, nil
+}
+
+// renameRepository applies the first matching rule to the source repository
+// and reports whether any rule matched
+func renameRepository(rules []repoRenameRule, srcRepo string) (string, bool) {
+	for _, rule := range rules {
+		if rule.regex.MatchString(srcRepo) {
+			return rule.regex.ReplaceAllString(srcRepo, rule.replacement), true
+		}
+	}
+	return srcRepo, false
+}
@@ -0,0 +1,60 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRepoRenameRulesErrors(t *testing.T) {
+	_, err := parseRepoRenameRules([]string{"no-separator"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected --repo-rename to be in the form regex=replacement, got 'no-separator'")
+
+	_, err = parseRepoRenameRules([]string{"[invalid=replacement"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Compiling --repo-rename regex '[invalid'")
+}
+
+func TestRenameRepositoryFirstMatchWins(t *testing.T) {
+	rules, err := parseRepoRenameRules([]string{
+		`^docker\.io/library/(.*)$=mirror/dockerhub/$1`,
+		`^docker\.io/(.*)$=mirror/other/$1`,
+	})
+	require.NoError(t, err)
+
+	renamed, matched := renameRepository(rules, "docker.io/library/nginx")
+	assert.True(t, matched)
+	assert.Equal(t, "mirror/dockerhub/nginx", renamed)
+
+	renamed, matched = renameRepository(rules, "docker.io/someuser/app")
+	assert.True(t, matched)
+	assert.Equal(t, "mirror/other/someuser/app", renamed)
+
+	renamed, matched = renameRepository(rules, "quay.io/someuser/app")
+	assert.False(t, matched)
+	assert.Equal(t, "quay.io/someuser/app", renamed)
+}
+
+func TestBundleSubRepositoryAppliesRenameRules(t *testing.T) {
+	importRepo, err := regname.NewRepository("dest.io/repo")
+	require.NoError(t, err)
+
+	rules, err := parseRepoRenameRules([]string{`^docker\.io/library/(.*)$=dockerhub/$1`})
+	require.NoError(t, err)
+
+	bundleRef := "docker.io/library/nginx@sha256:aaaabbbbccccddddaaaabbbbccccddddaaaabbbbccccddddaaaabbbbccccdddd"
+
+	subRepo, err := bundleSubRepository(importRepo, bundleRef, rules)
+	require.NoError(t, err)
+	assert.Equal(t, "dest.io/repo/dockerhub/nginx", subRepo.Name())
+
+	subRepo, err = bundleSubRepository(importRepo, bundleRef, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "dest.io/repo/nginx", subRepo.Name())
+}
@@ -0,0 +1,140 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+type ResolveOptions struct {
+	ui ui.UI
+
+	RegistryFlags   RegistryFlags
+	LockOutputFlags LockOutputFlags
+
+	Images      []string
+	FilePath    string
+	Concurrency int
+}
+
+func NewResolveOptions(ui ui.UI) *ResolveOptions {
+	return &ResolveOptions{ui: ui}
+}
+
+func NewResolveCmd(o *ResolveOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resolve",
+		Short: "Resolve image references to digests and emit an ImagesLock",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+    # Resolve a set of images to digests and write an ImagesLock
+    imgpkg resolve -i nginx:1.21 -i redis:6 --lock-output images.yml
+
+    # Resolve images listed in a plain text file (one reference per line)
+    imgpkg resolve -f images.txt --lock-output images.yml`,
+	}
+	o.RegistryFlags.Set(cmd)
+	o.LockOutputFlags.Set(cmd)
+	cmd.Flags().StringSliceVarP(&o.Images, "image", "i", nil, "Image reference to resolve (can be specified multiple times)")
+	cmd.Flags().StringVarP(&o.FilePath, "file", "f", "", "Path to a file with one image reference per line ('#' lines are ignored)")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", 5, "Concurrency")
+	return cmd
+}
+
+func (r *ResolveOptions) Run() error {
+	refs, err := r.imageRefs()
+	if err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		return fmt.Errorf("Expected at least one image reference via --image (-i) or --file (-f)")
+	}
+
+	reg, err := registry.NewSimpleRegistry(r.RegistryFlags.AsRegistryOpts())
+	if err != nil {
+		return err
+	}
+
+	resolvedRefs := make([]string, len(refs))
+	throttle := util.NewThrottle(r.Concurrency)
+	errCh := make(chan error, len(refs))
+
+	for i, ref := range refs {
+		i, ref := i, ref // copy
+
+		go func() {
+			throttle.Take()
+			defer throttle.Done()
+
+			parsedRef, err := regname.ParseReference(ref, regname.WeakValidation)
+			if err != nil {
+				errCh <- fmt.Errorf("Parsing image reference '%s': %s", ref, err)
+				return
+			}
+
+			digest, err := reg.Digest(parsedRef)
+			if err != nil {
+				errCh <- fmt.Errorf("Resolving image '%s': %s", ref, err)
+				return
+			}
+
+			resolvedRefs[i] = fmt.Sprintf("%s@%s", parsedRef.Context().Name(), digest.String())
+			errCh <- nil
+		}()
+	}
+
+	for i := 0; i < len(refs); i++ {
+		if err := <-errCh; err != nil {
+			return err
+		}
+	}
+
+	imagesLock := lockconfig.NewEmptyImagesLock()
+	sort.Strings(resolvedRefs)
+	for _, resolvedRef := range resolvedRefs {
+		imagesLock.AddImageRef(lockconfig.ImageRef{Image: resolvedRef})
+	}
+
+	if r.LockOutputFlags.LockFilePath != "" {
+		return r.LockOutputFlags.WriteLock(imagesLock, r.ui)
+	}
+
+	bs, err := imagesLock.AsBytes()
+	if err != nil {
+		return err
+	}
+	r.ui.PrintBlock(bs)
+	return nil
+}
+
+func (r *ResolveOptions) imageRefs() ([]string, error) {
+	refs := append([]string{}, r.Images...)
+
+	if r.FilePath != "" {
+		bs, err := ioutil.ReadFile(r.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("Reading path %s: %s", r.FilePath, err)
+		}
+
+		for _, line := range strings.Split(string(bs), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			refs = append(refs, line)
+		}
+	}
+
+	return refs, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/ociregistry"
+)
+
+type ServeOptions struct {
+	ui ui.UI
+
+	Host       string
+	Port       int
+	StorageDir string
+}
+
+func NewServeOptions(ui ui.UI) *ServeOptions {
+	return &ServeOptions{ui: ui}
+}
+
+func NewServeCmd(o *ServeOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a local OCI registry",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Serve an in-memory registry on port 5000
+  imgpkg serve
+
+  # Serve a registry on port 9001 that keeps its contents in /tmp/registry
+  imgpkg serve --port 9001 --storage /tmp/registry`,
+	}
+	cmd.Flags().StringVar(&o.Host, "host", "127.0.0.1",
+		"Host address to bind to; the registry accepts unauthenticated pushes, so only widen this (ex: 0.0.0.0) deliberately")
+	cmd.Flags().IntVar(&o.Port, "port", 5000, "Port to listen on")
+	cmd.Flags().StringVar(&o.StorageDir, "storage", "", "Directory used to persist registry contents (default: in-memory)")
+	return cmd
+}
+
+func (o *ServeOptions) Run() error {
+	var handler http.Handler
+	var err error
+
+	if o.StorageDir != "" {
+		handler, err = ociregistry.NewHandler(o.StorageDir)
+		if err != nil {
+			return err
+		}
+	} else {
+		handler = regregistry.New()
+	}
+
+	addr := net.JoinHostPort(o.Host, strconv.Itoa(o.Port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("Listening on %s: %s", addr, err)
+	}
+
+	if o.StorageDir != "" {
+		o.ui.BeginLinef("Serving OCI registry on %s (storage: %s)\n", listener.Addr(), o.StorageDir)
+	} else {
+		o.ui.BeginLinef("Serving in-memory OCI registry on %s\n", listener.Addr())
+	}
+
+	return http.Serve(listener, handler)
+}
@@ -0,0 +1,295 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/spf13/cobra"
+)
+
+// ServerOptions options for the server command
+type ServerOptions struct {
+	ui ui.UI
+
+	Host        string
+	Port        int
+	Concurrency int
+}
+
+// NewServerOptions constructor for building a ServerOptions, holding values derived via flags
+func NewServerOptions(ui ui.UI) *ServerOptions {
+	return &ServerOptions{ui: ui}
+}
+
+func NewServerCmd(o *ServerOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "server",
+		Short: "Run a local REST API server for driving copy operations",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Start the relocation API server on port 8080
+  imgpkg server --port 8080
+
+  # Submit a copy and poll its progress
+  curl -d '{"bundle":"repo/app1-bundle","toRepo":"internal-registry/app1-bundle"}' localhost:8080/api/v1/copy
+  curl localhost:8080/api/v1/jobs/job-1`,
+	}
+	cmd.Flags().StringVar(&o.Host, "host", "127.0.0.1",
+		"Host address to bind to; the API is unauthenticated and copies run with this machine's registry credentials, so only widen this (ex: 0.0.0.0) deliberately")
+	cmd.Flags().IntVar(&o.Port, "port", 8080, "Port to listen on")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", 5, "Concurrency used by copy jobs that do not set their own")
+	return cmd
+}
+
+// Run starts an HTTP server exposing copy operations as asynchronous jobs.
+// A job is created with POST /api/v1/copy and its status and accumulated
+// output can be polled via GET /api/v1/jobs/<id> while the copy runs
+func (o *ServerOptions) Run() error {
+	addr := net.JoinHostPort(o.Host, strconv.Itoa(o.Port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("Listening on %s: %s", addr, err)
+	}
+
+	o.ui.BeginLinef("Serving relocation API on %s\n", listener.Addr())
+
+	return http.Serve(listener, o.Handler())
+}
+
+// Handler builds the API routes; exposed separately so tests can drive the
+// server without binding a port
+func (o *ServerOptions) Handler() http.Handler {
+	jobs := newJobStore()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/copy", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req copyJobRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("parsing request: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		err = req.Validate()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		job := jobs.Create(req)
+		go o.runCopyJob(job)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job.View())
+	})
+
+	mux.HandleFunc("/api/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jobs.Views())
+	})
+
+	mux.HandleFunc("/api/v1/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/api/v1/jobs/")
+		job, found := jobs.Get(id)
+		if !found {
+			http.Error(w, fmt.Sprintf("job '%s' not found", id), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(job.View())
+	})
+
+	return mux
+}
+
+func (o *ServerOptions) runCopyJob(job *relocationJob) {
+	job.Start()
+
+	concurrency := job.request.Concurrency
+	if concurrency == 0 {
+		concurrency = o.Concurrency
+	}
+
+	copyOpts := &CopyOptions{
+		ui:          ui.NewWriterUI(job, job, ui.NewNoopLogger()),
+		ImageFlags:  ImageFlags{Image: job.request.Image},
+		BundleFlags: BundleFlags{Bundle: job.request.Bundle},
+		LockInputFlags: LockInputFlags{
+			LockFilePath: job.request.LockFile,
+		},
+		TarFlags:    TarFlags{TarSrc: job.request.Tar, TarDst: job.request.ToTar},
+		RepoDst:     job.request.ToRepo,
+		Concurrency: concurrency,
+	}
+
+	job.Finish(copyOpts.Run())
+}
+
+// copyJobRequest JSON body accepted by POST /api/v1/copy; the fields mirror
+// the copy command flags
+type copyJobRequest struct {
+	Image    string `json:"image,omitempty"`
+	Bundle   string `json:"bundle,omitempty"`
+	LockFile string `json:"lockFile,omitempty"`
+	Tar      string `json:"tar,omitempty"`
+
+	ToRepo string `json:"toRepo,omitempty"`
+	ToTar  string `json:"toTar,omitempty"`
+
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// Validate rejects malformed requests before a job is created; errors found
+// while the copy runs are reported on the job instead
+func (r copyJobRequest) Validate() error {
+	srcCount := 0
+	for _, src := range []string{r.Image, r.Bundle, r.LockFile, r.Tar} {
+		if src != "" {
+			srcCount++
+		}
+	}
+	if srcCount != 1 {
+		return fmt.Errorf("Expected exactly one of image, bundle, lockFile or tar as a source")
+	}
+
+	if (r.ToRepo == "") == (r.ToTar == "") {
+		return fmt.Errorf("Expected exactly one of toRepo or toTar as a destination")
+	}
+	return nil
+}
+
+type jobStore struct {
+	lock   sync.Mutex
+	nextID int
+	jobs   map[string]*relocationJob
+	order  []string
+}
+
+func newJobStore() *jobStore {
+	return &jobStore{nextID: 1, jobs: map[string]*relocationJob{}}
+}
+
+func (s *jobStore) Create(req copyJobRequest) *relocationJob {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	job := &relocationJob{id: fmt.Sprintf("job-%d", s.nextID), request: req, status: jobStatusPending}
+	s.nextID++
+	s.jobs[job.id] = job
+	s.order = append(s.order, job.id)
+	return job
+}
+
+func (s *jobStore) Get(id string) (*relocationJob, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	job, found := s.jobs[id]
+	return job, found
+}
+
+func (s *jobStore) Views() []relocationJobView {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	views := []relocationJobView{}
+	for _, id := range s.order {
+		views = append(views, s.jobs[id].View())
+	}
+	return views
+}
+
+const (
+	jobStatusPending   = "pending"
+	jobStatusRunning   = "running"
+	jobStatusSucceeded = "succeeded"
+	jobStatusFailed    = "failed"
+)
+
+// relocationJob a single asynchronous copy tracked by the server; its Write
+// method collects the copy output so clients can poll progress
+type relocationJob struct {
+	id      string
+	request copyJobRequest
+
+	lock       sync.Mutex
+	status     string
+	err        string
+	startedAt  time.Time
+	finishedAt time.Time
+	output     strings.Builder
+}
+
+func (j *relocationJob) Start() {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	j.status = jobStatusRunning
+	j.startedAt = time.Now()
+}
+
+func (j *relocationJob) Finish(err error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	j.finishedAt = time.Now()
+	if err != nil {
+		j.status = jobStatusFailed
+		j.err = err.Error()
+	} else {
+		j.status = jobStatusSucceeded
+	}
+}
+
+// Write implements io.Writer so the job can act as the UI sink of its copy
+func (j *relocationJob) Write(p []byte) (int, error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	return j.output.Write(p)
+}
+
+// relocationJobView JSON representation of a job returned by the API
+type relocationJobView struct {
+	ID         string `json:"id"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  string `json:"startedAt,omitempty"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+	Output     string `json:"output,omitempty"`
+}
+
+func (j *relocationJob) View() relocationJobView {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	view := relocationJobView{
+		ID:     j.id,
+		Status: j.status,
+		Error:  j.err,
+		Output: j.output.String(),
+	}
+	if !j.startedAt.IsZero() {
+		view.StartedAt = j.startedAt.Format(time.RFC3339)
+	}
+	if !j.finishedAt.IsZero() {
+		view.FinishedAt = j.finishedAt.Format(time.RFC3339)
+	}
+	return view
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerRejectsInvalidCopyRequest(t *testing.T) {
+	server := httptest.NewServer(serverOptionsForTest().Handler())
+	defer server.Close()
+
+	resp, err := server.Client().Post(server.URL+"/api/v1/copy", "application/json",
+		strings.NewReader(`{"toRepo":"some-repo"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 400, resp.StatusCode)
+
+	resp, err = server.Client().Post(server.URL+"/api/v1/copy", "application/json",
+		strings.NewReader(`{"image":"some-image"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestServerTracksCopyJob(t *testing.T) {
+	server := httptest.NewServer(serverOptionsForTest().Handler())
+	defer server.Close()
+
+	resp, err := server.Client().Post(server.URL+"/api/v1/copy", "application/json",
+		strings.NewReader(`{"image":"localhost:1/does-not-exist@sha256:7e30dddd23b4ba2723de4c5d3a10a7b7faa119d90d3789e44117e0bdc1c3c0a3","toRepo":"localhost:1/dest"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, 202, resp.StatusCode)
+
+	var job relocationJobView
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+	assert.Equal(t, "job-1", job.ID)
+
+	// the copy target is unreachable, so the job is expected to fail quickly
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		getResp, err := server.Client().Get(server.URL + "/api/v1/jobs/" + job.ID)
+		require.NoError(t, err)
+		require.NoError(t, json.NewDecoder(getResp.Body).Decode(&job))
+		getResp.Body.Close()
+
+		if job.Status == jobStatusFailed || job.Status == jobStatusSucceeded {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected job to finish, still in status '%s'", job.Status)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	assert.Equal(t, jobStatusFailed, job.Status)
+	assert.NotEmpty(t, job.Error)
+
+	listResp, err := server.Client().Get(server.URL + "/api/v1/jobs")
+	require.NoError(t, err)
+	defer listResp.Body.Close()
+
+	var jobs []relocationJobView
+	require.NoError(t, json.NewDecoder(listResp.Body).Decode(&jobs))
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "job-1", jobs[0].ID)
+}
+
+func TestServerUnknownJob(t *testing.T) {
+	server := httptest.NewServer(serverOptionsForTest().Handler())
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/api/v1/jobs/job-42")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func serverOptionsForTest() *ServerOptions {
+	return &ServerOptions{ui: goui.NewNoopUI(), Concurrency: 5}
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// ShortNamePolicyDockerHub default behavior: short names resolve to Docker Hub
+	ShortNamePolicyDockerHub = "docker-hub"
+	// ShortNamePolicyError reject short names, requiring fully qualified references
+	ShortNamePolicyError = "error"
+	// ShortNamePolicyDefaultRegistry qualify short names with --default-registry
+	ShortNamePolicyDefaultRegistry = "default-registry"
+)
+
+// applyShortNamePolicy enforces the configured short-name resolution policy on
+// the image and bundle flags before a command runs. Short names (ex: 'ubuntu')
+// silently resolve to Docker Hub by default; enterprises can instead reject
+// them or qualify them with a configured registry, typically via the imgpkg
+// configuration file so every invocation is covered
+func applyShortNamePolicy(cmd *cobra.Command, _ []string) error {
+	policyFlag := cmd.Flags().Lookup("short-name-policy")
+	if policyFlag == nil {
+		return nil
+	}
+
+	policy := policyFlag.Value.String()
+	defaultRegistry := ""
+	if flag := cmd.Flags().Lookup("default-registry"); flag != nil {
+		defaultRegistry = flag.Value.String()
+	}
+	if defaultRegistry != "" && !policyFlag.Changed {
+		policy = ShortNamePolicyDefaultRegistry
+	}
+
+	switch policy {
+	case ShortNamePolicyDockerHub, ShortNamePolicyError, ShortNamePolicyDefaultRegistry:
+	default:
+		return fmt.Errorf("Expected --short-name-policy to be one of '%s', '%s' or '%s', got '%s'",
+			ShortNamePolicyDockerHub, ShortNamePolicyError, ShortNamePolicyDefaultRegistry, policy)
+	}
+
+	for _, flagName := range []string{"image", "bundle"} {
+		flag := cmd.Flags().Lookup(flagName)
+		if flag == nil {
+			continue
+		}
+		refStr := flag.Value.String()
+		if refStr == "" || !isShortName(refStr) {
+			continue
+		}
+
+		switch policy {
+		case ShortNamePolicyError:
+			return fmt.Errorf("Expected reference '%s' to be fully qualified: short names resolve to Docker Hub (hint: Qualify the reference or set default-registry in the imgpkg config)", refStr)
+		case ShortNamePolicyDefaultRegistry:
+			if defaultRegistry == "" {
+				return fmt.Errorf("Expected --default-registry to be set when --short-name-policy is '%s'", ShortNamePolicyDefaultRegistry)
+			}
+			err := cmd.Flags().Set(flagName, defaultRegistry+"/"+refStr)
+			if err != nil {
+				return fmt.Errorf("Qualifying reference '%s': %s", refStr, err)
+			}
+		}
+	}
+	return nil
+}
+
+// isShortName reports whether the reference does not name a registry host and
+// would therefore default to Docker Hub (ex: 'ubuntu', 'library/ubuntu').
+// Hosts are recognized the same way docker does: the first path component must
+// contain a '.' or ':' or be 'localhost'
+func isShortName(refStr string) bool {
+	firstPart := strings.SplitN(refStr, "/", 2)[0]
+	if firstPart == refStr {
+		return true
+	}
+	return !strings.ContainsAny(firstPart, ".:") && firstPart != "localhost"
+}
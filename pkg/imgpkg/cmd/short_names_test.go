@@ -0,0 +1,94 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsShortName(t *testing.T) {
+	tests := []struct {
+		ref   string
+		short bool
+	}{
+		{"ubuntu", true},
+		{"ubuntu:latest", true},
+		{"library/ubuntu", true},
+		{"docker.io/library/ubuntu", false},
+		{"registry.corp.com/app1-bundle", false},
+		{"localhost/app1-bundle", false},
+		{"localhost:5000/app1-bundle", false},
+		{"registry:5000/app1-bundle", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.short, isShortName(test.ref), "ref '%s'", test.ref)
+	}
+}
+
+func TestApplyShortNamePolicy(t *testing.T) {
+	newCmd := func(image, policy, defaultRegistry string) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("image", "", "")
+		cmd.Flags().String("bundle", "", "")
+		cmd.Flags().String("short-name-policy", ShortNamePolicyDockerHub, "")
+		cmd.Flags().String("default-registry", "", "")
+		require.NoError(t, cmd.Flags().Set("image", image))
+		if policy != "" {
+			require.NoError(t, cmd.Flags().Set("short-name-policy", policy))
+		}
+		if defaultRegistry != "" {
+			require.NoError(t, cmd.Flags().Set("default-registry", defaultRegistry))
+		}
+		return cmd
+	}
+
+	t.Run("short names pass through by default", func(t *testing.T) {
+		cmd := newCmd("ubuntu", "", "")
+		require.NoError(t, applyShortNamePolicy(cmd, nil))
+		assert.Equal(t, "ubuntu", cmd.Flags().Lookup("image").Value.String())
+	})
+
+	t.Run("error policy rejects short names", func(t *testing.T) {
+		err := applyShortNamePolicy(newCmd("ubuntu", ShortNamePolicyError, ""), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "to be fully qualified")
+	})
+
+	t.Run("error policy allows qualified references", func(t *testing.T) {
+		require.NoError(t, applyShortNamePolicy(newCmd("registry.corp.com/app", ShortNamePolicyError, ""), nil))
+	})
+
+	t.Run("default registry qualifies short names", func(t *testing.T) {
+		cmd := newCmd("ubuntu", "", "registry.corp.com/mirror")
+		require.NoError(t, applyShortNamePolicy(cmd, nil))
+		assert.Equal(t, "registry.corp.com/mirror/ubuntu", cmd.Flags().Lookup("image").Value.String())
+	})
+
+	t.Run("default registry leaves qualified references alone", func(t *testing.T) {
+		cmd := newCmd("gcr.io/project/app", "", "registry.corp.com/mirror")
+		require.NoError(t, applyShortNamePolicy(cmd, nil))
+		assert.Equal(t, "gcr.io/project/app", cmd.Flags().Lookup("image").Value.String())
+	})
+
+	t.Run("default-registry policy without a registry errors", func(t *testing.T) {
+		err := applyShortNamePolicy(newCmd("ubuntu", ShortNamePolicyDefaultRegistry, ""), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected --default-registry to be set")
+	})
+
+	t.Run("unknown policy errors", func(t *testing.T) {
+		err := applyShortNamePolicy(newCmd("ubuntu", "prompt", ""), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected --short-name-policy to be one of")
+	})
+
+	t.Run("commands without the flags are skipped", func(t *testing.T) {
+		require.NoError(t, applyShortNamePolicy(&cobra.Command{}, nil))
+	})
+}
@@ -6,9 +6,12 @@ package cmd
 import "github.com/spf13/cobra"
 
 type SignatureFlags struct {
-	CopyCosignSignatures bool
+	CopyCosignSignatures   bool
+	CopySignatureArtifacts bool
 }
 
 func (s *SignatureFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&s.CopyCosignSignatures, "cosign-signatures", false, "Find and copy cosign signatures for images")
+	cmd.Flags().BoolVar(&s.CopySignatureArtifacts, "signature-artifacts", false,
+		"Find and copy all signature artifacts attached to images via tags (cosign signatures, attestations, SBOMs and notation signatures)")
 }
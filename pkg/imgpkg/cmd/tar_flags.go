@@ -14,7 +14,7 @@ type TarFlags struct {
 
 func (t *TarFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&t.TarDst, "to-tar", "", "Location to write a tar file containing assets")
-	cmd.Flags().StringVar(&t.TarSrc, "tar", "", "Path to tar file which contains assets to be copied to a registry")
+	cmd.Flags().StringVar(&t.TarSrc, "tar", "", "Path to tar file which contains assets to be copied to a registry (also accepts s3://, gs:// and azblob:// locations)")
 }
 
 func (t TarFlags) IsSrc() bool { return t.TarSrc != "" }
@@ -0,0 +1,52 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/tofu"
+)
+
+// checkTrustOnFirstUse resolves refStr and compares the digest against the
+// local trust store. A reference resolving to a different digest than the one
+// recorded on first use warns, or fails when strict is set; unseen references
+// are recorded for later runs
+func checkTrustOnFirstUse(refStr string, strict bool, reg registry.ImagesReader, ui ui.UI) error {
+	parsedRef, err := regname.ParseReference(refStr, regname.WeakValidation)
+	if err != nil {
+		return fmt.Errorf("Parsing '%s': %s", refStr, err)
+	}
+
+	digest, err := reg.Digest(parsedRef)
+	if err != nil {
+		return fmt.Errorf("Resolving '%s': %s", refStr, err)
+	}
+
+	storePath, err := tofu.DefaultPath()
+	if err != nil {
+		return err
+	}
+
+	store, err := tofu.NewStore(storePath)
+	if err != nil {
+		return err
+	}
+
+	trustedDigest, found := store.TrustedDigest(refStr)
+	if found && trustedDigest != digest.String() {
+		if strict {
+			return fmt.Errorf("Reference '%s' previously resolved to digest '%s', but now resolves to '%s' (hint: Remove the entry from '%s' if the change is expected)",
+				refStr, trustedDigest, digest.String(), storePath)
+		}
+		ui.BeginLinef("Warning: Reference '%s' previously resolved to digest '%s', but now resolves to '%s'\n",
+			refStr, trustedDigest, digest.String())
+		return nil
+	}
+
+	return store.Record(refStr, digest.String())
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/tofu"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestCheckTrustOnFirstUse(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+
+	imgInfo := fakeRegistry.WithRandomImage("repo/some-image")
+	reg := fakeRegistry.Build()
+
+	tagRef := fakeRegistry.ReferenceOnTestServer("repo/some-image")
+	digest := imgInfo.RefDigest[strings.Index(imgInfo.RefDigest, "@")+1:]
+
+	storePath := filepath.Join(t.TempDir(), "trusted-digests.json")
+	t.Setenv(tofu.EnvStorePath, storePath)
+
+	t.Run("records the digest on first use and accepts it afterwards", func(t *testing.T) {
+		require.NoError(t, checkTrustOnFirstUse(tagRef, true, reg, goui.NewNoopUI()))
+
+		store, err := tofu.NewStore(storePath)
+		require.NoError(t, err)
+		trustedDigest, found := store.TrustedDigest(tagRef)
+		require.True(t, found)
+		assert.Equal(t, digest, trustedDigest)
+
+		require.NoError(t, checkTrustOnFirstUse(tagRef, true, reg, goui.NewNoopUI()))
+	})
+
+	t.Run("fails in strict mode when the recorded digest differs", func(t *testing.T) {
+		store, err := tofu.NewStore(filepath.Join(t.TempDir(), "other-store.json"))
+		require.NoError(t, err)
+		require.NoError(t, store.Record(tagRef, "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"))
+
+		t.Setenv(tofu.EnvStorePath, store.Path())
+
+		err = checkTrustOnFirstUse(tagRef, true, reg, goui.NewNoopUI())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "previously resolved to digest")
+
+		// non-strict only warns
+		require.NoError(t, checkTrustOnFirstUse(tagRef, false, reg, goui.NewNoopUI()))
+	})
+}
+
+func TestTofuRequiresBundleOrImage(t *testing.T) {
+	pull := PullOptions{Tofu: true, OutputPath: "/tmp/some-output"}
+	pull.LockInputFlags.LockFilePath = "bundle.lock.yml"
+
+	err := pull.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected --tofu to only be used with --bundle or --image sources")
+}
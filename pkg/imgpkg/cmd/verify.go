@@ -0,0 +1,150 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/spf13/cobra"
+	ctlbundle "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/rekor"
+)
+
+type VerifyOptions struct {
+	ui ui.UI
+
+	BundleFlags   BundleFlags
+	RegistryFlags RegistryFlags
+
+	RekorURL       string
+	CertIdentity   string
+	CertOIDCIssuer string
+	Concurrency    int
+}
+
+func NewVerifyOptions(ui *ui.ConfUI) *VerifyOptions {
+	return &VerifyOptions{ui: ui}
+}
+
+func NewVerifyCmd(o *VerifyOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify a bundle and the images it references against a Rekor transparency log",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Verify that the bundle and every image in its ImagesLock were recorded in the public Rekor log
+  imgpkg verify -b repo/app1-bundle
+
+  # Verify against a private Rekor instance, requiring a specific signer identity
+  imgpkg verify -b repo/app1-bundle --rekor-url https://rekor.corp.example.com \
+                --certificate-identity release-bot@corp.example.com \
+                --certificate-oidc-issuer https://accounts.corp.example.com`,
+	}
+	o.BundleFlags.Set(cmd)
+	o.RegistryFlags.Set(cmd)
+	cmd.Flags().StringVar(&o.RekorURL, "rekor-url", rekor.DefaultURL, "URL of the Rekor transparency log instance to query")
+	cmd.Flags().StringVar(&o.CertIdentity, "certificate-identity", "",
+		"Require at least one log entry per digest signed by a certificate issued to this identity (email or URI)")
+	cmd.Flags().StringVar(&o.CertOIDCIssuer, "certificate-oidc-issuer", "",
+		"Require the certificate identity to have been authenticated by this OIDC issuer (used with --certificate-identity)")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", 5, "Concurrency")
+	return cmd
+}
+
+func (v *VerifyOptions) Run() error {
+	if v.BundleFlags.Bundle == "" {
+		return fmt.Errorf("Expected bundle reference (-b)")
+	}
+	if v.CertOIDCIssuer != "" && v.CertIdentity == "" {
+		return fmt.Errorf("Expected --certificate-oidc-issuer to only be used together with --certificate-identity")
+	}
+
+	reg, err := registry.NewSimpleRegistry(v.RegistryFlags.AsRegistryOpts())
+	if err != nil {
+		return err
+	}
+
+	levelLogger := newLevelLogger(v.ui)
+
+	bundle := ctlbundle.NewBundle(v.BundleFlags.Bundle, reg)
+	isBundle, err := bundle.IsBundle()
+	if err != nil {
+		return err
+	}
+	if !isBundle {
+		return fmt.Errorf("Expected bundle image but found plain image (hint: Did you use -i instead of -b?)")
+	}
+
+	_, imageRefs, err := bundle.AllImagesLockRefs(v.Concurrency, levelLogger)
+	if err != nil {
+		return fmt.Errorf("Reading Images from Bundle: %s", err)
+	}
+
+	digestRefs := []string{bundle.DigestRef()}
+	for _, img := range imageRefs.ImageRefs() {
+		digestRefs = append(digestRefs, img.Image)
+	}
+
+	client := rekor.NewClient(v.RekorURL)
+
+	var failures []string
+	for _, digestRef := range digestRefs {
+		err := v.verifyDigestRef(client, digestRef)
+		if err != nil {
+			v.ui.BeginLinef("%s: %s\n", digestRef, err)
+			failures = append(failures, digestRef)
+			continue
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("Verification failed for %d of %d digest(s):\n- %s",
+			len(failures), len(digestRefs), strings.Join(failures, "\n- "))
+	}
+
+	v.ui.BeginLinef("Verified %d digest(s) against '%s'\n", len(digestRefs), v.RekorURL)
+	v.ui.BeginLinef("Succeeded\n")
+
+	return nil
+}
+
+// verifyDigestRef checks that the digest has at least one entry in the log,
+// and when an identity constraint was given, that at least one entry carries
+// a certificate matching it
+func (v *VerifyOptions) verifyDigestRef(client rekor.Client, digestRef string) error {
+	digest, err := regname.NewDigest(digestRef)
+	if err != nil {
+		return fmt.Errorf("Parsing '%s': %s", digestRef, err)
+	}
+
+	uuids, err := client.SearchByHash(digest.DigestStr())
+	if err != nil {
+		return err
+	}
+	if len(uuids) == 0 {
+		return fmt.Errorf("No transparency log entry found")
+	}
+
+	if v.CertIdentity == "" {
+		v.ui.BeginLinef("%s: %d log entry(ies)\n", digestRef, len(uuids))
+		return nil
+	}
+
+	for _, uuid := range uuids {
+		entry, err := client.GetEntry(uuid)
+		if err != nil {
+			return err
+		}
+		if entry.MatchesIdentity(v.CertIdentity, v.CertOIDCIssuer) {
+			v.ui.BeginLinef("%s: log entry '%s' signed by '%s'\n", digestRef, entry.UUID, v.CertIdentity)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Found %d log entry(ies) but none signed by '%s'", len(uuids), v.CertIdentity)
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package config provides the imgpkg configuration file that holds default
+// values for command line flags
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ConfigKind kind of the configuration document
+	ConfigKind = "Config"
+	// ConfigAPIVersion apiVersion of the configuration document
+	ConfigAPIVersion = "imgpkg.carvel.dev/v1alpha1"
+
+	// ConfigEnvVar environment variable that overrides the default configuration file location
+	ConfigEnvVar = "IMGPKG_CONFIG"
+)
+
+// Config holds default values for command line flags, keyed by flag name
+// (ex: concurrency, registry-ca-cert-path). Values provided via flags always
+// take precedence over values present in this configuration
+type Config struct {
+	APIVersion string            `json:"apiVersion"` // This generated yaml, but due to lib we need to use `json`
+	Kind       string            `json:"kind"`       // This generated yaml, but due to lib we need to use `json`
+	Defaults   map[string]string `json:"defaults,omitempty"`
+	// Profiles named sets of flag defaults (ex: one per environment) selected
+	// via --profile; profile values override the top level defaults
+	Profiles map[string]map[string]string `json:"profiles,omitempty"`
+}
+
+// NewConfigFromDefaultPath reads the configuration from $IMGPKG_CONFIG when set,
+// falling back to ~/.imgpkg/config.yml. The second return value reports whether
+// a configuration file was found
+func NewConfigFromDefaultPath() (Config, bool, error) {
+	path := os.Getenv(ConfigEnvVar)
+	if path == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return Config{}, false, nil
+		}
+		path = filepath.Join(homeDir, ".imgpkg", "config.yml")
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) && os.Getenv(ConfigEnvVar) == "" {
+			return Config{}, false, nil
+		}
+		return Config{}, false, fmt.Errorf("Checking config path %s: %s", path, err)
+	}
+
+	conf, err := NewConfigFromPath(path)
+	if err != nil {
+		return Config{}, false, err
+	}
+	return conf, true, nil
+}
+
+// NewConfigFromPath reads the configuration present at path
+func NewConfigFromPath(path string) (Config, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("Reading path %s: %s", path, err)
+	}
+
+	conf, err := NewConfigFromBytes(bs)
+	if err != nil {
+		return Config{}, fmt.Errorf("Parsing config %s: %s", path, err)
+	}
+	return conf, nil
+}
+
+// NewConfigFromBytes parses the configuration from data
+func NewConfigFromBytes(data []byte) (Config, error) {
+	var conf Config
+
+	err := yaml.UnmarshalStrict(data, &conf)
+	if err != nil {
+		return conf, fmt.Errorf("Unmarshaling config: %s", err)
+	}
+
+	err = conf.Validate()
+	if err != nil {
+		return conf, fmt.Errorf("Validating config: %s", err)
+	}
+
+	return conf, nil
+}
+
+// FlagDefaults returns the flag defaults to apply, merging the defaults of the
+// named profile over the top level defaults. An empty profile name selects the
+// top level defaults only
+func (c Config) FlagDefaults(profileName string) (map[string]string, error) {
+	defaults := map[string]string{}
+	for name, val := range c.Defaults {
+		defaults[name] = val
+	}
+	if profileName == "" {
+		return defaults, nil
+	}
+
+	profile, found := c.Profiles[profileName]
+	if !found {
+		var known []string
+		for name := range c.Profiles {
+			known = append(known, name)
+		}
+		sort.Strings(known)
+		return nil, fmt.Errorf("Unknown profile '%s' (known profiles: %s)", profileName, strings.Join(known, ", "))
+	}
+
+	for name, val := range profile {
+		defaults[name] = val
+	}
+	return defaults, nil
+}
+
+// Validate checks the configuration document is of a known apiVersion and kind
+func (c Config) Validate() error {
+	if c.APIVersion != ConfigAPIVersion {
+		return fmt.Errorf("Validating apiVersion: Unknown version (known: %s)", ConfigAPIVersion)
+	}
+	if c.Kind != ConfigKind {
+		return fmt.Errorf("Validating kind: Unknown kind (known: %s)", ConfigKind)
+	}
+	return nil
+}
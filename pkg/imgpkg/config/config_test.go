@@ -0,0 +1,107 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/config"
+)
+
+func TestNewConfigFromBytes(t *testing.T) {
+	t.Run("when config is valid, defaults are parsed", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: Config
+defaults:
+  concurrency: "10"
+  registry-retry-count: "3"
+`
+
+		conf, err := config.NewConfigFromBytes([]byte(data))
+		require.NoError(t, err)
+		require.Equal(t, "10", conf.Defaults["concurrency"])
+		require.Equal(t, "3", conf.Defaults["registry-retry-count"])
+	})
+
+	t.Run("when config contains profiles, they are parsed", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: Config
+defaults:
+  concurrency: "10"
+profiles:
+  prod-airgap:
+    concurrency: "3"
+    registry-ca-cert-path: /etc/prod-ca.crt
+`
+
+		conf, err := config.NewConfigFromBytes([]byte(data))
+		require.NoError(t, err)
+		require.Equal(t, "3", conf.Profiles["prod-airgap"]["concurrency"])
+	})
+
+	t.Run("when apiVersion is unknown, it errors", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1beta9
+kind: Config
+`
+
+		_, err := config.NewConfigFromBytes([]byte(data))
+		require.EqualError(t, err, "Validating config: Validating apiVersion: Unknown version (known: imgpkg.carvel.dev/v1alpha1)")
+	})
+
+	t.Run("when kind is unknown, it errors", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: NotConfig
+`
+
+		_, err := config.NewConfigFromBytes([]byte(data))
+		require.EqualError(t, err, "Validating config: Validating kind: Unknown kind (known: Config)")
+	})
+
+	t.Run("when yaml contains keys that are unknown, it errors", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: Config
+another-key: invalid
+`
+
+		_, err := config.NewConfigFromBytes([]byte(data))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `unknown field "another-key"`)
+	})
+}
+
+func TestFlagDefaults(t *testing.T) {
+	conf := config.Config{
+		Defaults: map[string]string{"concurrency": "10", "registry-retry-count": "3"},
+		Profiles: map[string]map[string]string{
+			"prod-airgap": {"concurrency": "3", "registry-ca-cert-path": "/etc/prod-ca.crt"},
+		},
+	}
+
+	t.Run("without a profile, top level defaults apply", func(t *testing.T) {
+		defaults, err := conf.FlagDefaults("")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"concurrency": "10", "registry-retry-count": "3"}, defaults)
+	})
+
+	t.Run("profile values override top level defaults", func(t *testing.T) {
+		defaults, err := conf.FlagDefaults("prod-airgap")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"concurrency":           "3",
+			"registry-retry-count":  "3",
+			"registry-ca-cert-path": "/etc/prod-ca.crt",
+		}, defaults)
+	})
+
+	t.Run("unknown profiles error listing the known ones", func(t *testing.T) {
+		_, err := conf.FlagDefaults("staging")
+		require.EqualError(t, err, "Unknown profile 'staging' (known profiles: prod-airgap)")
+	})
+}
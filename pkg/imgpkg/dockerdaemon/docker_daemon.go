@@ -0,0 +1,160 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package dockerdaemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+const defaultDockerSocket = "/var/run/docker.sock"
+
+// Client talks to a local Docker or Podman daemon over its HTTP API
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewClient builds a Client for the daemon named by DOCKER_HOST, falling back
+// to the default Docker socket (or the Podman user socket when only that one
+// is present)
+func NewClient() (*Client, error) {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = defaultHost()
+	}
+
+	switch {
+	case strings.HasPrefix(host, "unix://"):
+		socketPath := strings.TrimPrefix(host, "unix://")
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+			},
+		}
+		return &Client{httpClient: &http.Client{Transport: transport}, baseURL: "http://localhost"}, nil
+
+	case strings.HasPrefix(host, "tcp://"):
+		return &Client{httpClient: &http.Client{}, baseURL: "http://" + strings.TrimPrefix(host, "tcp://")}, nil
+
+	default:
+		return nil, fmt.Errorf("Unsupported daemon host '%s' (expected unix:// or tcp://)", host)
+	}
+}
+
+// defaultHost prefers the Docker socket, using the Podman user socket only
+// when the Docker one does not exist
+func defaultHost() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		podmanSocket := filepath.Join(runtimeDir, "podman", "podman.sock")
+		_, podmanErr := os.Stat(podmanSocket)
+		_, dockerErr := os.Stat(defaultDockerSocket)
+		if podmanErr == nil && os.IsNotExist(dockerErr) {
+			return "unix://" + podmanSocket
+		}
+	}
+	return "unix://" + defaultDockerSocket
+}
+
+// Image exports the referenced image from the daemon (docker save format) and
+// loads it. The returned cleanup func removes the temporary tarball backing
+// the image, so it must only be called once the image is no longer used
+func (c *Client) Image(ref string) (regv1.Image, func() error, error) {
+	resp, err := c.httpClient.Get(fmt.Sprintf("%s/v1.24/images/%s/get", c.baseURL, url.PathEscape(ref)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Requesting image '%s' from the daemon: %s", ref, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil, fmt.Errorf("Image '%s' does not exist in the daemon", ref)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, nil, fmt.Errorf("Exporting image '%s' from the daemon: %s (%s)", ref, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	tmpFile, err := ioutil.TempFile("", "imgpkg-docker-daemon")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() error { return os.Remove(tmpFile.Name()) }
+
+	_, err = io.Copy(tmpFile, resp.Body)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("Saving image '%s' exported from the daemon: %s", ref, err)
+	}
+
+	img, err := c.loadImage(tmpFile.Name(), ref)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	return img, cleanup, nil
+}
+
+// LoadImage streams the image in docker save format to the daemon's load
+// endpoint, making it available under tagRef
+func (c *Client) LoadImage(tagRef regname.Tag, img regv1.Image) error {
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(tarball.Write(tagRef, img, pipeWriter))
+	}()
+
+	resp, err := c.httpClient.Post(c.baseURL+"/v1.24/images/load?quiet=1", "application/x-tar", pipeReader)
+	if err != nil {
+		return fmt.Errorf("Loading image '%s' into the daemon: %s", tagRef.Name(), err)
+	}
+	defer resp.Body.Close()
+
+	body, readErr := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Loading image '%s' into the daemon: %s (%s)", tagRef.Name(), resp.Status, strings.TrimSpace(string(body)))
+	}
+	if readErr != nil {
+		return fmt.Errorf("Loading image '%s' into the daemon: %s", tagRef.Name(), readErr)
+	}
+
+	// The load endpoint reports failures inside the 200 response stream
+	if strings.Contains(string(body), `"error"`) {
+		return fmt.Errorf("Loading image '%s' into the daemon: %s", tagRef.Name(), strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// loadImage reads the saved tarball, selecting the entry matching ref when
+// the tarball records repo tags (image IDs produce tarballs without them)
+func (c *Client) loadImage(path string, ref string) (regv1.Image, error) {
+	tagRef, err := regname.NewTag(ref, regname.WeakValidation)
+	if err == nil {
+		img, err := tarball.ImageFromPath(path, &tagRef)
+		if err == nil {
+			return img, nil
+		}
+	}
+
+	img, err := tarball.ImageFromPath(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Loading image '%s' exported from the daemon: %s", ref, err)
+	}
+	return img, nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package dockerdaemon
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageExportedFromDaemon(t *testing.T) {
+	srcImg, err := random.Image(1024, 1)
+	require.NoError(t, err)
+
+	tagRef, err := regname.NewTag("myimage:latest")
+	require.NoError(t, err)
+
+	var savedTar bytes.Buffer
+	require.NoError(t, tarball.Write(tagRef, srcImg, &savedTar))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/images/myimage:latest/get") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(savedTar.Bytes())
+	}))
+	defer server.Close()
+
+	t.Setenv("DOCKER_HOST", "tcp://"+strings.TrimPrefix(server.URL, "http://"))
+
+	client, err := NewClient()
+	require.NoError(t, err)
+
+	img, cleanup, err := client.Image("myimage:latest")
+	require.NoError(t, err)
+	defer cleanup()
+
+	srcLayers, err := srcImg.Layers()
+	require.NoError(t, err)
+	layers, err := img.Layers()
+	require.NoError(t, err)
+	require.Len(t, layers, len(srcLayers))
+
+	srcDiffID, err := srcLayers[0].DiffID()
+	require.NoError(t, err)
+	diffID, err := layers[0].DiffID()
+	require.NoError(t, err)
+	assert.Equal(t, srcDiffID, diffID)
+}
+
+func TestLoadImageIntoDaemon(t *testing.T) {
+	srcImg, err := random.Image(1024, 1)
+	require.NoError(t, err)
+
+	tagRef, err := regname.NewTag("myimage:latest")
+	require.NoError(t, err)
+
+	var loadedTar bytes.Buffer
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/images/load") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, err := loadedTar.ReadFrom(r.Body)
+		require.NoError(t, err)
+		w.Write([]byte(`{"stream":"Loaded image: myimage:latest"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("DOCKER_HOST", "tcp://"+strings.TrimPrefix(server.URL, "http://"))
+
+	client, err := NewClient()
+	require.NoError(t, err)
+
+	require.NoError(t, client.LoadImage(tagRef, srcImg))
+
+	loadedImg, err := tarball.Image(func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(loadedTar.Bytes())), nil
+	}, &tagRef)
+	require.NoError(t, err)
+
+	srcDigest, err := srcImg.Digest()
+	require.NoError(t, err)
+	loadedDigest, err := loadedImg.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, srcDigest, loadedDigest)
+}
+
+func TestLoadImageDaemonReportsError(t *testing.T) {
+	srcImg, err := random.Image(1024, 1)
+	require.NoError(t, err)
+
+	tagRef, err := regname.NewTag("myimage:latest")
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"errorDetail":{"message":"no space left"},"error":"no space left"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("DOCKER_HOST", "tcp://"+strings.TrimPrefix(server.URL, "http://"))
+
+	client, err := NewClient()
+	require.NoError(t, err)
+
+	err = client.LoadImage(tagRef, srcImg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no space left")
+}
+
+func TestImageMissingFromDaemon(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	t.Setenv("DOCKER_HOST", "tcp://"+strings.TrimPrefix(server.URL, "http://"))
+
+	client, err := NewClient()
+	require.NoError(t, err)
+
+	_, _, err = client.Image("missing:latest")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Image 'missing:latest' does not exist in the daemon")
+}
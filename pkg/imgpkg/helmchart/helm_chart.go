@@ -0,0 +1,252 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package helmchart packages Helm chart directories as bundles by extracting
+// the image references the chart deploys into an ImagesLock
+package helmchart
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultImagesAnnotation Chart.yaml annotation listing the image references
+// deployed by the chart (a YAML list of strings)
+const DefaultImagesAnnotation = "imgpkg.carvel.dev/images"
+
+// chartManifestFile name of the manifest every Helm chart directory contains
+const chartManifestFile = "Chart.yaml"
+
+// DigestResolver resolves a tag reference to its digest
+type DigestResolver interface {
+	Digest(regname.Reference) (regv1.Hash, error)
+}
+
+type Chart struct {
+	path             string
+	imagesAnnotation string
+}
+
+// NewChart constructor for a Helm chart rooted at path, reading image
+// references from the given Chart.yaml annotation
+func NewChart(path string, imagesAnnotation string) Chart {
+	if imagesAnnotation == "" {
+		imagesAnnotation = DefaultImagesAnnotation
+	}
+	return Chart{path: path, imagesAnnotation: imagesAnnotation}
+}
+
+// IsChartDir reports whether path contains a Helm chart
+func IsChartDir(path string) bool {
+	info, err := os.Stat(filepath.Join(path, chartManifestFile))
+	return err == nil && !info.IsDir()
+}
+
+// Validate errors when the path does not look like a Helm chart directory
+func (c Chart) Validate() error {
+	if !IsChartDir(c.path) {
+		return fmt.Errorf("Expected '%s' to be a Helm chart directory containing %s", c.path, chartManifestFile)
+	}
+	return nil
+}
+
+// ImagesLock extracts the image references deployed by the chart into an
+// ImagesLock, resolving tag references to digests via resolver. References
+// come from the configured Chart.yaml annotation when present, and are
+// otherwise discovered by scanning the chart's values files
+func (c Chart) ImagesLock(resolver DigestResolver) (lockconfig.ImagesLock, error) {
+	imagesLock := lockconfig.NewEmptyImagesLock()
+
+	refs, err := c.annotationRefs()
+	if err != nil {
+		return imagesLock, err
+	}
+
+	if len(refs) == 0 {
+		refs, err = c.valuesRefs()
+		if err != nil {
+			return imagesLock, err
+		}
+	}
+
+	if len(refs) == 0 {
+		return imagesLock, fmt.Errorf("Expected to find image references in the '%s' annotation of %s or in the chart's values files, but found none", c.imagesAnnotation, chartManifestFile)
+	}
+
+	for _, ref := range refs {
+		digestRef, err := resolveToDigest(ref, resolver)
+		if err != nil {
+			return imagesLock, err
+		}
+		imagesLock.AddImageRef(lockconfig.ImageRef{Image: digestRef})
+	}
+
+	return imagesLock, nil
+}
+
+// CopyTo copies the chart directory contents into dstPath
+func (c Chart) CopyTo(dstPath string) error {
+	return filepath.Walk(c.path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(c.path, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dstPath, relPath)
+
+		if info.IsDir() {
+			return os.MkdirAll(dst, info.Mode())
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("Expected chart entry '%s' to be a regular file or directory", path)
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(dst, contents, info.Mode())
+	})
+}
+
+// annotationRefs reads image references from the chart manifest annotation
+// (a YAML list of strings)
+func (c Chart) annotationRefs() ([]string, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(c.path, chartManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	err = yaml.Unmarshal(contents, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing %s: %s", chartManifestFile, err)
+	}
+
+	annotation, found := manifest.Annotations[c.imagesAnnotation]
+	if !found {
+		return nil, nil
+	}
+
+	var refs []string
+	err = yaml.Unmarshal([]byte(annotation), &refs)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing '%s' annotation: %s", c.imagesAnnotation, err)
+	}
+	return refs, nil
+}
+
+// valuesRefs discovers image references in the chart's values files. Strings
+// under an 'image' key are collected, as are maps containing a 'repository'
+// key (combined with 'registry', 'tag' and 'digest' keys when present)
+func (c Chart) valuesRefs() ([]string, error) {
+	var refs []string
+
+	for _, name := range []string{"values.yaml", "values.yml"} {
+		contents, err := ioutil.ReadFile(filepath.Join(c.path, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var values interface{}
+		err = yaml.Unmarshal(contents, &values)
+		if err != nil {
+			return nil, fmt.Errorf("Parsing %s: %s", name, err)
+		}
+
+		refs = append(refs, refsFromValue("", values)...)
+	}
+
+	return dedupRefs(refs), nil
+}
+
+func refsFromValue(key string, value interface{}) []string {
+	var refs []string
+
+	switch typedValue := value.(type) {
+	case string:
+		if key == "image" && strings.Contains(typedValue, "/") {
+			refs = append(refs, typedValue)
+		}
+
+	case map[string]interface{}:
+		if ref, ok := refFromImageMap(typedValue); ok {
+			return []string{ref}
+		}
+		for childKey, childValue := range typedValue {
+			refs = append(refs, refsFromValue(childKey, childValue)...)
+		}
+
+	case []interface{}:
+		for _, item := range typedValue {
+			refs = append(refs, refsFromValue(key, item)...)
+		}
+	}
+
+	return refs
+}
+
+func refFromImageMap(value map[string]interface{}) (string, bool) {
+	repository, ok := value["repository"].(string)
+	if !ok {
+		return "", false
+	}
+
+	ref := repository
+	if registryHost, ok := value["registry"].(string); ok {
+		ref = registryHost + "/" + ref
+	}
+	if digest, ok := value["digest"].(string); ok {
+		return ref + "@" + digest, true
+	}
+	if tag, ok := value["tag"].(string); ok {
+		return ref + ":" + tag, true
+	}
+	return ref, true
+}
+
+func resolveToDigest(ref string, resolver DigestResolver) (string, error) {
+	parsedRef, err := regname.ParseReference(ref, regname.WeakValidation)
+	if err != nil {
+		return "", fmt.Errorf("Parsing image reference '%s': %s", ref, err)
+	}
+
+	if digestRef, ok := parsedRef.(regname.Digest); ok {
+		return digestRef.Name(), nil
+	}
+
+	digest, err := resolver.Digest(parsedRef)
+	if err != nil {
+		return "", fmt.Errorf("Resolving digest for image reference '%s': %s", ref, err)
+	}
+	return parsedRef.Context().Name() + "@" + digest.String(), nil
+}
+
+func dedupRefs(refs []string) []string {
+	var result []string
+	seen := map[string]struct{}{}
+	for _, ref := range refs {
+		if _, found := seen[ref]; found {
+			continue
+		}
+		seen[ref] = struct{}{}
+		result = append(result, ref)
+	}
+	return result
+}
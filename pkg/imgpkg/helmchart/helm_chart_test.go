@@ -0,0 +1,148 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package helmchart_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/helmchart"
+)
+
+const digest1 = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+const digest2 = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+type fakeResolver struct {
+	digests map[string]string
+}
+
+func (r fakeResolver) Digest(ref regname.Reference) (regv1.Hash, error) {
+	digest, found := r.digests[ref.Name()]
+	if !found {
+		return regv1.Hash{}, fmt.Errorf("image '%s' not found", ref.Name())
+	}
+	return regv1.NewHash(digest)
+}
+
+func TestImagesLockFromAnnotation(t *testing.T) {
+	chartDir := t.TempDir()
+	writeChartFile(t, chartDir, "Chart.yaml", fmt.Sprintf(`
+apiVersion: v2
+name: app
+version: 1.0.0
+annotations:
+  imgpkg.carvel.dev/images: |
+    - registry.io/app@%s
+    - registry.io/sidecar:v1
+`, digest1))
+
+	resolver := fakeResolver{digests: map[string]string{"registry.io/sidecar:v1": digest2}}
+
+	imagesLock, err := helmchart.NewChart(chartDir, "").ImagesLock(resolver)
+	require.NoError(t, err)
+
+	var refs []string
+	for _, imgRef := range imagesLock.Images {
+		refs = append(refs, imgRef.Image)
+	}
+	assert.ElementsMatch(t, []string{
+		"registry.io/app@" + digest1,
+		"registry.io/sidecar@" + digest2,
+	}, refs)
+}
+
+func TestImagesLockFromValues(t *testing.T) {
+	chartDir := t.TempDir()
+	writeChartFile(t, chartDir, "Chart.yaml", "apiVersion: v2\nname: app\nversion: 1.0.0\n")
+	writeChartFile(t, chartDir, "values.yaml", fmt.Sprintf(`
+image: registry.io/app@%s
+sidecar:
+  image:
+    registry: registry.io
+    repository: sidecar
+    tag: v1
+replicas: 3
+`, digest1))
+
+	resolver := fakeResolver{digests: map[string]string{"registry.io/sidecar:v1": digest2}}
+
+	imagesLock, err := helmchart.NewChart(chartDir, "").ImagesLock(resolver)
+	require.NoError(t, err)
+
+	var refs []string
+	for _, imgRef := range imagesLock.Images {
+		refs = append(refs, imgRef.Image)
+	}
+	assert.ElementsMatch(t, []string{
+		"registry.io/app@" + digest1,
+		"registry.io/sidecar@" + digest2,
+	}, refs)
+}
+
+func TestImagesLockErrorsWhenNoRefsFound(t *testing.T) {
+	chartDir := t.TempDir()
+	writeChartFile(t, chartDir, "Chart.yaml", "apiVersion: v2\nname: app\nversion: 1.0.0\n")
+	writeChartFile(t, chartDir, "values.yaml", "replicas: 3\n")
+
+	_, err := helmchart.NewChart(chartDir, "").ImagesLock(fakeResolver{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected to find image references")
+}
+
+func TestValidateErrorsForNonChartDir(t *testing.T) {
+	err := helmchart.NewChart(t.TempDir(), "").Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Chart.yaml")
+}
+
+func TestCopyTo(t *testing.T) {
+	chartDir := t.TempDir()
+	writeChartFile(t, chartDir, "Chart.yaml", "apiVersion: v2\nname: app\nversion: 1.0.0\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(chartDir, "templates"), 0700))
+	writeChartFile(t, chartDir, filepath.Join("templates", "deployment.yaml"), "kind: Deployment\n")
+
+	dstDir := t.TempDir()
+	require.NoError(t, helmchart.NewChart(chartDir, "").CopyTo(dstDir))
+
+	assert.FileExists(t, filepath.Join(dstDir, "Chart.yaml"))
+	assert.FileExists(t, filepath.Join(dstDir, "templates", "deployment.yaml"))
+}
+
+func TestWriteRelocatedValuesOverlay(t *testing.T) {
+	chartDir := t.TempDir()
+	writeChartFile(t, chartDir, "values.yaml", fmt.Sprintf("image: registry.io/app@%s\n", digest1))
+
+	overlayPath, err := helmchart.WriteRelocatedValuesOverlay(chartDir, map[string]string{
+		"registry.io/app@" + digest1: "internal.io/relocated@" + digest1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(chartDir, helmchart.RelocatedValuesFile), overlayPath)
+
+	contents, err := os.ReadFile(overlayPath)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("image: internal.io/relocated@%s\n", digest1), string(contents))
+}
+
+func TestWriteRelocatedValuesOverlaySkipsUnchangedValues(t *testing.T) {
+	chartDir := t.TempDir()
+	writeChartFile(t, chartDir, "values.yaml", "replicas: 3\n")
+
+	overlayPath, err := helmchart.WriteRelocatedValuesOverlay(chartDir, map[string]string{
+		"registry.io/app@" + digest1: "internal.io/relocated@" + digest1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "", overlayPath)
+	assert.NoFileExists(t, filepath.Join(chartDir, helmchart.RelocatedValuesFile))
+}
+
+func writeChartFile(t *testing.T, dir string, name string, contents string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0600))
+}
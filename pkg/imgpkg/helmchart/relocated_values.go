@@ -0,0 +1,51 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package helmchart
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RelocatedValuesFile name of the values overlay written next to a relocated
+// chart's values file
+const RelocatedValuesFile = "values-relocated.yml"
+
+// WriteRelocatedValuesOverlay writes a copy of the chart's values file with
+// every original image reference replaced by its relocated location, for use
+// as an additional values file (ex: helm install -f values-relocated.yml).
+// Returns the path of the written overlay, or "" when no reference in the
+// values file needed replacing
+func WriteRelocatedValuesOverlay(chartPath string, replacements map[string]string) (string, error) {
+	for _, name := range []string{"values.yaml", "values.yml"} {
+		valuesPath := filepath.Join(chartPath, name)
+		contents, err := ioutil.ReadFile(valuesPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		updated := string(contents)
+		for origRef, relocatedRef := range replacements {
+			updated = strings.ReplaceAll(updated, origRef, relocatedRef)
+		}
+		if updated == string(contents) {
+			return "", nil
+		}
+
+		overlayPath := filepath.Join(chartPath, RelocatedValuesFile)
+		err = ioutil.WriteFile(overlayPath, []byte(updated), 0600)
+		if err != nil {
+			return "", fmt.Errorf("Writing relocated values overlay: %s", err)
+		}
+		return overlayPath, nil
+	}
+
+	return "", nil
+}
@@ -14,17 +14,32 @@ import (
 
 	goui "github.com/cppforlife/go-cli-ui/ui"
 	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"golang.org/x/text/unicode/norm"
 )
 
 type DirImage struct {
-	dirPath     string
-	img         regv1.Image
-	shouldChown bool
-	ui          goui.UI
+	dirPath          string
+	img              regv1.Image
+	shouldChown      bool
+	strictExtraction bool
+	ui               goui.UI
 }
 
-func NewDirImage(dirPath string, img regv1.Image, ui goui.UI) *DirImage {
-	return &DirImage{dirPath, img, os.Getuid() == 0, ui}
+// DirImageOpt configures how a DirImage extracts layers to disk
+type DirImageOpt func(*DirImage)
+
+// StrictExtraction errors on suspicious tar entries, such as links pointing
+// outside of the output directory, instead of skipping them with a warning
+func StrictExtraction() DirImageOpt {
+	return func(i *DirImage) { i.strictExtraction = true }
+}
+
+func NewDirImage(dirPath string, img regv1.Image, ui goui.UI, opts ...DirImageOpt) *DirImage {
+	dirImage := &DirImage{dirPath: dirPath, img: img, shouldChown: os.Getuid() == 0, ui: ui}
+	for _, opt := range opts {
+		opt(dirImage)
+	}
+	return dirImage
 }
 
 func (i *DirImage) AsDirectory() error {
@@ -51,7 +66,7 @@ func (i *DirImage) AsDirectory() error {
 
 		i.ui.BeginLinef("Extracting layer '%s' (%d/%d)\n", digest, idx+1, len(layers))
 
-		layerStream, err := imgLayer.Uncompressed()
+		layerStream, err := NewUncompressedLayerStream(imgLayer)
 		if err != nil {
 			return err
 		}
@@ -81,7 +96,10 @@ func (i *DirImage) writeLayer(stream io.Reader) error {
 			return err
 		}
 
-		path := filepath.Join(i.dirPath, filepath.Clean(hdr.Name))
+		path := i.entryPath(hdr.Name)
+		if !i.insideOutputDir(path) {
+			return fmt.Errorf("Refusing to extract entry '%s' outside of the output directory", hdr.Name)
+		}
 		base := filepath.Base(path)
 
 		const (
@@ -109,7 +127,7 @@ func (i *DirImage) writeLayer(stream io.Reader) error {
 			}
 		}
 
-		err = i.extractTarEntry(hdr, tarReader)
+		err = i.extractTarEntry(path, hdr, tarReader)
 		if err != nil {
 			return err
 		}
@@ -118,11 +136,37 @@ func (i *DirImage) writeLayer(stream io.Reader) error {
 	return nil
 }
 
+// entryPath builds the on-disk path for a tar entry, rewriting names that
+// cannot be represented on a Windows filesystem and switching to
+// extended-length paths when the result is too long for Windows to address.
+// Names are normalized to NFC so layers written on platforms that store
+// decomposed unicode filenames extract to the same paths everywhere
+func (i *DirImage) entryPath(name string) string {
+	name = norm.NFC.String(name)
+	if runtime.GOOS == "windows" {
+		name = windowsEntryName(name)
+		return extendedLengthPath(filepath.Join(i.dirPath, filepath.Clean(name)))
+	}
+	return filepath.Join(i.dirPath, filepath.Clean(name))
+}
+
+// insideOutputDir reports whether path stays within the output directory once
+// cleaned, guarding against entry names that traverse out of it
+func (i *DirImage) insideOutputDir(path string) bool {
+	rel, err := filepath.Rel(i.dirPath, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 // Taken from https://github.com/concourse/go-archive/blob/f26802964d15194bddb07bf116ea567c56af973f/tarfs/extract.go
 
-func (i *DirImage) extractTarEntry(header *tar.Header, input io.Reader) error {
-	path := filepath.Join(i.dirPath, header.Name)
+func (i *DirImage) extractTarEntry(path string, header *tar.Header, input io.Reader) error {
 	mode := header.FileInfo().Mode()
+	if runtime.GOOS == "windows" {
+		mode = windowsFileMode(header.Typeflag, mode)
+	}
 
 	err := os.MkdirAll(filepath.Dir(path), 0700)
 	if err != nil {
@@ -142,7 +186,7 @@ func (i *DirImage) extractTarEntry(header *tar.Header, input io.Reader) error {
 			return err
 		}
 
-		_, err = io.Copy(file, input)
+		err = copySparse(file, input)
 		if err != nil {
 			_ = file.Close()
 			return err
@@ -153,9 +197,21 @@ func (i *DirImage) extractTarEntry(header *tar.Header, input io.Reader) error {
 			return err
 		}
 
-	case tar.TypeLink, tar.TypeSymlink:
-		// skipping symlinks as a security feature
-		return nil
+	case tar.TypeFifo, tar.TypeChar, tar.TypeBlock:
+		created, err := i.createSpecialFile(path, header)
+		if err != nil {
+			return fmt.Errorf("Creating special file '%s': %s", header.Name, err)
+		}
+		if !created {
+			i.ui.BeginLinef("Skipping special file '%s': cannot be created on this host\n", header.Name)
+			return nil
+		}
+
+	case tar.TypeSymlink:
+		return i.extractSymlink(path, header)
+
+	case tar.TypeLink:
+		return i.extractHardlink(path, header)
 
 	default:
 		return fmt.Errorf("Unsupported tar entry type '%c' for file '%s'", header.Typeflag, header.Name)
@@ -178,6 +234,144 @@ func (i *DirImage) extractTarEntry(header *tar.Header, input io.Reader) error {
 	return lchtimes(header, path)
 }
 
+// extractSymlink materializes a symlink entry. Absolute link targets are
+// rewritten to be relative to the output directory so the extracted tree is
+// self-contained, and links that would resolve outside of the output
+// directory are never created: they are skipped with a warning, or rejected
+// when strict extraction was requested
+func (i *DirImage) extractSymlink(path string, header *tar.Header) error {
+	linkname := header.Linkname
+
+	if filepath.IsAbs(linkname) {
+		rel, err := filepath.Rel(filepath.Dir(path), filepath.Join(i.dirPath, filepath.Clean(linkname)))
+		if err != nil {
+			return i.suspiciousEntry(header, "absolute link target cannot be made relative")
+		}
+		linkname = rel
+	}
+
+	if !i.insideOutputDir(filepath.Join(filepath.Dir(path), linkname)) {
+		return i.suspiciousEntry(header, "link target points outside of the output directory")
+	}
+
+	return i.symlink(linkname, path)
+}
+
+// extractHardlink materializes a hardlink entry. Link targets are interpreted
+// relative to the root of the image, matching how layer tars record them, and
+// targets outside of the output directory are never linked
+func (i *DirImage) extractHardlink(path string, header *tar.Header) error {
+	target := i.entryPath(header.Linkname)
+
+	if !i.insideOutputDir(target) {
+		return i.suspiciousEntry(header, "link target points outside of the output directory")
+	}
+
+	if _, err := os.Lstat(target); err != nil {
+		return i.suspiciousEntry(header, "link target does not exist")
+	}
+
+	return os.Link(target, path)
+}
+
+// createSpecialFile materializes FIFO and device entries where the platform
+// and privileges allow it; device nodes can only be created by root
+func (i *DirImage) createSpecialFile(path string, header *tar.Header) (bool, error) {
+	mode := header.FileInfo().Mode()
+
+	if header.Typeflag == tar.TypeFifo {
+		return createFifo(path, mode)
+	}
+	if !i.shouldChown {
+		return false, nil
+	}
+	return createDevice(path, header.Typeflag, header.Devmajor, header.Devminor, mode)
+}
+
+// copySparse writes src into dst, seeking over blocks that are entirely zero
+// so sparse file contents do not take up space on disk
+func copySparse(dst *os.File, src io.Reader) error {
+	buf := make([]byte, 64*1024)
+	var offset int64
+
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if !allZeros(buf[:n]) {
+				_, werr := dst.WriteAt(buf[:n], offset)
+				if werr != nil {
+					return werr
+				}
+			}
+			offset += int64(n)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// extends the file to its full length when it ends in a zero block
+	return dst.Truncate(offset)
+}
+
+func allZeros(buf []byte) bool {
+	for _, b := range buf {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// symlink creates the symlink, falling back to copying the link target on
+// Windows hosts where symlink creation needs developer mode or elevation
+func (i *DirImage) symlink(linkname, path string) error {
+	err := os.Symlink(linkname, path)
+	if err != nil && runtime.GOOS == "windows" {
+		target := filepath.Join(filepath.Dir(path), linkname)
+		if _, statErr := os.Stat(target); statErr == nil {
+			return copyFile(target, path)
+		}
+		i.ui.BeginLinef("Skipping symlink '%s': %s\n", path, err)
+		return nil
+	}
+	return err
+}
+
+// suspiciousEntry skips an entry that violates the link safety policy, or
+// rejects it when strict extraction was requested
+func (i *DirImage) suspiciousEntry(header *tar.Header, reason string) error {
+	if i.strictExtraction {
+		return fmt.Errorf("Refusing to extract entry '%s': %s", header.Name, reason)
+	}
+	i.ui.BeginLinef("Skipping entry '%s': %s\n", header.Name, reason)
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		_ = out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
 func lchmod(header *tar.Header, path string, mode os.FileMode) error {
 	if header.Typeflag == tar.TypeLink {
 		if fi, err := os.Lstat(header.Linkname); err == nil && (fi.Mode()&os.ModeSymlink == 0) {
@@ -0,0 +1,178 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirImageLinkSafetyPolicy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlink creation is not reliable on Windows test hosts")
+	}
+
+	subject := func(outputPath string, strict bool) *DirImage {
+		dirImage := &DirImage{dirPath: outputPath, strictExtraction: strict, ui: goui.NewNoopUI()}
+		return dirImage
+	}
+
+	t.Run("creates symlinks pointing within the output directory", func(t *testing.T) {
+		outputPath := t.TempDir()
+
+		stream := buildLayerTar(t, []tarEntry{
+			{header: &tar.Header{Name: "file.txt", Typeflag: tar.TypeReg, Mode: 0644}, contents: "contents"},
+			{header: &tar.Header{Name: "link.txt", Typeflag: tar.TypeSymlink, Linkname: "file.txt"}},
+		})
+
+		require.NoError(t, subject(outputPath, false).writeLayer(stream))
+
+		linkname, err := os.Readlink(filepath.Join(outputPath, "link.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "file.txt", linkname)
+	})
+
+	t.Run("rewrites absolute symlink targets to be relative", func(t *testing.T) {
+		outputPath := t.TempDir()
+
+		stream := buildLayerTar(t, []tarEntry{
+			{header: &tar.Header{Name: "file.txt", Typeflag: tar.TypeReg, Mode: 0644}, contents: "contents"},
+			{header: &tar.Header{Name: "nested/link.txt", Typeflag: tar.TypeSymlink, Linkname: "/file.txt"}},
+		})
+
+		require.NoError(t, subject(outputPath, false).writeLayer(stream))
+
+		linkname, err := os.Readlink(filepath.Join(outputPath, "nested", "link.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("..", "file.txt"), linkname)
+	})
+
+	t.Run("skips symlinks escaping the output directory with a warning", func(t *testing.T) {
+		outputPath := t.TempDir()
+
+		stream := buildLayerTar(t, []tarEntry{
+			{header: &tar.Header{Name: "link.txt", Typeflag: tar.TypeSymlink, Linkname: "../../etc/passwd"}},
+		})
+
+		require.NoError(t, subject(outputPath, false).writeLayer(stream))
+		assert.NoFileExists(t, filepath.Join(outputPath, "link.txt"))
+	})
+
+	t.Run("errors on symlinks escaping the output directory when strict", func(t *testing.T) {
+		outputPath := t.TempDir()
+
+		stream := buildLayerTar(t, []tarEntry{
+			{header: &tar.Header{Name: "link.txt", Typeflag: tar.TypeSymlink, Linkname: "../../etc/passwd"}},
+		})
+
+		err := subject(outputPath, true).writeLayer(stream)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Refusing to extract entry 'link.txt'")
+	})
+
+	t.Run("creates hardlinks pointing within the output directory", func(t *testing.T) {
+		outputPath := t.TempDir()
+
+		stream := buildLayerTar(t, []tarEntry{
+			{header: &tar.Header{Name: "file.txt", Typeflag: tar.TypeReg, Mode: 0644}, contents: "contents"},
+			{header: &tar.Header{Name: "link.txt", Typeflag: tar.TypeLink, Linkname: "file.txt"}},
+		})
+
+		require.NoError(t, subject(outputPath, false).writeLayer(stream))
+
+		contents, err := os.ReadFile(filepath.Join(outputPath, "link.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "contents", string(contents))
+	})
+
+	t.Run("errors on hardlinks escaping the output directory when strict", func(t *testing.T) {
+		outputPath := t.TempDir()
+
+		stream := buildLayerTar(t, []tarEntry{
+			{header: &tar.Header{Name: "link.txt", Typeflag: tar.TypeLink, Linkname: "../../etc/passwd"}},
+		})
+
+		err := subject(outputPath, true).writeLayer(stream)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Refusing to extract entry 'link.txt'")
+	})
+
+	t.Run("always rejects entry names escaping the output directory", func(t *testing.T) {
+		outputPath := t.TempDir()
+
+		stream := buildLayerTar(t, []tarEntry{
+			{header: &tar.Header{Name: "../evil.txt", Typeflag: tar.TypeReg, Mode: 0644}, contents: "contents"},
+		})
+
+		err := subject(outputPath, false).writeLayer(stream)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Refusing to extract entry '../evil.txt'")
+	})
+}
+
+func TestDirImageSpecialFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("special files cannot be created on Windows test hosts")
+	}
+
+	t.Run("creates FIFO entries", func(t *testing.T) {
+		outputPath := t.TempDir()
+
+		stream := buildLayerTar(t, []tarEntry{
+			{header: &tar.Header{Name: "some.pipe", Typeflag: tar.TypeFifo, Mode: 0644}},
+		})
+
+		dirImage := &DirImage{dirPath: outputPath, ui: goui.NewNoopUI()}
+		require.NoError(t, dirImage.writeLayer(stream))
+
+		info, err := os.Lstat(filepath.Join(outputPath, "some.pipe"))
+		require.NoError(t, err)
+		assert.NotZero(t, info.Mode()&os.ModeNamedPipe)
+	})
+
+	t.Run("extracts files with long zero runs without losing contents", func(t *testing.T) {
+		outputPath := t.TempDir()
+
+		contents := make([]byte, 256*1024)
+		copy(contents, "start")
+		copy(contents[len(contents)-4:], "end!")
+
+		stream := buildLayerTar(t, []tarEntry{
+			{header: &tar.Header{Name: "sparse.bin", Typeflag: tar.TypeReg, Mode: 0644}, contents: string(contents)},
+		})
+
+		dirImage := &DirImage{dirPath: outputPath, ui: goui.NewNoopUI()}
+		require.NoError(t, dirImage.writeLayer(stream))
+
+		extracted, err := os.ReadFile(filepath.Join(outputPath, "sparse.bin"))
+		require.NoError(t, err)
+		assert.Equal(t, contents, extracted)
+	})
+}
+
+type tarEntry struct {
+	header   *tar.Header
+	contents string
+}
+
+func buildLayerTar(t *testing.T, entries []tarEntry) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, entry := range entries {
+		entry.header.Size = int64(len(entry.contents))
+		require.NoError(t, tw.WriteHeader(entry.header))
+		_, err := tw.Write([]byte(entry.contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf
+}
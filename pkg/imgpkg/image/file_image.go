@@ -14,6 +14,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 )
 
@@ -37,8 +38,40 @@ func NewFileImage(path string, labels map[string]string) (*FileImage, error) {
 		return nil, err
 	}
 
+	return newFileImage(path, layer, nil, labels)
+}
+
+// NewEstargzFileImage builds a single-layer image like NewFileImage, but
+// compresses the layer in the eStargz format (seekable gzip with a TOC) so
+// the pushed image stays lazily pullable by stargz snapshotters. The TOC
+// digest annotation is recorded on the layer's manifest descriptor
+func NewEstargzFileImage(path string, labels map[string]string) (*FileImage, error) {
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return os.Open(path)
+	}, tarball.WithEstargz)
+	if err != nil {
+		return nil, fmt.Errorf("Building eStargz layer: %s", err)
+	}
+
+	var annotations map[string]string
+	if layerWithDesc, ok := layer.(interface {
+		Descriptor() (*v1.Descriptor, error)
+	}); ok {
+		desc, err := layerWithDesc.Descriptor()
+		if err == nil && desc != nil {
+			annotations = desc.Annotations
+		}
+	}
+
+	return newFileImage(path, layer, annotations, labels)
+}
+
+// newFileImage appends the layer to an empty image along with the shared
+// imgpkg history entry and labels
+func newFileImage(path string, layer v1.Layer, annotations map[string]string, labels map[string]string) (*FileImage, error) {
 	add := mutate.Addendum{
-		Layer: layer,
+		Layer:       layer,
+		Annotations: annotations,
 		History: v1.History{
 			Author:    "imgpkg",
 			CreatedBy: "imgpkg",
@@ -72,6 +105,27 @@ func (i *FileImage) Remove() error {
 	return os.Remove(i.path)
 }
 
+// SetPlatform records the platform in the image config, used when the image
+// is one entry of a multi-platform image index
+func (i *FileImage) SetPlatform(platform *v1.Platform) error {
+	cfg, err := i.ConfigFile()
+	if err != nil {
+		return fmt.Errorf("Fetching image config: %s", err)
+	}
+
+	cfg = cfg.DeepCopy()
+	cfg.OS = platform.OS
+	cfg.Architecture = platform.Architecture
+
+	img, err := mutate.ConfigFile(i.Image, cfg)
+	if err != nil {
+		return err
+	}
+
+	i.Image = img
+	return nil
+}
+
 func sha256Path(path string) (string, error) {
 	file, err := os.Open(path)
 	if err != nil {
@@ -0,0 +1,63 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/stargz-snapshotter/estargz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEstargzFileImage(t *testing.T) {
+	tarPath := filepath.Join(t.TempDir(), "layer.tar")
+	tarFile, err := os.Create(tarPath)
+	require.NoError(t, err)
+
+	tarWriter := tar.NewWriter(tarFile)
+	contents := []byte("file contents\n")
+	require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "config.yml", Size: int64(len(contents)), Mode: 0600}))
+	_, err = tarWriter.Write(contents)
+	require.NoError(t, err)
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, tarFile.Close())
+
+	img, err := NewEstargzFileImage(tarPath, nil)
+	require.NoError(t, err)
+
+	manifest, err := img.Manifest()
+	require.NoError(t, err)
+	require.Len(t, manifest.Layers, 1)
+	assert.Contains(t, manifest.Layers[0].Annotations, estargz.TOCJSONDigestAnnotation)
+
+	layers, err := img.Layers()
+	require.NoError(t, err)
+	require.Len(t, layers, 1)
+
+	stream, err := layers[0].Uncompressed()
+	require.NoError(t, err)
+	defer stream.Close()
+
+	var entryNames []string
+	tarReader := tar.NewReader(stream)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		entryNames = append(entryNames, header.Name)
+		_, err = io.Copy(ioutil.Discard, tarReader)
+		require.NoError(t, err)
+	}
+
+	assert.Contains(t, entryNames, "config.yml")
+	assert.Contains(t, entryNames, estargz.TOCTarName)
+}
@@ -0,0 +1,224 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	regtypes "github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// dockerToOCIMediaTypes maps each Docker media type to its OCI equivalent
+var dockerToOCIMediaTypes = map[regtypes.MediaType]regtypes.MediaType{
+	regtypes.DockerManifestList:      regtypes.OCIImageIndex,
+	regtypes.DockerManifestSchema2:   regtypes.OCIManifestSchema1,
+	regtypes.DockerConfigJSON:        regtypes.OCIConfigJSON,
+	regtypes.DockerLayer:             regtypes.OCILayer,
+	regtypes.DockerUncompressedLayer: regtypes.OCIUncompressedLayer,
+	regtypes.DockerForeignLayer:      regtypes.OCIRestrictedLayer,
+}
+
+// ociMediaType returns the OCI equivalent of mt, or mt itself when it is not
+// a Docker media type
+func ociMediaType(mt regtypes.MediaType) regtypes.MediaType {
+	if oci, found := dockerToOCIMediaTypes[mt]; found {
+		return oci
+	}
+	return mt
+}
+
+// NormalizeToOCI returns img with its manifest rewritten to use OCI media
+// types, for destination registries that require OCI content. Only the
+// manifest bytes change (config and layer blobs are untouched), but the image
+// digest does change. Reports false when the image already uses OCI types
+func NormalizeToOCI(img regv1.Image) (regv1.Image, bool, error) {
+	mediaType, err := img.MediaType()
+	if err != nil {
+		return nil, false, err
+	}
+	if mediaType != regtypes.DockerManifestSchema2 {
+		return img, false, nil
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, false, err
+	}
+
+	newManifest := manifest.DeepCopy()
+	newManifest.MediaType = ociMediaType(newManifest.MediaType)
+	newManifest.Config.MediaType = ociMediaType(newManifest.Config.MediaType)
+	for i, layer := range newManifest.Layers {
+		newManifest.Layers[i].MediaType = ociMediaType(layer.MediaType)
+	}
+
+	rawManifest, err := json.Marshal(newManifest)
+	if err != nil {
+		return nil, false, fmt.Errorf("Marshaling normalized manifest: %s", err)
+	}
+
+	digest, _, err := regv1.SHA256(bytes.NewReader(rawManifest))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return ociNormalizedImage{Image: img, rawManifest: rawManifest, manifest: newManifest, digest: digest}, true, nil
+}
+
+// NormalizeIndexToOCI returns idx rebuilt as an OCI image index with every
+// Docker image it references normalized as well, updating the child
+// descriptors to the converted digests. Reports false when the index is not a
+// Docker manifest list
+func NormalizeIndexToOCI(idx regv1.ImageIndex) (regv1.ImageIndex, bool, error) {
+	mediaType, err := idx.MediaType()
+	if err != nil {
+		return nil, false, err
+	}
+	if mediaType != regtypes.DockerManifestList {
+		return idx, false, nil
+	}
+
+	indexManifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, false, err
+	}
+
+	newIndexManifest := indexManifest.DeepCopy()
+	newIndexManifest.MediaType = regtypes.OCIImageIndex
+
+	images := map[regv1.Hash]regv1.Image{}
+	indexes := map[regv1.Hash]regv1.ImageIndex{}
+
+	for i, desc := range newIndexManifest.Manifests {
+		switch {
+		case desc.MediaType.IsIndex():
+			childIdx, err := idx.ImageIndex(desc.Digest)
+			if err != nil {
+				return nil, false, err
+			}
+			newChild, _, err := NormalizeIndexToOCI(childIdx)
+			if err != nil {
+				return nil, false, err
+			}
+			rawChild, err := newChild.RawManifest()
+			if err != nil {
+				return nil, false, err
+			}
+			childDigest, _, err := regv1.SHA256(bytes.NewReader(rawChild))
+			if err != nil {
+				return nil, false, err
+			}
+			newIndexManifest.Manifests[i].MediaType = ociMediaType(desc.MediaType)
+			newIndexManifest.Manifests[i].Digest = childDigest
+			newIndexManifest.Manifests[i].Size = int64(len(rawChild))
+			indexes[childDigest] = newChild
+
+		case desc.MediaType.IsImage():
+			childImg, err := idx.Image(desc.Digest)
+			if err != nil {
+				return nil, false, err
+			}
+			newChild, _, err := NormalizeToOCI(childImg)
+			if err != nil {
+				return nil, false, err
+			}
+			rawChild, err := newChild.RawManifest()
+			if err != nil {
+				return nil, false, err
+			}
+			childDigest, _, err := regv1.SHA256(bytes.NewReader(rawChild))
+			if err != nil {
+				return nil, false, err
+			}
+			newIndexManifest.Manifests[i].MediaType = ociMediaType(desc.MediaType)
+			newIndexManifest.Manifests[i].Digest = childDigest
+			newIndexManifest.Manifests[i].Size = int64(len(rawChild))
+			images[childDigest] = newChild
+
+		default:
+			// non image content (ex: attestations) passes through untouched
+		}
+	}
+
+	rawManifest, err := json.Marshal(newIndexManifest)
+	if err != nil {
+		return nil, false, fmt.Errorf("Marshaling normalized index manifest: %s", err)
+	}
+
+	digest, _, err := regv1.SHA256(bytes.NewReader(rawManifest))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return ociNormalizedIndex{
+		orig:          idx,
+		rawManifest:   rawManifest,
+		indexManifest: newIndexManifest,
+		digest:        digest,
+		images:        images,
+		indexes:       indexes,
+	}, true, nil
+}
+
+// ociNormalizedImage image whose manifest was rewritten with OCI media types;
+// everything except the manifest delegates to the original image
+type ociNormalizedImage struct {
+	regv1.Image
+
+	rawManifest []byte
+	manifest    *regv1.Manifest
+	digest      regv1.Hash
+}
+
+func (i ociNormalizedImage) MediaType() (regtypes.MediaType, error) { return i.manifest.MediaType, nil }
+func (i ociNormalizedImage) RawManifest() ([]byte, error)           { return i.rawManifest, nil }
+func (i ociNormalizedImage) Manifest() (*regv1.Manifest, error)     { return i.manifest, nil }
+func (i ociNormalizedImage) Digest() (regv1.Hash, error)            { return i.digest, nil }
+func (i ociNormalizedImage) Size() (int64, error)                   { return int64(len(i.rawManifest)), nil }
+
+// ociNormalizedIndex index rebuilt with OCI media types whose children are
+// served under their converted digests
+type ociNormalizedIndex struct {
+	orig regv1.ImageIndex
+
+	rawManifest   []byte
+	indexManifest *regv1.IndexManifest
+	digest        regv1.Hash
+
+	images  map[regv1.Hash]regv1.Image
+	indexes map[regv1.Hash]regv1.ImageIndex
+}
+
+var _ regv1.ImageIndex = ociNormalizedIndex{}
+
+func (i ociNormalizedIndex) MediaType() (regtypes.MediaType, error) {
+	return i.indexManifest.MediaType, nil
+}
+
+func (i ociNormalizedIndex) RawManifest() ([]byte, error) { return i.rawManifest, nil }
+
+func (i ociNormalizedIndex) IndexManifest() (*regv1.IndexManifest, error) {
+	return i.indexManifest, nil
+}
+
+func (i ociNormalizedIndex) Digest() (regv1.Hash, error) { return i.digest, nil }
+
+func (i ociNormalizedIndex) Size() (int64, error) { return int64(len(i.rawManifest)), nil }
+
+func (i ociNormalizedIndex) Image(h regv1.Hash) (regv1.Image, error) {
+	if img, found := i.images[h]; found {
+		return img, nil
+	}
+	return i.orig.Image(h)
+}
+
+func (i ociNormalizedIndex) ImageIndex(h regv1.Hash) (regv1.ImageIndex, error) {
+	if idx, found := i.indexes[h]; found {
+		return idx, nil
+	}
+	return i.orig.ImageIndex(h)
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	regtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeToOCI(t *testing.T) {
+	img, err := random.Image(512, 2)
+	require.NoError(t, err)
+
+	origMediaType, err := img.MediaType()
+	require.NoError(t, err)
+	require.Equal(t, regtypes.DockerManifestSchema2, origMediaType)
+	origDigest, err := img.Digest()
+	require.NoError(t, err)
+
+	newImg, normalized, err := NormalizeToOCI(img)
+	require.NoError(t, err)
+	require.True(t, normalized)
+
+	newMediaType, err := newImg.MediaType()
+	require.NoError(t, err)
+	assert.Equal(t, regtypes.OCIManifestSchema1, newMediaType)
+
+	manifest, err := newImg.Manifest()
+	require.NoError(t, err)
+	assert.Equal(t, regtypes.OCIConfigJSON, manifest.Config.MediaType)
+	for _, layer := range manifest.Layers {
+		assert.Equal(t, regtypes.OCILayer, layer.MediaType)
+	}
+
+	newDigest, err := newImg.Digest()
+	require.NoError(t, err)
+	assert.NotEqual(t, origDigest, newDigest)
+
+	// config and layer blobs must be untouched
+	origConfig, err := img.ConfigName()
+	require.NoError(t, err)
+	newConfig, err := newImg.ConfigName()
+	require.NoError(t, err)
+	assert.Equal(t, origConfig, newConfig)
+}
+
+func TestNormalizeToOCIPassesThroughOCIImages(t *testing.T) {
+	img, err := random.Image(512, 1)
+	require.NoError(t, err)
+	ociImg := mutate.MediaType(img, regtypes.OCIManifestSchema1)
+
+	newImg, normalized, err := NormalizeToOCI(ociImg)
+	require.NoError(t, err)
+	assert.False(t, normalized)
+	assert.Equal(t, ociImg, newImg)
+}
+
+func TestNormalizeIndexToOCI(t *testing.T) {
+	idx, err := random.Index(512, 1, 2)
+	require.NoError(t, err)
+	dockerIdx := mutate.IndexMediaType(idx, regtypes.DockerManifestList)
+
+	newIdx, normalized, err := NormalizeIndexToOCI(dockerIdx)
+	require.NoError(t, err)
+	require.True(t, normalized)
+
+	newMediaType, err := newIdx.MediaType()
+	require.NoError(t, err)
+	assert.Equal(t, regtypes.OCIImageIndex, newMediaType)
+
+	indexManifest, err := newIdx.IndexManifest()
+	require.NoError(t, err)
+	require.Len(t, indexManifest.Manifests, 2)
+
+	// children must be reachable under their converted digests
+	for _, desc := range indexManifest.Manifests {
+		childImg, err := newIdx.Image(desc.Digest)
+		require.NoError(t, err)
+
+		childMediaType, err := childImg.MediaType()
+		require.NoError(t, err)
+		assert.Equal(t, regtypes.OCIManifestSchema1, childMediaType)
+	}
+}
+
+func TestNormalizeIndexToOCIPassesThroughOCIIndexes(t *testing.T) {
+	idx, err := random.Index(512, 1, 1)
+	require.NoError(t, err)
+
+	mediaType, err := idx.MediaType()
+	require.NoError(t, err)
+	require.Equal(t, regtypes.OCIImageIndex, mediaType)
+
+	newIdx, normalized, err := NormalizeIndexToOCI(idx)
+	require.NoError(t, err)
+	assert.False(t, normalized)
+	assert.Equal(t, idx, newIdx)
+}
@@ -0,0 +1,10 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+// mkdev combines major and minor device numbers the way the Darwin kernel
+// expects them
+func mkdev(major, minor int64) int {
+	return int((major << 24) | minor)
+}
@@ -0,0 +1,21 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package image
+
+import (
+	"os"
+)
+
+// createFifo reports that named pipes cannot be created on this platform
+func createFifo(path string, mode os.FileMode) (bool, error) {
+	return false, nil
+}
+
+// createDevice reports that device nodes cannot be created on this platform
+func createDevice(path string, typeflag byte, devmajor, devminor int64, mode os.FileMode) (bool, error) {
+	return false, nil
+}
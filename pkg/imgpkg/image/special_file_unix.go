@@ -0,0 +1,38 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux || darwin
+// +build linux darwin
+
+package image
+
+import (
+	"archive/tar"
+	"os"
+	"syscall"
+)
+
+// createFifo creates a named pipe at path
+func createFifo(path string, mode os.FileMode) (bool, error) {
+	err := syscall.Mkfifo(path, uint32(mode.Perm()))
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// createDevice creates a character or block device node at path
+func createDevice(path string, typeflag byte, devmajor, devminor int64, mode os.FileMode) (bool, error) {
+	devmode := uint32(mode.Perm())
+	if typeflag == tar.TypeChar {
+		devmode |= syscall.S_IFCHR
+	} else {
+		devmode |= syscall.S_IFBLK
+	}
+
+	err := syscall.Mknod(path, devmode, mkdev(devmajor, devminor))
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
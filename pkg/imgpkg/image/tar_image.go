@@ -10,17 +10,54 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 type TarImage struct {
 	files        []string
 	excludePaths []string
 	infoLog      io.Writer
+	reproducible bool
+	estargz      bool
+	includeVCS   bool
+
+	derefSymlinks  bool
+	strictSymlinks bool
 }
 
 func NewTarImage(files []string, excludePaths []string, infoLog io.Writer) *TarImage {
-	return &TarImage{files, excludePaths, infoLog}
+	return &TarImage{files: files, excludePaths: excludePaths, infoLog: infoLog}
+}
+
+// NewReproducibleTarImage constructor for TarImage that normalizes file modes
+// and zeroes timestamps so the same content always produces the same layer
+func NewReproducibleTarImage(files []string, excludePaths []string, infoLog io.Writer) *TarImage {
+	return &TarImage{files: files, excludePaths: excludePaths, infoLog: infoLog, reproducible: true}
+}
+
+// SetEstargz when enabled, AsFileImage compresses the layer in the eStargz
+// format so the image stays lazily pullable by stargz snapshotters
+func (i *TarImage) SetEstargz(enabled bool) {
+	i.estargz = enabled
+}
+
+// SetIncludeVCS when enabled, VCS and editor artifacts (.git, .svn,
+// .DS_Store, swap files) are kept instead of being excluded by default
+func (i *TarImage) SetIncludeVCS(enabled bool) {
+	i.includeVCS = enabled
+}
+
+// SetDerefSymlinks when enabled, symlinks are followed and the target's
+// contents embedded instead of failing the push. Symlink loops are detected
+// while resolving; with strict set, links pointing outside the pushed
+// directory are an error
+func (i *TarImage) SetDerefSymlinks(enabled bool, strict bool) {
+	i.derefSymlinks = enabled
+	i.strictSymlinks = strict
 }
 
 func (i *TarImage) AsFileImage(labels map[string]string) (*FileImage, error) {
@@ -43,7 +80,12 @@ func (i *TarImage) AsFileImage(labels map[string]string) (*FileImage, error) {
 		return nil, err
 	}
 
-	fileImg, err := NewFileImage(tmpFile.Name(), labels)
+	newFileImage := NewFileImage
+	if i.estargz {
+		newFileImage = NewEstargzFileImage
+	}
+
+	fileImg, err := newFileImage(tmpFile.Name(), labels)
 	if err != nil {
 		_ = os.Remove(tmpFile.Name())
 		return nil, err
@@ -52,14 +94,60 @@ func (i *TarImage) AsFileImage(labels map[string]string) (*FileImage, error) {
 	return fileImg, nil
 }
 
+type tarballEntry struct {
+	fullPath string
+	relPath  string
+	info     os.FileInfo
+	dir      bool
+}
+
 func (i *TarImage) createTarball(file *os.File, filePaths []string) error {
+	entries, err := i.collectEntries(filePaths)
+	if err != nil {
+		return err
+	}
+
 	tarWriter := tar.NewWriter(file)
 	defer tarWriter.Close()
 
+	for _, entry := range entries {
+		if entry.dir {
+			err = i.addDirToTar(entry.relPath, entry.info, tarWriter)
+		} else {
+			err = i.addFileToTar(entry.fullPath, entry.relPath, entry.info, tarWriter)
+		}
+		if err != nil {
+			return fmt.Errorf("Adding file '%s' to tar: %s", entry.relPath, err)
+		}
+	}
+
+	return nil
+}
+
+// collectEntries walks the provided paths in order recording a single entry
+// per image path; when several inputs provide the same path the entry from
+// the later input wins (overlay semantics), keeping the position of the
+// first occurrence
+func (i *TarImage) collectEntries(filePaths []string) ([]tarballEntry, error) {
+	var entries []tarballEntry
+	entryIdx := map[string]int{}
+
+	add := func(entry tarballEntry) {
+		// Normalize names to NFC so files created on platforms that store
+		// decomposed unicode filenames (e.g. macOS) round-trip consistently
+		entry.relPath = norm.NFC.String(entry.relPath)
+		if idx, found := entryIdx[entry.relPath]; found {
+			entries[idx] = entry
+			return
+		}
+		entryIdx[entry.relPath] = len(entries)
+		entries = append(entries, entry)
+	}
+
 	for _, path := range filePaths {
 		info, err := os.Stat(path)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if info.IsDir() {
@@ -76,25 +164,78 @@ func (i *TarImage) createTarball(file *os.File, filePaths []string) error {
 					if i.isExcluded(relPath) {
 						return filepath.SkipDir
 					}
-					return i.addDirToTar(relPath, info, tarWriter)
+					add(tarballEntry{relPath: relPath, info: info, dir: true})
+					return nil
 				}
 				if (info.Mode() & os.ModeType) != 0 {
+					if info.Mode()&os.ModeSymlink != 0 {
+						if !i.derefSymlinks {
+							return fmt.Errorf("Expected file '%s' to be a regular file (hint: Use --dereference-symlinks to follow symlinks)", walkedPath)
+						}
+
+						entry, err := i.resolveSymlink(path, walkedPath, relPath)
+						if err != nil {
+							return err
+						}
+						add(entry)
+						return nil
+					}
+					if isSpecialFile(info.Mode()) {
+						i.infoLog.Write([]byte(fmt.Sprintf("skipping special file: %s\n", relPath)))
+						return nil
+					}
 					return fmt.Errorf("Expected file '%s' to be a regular file", walkedPath)
 				}
-				return i.addFileToTar(walkedPath, relPath, info, tarWriter)
+				add(tarballEntry{fullPath: walkedPath, relPath: relPath, info: info})
+				return nil
 			})
 			if err != nil {
-				return fmt.Errorf("Adding file '%s' to tar: %s", path, err)
+				return nil, fmt.Errorf("Adding file '%s' to tar: %s", path, err)
 			}
 		} else {
-			err := i.addFileToTar(path, filepath.Base(path), info, tarWriter)
-			if err != nil {
-				return err
-			}
+			add(tarballEntry{fullPath: path, relPath: filepath.Base(path), info: info})
 		}
 	}
 
-	return nil
+	return entries, nil
+}
+
+// resolveSymlink follows the symlink at walkedPath so the target's contents
+// are embedded under the link's path. Resolution fails on symlink loops; with
+// strict symlinks set, targets outside the pushed directory are an error
+func (i *TarImage) resolveSymlink(rootPath, walkedPath, relPath string) (tarballEntry, error) {
+	targetPath, err := filepath.EvalSymlinks(walkedPath)
+	if err != nil {
+		return tarballEntry{}, fmt.Errorf("Resolving symlink '%s': %s", walkedPath, err)
+	}
+
+	if i.strictSymlinks {
+		resolvedRoot, err := filepath.EvalSymlinks(rootPath)
+		if err != nil {
+			return tarballEntry{}, err
+		}
+		absRoot, err := filepath.Abs(resolvedRoot)
+		if err != nil {
+			return tarballEntry{}, err
+		}
+		absTarget, err := filepath.Abs(targetPath)
+		if err != nil {
+			return tarballEntry{}, err
+		}
+		if absTarget != absRoot && !strings.HasPrefix(absTarget, absRoot+string(os.PathSeparator)) {
+			return tarballEntry{}, fmt.Errorf("Symlink '%s' points outside the pushed directory: '%s'", walkedPath, targetPath)
+		}
+	}
+
+	targetInfo, err := os.Stat(targetPath)
+	if err != nil {
+		return tarballEntry{}, fmt.Errorf("Resolving symlink '%s': %s", walkedPath, err)
+	}
+	if targetInfo.IsDir() {
+		return tarballEntry{}, fmt.Errorf("Expected symlink '%s' to point at a file, but it points at directory '%s'", walkedPath, targetPath)
+	}
+
+	return tarballEntry{fullPath: targetPath, relPath: relPath, info: targetInfo}, nil
 }
 
 func (i *TarImage) addDirToTar(relPath string, info os.FileInfo, tarWriter *tar.Writer) error {
@@ -104,11 +245,19 @@ func (i *TarImage) addDirToTar(relPath string, info os.FileInfo, tarWriter *tar.
 
 	i.infoLog.Write([]byte(fmt.Sprintf("dir: %s\n", relPath)))
 
+	mode := int64(info.Mode().Perm())
+	modTime := info.ModTime()
+	if i.reproducible {
+		mode = 0700
+		modTime = time.Time{}
+	}
+
 	header := &tar.Header{
 		Name:     relPath,
-		Mode:     0700,        // static
-		ModTime:  time.Time{}, // static
+		Mode:     mode,
+		ModTime:  modTime,
 		Typeflag: tar.TypeDir,
+		Format:   tar.FormatPAX, // supports paths beyond USTAR limits
 	}
 
 	return tarWriter.WriteHeader(header)
@@ -128,12 +277,18 @@ func (i *TarImage) addFileToTar(fullPath, relPath string, info os.FileInfo, tarW
 
 	defer file.Close()
 
+	modTime := info.ModTime()
+	if i.reproducible {
+		modTime = time.Time{}
+	}
+
 	header := &tar.Header{
 		Name:     relPath,
 		Size:     info.Size(),
-		Mode:     int64(info.Mode() & 0700), // static
-		ModTime:  time.Time{},               // static
+		Mode:     i.tarFileMode(info, relPath),
+		ModTime:  modTime,
 		Typeflag: tar.TypeReg,
+		Format:   tar.FormatPAX, // supports paths beyond USTAR limits
 	}
 
 	err = tarWriter.WriteHeader(header)
@@ -145,11 +300,65 @@ func (i *TarImage) addFileToTar(fullPath, relPath string, info os.FileInfo, tarW
 	return err
 }
 
+// tarFileMode returns the permissions recorded for a file entry. In
+// reproducible mode permissions are masked to the owner bits. On Windows
+// os.FileInfo carries no exec bits, so executability follows a file
+// extension convention instead
+func (i *TarImage) tarFileMode(info os.FileInfo, relPath string) int64 {
+	if i.reproducible {
+		return int64(info.Mode() & 0700)
+	}
+
+	mode := info.Mode().Perm()
+	if runtime.GOOS == "windows" {
+		mode = 0644
+		if info.Mode()&0222 == 0 {
+			mode = 0444
+		}
+		if isWindowsExecutable(relPath) {
+			mode |= 0111
+		}
+	}
+	return int64(mode)
+}
+
+// windowsExecutableExtensions file extensions treated as executable when
+// building layers on Windows hosts
+var windowsExecutableExtensions = map[string]struct{}{
+	".bat": {}, ".cmd": {}, ".com": {}, ".exe": {}, ".ps1": {},
+}
+
+func isWindowsExecutable(relPath string) bool {
+	_, found := windowsExecutableExtensions[strings.ToLower(filepath.Ext(relPath))]
+	return found
+}
+
+// isSpecialFile reports whether mode describes a FIFO, socket, or device
+// node; such entries cannot be pushed as regular file contents and are
+// skipped with a warning
+func isSpecialFile(mode os.FileMode) bool {
+	return (mode & (os.ModeDevice | os.ModeCharDevice | os.ModeNamedPipe | os.ModeSocket)) != 0
+}
+
 func (i *TarImage) isExcluded(relPath string) bool {
 	for _, path := range i.excludePaths {
 		if path == relPath {
 			return true
 		}
 	}
+	if !i.includeVCS && isVCSArtifact(filepath.Base(relPath)) {
+		return true
+	}
 	return false
 }
+
+// isVCSArtifact reports whether the file or directory name is a VCS or editor
+// artifact that routinely leaks into bundles and changes digests between
+// checkouts
+func isVCSArtifact(base string) bool {
+	switch base {
+	case ".git", ".svn", ".hg", ".DS_Store":
+		return true
+	}
+	return strings.HasSuffix(base, ".swp") || strings.HasSuffix(base, ".swo") || strings.HasSuffix(base, "~")
+}
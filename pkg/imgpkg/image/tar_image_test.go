@@ -0,0 +1,282 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarImageOverlaySemantics(t *testing.T) {
+	dir1 := t.TempDir()
+	dir2 := t.TempDir()
+
+	writeTarImageTestFile(t, dir1, filepath.Join("config", "a.yml"), "from-dir1")
+	writeTarImageTestFile(t, dir1, filepath.Join("config", "b.yml"), "only-dir1")
+	writeTarImageTestFile(t, dir2, filepath.Join("config", "a.yml"), "from-dir2")
+	writeTarImageTestFile(t, dir2, filepath.Join("config", "nested", "c.yml"), "only-dir2")
+
+	entries := readTarImageEntries(t, []string{dir1, dir2})
+
+	t.Run("later inputs win for conflicting paths", func(t *testing.T) {
+		contents, found := entries[filepath.Join("config", "a.yml")]
+		require.True(t, found)
+		assert.Equal(t, "from-dir2", contents)
+	})
+
+	t.Run("non conflicting paths from all inputs are merged", func(t *testing.T) {
+		assert.Equal(t, "only-dir1", entries[filepath.Join("config", "b.yml")])
+		assert.Equal(t, "only-dir2", entries[filepath.Join("config", "nested", "c.yml")])
+	})
+
+	t.Run("conflicting paths produce a single tar entry", func(t *testing.T) {
+		assert.Equal(t, 1, tarImageEntryCount(t, []string{dir1, dir2}, filepath.Join("config", "a.yml")))
+	})
+}
+
+func TestTarImagePreservesMetadata(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTarImageTestFile(t, dir, filepath.Join("config", "a.yml"), "contents")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "empty"), 0700))
+
+	mTime := time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "config", "a.yml"), mTime, mTime))
+	require.NoError(t, os.Chmod(filepath.Join(dir, "config", "a.yml"), 0754))
+
+	headers := map[string]*tar.Header{}
+	forEachTarImageEntry(t, NewTarImage([]string{dir}, nil, &bytes.Buffer{}), []string{dir}, func(header *tar.Header, _ io.Reader) {
+		headers[header.Name] = header
+	})
+
+	t.Run("empty directories are preserved", func(t *testing.T) {
+		header, found := headers["empty"]
+		require.True(t, found)
+		assert.Equal(t, byte(tar.TypeDir), header.Typeflag)
+	})
+
+	t.Run("file modification times are preserved", func(t *testing.T) {
+		header, found := headers[filepath.Join("config", "a.yml")]
+		require.True(t, found)
+		assert.True(t, header.ModTime.Equal(mTime), "expected mtime %s, got %s", mTime, header.ModTime)
+	})
+
+	if runtime.GOOS != "windows" {
+		t.Run("file permissions are preserved", func(t *testing.T) {
+			header := headers[filepath.Join("config", "a.yml")]
+			assert.Equal(t, int64(0754), header.Mode)
+		})
+	}
+
+	t.Run("reproducible mode normalizes modes and timestamps", func(t *testing.T) {
+		forEachTarImageEntry(t, NewReproducibleTarImage([]string{dir}, nil, &bytes.Buffer{}), []string{dir}, func(header *tar.Header, _ io.Reader) {
+			// the zero time round-trips through the tar header as the Unix epoch
+			assert.Equal(t, int64(0), header.ModTime.Unix(), "expected static mtime for '%s', got %s", header.Name, header.ModTime)
+			if header.Name == filepath.Join("config", "a.yml") {
+				assert.Equal(t, int64(0700), header.Mode)
+			}
+		})
+	})
+}
+
+func TestTarImageLongAndUnicodePaths(t *testing.T) {
+	dir := t.TempDir()
+
+	// deeply nested path well beyond the 100 character USTAR name limit
+	longPath := filepath.Join(
+		strings.Repeat("a", 60),
+		strings.Repeat("b", 60),
+		strings.Repeat("c", 60),
+		"values.yml",
+	)
+	writeTarImageTestFile(t, dir, longPath, "long-path")
+
+	// decomposed form of 'café.yml' as stored by e.g. macOS filesystems
+	writeTarImageTestFile(t, dir, "cafe\u0301.yml", "unicode")
+
+	entries := readTarImageEntries(t, []string{dir})
+
+	t.Run("paths beyond USTAR limits round-trip", func(t *testing.T) {
+		contents, found := entries[longPath]
+		require.True(t, found)
+		assert.Equal(t, "long-path", contents)
+	})
+
+	t.Run("filenames are normalized to NFC", func(t *testing.T) {
+		contents, found := entries["caf\u00e9.yml"]
+		require.True(t, found, "expected NFC normalized entry name")
+		assert.Equal(t, "unicode", contents)
+	})
+}
+
+func TestTarImageExcludesVCSArtifacts(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTarImageTestFile(t, dir, filepath.Join("config", "a.yml"), "contents")
+	writeTarImageTestFile(t, dir, filepath.Join(".git", "HEAD"), "ref: refs/heads/main")
+	writeTarImageTestFile(t, dir, filepath.Join("config", ".svn", "entries"), "12")
+	writeTarImageTestFile(t, dir, ".DS_Store", "junk")
+	writeTarImageTestFile(t, dir, filepath.Join("config", ".a.yml.swp"), "swap")
+	writeTarImageTestFile(t, dir, "a.yml~", "backup")
+
+	t.Run("VCS and editor artifacts are excluded by default", func(t *testing.T) {
+		entries := readTarImageEntries(t, []string{dir})
+		assert.Contains(t, entries, filepath.Join("config", "a.yml"))
+		assert.NotContains(t, entries, filepath.Join(".git", "HEAD"))
+		assert.NotContains(t, entries, filepath.Join("config", ".svn", "entries"))
+		assert.NotContains(t, entries, ".DS_Store")
+		assert.NotContains(t, entries, filepath.Join("config", ".a.yml.swp"))
+		assert.NotContains(t, entries, "a.yml~")
+	})
+
+	t.Run("SetIncludeVCS keeps the artifacts", func(t *testing.T) {
+		subject := NewTarImage([]string{dir}, nil, &bytes.Buffer{})
+		subject.SetIncludeVCS(true)
+
+		entries := map[string]string{}
+		forEachTarImageEntry(t, subject, []string{dir}, func(header *tar.Header, _ io.Reader) {
+			entries[header.Name] = ""
+		})
+		assert.Contains(t, entries, filepath.Join(".git", "HEAD"))
+		assert.Contains(t, entries, ".DS_Store")
+	})
+}
+
+func TestTarImageSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlink creation is restricted on windows")
+	}
+
+	newDirWithLink := func(t *testing.T, target string) (string, *TarImage) {
+		dir := t.TempDir()
+		writeTarImageTestFile(t, dir, "a.yml", "contents")
+		require.NoError(t, os.Symlink(target, filepath.Join(dir, "link.yml")))
+		return dir, NewTarImage([]string{dir}, nil, &bytes.Buffer{})
+	}
+
+	createTarball := func(t *testing.T, subject *TarImage, dir string) error {
+		tmpFile, err := ioutil.TempFile("", "imgpkg-tar-image-test")
+		require.NoError(t, err)
+		defer os.Remove(tmpFile.Name())
+		defer tmpFile.Close()
+		return subject.createTarball(tmpFile, []string{dir})
+	}
+
+	t.Run("symlinks fail the push by default", func(t *testing.T) {
+		dir, subject := newDirWithLink(t, "a.yml")
+		err := createTarball(t, subject, dir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Use --dereference-symlinks")
+	})
+
+	t.Run("dereferencing embeds the target's contents", func(t *testing.T) {
+		dir, subject := newDirWithLink(t, "a.yml")
+		subject.SetDerefSymlinks(true, false)
+
+		entries := map[string]string{}
+		forEachTarImageEntry(t, subject, []string{dir}, func(header *tar.Header, reader io.Reader) {
+			if header.Typeflag != tar.TypeReg {
+				return
+			}
+			bs, err := ioutil.ReadAll(reader)
+			require.NoError(t, err)
+			entries[header.Name] = string(bs)
+		})
+		assert.Equal(t, "contents", entries["link.yml"])
+	})
+
+	t.Run("symlink loops are detected", func(t *testing.T) {
+		dir, subject := newDirWithLink(t, "link.yml")
+		subject.SetDerefSymlinks(true, false)
+
+		err := createTarball(t, subject, dir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Resolving symlink")
+	})
+
+	t.Run("strict mode errors on out-of-tree targets", func(t *testing.T) {
+		outsideDir := t.TempDir()
+		writeTarImageTestFile(t, outsideDir, "secret.yml", "outside")
+
+		dir, subject := newDirWithLink(t, filepath.Join(outsideDir, "secret.yml"))
+		subject.SetDerefSymlinks(true, true)
+
+		err := createTarball(t, subject, dir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "points outside the pushed directory")
+	})
+
+	t.Run("strict mode allows in-tree targets", func(t *testing.T) {
+		dir, subject := newDirWithLink(t, "a.yml")
+		subject.SetDerefSymlinks(true, true)
+
+		require.NoError(t, createTarball(t, subject, dir))
+	})
+}
+
+func writeTarImageTestFile(t *testing.T, root, relPath, contents string) {
+	t.Helper()
+	path := filepath.Join(root, relPath)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+}
+
+func readTarImageEntries(t *testing.T, paths []string) map[string]string {
+	t.Helper()
+	entries := map[string]string{}
+	forEachTarImageEntry(t, NewTarImage(paths, nil, &bytes.Buffer{}), paths, func(header *tar.Header, reader io.Reader) {
+		if header.Typeflag != tar.TypeReg {
+			return
+		}
+		bs, err := ioutil.ReadAll(reader)
+		require.NoError(t, err)
+		entries[header.Name] = string(bs)
+	})
+	return entries
+}
+
+func tarImageEntryCount(t *testing.T, paths []string, relPath string) int {
+	t.Helper()
+	count := 0
+	forEachTarImageEntry(t, NewTarImage(paths, nil, &bytes.Buffer{}), paths, func(header *tar.Header, _ io.Reader) {
+		if header.Name == relPath {
+			count++
+		}
+	})
+	return count
+}
+
+func forEachTarImageEntry(t *testing.T, subject *TarImage, paths []string, visit func(*tar.Header, io.Reader)) {
+	t.Helper()
+	tmpFile, err := ioutil.TempFile("", "imgpkg-tar-image-test")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	require.NoError(t, subject.createTarball(tmpFile, paths))
+
+	_, err = tmpFile.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+
+	tarReader := tar.NewReader(tmpFile)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		visit(header, tarReader)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// windowsMaxPath is the default path length limit on Windows; longer paths
+// need the extended-length prefix to be addressable
+const windowsMaxPath = 260
+
+// windowsReservedNames are path elements Windows refuses to create
+var windowsReservedNames = func() map[string]struct{} {
+	names := map[string]struct{}{"CON": {}, "PRN": {}, "AUX": {}, "NUL": {}}
+	for i := 1; i <= 9; i++ {
+		names["COM"+string(rune('0'+i))] = struct{}{}
+		names["LPT"+string(rune('0'+i))] = struct{}{}
+	}
+	return names
+}()
+
+// windowsEntryName rewrites a tar entry name so it can be created on a
+// Windows filesystem: backslashes become path separators, characters that are
+// illegal in Windows filenames are replaced with '_', and path elements that
+// match reserved device names are prefixed with '_'
+func windowsEntryName(name string) string {
+	name = strings.ReplaceAll(name, `\`, "/")
+
+	parts := strings.Split(name, "/")
+	for idx, part := range parts {
+		part = strings.Map(func(r rune) rune {
+			if r < 32 || strings.ContainsRune(`<>:"|?*`, r) {
+				return '_'
+			}
+			return r
+		}, part)
+
+		base := part
+		if dot := strings.Index(base, "."); dot != -1 {
+			base = base[:dot]
+		}
+		if _, found := windowsReservedNames[strings.ToUpper(base)]; found {
+			part = "_" + part
+		}
+
+		parts[idx] = part
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// windowsFileMode maps a tar mode into permissions Windows can represent:
+// only the read-only attribute has a meaningful ACL mapping, and directories
+// must stay writable so entries from later layers can be created inside them
+func windowsFileMode(typeflag byte, mode os.FileMode) os.FileMode {
+	if typeflag == tar.TypeDir {
+		return mode | 0300
+	}
+	if mode&0222 == 0 {
+		return 0444
+	}
+	return 0666
+}
+
+// extendedLengthPath returns path with the extended-length prefix needed for
+// Windows to address paths longer than windowsMaxPath characters
+func extendedLengthPath(path string) string {
+	if len(path) < windowsMaxPath || strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	return `\\?\` + abs
+}
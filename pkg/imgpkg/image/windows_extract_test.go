@@ -0,0 +1,40 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowsEntryName(t *testing.T) {
+	t.Run("converts backslashes into path separators", func(t *testing.T) {
+		assert.Equal(t, "some/nested/file.txt", windowsEntryName(`some\nested\file.txt`))
+	})
+
+	t.Run("replaces characters that are illegal on Windows", func(t *testing.T) {
+		assert.Equal(t, "dir/what_time_is_it_.txt", windowsEntryName("dir/what:time<is>it?.txt"))
+	})
+
+	t.Run("prefixes reserved device names", func(t *testing.T) {
+		assert.Equal(t, "dir/_CON/_aux.txt", windowsEntryName("dir/CON/aux.txt"))
+	})
+
+	t.Run("leaves representable names untouched", func(t *testing.T) {
+		assert.Equal(t, "dir/console/file.txt", windowsEntryName("dir/console/file.txt"))
+	})
+}
+
+func TestWindowsFileMode(t *testing.T) {
+	t.Run("directories stay writable", func(t *testing.T) {
+		assert.NotZero(t, windowsFileMode(tar.TypeDir, 0500)&0300)
+	})
+
+	t.Run("read-only files map onto the read-only attribute", func(t *testing.T) {
+		assert.EqualValues(t, 0444, windowsFileMode(tar.TypeReg, 0400))
+		assert.EqualValues(t, 0666, windowsFileMode(tar.TypeReg, 0644))
+	})
+}
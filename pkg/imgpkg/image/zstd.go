@@ -0,0 +1,128 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	regtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/klauspost/compress/zstd"
+)
+
+// OCILayerZstd media type of an OCI image layer compressed with zstd, which
+// go-containerregistry does not decompress on its own
+const OCILayerZstd regtypes.MediaType = "application/vnd.oci.image.layer.v1.tar+zstd"
+
+// IsZstdLayer reports whether the layer's media type declares zstd compression
+func IsZstdLayer(layer regv1.Layer) (bool, error) {
+	mediaType, err := layer.MediaType()
+	if err != nil {
+		return false, err
+	}
+	return strings.HasSuffix(string(mediaType), "+zstd"), nil
+}
+
+// NewUncompressedLayerStream returns the layer's uncompressed tar stream,
+// decompressing zstd layers itself since go-containerregistry only knows
+// how to decompress gzip
+func NewUncompressedLayerStream(layer regv1.Layer) (io.ReadCloser, error) {
+	isZstd, err := IsZstdLayer(layer)
+	if err != nil {
+		return nil, err
+	}
+	if !isZstd {
+		return layer.Uncompressed()
+	}
+
+	compressedStream, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := zstd.NewReader(compressedStream)
+	if err != nil {
+		compressedStream.Close()
+		return nil, fmt.Errorf("Creating zstd decoder: %s", err)
+	}
+
+	return zstdLayerStream{decoder, compressedStream}, nil
+}
+
+// zstdLayerStream ReadCloser over the decoded zstd stream that releases both
+// the decoder and the underlying blob stream on Close
+type zstdLayerStream struct {
+	decoder    *zstd.Decoder
+	compressed io.ReadCloser
+}
+
+func (s zstdLayerStream) Read(p []byte) (int, error) { return s.decoder.Read(p) }
+
+func (s zstdLayerStream) Close() error {
+	s.decoder.Close()
+	return s.compressed.Close()
+}
+
+// TranscodeZstdLayers returns img rebuilt with every zstd layer recompressed
+// with gzip, for destination registries that cannot serve zstd. Reports false
+// when the image has no zstd layers. Note that recompressing changes the
+// image digest
+func TranscodeZstdLayers(img regv1.Image) (regv1.Image, bool, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasZstd := false
+	var newLayers []regv1.Layer
+	for _, layer := range layers {
+		isZstd, err := IsZstdLayer(layer)
+		if err != nil {
+			return nil, false, err
+		}
+		if !isZstd {
+			newLayers = append(newLayers, layer)
+			continue
+		}
+
+		hasZstd = true
+		layer := layer // copy
+		gzipLayer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+			return NewUncompressedLayerStream(layer)
+		})
+		if err != nil {
+			return nil, false, fmt.Errorf("Recompressing zstd layer with gzip: %s", err)
+		}
+		newLayers = append(newLayers, gzipLayer)
+	}
+
+	if !hasZstd {
+		return img, false, nil
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, false, err
+	}
+	cfg = cfg.DeepCopy()
+	// AppendLayers rebuilds the diff IDs and history below
+	cfg.RootFS.DiffIDs = nil
+	cfg.History = nil
+
+	newImg, err := mutate.ConfigFile(empty.Image, cfg)
+	if err != nil {
+		return nil, false, err
+	}
+	newImg, err = mutate.AppendLayers(newImg, newLayers...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return newImg, true, nil
+}
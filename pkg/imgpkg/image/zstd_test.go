@@ -0,0 +1,123 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	regtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// zstdTestLayer regv1.Layer backed by in-memory zstd compressed contents
+type zstdTestLayer struct {
+	compressed   []byte
+	uncompressed []byte
+}
+
+func newZstdTestLayer(t *testing.T) zstdTestLayer {
+	buf := bytes.NewBuffer(nil)
+	tarWriter := tar.NewWriter(buf)
+	contents := []byte("layer contents\n")
+	require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "file.txt", Size: int64(len(contents)), Mode: 0600}))
+	_, err := tarWriter.Write(contents)
+	require.NoError(t, err)
+	require.NoError(t, tarWriter.Close())
+
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+
+	return zstdTestLayer{
+		compressed:   encoder.EncodeAll(buf.Bytes(), nil),
+		uncompressed: buf.Bytes(),
+	}
+}
+
+func (l zstdTestLayer) Digest() (regv1.Hash, error) {
+	hash, _, err := regv1.SHA256(bytes.NewReader(l.compressed))
+	return hash, err
+}
+
+func (l zstdTestLayer) DiffID() (regv1.Hash, error) {
+	hash, _, err := regv1.SHA256(bytes.NewReader(l.uncompressed))
+	return hash, err
+}
+
+func (l zstdTestLayer) Compressed() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(l.compressed)), nil
+}
+
+func (l zstdTestLayer) Uncompressed() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(l.uncompressed)), nil
+}
+
+func (l zstdTestLayer) Size() (int64, error) { return int64(len(l.compressed)), nil }
+
+func (l zstdTestLayer) MediaType() (regtypes.MediaType, error) { return OCILayerZstd, nil }
+
+func TestNewUncompressedLayerStreamDecodesZstd(t *testing.T) {
+	layer := newZstdTestLayer(t)
+
+	stream, err := NewUncompressedLayerStream(layer)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	contents, err := ioutil.ReadAll(stream)
+	require.NoError(t, err)
+	assert.Equal(t, layer.uncompressed, contents)
+}
+
+func TestTranscodeZstdLayers(t *testing.T) {
+	t.Run("recompresses zstd layers with gzip", func(t *testing.T) {
+		layer := newZstdTestLayer(t)
+		img, err := mutate.AppendLayers(empty.Image, layer)
+		require.NoError(t, err)
+
+		newImg, transcoded, err := TranscodeZstdLayers(img)
+		require.NoError(t, err)
+		require.True(t, transcoded)
+
+		layers, err := newImg.Layers()
+		require.NoError(t, err)
+		require.Len(t, layers, 1)
+
+		mediaType, err := layers[0].MediaType()
+		require.NoError(t, err)
+		assert.Equal(t, regtypes.DockerLayer, mediaType)
+
+		stream, err := layers[0].Uncompressed()
+		require.NoError(t, err)
+		defer stream.Close()
+		contents, err := ioutil.ReadAll(stream)
+		require.NoError(t, err)
+		assert.Equal(t, layer.uncompressed, contents)
+
+		diffID, err := layers[0].DiffID()
+		require.NoError(t, err)
+		cfg, err := newImg.ConfigFile()
+		require.NoError(t, err)
+		require.Len(t, cfg.RootFS.DiffIDs, 1)
+		assert.Equal(t, diffID, cfg.RootFS.DiffIDs[0])
+	})
+
+	t.Run("leaves images without zstd layers untouched", func(t *testing.T) {
+		img, err := random.Image(1024, 1)
+		require.NoError(t, err)
+
+		newImg, transcoded, err := TranscodeZstdLayers(img)
+		require.NoError(t, err)
+		assert.False(t, transcoded)
+		assert.Equal(t, img, newImg)
+	})
+}
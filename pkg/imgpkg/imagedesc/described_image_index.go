@@ -48,6 +48,12 @@ func (i DescribedImageIndex) IndexManifest() (*regv1.IndexManifest, error) {
 	return manifest, nil
 }
 
+// Images returns every image carried directly by this index
+func (i DescribedImageIndex) Images() []regv1.Image { return i.images }
+
+// Indexes returns every index nested directly under this index
+func (i DescribedImageIndex) Indexes() []regv1.ImageIndex { return i.indexes }
+
 func (i DescribedImageIndex) Image(digest regv1.Hash) (regv1.Image, error) {
 	for _, img := range i.images {
 		imgDigest, err := img.Digest()
@@ -78,6 +78,10 @@ func NewImageRefDescriptors(refs []Metadata, registry Registry) (*ImageRefDescri
 				return err
 			}
 
+			if IsSchema1(regDesc.MediaType) {
+				return Schema1Error(ref.Ref.String())
+			}
+
 			var td ImageOrImageIndexDescriptor
 
 			if imageRefDescs.isImageIndex(regDesc.Descriptor) {
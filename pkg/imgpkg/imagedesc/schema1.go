@@ -0,0 +1,22 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagedesc
+
+import (
+	"fmt"
+
+	regv1types "github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// IsSchema1 reports whether mediaType is a legacy Docker schema1 manifest,
+// which imgpkg cannot process
+func IsSchema1(mediaType regv1types.MediaType) bool {
+	return mediaType == regv1types.DockerManifestSchema1 || mediaType == regv1types.DockerManifestSchema1Signed
+}
+
+// Schema1Error explicit error for references served as schema1 manifests,
+// which would otherwise fail in obscure ways further down
+func Schema1Error(ref string) error {
+	return fmt.Errorf("Image '%s' is served as a legacy Docker schema1 manifest, which imgpkg cannot process (hint: Re-push the image with a current docker or crane client to convert it to schema2)", ref)
+}
@@ -11,6 +11,8 @@ import (
 	regname "github.com/google/go-containerregistry/pkg/name"
 	regv1 "github.com/google/go-containerregistry/pkg/v1"
 	regremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	regtypes "github.com/google/go-containerregistry/pkg/v1/types"
+	ctlimg "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/image"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedigest"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
@@ -22,14 +24,45 @@ type Logger interface {
 }
 
 type ImageSet struct {
-	concurrency int
-	ui          goui.UI
-	tagGen      util.TagGenerator
+	concurrency        int
+	ui                 goui.UI
+	tagGen             util.TagGenerator
+	zstdToGzip         bool
+	normalizeToOCI     bool
+	uploadTagsDisabled bool
+	hostConcurrency    map[string]int
 }
 
 // NewImageSet constructor for creating an ImageSet
 func NewImageSet(concurrency int, ui goui.UI, tagGen util.TagGenerator) ImageSet {
-	return ImageSet{concurrency, ui, tagGen}
+	return ImageSet{concurrency: concurrency, ui: ui, tagGen: tagGen}
+}
+
+// SetZstdToGzip when enabled, zstd layers are recompressed with gzip during
+// Import, for destination registries that cannot serve zstd
+func (i *ImageSet) SetZstdToGzip(enabled bool) {
+	i.zstdToGzip = enabled
+}
+
+// SetNormalizeToOCI when enabled, Docker manifest and layer media types are
+// rewritten to their OCI equivalents during Import, for destination
+// registries that require OCI content. Note that converting changes digests
+func (i *ImageSet) SetNormalizeToOCI(enabled bool) {
+	i.normalizeToOCI = enabled
+}
+
+// SetHostConcurrency overrides the global concurrency limit for specific
+// registry hostnames, for source and destination registries with asymmetric
+// rate limits
+func (i *ImageSet) SetHostConcurrency(limits map[string]int) {
+	i.hostConcurrency = limits
+}
+
+// SetUploadTagsDisabled when disabled, no synthesized tag is applied to
+// imported images; manifests are uploaded by digest instead. Note that some
+// registries (ex: AWS ECR) do not accept manifest uploads by digest
+func (i *ImageSet) SetUploadTagsDisabled(disabled bool) {
+	i.uploadTagsDisabled = disabled
 }
 
 func (i ImageSet) Relocate(foundImages *UnprocessedImageRefs,
@@ -77,9 +110,27 @@ func (i *ImageSet) Import(imgOrIndexes []imagedesc.ImageOrIndex,
 
 	importedImages := NewProcessedImages()
 
+	if i.zstdToGzip {
+		var err error
+		imgOrIndexes, err = i.transcodeZstdImages(imgOrIndexes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if i.normalizeToOCI {
+		var err error
+		imgOrIndexes, err = i.normalizeImagesToOCI(imgOrIndexes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	i.ui.BeginLinef("importing %d images...\n", len(imgOrIndexes))
 
-	importThrottle := util.NewThrottle(i.concurrency)
+	// honors per-host overrides so a strict source registry does not dictate
+	// the pace of uploads to a permissive destination (and vice versa)
+	hostThrottle := util.NewRegistryThrottleWithLimits(i.concurrency, i.hostConcurrency)
 
 	imageOrIndexesToWrite := map[regname.Reference]regremote.Taggable{}
 	var imageOrIndexesToWriteLock = &sync.Mutex{}
@@ -88,8 +139,9 @@ func (i *ImageSet) Import(imgOrIndexes []imagedesc.ImageOrIndex,
 		item := item // copy
 
 		go func() {
-			importThrottle.Take()
-			defer importThrottle.Done()
+			srcHost := refRegistry(item.Ref())
+			hostThrottle.Take(srcHost)
+			defer hostThrottle.Done(srcHost)
 			tag, taggable, err := i.getImageOrImageIndexForMultiWrite(item, importRepo, registry)
 			if err != nil {
 				errCh <- err
@@ -108,7 +160,7 @@ func (i *ImageSet) Import(imgOrIndexes []imagedesc.ImageOrIndex,
 		return nil, err
 	}
 
-	err = registry.MultiWrite(imageOrIndexesToWrite, i.concurrency, nil)
+	err = registry.MultiWrite(imageOrIndexesToWrite, hostThrottle.Limit(importRepo.RegistryStr()), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -118,8 +170,8 @@ func (i *ImageSet) Import(imgOrIndexes []imagedesc.ImageOrIndex,
 		item := item // copy
 
 		go func() {
-			importThrottle.Take()
-			defer importThrottle.Done()
+			hostThrottle.Take(importRepo.RegistryStr())
+			defer hostThrottle.Done(importRepo.RegistryStr())
 
 			processedImage, err := i.verifyImageOrIndex(item, importRepo, registry)
 			if err == nil {
@@ -137,6 +189,16 @@ func (i *ImageSet) Import(imgOrIndexes []imagedesc.ImageOrIndex,
 	return importedImages, nil
 }
 
+// refRegistry returns the registry hostname of ref, or an empty string when
+// the reference cannot be parsed (throttled under the default limit)
+func refRegistry(ref string) string {
+	parsed, err := regname.ParseReference(ref, regname.WeakValidation)
+	if err != nil {
+		return ""
+	}
+	return parsed.Context().RegistryStr()
+}
+
 func checkForAnyAsyncErrors(imgOrIndexes []imagedesc.ImageOrIndex, errCh chan error) error {
 	for i := 0; i < len(imgOrIndexes); i++ {
 		err := <-errCh
@@ -147,15 +209,23 @@ func checkForAnyAsyncErrors(imgOrIndexes []imagedesc.ImageOrIndex, errCh chan er
 	return nil
 }
 
-func (i ImageSet) getImageOrImageIndexForMultiWrite(item imagedesc.ImageOrIndex, importRepo regname.Repository, registry registry.ImagesReaderWriter) (regname.Tag, regremote.Taggable, error) {
+func (i ImageSet) getImageOrImageIndexForMultiWrite(item imagedesc.ImageOrIndex, importRepo regname.Repository, registry registry.ImagesReaderWriter) (regname.Reference, regremote.Taggable, error) {
 	digestWrap := imagedigest.DigestWrap{}
 	err := digestWrap.DigestWrap(item.Ref(), item.OrigRef)
 	if err != nil {
-		return regname.Tag{}, nil, err
+		return nil, nil, err
 	}
 	uploadTagRef, err := i.tagGen.GenerateTag(digestWrap, importRepo)
 	if err != nil {
-		return regname.Tag{}, nil, err
+		return nil, nil, err
+	}
+
+	var uploadRef regname.Reference = uploadTagRef
+	if i.uploadTagsDisabled {
+		uploadRef, err = regname.NewDigest(fmt.Sprintf("%s@%s", importRepo.Name(), digestWrap.RegnameDigest().DigestStr()))
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	var artifactToWrite regremote.Taggable
@@ -164,7 +234,7 @@ func (i ImageSet) getImageOrImageIndexForMultiWrite(item imagedesc.ImageOrIndex,
 	case item.Image != nil:
 		artifactToWrite, err = i.mountableImage(*item.Image, uploadTagRef, registry)
 		if err != nil {
-			return regname.Tag{}, nil, err
+			return nil, nil, err
 		}
 	case item.Index != nil:
 		artifactToWrite = *item.Index
@@ -172,7 +242,7 @@ func (i ImageSet) getImageOrImageIndexForMultiWrite(item imagedesc.ImageOrIndex,
 		panic("Unknown item")
 	}
 
-	return uploadTagRef, artifactToWrite, nil
+	return uploadRef, artifactToWrite, nil
 }
 
 func (i ImageSet) mountableImage(imageWithRef imagedesc.ImageWithRef, uploadTagRef regname.Tag, registry registry.ImagesReaderWriter) (regremote.Taggable, error) {
@@ -181,6 +251,14 @@ func (i ImageSet) mountableImage(imageWithRef imagedesc.ImageWithRef, uploadTagR
 		return nil, fmt.Errorf("Unable to parse reference: %s: %s", imageWithRef.Ref(), err)
 	}
 
+	// Content that was rewritten during the transfer (ex: zstd layers
+	// recompressed with gzip) no longer matches the source blobs, so it cannot
+	// be mounted from the source repository
+	itemDigest, err := imageWithRef.Digest()
+	if err == nil && itemRef.DigestStr() != itemDigest.String() {
+		return regv1.Image(imageWithRef), nil
+	}
+
 	if imageBlobsCanBeMounted(itemRef, uploadTagRef, registry) {
 		descriptor, err := registry.Get(itemRef)
 		if err != nil {
@@ -241,16 +319,23 @@ func (i *ImageSet) verifyItemCopied(item imagedesc.ImageOrIndex, importRepo regn
 	if err != nil {
 		return regname.Digest{}, nil
 	}
-	uploadTagRef, err := i.tagGen.GenerateTag(digestWrap, importRepo)
-	if err != nil {
-		return regname.Digest{}, err
+
+	var uploadRef regname.Reference
+	if i.uploadTagsDisabled {
+		// No tag was written; resolve the digest ref directly
+		uploadRef = importDigestRef
+	} else {
+		uploadRef, err = i.tagGen.GenerateTag(digestWrap, importRepo)
+		if err != nil {
+			return regname.Digest{}, err
+		}
 	}
 
 	// Verify that imported image still has the same digest as we expect.
 	// Being a little bit paranoid here because tag ref is used for import
 	// instead of plain digest ref, because AWS ECR doesnt like digests
 	// during manifest upload.
-	err = i.verifyTagDigest(uploadTagRef, importDigestRef, registry)
+	err = i.verifyTagDigest(uploadRef, importDigestRef, registry)
 	if err != nil {
 		return regname.Digest{}, err
 	}
@@ -277,6 +362,104 @@ func (i *ImageSet) verifyTagDigest(
 	return nil
 }
 
+// transcodedImageWithRef rewritten image that keeps the reference and tag of
+// the image it was derived from
+type transcodedImageWithRef struct {
+	regv1.Image
+	ref string
+	tag string
+}
+
+func (t transcodedImageWithRef) Ref() string { return t.ref }
+func (t transcodedImageWithRef) Tag() string { return t.tag }
+
+// transcodedIndexWithRef rewritten index that keeps the reference and tag of
+// the index it was derived from (the regv1.ImageIndex interface is
+// implemented by delegation since the embedded name would clash with the
+// ImageIndex method)
+type transcodedIndexWithRef struct {
+	idx regv1.ImageIndex
+	ref string
+	tag string
+}
+
+func (t transcodedIndexWithRef) Ref() string { return t.ref }
+func (t transcodedIndexWithRef) Tag() string { return t.tag }
+
+func (t transcodedIndexWithRef) MediaType() (regtypes.MediaType, error) { return t.idx.MediaType() }
+func (t transcodedIndexWithRef) Digest() (regv1.Hash, error)            { return t.idx.Digest() }
+func (t transcodedIndexWithRef) Size() (int64, error)                   { return t.idx.Size() }
+func (t transcodedIndexWithRef) IndexManifest() (*regv1.IndexManifest, error) {
+	return t.idx.IndexManifest()
+}
+func (t transcodedIndexWithRef) RawManifest() ([]byte, error) { return t.idx.RawManifest() }
+func (t transcodedIndexWithRef) Image(h regv1.Hash) (regv1.Image, error) {
+	return t.idx.Image(h)
+}
+func (t transcodedIndexWithRef) ImageIndex(h regv1.Hash) (regv1.ImageIndex, error) {
+	return t.idx.ImageIndex(h)
+}
+
+// normalizeImagesToOCI rewrites Docker media types to their OCI equivalents
+// for every image and index in the set; OCI content passes through untouched
+func (i *ImageSet) normalizeImagesToOCI(imgOrIndexes []imagedesc.ImageOrIndex) ([]imagedesc.ImageOrIndex, error) {
+	var result []imagedesc.ImageOrIndex
+	for _, item := range imgOrIndexes {
+		switch {
+		case item.Image != nil:
+			origImage := *item.Image
+			newImage, normalized, err := ctlimg.NormalizeToOCI(origImage)
+			if err != nil {
+				return nil, fmt.Errorf("Normalizing '%s' to OCI media types: %s", origImage.Ref(), err)
+			}
+			if normalized {
+				i.ui.BeginLinef("normalizing %s to OCI media types\n", origImage.Ref())
+				var withRef imagedesc.ImageWithRef = transcodedImageWithRef{newImage, origImage.Ref(), origImage.Tag()}
+				item.Image = &withRef
+			}
+
+		case item.Index != nil:
+			origIndex := *item.Index
+			newIndex, normalized, err := ctlimg.NormalizeIndexToOCI(origIndex)
+			if err != nil {
+				return nil, fmt.Errorf("Normalizing '%s' to OCI media types: %s", origIndex.Ref(), err)
+			}
+			if normalized {
+				i.ui.BeginLinef("normalizing %s to OCI media types\n", origIndex.Ref())
+				var withRef imagedesc.ImageIndexWithRef = transcodedIndexWithRef{newIndex, origIndex.Ref(), origIndex.Tag()}
+				item.Index = &withRef
+			}
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// transcodeZstdImages recompresses the zstd layers of every image in the set
+// with gzip; images without zstd layers and indexes pass through untouched
+func (i *ImageSet) transcodeZstdImages(imgOrIndexes []imagedesc.ImageOrIndex) ([]imagedesc.ImageOrIndex, error) {
+	var result []imagedesc.ImageOrIndex
+	for _, item := range imgOrIndexes {
+		if item.Image == nil {
+			result = append(result, item)
+			continue
+		}
+
+		origImage := *item.Image
+		newImage, transcoded, err := ctlimg.TranscodeZstdLayers(origImage)
+		if err != nil {
+			return nil, fmt.Errorf("Recompressing zstd layers of '%s' with gzip: %s", origImage.Ref(), err)
+		}
+		if transcoded {
+			i.ui.BeginLinef("recompressing zstd layers of %s with gzip\n", origImage.Ref())
+			var withRef imagedesc.ImageWithRef = transcodedImageWithRef{newImage, origImage.Ref(), origImage.Tag()}
+			item.Image = &withRef
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
 func getResolvedImageURL(tagRef string, registry registry.ImagesReader) (string, error) {
 	tag, err := regname.NewTag(tagRef, regname.WeakValidation)
 	if err != nil {
@@ -5,62 +5,106 @@ package imageset
 
 import (
 	"fmt"
-	"io"
-	"os"
+	"sort"
 
 	goui "github.com/cppforlife/go-cli-ui/ui"
 	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagetar"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
 )
 
 type TarImageSet struct {
-	imageSet    ImageSet
-	concurrency int
-	ui          goui.UI
+	imageSet       ImageSet
+	concurrency    int
+	maxMemoryBytes int64
+	skipDiskCheck  bool
+	ui             goui.UI
+
+	importOnlyRefs  []string
+	hostConcurrency map[string]int
 }
 
 // NewTarImageSet provides export/import operations on a tarball for a set of images
-func NewTarImageSet(imageSet ImageSet, concurrency int, ui goui.UI) TarImageSet {
-	return TarImageSet{imageSet, concurrency, ui}
+func NewTarImageSet(imageSet ImageSet, concurrency int, maxMemoryBytes int64, skipDiskCheck bool, ui goui.UI) TarImageSet {
+	return TarImageSet{imageSet: imageSet, concurrency: concurrency, maxMemoryBytes: maxMemoryBytes, skipDiskCheck: skipDiskCheck, ui: ui}
+}
+
+// SetImportOnlyRefs limits imports to the images matching the given
+// references (format: digest or repo@digest); an empty list imports everything
+func (i *TarImageSet) SetImportOnlyRefs(refs []string) {
+	i.importOnlyRefs = refs
+}
+
+// SetHostConcurrency overrides the global concurrency limit for specific
+// registry hostnames when uploading layers from a tar
+func (i *TarImageSet) SetHostConcurrency(limits map[string]int) {
+	i.hostConcurrency = limits
 }
 
 // Export Creates a Tar with the provided Images
 func (i TarImageSet) Export(foundImages *UnprocessedImageRefs, outputPath string, registry registry.ImagesReaderWriter, imageLayerWriterCheck imagetar.ImageLayerWriterFilter) (*imagedesc.ImageRefDescriptors, error) {
+	return i.ExportTo(foundImages, imagetar.NewFileDestination(outputPath, i.skipDiskCheck), registry, imageLayerWriterCheck)
+}
+
+// ExportTo Creates a Tar with the provided Images in the given destination,
+// allowing sinks other than the local filesystem to be plugged in
+func (i TarImageSet) ExportTo(foundImages *UnprocessedImageRefs, dst imagetar.Destination, registry registry.ImagesReaderWriter, imageLayerWriterCheck imagetar.ImageLayerWriterFilter) (*imagedesc.ImageRefDescriptors, error) {
 	ids, err := i.imageSet.Export(foundImages, registry)
 	if err != nil {
 		return nil, err
 	}
 
-	outputFile, err := os.Create(outputPath)
-	if err != nil {
-		return nil, fmt.Errorf("Creating file '%s': %s", outputPath, err)
-	}
-
-	err = outputFile.Close()
+	opener, err := dst.Prepare(uniqueLayersSize(ids))
 	if err != nil {
 		return nil, err
 	}
 
-	outputFileOpener := func() (io.WriteCloser, error) {
-		return os.OpenFile(outputPath, os.O_RDWR, 0755)
-	}
-
 	i.ui.BeginLinef("writing layers...\n")
 
-	opts := imagetar.TarWriterOpts{Concurrency: i.concurrency}
+	opts := imagetar.TarWriterOpts{Concurrency: i.concurrency, MaxMemoryBytes: i.maxMemoryBytes}
 
-	return ids, imagetar.NewTarWriter(ids, outputFileOpener, opts, i.ui, imageLayerWriterCheck).Write()
+	err = imagetar.NewTarWriter(ids, opener, opts, i.ui, imageLayerWriterCheck).Write()
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, dst.Finalize()
 }
 
 // Import Copy tar with Images to the Registry
 func (i *TarImageSet) Import(path string, importRepo regname.Repository, registry registry.ImagesReaderWriter) (*ProcessedImages, error) {
+	return i.ImportFrom(imagetar.NewFileSource(path), importRepo, registry)
+}
+
+// ImportFrom Copy tar with Images from the given source to the Registry,
+// allowing archives staged outside the local filesystem to be plugged in
+func (i *TarImageSet) ImportFrom(src imagetar.Source, importRepo regname.Repository, registry registry.ImagesReaderWriter) (*ProcessedImages, error) {
+	path, cleanup, err := src.LocalPath()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
 	imgOrIndexes, err := imagetar.NewTarReader(path).Read()
 	if err != nil {
 		return nil, err
 	}
 
+	if len(i.importOnlyRefs) > 0 {
+		imgOrIndexes, err = filterOnlyRefs(imgOrIndexes, i.importOnlyRefs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = i.preUploadLayers(imgOrIndexes, importRepo, registry)
+	if err != nil {
+		return nil, err
+	}
+
 	processedImages, err := i.imageSet.Import(imgOrIndexes, importRepo, registry)
 	if err != nil {
 		return nil, err
@@ -68,3 +112,201 @@ func (i *TarImageSet) Import(path string, importRepo regname.Repository, registr
 
 	return processedImages, err
 }
+
+// filterOnlyRefs keeps the images and indexes matching onlyRefs, failing when
+// a requested reference matches nothing in the tar so typos do not silently
+// import an empty set
+func filterOnlyRefs(imgOrIndexes []imagedesc.ImageOrIndex, onlyRefs []string) ([]imagedesc.ImageOrIndex, error) {
+	var filtered []imagedesc.ImageOrIndex
+	matched := map[string]struct{}{}
+
+	for _, item := range imgOrIndexes {
+		digest, err := item.Digest()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, only := range onlyRefs {
+			if only == digest.String() || only == item.Ref() || only == item.OrigRef {
+				filtered = append(filtered, item)
+				matched[only] = struct{}{}
+				break
+			}
+		}
+	}
+
+	for _, only := range onlyRefs {
+		if _, found := matched[only]; !found {
+			return nil, fmt.Errorf("Expected to find image '%s' in tar (hint: Use a digest or repo@digest as written to the tar)", only)
+		}
+	}
+
+	return filtered, nil
+}
+
+// preUploadLayers uploads every unique distributable layer found in the tar
+// in parallel (bounded by concurrency) before any manifest is written, so
+// blob uploads are not serialized behind per-image manifest pushes
+func (i *TarImageSet) preUploadLayers(imgOrIndexes []imagedesc.ImageOrIndex, importRepo regname.Repository, registry registry.ImagesReaderWriter) error {
+	layers, err := uniqueDistributableLayers(imgOrIndexes)
+	if err != nil {
+		return err
+	}
+	if len(layers) == 0 {
+		return nil
+	}
+
+	i.ui.BeginLinef("uploading %d layers...\n", len(layers))
+
+	maxUploads := i.concurrency
+	if limit, found := i.hostConcurrency[importRepo.RegistryStr()]; found {
+		maxUploads = limit
+	}
+
+	errCh := make(chan error, len(layers))
+	uploadThrottle := util.NewThrottle(maxUploads)
+
+	for _, layer := range layers {
+		layer := layer // copy
+
+		go func() {
+			uploadThrottle.Take()
+			defer uploadThrottle.Done()
+
+			errCh <- registry.WriteLayer(importRepo, layer)
+		}()
+	}
+
+	for j := 0; j < len(layers); j++ {
+		err := <-errCh
+		if err != nil {
+			return fmt.Errorf("Uploading layer: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// uniqueDistributableLayers walks every image in the set (including images
+// nested in indexes) collecting each distributable layer once, sorted larger
+// layers first so uploads of the biggest blobs start earliest
+func uniqueDistributableLayers(imgOrIndexes []imagedesc.ImageOrIndex) ([]regv1.Layer, error) {
+	seenLayers := map[string]struct{}{}
+	var layers []regv1.Layer
+
+	var collectImage func(img regv1.Image) error
+	collectImage = func(img regv1.Image) error {
+		imgLayers, err := img.Layers()
+		if err != nil {
+			return err
+		}
+		for _, layer := range imgLayers {
+			mediaType, err := layer.MediaType()
+			if err != nil {
+				return err
+			}
+			if !mediaType.IsDistributable() {
+				continue
+			}
+			digest, err := layer.Digest()
+			if err != nil {
+				return err
+			}
+			if _, found := seenLayers[digest.String()]; found {
+				continue
+			}
+			seenLayers[digest.String()] = struct{}{}
+			layers = append(layers, layer)
+		}
+		return nil
+	}
+
+	var collectIndex func(idx regv1.ImageIndex) error
+	collectIndex = func(idx regv1.ImageIndex) error {
+		describedIdx, ok := idx.(imagedesc.DescribedImageIndex)
+		if !ok {
+			return nil
+		}
+		for _, nestedIdx := range describedIdx.Indexes() {
+			err := collectIndex(nestedIdx)
+			if err != nil {
+				return err
+			}
+		}
+		for _, img := range describedIdx.Images() {
+			err := collectImage(img)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, item := range imgOrIndexes {
+		switch {
+		case item.Image != nil:
+			err := collectImage(*item.Image)
+			if err != nil {
+				return nil, err
+			}
+		case item.Index != nil:
+			err := collectIndex(*item.Index)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.Slice(layers, func(i, j int) bool {
+		sizeI, err := layers[i].Size()
+		if err != nil {
+			return false
+		}
+		sizeJ, err := layers[j].Size()
+		if err != nil {
+			return true
+		}
+		return sizeI > sizeJ
+	})
+
+	return layers, nil
+}
+
+// uniqueLayersSize estimates the tar size from the manifest reported sizes of
+// every unique layer that will be written
+func uniqueLayersSize(ids *imagedesc.ImageRefDescriptors) int64 {
+	var totalSize int64
+	seenLayers := map[string]struct{}{}
+
+	var sumImage func(td imagedesc.ImageDescriptor)
+	sumImage = func(td imagedesc.ImageDescriptor) {
+		for _, imgLayer := range td.Layers {
+			if _, found := seenLayers[imgLayer.Digest]; found {
+				continue
+			}
+			seenLayers[imgLayer.Digest] = struct{}{}
+			totalSize += imgLayer.Size
+		}
+	}
+
+	var sumIndex func(td imagedesc.ImageIndexDescriptor)
+	sumIndex = func(td imagedesc.ImageIndexDescriptor) {
+		for _, idx := range td.Indexes {
+			sumIndex(idx)
+		}
+		for _, img := range td.Images {
+			sumImage(img)
+		}
+	}
+
+	for _, td := range ids.Descriptors() {
+		switch {
+		case td.Image != nil:
+			sumImage(*td.Image)
+		case td.ImageIndex != nil:
+			sumIndex(*td.ImageIndex)
+		}
+	}
+
+	return totalSize
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imageset_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagetar"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestTarImportUploadsLayersBeforeManifests(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+	img1 := fakeRegistry.WithRandomImage("some/image-1")
+	img2 := fakeRegistry.WithRandomImage("some/image-2")
+	reg := fakeRegistry.Build()
+
+	tarPath := filepath.Join(t.TempDir(), "images.tar")
+	tarImageSet := imageset.NewTarImageSet(newTestImageSet(2), 2, 0, true, goui.NewNoopUI())
+
+	unprocessedImageRefs := imageset.NewUnprocessedImageRefs()
+	unprocessedImageRefs.Add(imageset.UnprocessedImageRef{DigestRef: img1.RefDigest})
+	unprocessedImageRefs.Add(imageset.UnprocessedImageRef{DigestRef: img2.RefDigest})
+
+	_, err := tarImageSet.Export(unprocessedImageRefs, tarPath, reg, imagetar.NewImageLayerWriterCheck(false))
+	require.NoError(t, err)
+
+	importRepo, err := regname.NewRepository(fakeRegistry.ReferenceOnTestServer("import/repo"))
+	require.NoError(t, err)
+
+	processedImages, err := tarImageSet.Import(tarPath, importRepo, reg)
+	require.NoError(t, err)
+	require.Equal(t, 2, processedImages.Len())
+}
+
+func TestTarImportOnlySelectedImages(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+	img1 := fakeRegistry.WithRandomImage("some/image-1")
+	img2 := fakeRegistry.WithRandomImage("some/image-2")
+	reg := fakeRegistry.Build()
+
+	tarPath := filepath.Join(t.TempDir(), "images.tar")
+	tarImageSet := imageset.NewTarImageSet(newTestImageSet(2), 2, 0, true, goui.NewNoopUI())
+
+	unprocessedImageRefs := imageset.NewUnprocessedImageRefs()
+	unprocessedImageRefs.Add(imageset.UnprocessedImageRef{DigestRef: img1.RefDigest})
+	unprocessedImageRefs.Add(imageset.UnprocessedImageRef{DigestRef: img2.RefDigest})
+
+	_, err := tarImageSet.Export(unprocessedImageRefs, tarPath, reg, imagetar.NewImageLayerWriterCheck(false))
+	require.NoError(t, err)
+
+	importRepo, err := regname.NewRepository(fakeRegistry.ReferenceOnTestServer("import/repo"))
+	require.NoError(t, err)
+
+	img1Digest := strings.SplitN(img1.RefDigest, "@", 2)[1]
+	tarImageSet.SetImportOnlyRefs([]string{img1Digest})
+
+	processedImages, err := tarImageSet.Import(tarPath, importRepo, reg)
+	require.NoError(t, err)
+	require.Equal(t, 1, processedImages.Len())
+	require.Contains(t, processedImages.All()[0].DigestRef, img1Digest)
+
+	tarImageSet.SetImportOnlyRefs([]string{"sha256:0000000000000000000000000000000000000000000000000000000000000000"})
+	_, err = tarImageSet.Import(tarPath, importRepo, reg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Expected to find image")
+}
+
+// BenchmarkTarImport compares import wall time for serial and parallel blob
+// uploads from a tar; run against a real registry by pointing the fake
+// registry's underlying server at it
+func BenchmarkTarImport(b *testing.B) {
+	for _, concurrency := range []int{1, 5} {
+		concurrency := concurrency
+
+		b.Run(fmt.Sprintf("concurrency-%d", concurrency), func(b *testing.B) {
+			fakeRegistry := helpers.NewFakeRegistry(b, &helpers.Logger{LogLevel: helpers.LogDebug})
+			defer fakeRegistry.CleanUp()
+			var refs []string
+			for i := 0; i < 4; i++ {
+				refs = append(refs, fakeRegistry.WithRandomImage(fmt.Sprintf("some/image-%d", i)).RefDigest)
+			}
+			reg := fakeRegistry.Build()
+
+			tarPath := filepath.Join(b.TempDir(), "images.tar")
+			tarImageSet := imageset.NewTarImageSet(newTestImageSet(concurrency), concurrency, 0, true, goui.NewNoopUI())
+
+			unprocessedImageRefs := imageset.NewUnprocessedImageRefs()
+			for _, ref := range refs {
+				unprocessedImageRefs.Add(imageset.UnprocessedImageRef{DigestRef: ref})
+			}
+
+			_, err := tarImageSet.Export(unprocessedImageRefs, tarPath, reg, imagetar.NewImageLayerWriterCheck(false))
+			require.NoError(b, err)
+
+			importRepo, err := regname.NewRepository(fakeRegistry.ReferenceOnTestServer("import/repo"))
+			require.NoError(b, err)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := tarImageSet.Import(tarPath, importRepo, reg)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func newTestImageSet(concurrency int) imageset.ImageSet {
+	return imageset.NewImageSet(concurrency, goui.NewNoopUI(), util.DefaultTagGenerator{})
+}
@@ -18,12 +18,16 @@ type UnprocessedImageRef struct {
 	OrigRef   string
 }
 
+// Key identifies the image being transferred; transfers are deduplicated by
+// digest, so references to the same digest via different tags or annotations
+// collapse into a single entry
 func (u UnprocessedImageRef) Key() string {
-	return u.DigestRef + ":" + u.Tag
+	return u.DigestRef
 }
 
 type UnprocessedImageRefs struct {
-	imgRefs map[string]UnprocessedImageRef
+	imgRefs    map[string]UnprocessedImageRef
+	duplicates int
 
 	lock sync.Mutex
 }
@@ -37,7 +41,39 @@ func (i *UnprocessedImageRefs) Add(imgRef UnprocessedImageRef) {
 
 	i.lock.Lock()
 	defer i.lock.Unlock()
-	i.imgRefs[imgRef.Key()] = imgRef
+
+	existing, found := i.imgRefs[imgRef.Key()]
+	if !found {
+		i.imgRefs[imgRef.Key()] = imgRef
+		return
+	}
+
+	// Same digest referenced again; keep a single transfer but carry over the
+	// tag, labels and original reference the duplicates brought along
+	i.duplicates++
+	if existing.Tag == "" {
+		existing.Tag = imgRef.Tag
+	}
+	if existing.OrigRef == "" {
+		existing.OrigRef = imgRef.OrigRef
+	}
+	for label, value := range imgRef.Labels {
+		if existing.Labels == nil {
+			existing.Labels = map[string]string{}
+		}
+		if _, present := existing.Labels[label]; !present {
+			existing.Labels[label] = value
+		}
+	}
+	i.imgRefs[imgRef.Key()] = existing
+}
+
+// DuplicateCount number of added references that were collapsed because
+// another reference to the same digest was already in the set
+func (i *UnprocessedImageRefs) DuplicateCount() int {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.duplicates
 }
 
 func (i *UnprocessedImageRefs) Length() int {
@@ -0,0 +1,32 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imageset_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+)
+
+func TestUnprocessedImageRefsDedupsByDigest(t *testing.T) {
+	digestRef := "registry.io/repo/img@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	otherDigestRef := "registry.io/repo/img@sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	refs := imageset.NewUnprocessedImageRefs()
+	refs.Add(imageset.UnprocessedImageRef{DigestRef: digestRef, OrigRef: "registry.io/original/img"})
+	refs.Add(imageset.UnprocessedImageRef{DigestRef: digestRef, Tag: "v1.0.0", Labels: map[string]string{"some-label": ""}})
+	refs.Add(imageset.UnprocessedImageRef{DigestRef: otherDigestRef})
+
+	assert.Equal(t, 2, refs.Length())
+	assert.Equal(t, 1, refs.DuplicateCount())
+
+	all := refs.All()
+	require.Len(t, all, 2)
+	assert.Equal(t, digestRef, all[0].DigestRef)
+	assert.Equal(t, "v1.0.0", all[0].Tag)
+	assert.Equal(t, "registry.io/original/img", all[0].OrigRef)
+	assert.Contains(t, all[0].Labels, "some-label")
+}
@@ -0,0 +1,204 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
+)
+
+// Archive is a read-only handle on an imgpkg tarball, providing a stable API
+// for ecosystem tools to list the stored images, pull a single image out and
+// combine archives without depending on the on-disk format internals
+type Archive struct {
+	path string
+	file tarFile
+}
+
+// ArchiveEntry summary of a top level image or index stored in an archive
+type ArchiveEntry struct {
+	// Refs references the item was written under
+	Refs []string
+	// OrigRef the reference the item was originally copied from, when recorded
+	OrigRef string
+	// Digest the item's manifest digest
+	Digest string
+	// IsIndex reports whether the item is an image index rather than an image
+	IsIndex bool
+	// Labels config labels recorded for the item (ex: the bundle label)
+	Labels map[string]string
+}
+
+// OpenArchive validates the archive format at path and returns a handle on it
+func OpenArchive(path string) (*Archive, error) {
+	file := tarFile{path}
+
+	format, err := TarReader{path}.getFormat(file)
+	if err != nil {
+		return nil, err
+	}
+	err = format.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Archive{path: path, file: file}, nil
+}
+
+// List returns a summary of every top level image and index in the archive
+// without reading any layer contents
+func (a *Archive) List() ([]ArchiveEntry, error) {
+	ids, err := TarReader{a.path}.getIdsFromManifest(a.file)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ArchiveEntry
+	for _, td := range ids.Descriptors() {
+		switch {
+		case td.Image != nil:
+			entries = append(entries, ArchiveEntry{
+				Refs:    td.Image.Refs,
+				OrigRef: td.Image.OrigRef,
+				Digest:  td.Image.Manifest.Digest,
+				Labels:  td.Image.Labels,
+			})
+		case td.ImageIndex != nil:
+			entries = append(entries, ArchiveEntry{
+				Refs:    td.ImageIndex.Refs,
+				OrigRef: td.ImageIndex.OrigRef,
+				Digest:  td.ImageIndex.Digest,
+				IsIndex: true,
+				Labels:  td.ImageIndex.Labels,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// FindImage returns the image with the given manifest digest (ex:
+// sha256:abc...) backed by the archive's layer contents, ready to be written
+// with any go-containerregistry tooling
+func (a *Archive) FindImage(digest string) (regv1.Image, error) {
+	items, err := NewTarReader(a.path).Read()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		itemDigest, err := item.Digest()
+		if err != nil {
+			return nil, err
+		}
+		if itemDigest.String() != digest {
+			continue
+		}
+		if item.Image == nil {
+			return nil, fmt.Errorf("Expected '%s' to be an image, but it is an index", digest)
+		}
+		return *item.Image, nil
+	}
+
+	return nil, fmt.Errorf("Expected to find image '%s' in archive '%s'", digest, a.path)
+}
+
+// Append writes a new archive to dstPath containing every item of this
+// archive plus every item of the archive at otherPath, deduplicated by
+// digest, without contacting any registry
+func (a *Archive) Append(otherPath string, dstPath string, writerOpts TarWriterOpts, ui goui.UI) error {
+	other, err := OpenArchive(otherPath)
+	if err != nil {
+		return err
+	}
+
+	ids, err := TarReader{a.path}.getIdsFromManifest(a.file)
+	if err != nil {
+		return err
+	}
+	otherIds, err := TarReader{other.path}.getIdsFromManifest(other.file)
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]struct{}{}
+	var combined []imagedesc.ImageOrImageIndexDescriptor
+	for _, td := range append(ids.Descriptors(), otherIds.Descriptors()...) {
+		if _, found := seen[td.SortKey()]; found {
+			continue
+		}
+		seen[td.SortKey()] = struct{}{}
+		combined = append(combined, td)
+	}
+
+	combinedBytes, err := json.Marshal(combined)
+	if err != nil {
+		return err
+	}
+	combinedIds, err := imagedesc.NewImageRefDescriptorsFromBytes(combinedBytes)
+	if err != nil {
+		return err
+	}
+
+	outputFile, err := os.Create(dstPath)
+	if err != nil {
+		return fmt.Errorf("Creating file '%s': %s", dstPath, err)
+	}
+	err = outputFile.Close()
+	if err != nil {
+		return err
+	}
+
+	outputFileOpener := func() (io.WriteCloser, error) {
+		return os.OpenFile(dstPath, os.O_RDWR, 0755)
+	}
+
+	descriptors := mergedDescriptors{ids: combinedIds, files: []tarFile{a.file, other.file}}
+	return NewTarWriter(descriptors, outputFileOpener, writerOpts, ui, NewImageLayerWriterCheck(true)).Write()
+}
+
+// mergedDescriptors serves a combined set of descriptors while reading layer
+// contents out of whichever source archive holds them
+type mergedDescriptors struct {
+	ids   *imagedesc.ImageRefDescriptors
+	files []tarFile
+}
+
+func (m mergedDescriptors) Descriptors() []imagedesc.ImageOrImageIndexDescriptor {
+	return m.ids.Descriptors()
+}
+
+func (m mergedDescriptors) AsBytes() ([]byte, error) { return m.ids.AsBytes() }
+
+func (m mergedDescriptors) FindLayer(layerTD imagedesc.ImageLayerDescriptor) (imagedesc.LayerContents, error) {
+	digest, err := regv1.NewHash(layerTD.Digest)
+	if err != nil {
+		return nil, err
+	}
+	return mergedLayerContents{m.files, digest.Algorithm + "-" + digest.Hex + ".tar.gz"}, nil
+}
+
+// mergedLayerContents layer contents located in one of several source
+// archives; presence is only known once the layer is opened
+type mergedLayerContents struct {
+	files     []tarFile
+	chunkPath string
+}
+
+func (l mergedLayerContents) Open() (io.ReadCloser, error) {
+	var lastErr error
+	for _, file := range l.files {
+		contents, err := file.Chunk(l.chunkPath).Open()
+		if err == nil {
+			return contents, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
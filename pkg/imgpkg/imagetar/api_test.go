@@ -0,0 +1,228 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
+)
+
+func TestOpenArchiveRejectsMissingFile(t *testing.T) {
+	_, err := OpenArchive(filepath.Join(t.TempDir(), "missing.tar"))
+	require.Error(t, err)
+}
+
+func TestArchiveList(t *testing.T) {
+	img, desc := randomTestImage(t, "registry.example.com/repo")
+	path := writeTestArchive(t, desc, img)
+
+	archive, err := OpenArchive(path)
+	require.NoError(t, err)
+
+	entries, err := archive.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	digest, err := img.Digest()
+	require.NoError(t, err)
+
+	assert.Equal(t, desc.Image.Refs, entries[0].Refs)
+	assert.Equal(t, digest.String(), entries[0].Digest)
+	assert.False(t, entries[0].IsIndex)
+}
+
+func TestArchiveFindImage(t *testing.T) {
+	img, desc := randomTestImage(t, "registry.example.com/repo")
+	path := writeTestArchive(t, desc, img)
+
+	archive, err := OpenArchive(path)
+	require.NoError(t, err)
+
+	digest, err := img.Digest()
+	require.NoError(t, err)
+
+	foundImg, err := archive.FindImage(digest.String())
+	require.NoError(t, err)
+
+	foundDigest, err := foundImg.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, digest, foundDigest)
+
+	layers, err := foundImg.Layers()
+	require.NoError(t, err)
+	require.Len(t, layers, 1)
+
+	reader, err := layers[0].Compressed()
+	require.NoError(t, err)
+	defer reader.Close()
+	_, err = ioutil.ReadAll(reader)
+	require.NoError(t, err)
+
+	_, err = archive.FindImage("sha256:0000000000000000000000000000000000000000000000000000000000000000")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected to find image")
+}
+
+func TestArchiveAppend(t *testing.T) {
+	img1, desc1 := randomTestImage(t, "registry.example.com/repo1")
+	path1 := writeTestArchive(t, desc1, img1)
+	img2, desc2 := randomTestImage(t, "registry.example.com/repo2")
+	path2 := writeTestArchive(t, desc2, img2)
+
+	archive, err := OpenArchive(path1)
+	require.NoError(t, err)
+
+	dstPath := filepath.Join(t.TempDir(), "combined.tar")
+	err = archive.Append(path2, dstPath, TarWriterOpts{Concurrency: 1}, goui.NewNoopUI())
+	require.NoError(t, err)
+
+	combined, err := OpenArchive(dstPath)
+	require.NoError(t, err)
+
+	entries, err := combined.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	for _, img := range []regv1.Image{img1, img2} {
+		digest, err := img.Digest()
+		require.NoError(t, err)
+
+		foundImg, err := combined.FindImage(digest.String())
+		require.NoError(t, err)
+
+		foundDigest, err := foundImg.Digest()
+		require.NoError(t, err)
+		assert.Equal(t, digest, foundDigest)
+	}
+}
+
+func TestArchiveAppendDeduplicatesByDigest(t *testing.T) {
+	img, desc := randomTestImage(t, "registry.example.com/repo")
+	path1 := writeTestArchive(t, desc, img)
+	path2 := writeTestArchive(t, desc, img)
+
+	archive, err := OpenArchive(path1)
+	require.NoError(t, err)
+
+	dstPath := filepath.Join(t.TempDir(), "combined.tar")
+	err = archive.Append(path2, dstPath, TarWriterOpts{Concurrency: 1}, goui.NewNoopUI())
+	require.NoError(t, err)
+
+	combined, err := OpenArchive(dstPath)
+	require.NoError(t, err)
+
+	entries, err := combined.List()
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func randomTestImage(t *testing.T, repo string) (regv1.Image, imagedesc.ImageOrImageIndexDescriptor) {
+	img, err := random.Image(512, 1)
+	require.NoError(t, err)
+
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	manifestBytes, err := img.RawManifest()
+	require.NoError(t, err)
+	manifest, err := img.Manifest()
+	require.NoError(t, err)
+	configBytes, err := img.RawConfigFile()
+	require.NoError(t, err)
+	configDigest, err := img.ConfigName()
+	require.NoError(t, err)
+
+	var layerDescs []imagedesc.ImageLayerDescriptor
+	layers, err := img.Layers()
+	require.NoError(t, err)
+	for _, layer := range layers {
+		layerDigest, err := layer.Digest()
+		require.NoError(t, err)
+		layerDiffID, err := layer.DiffID()
+		require.NoError(t, err)
+		layerSize, err := layer.Size()
+		require.NoError(t, err)
+		layerMediaType, err := layer.MediaType()
+		require.NoError(t, err)
+		layerDescs = append(layerDescs, imagedesc.ImageLayerDescriptor{
+			MediaType: string(layerMediaType),
+			Digest:    layerDigest.String(),
+			DiffID:    layerDiffID.String(),
+			Size:      layerSize,
+		})
+	}
+
+	desc := imagedesc.ImageOrImageIndexDescriptor{Image: &imagedesc.ImageDescriptor{
+		Refs:   []string{repo + "@" + digest.String()},
+		Layers: layerDescs,
+		Config: imagedesc.ConfigDescriptor{
+			Digest: configDigest.String(),
+			Raw:    string(configBytes),
+		},
+		Manifest: imagedesc.ManifestDescriptor{
+			MediaType: string(manifest.MediaType),
+			Digest:    digest.String(),
+			Raw:       string(manifestBytes),
+		},
+	}}
+
+	return img, desc
+}
+
+func writeTestArchive(t *testing.T, desc imagedesc.ImageOrImageIndexDescriptor, img regv1.Image) string {
+	path := filepath.Join(t.TempDir(), "test.tar")
+
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	opener := func() (io.WriteCloser, error) {
+		return os.OpenFile(path, os.O_RDWR, 0755)
+	}
+
+	descriptors := testDescriptors{descs: []imagedesc.ImageOrImageIndexDescriptor{desc}, img: img}
+	err = NewTarWriter(descriptors, opener, TarWriterOpts{Concurrency: 1}, goui.NewNoopUI(), NewImageLayerWriterCheck(true)).Write()
+	require.NoError(t, err)
+
+	return path
+}
+
+type testDescriptors struct {
+	descs []imagedesc.ImageOrImageIndexDescriptor
+	img   regv1.Image
+}
+
+func (d testDescriptors) Descriptors() []imagedesc.ImageOrImageIndexDescriptor { return d.descs }
+
+func (d testDescriptors) AsBytes() ([]byte, error) {
+	return json.Marshal(d.descs)
+}
+
+func (d testDescriptors) FindLayer(layerTD imagedesc.ImageLayerDescriptor) (imagedesc.LayerContents, error) {
+	digest, err := regv1.NewHash(layerTD.Digest)
+	if err != nil {
+		return nil, err
+	}
+	layer, err := d.img.LayerByDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+	return testLayerContents{layer}, nil
+}
+
+type testLayerContents struct {
+	layer regv1.Layer
+}
+
+func (l testLayerContents) Open() (io.ReadCloser, error) { return l.layer.Compressed() }
@@ -0,0 +1,167 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// AzureBlobDestination uploads the exported tar to an Azure Blob Storage
+// container as a block blob. The archive is staged in a local spool file and
+// uploaded on Finalize.
+//
+// Authentication uses a SAS token from the AZURE_STORAGE_SAS_TOKEN
+// environment variable; AZURE_STORAGE_ACCOUNT selects the storage account and
+// AZURE_STORAGE_ENDPOINT overrides the endpoint (ex: Azurite)
+type AzureBlobDestination struct {
+	container string
+	key       string
+
+	endpoint string
+	sasToken string
+
+	client    *http.Client
+	spoolPath string
+}
+
+// NewAzureBlobDestination constructor for a destination parsed from an
+// azblob://container/key URL
+func NewAzureBlobDestination(azURL string) (*AzureBlobDestination, error) {
+	parsedURL, err := url.Parse(azURL)
+	if err != nil || parsedURL.Scheme != "azblob" || parsedURL.Host == "" || strings.TrimPrefix(parsedURL.Path, "/") == "" {
+		return nil, fmt.Errorf("Expected Azure Blob destination to be in the format azblob://container/key, got '%s'", azURL)
+	}
+
+	endpoint := os.Getenv("AZURE_STORAGE_ENDPOINT")
+	if endpoint == "" {
+		account := os.Getenv("AZURE_STORAGE_ACCOUNT")
+		if account != "" {
+			endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", account)
+		}
+	}
+
+	return &AzureBlobDestination{
+		container: parsedURL.Host,
+		key:       strings.TrimPrefix(parsedURL.Path, "/"),
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		sasToken:  strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?"),
+		client:    http.DefaultClient,
+	}, nil
+}
+
+// Description of the destination for logs and error messages
+func (d *AzureBlobDestination) Description() string {
+	return fmt.Sprintf("azblob://%s/%s", d.container, d.key)
+}
+
+func (d *AzureBlobDestination) validate() error {
+	if d.endpoint == "" {
+		return fmt.Errorf("Expected AZURE_STORAGE_ACCOUNT or AZURE_STORAGE_ENDPOINT to be set when using an Azure Blob location")
+	}
+	if d.sasToken == "" {
+		return fmt.Errorf("Expected AZURE_STORAGE_SAS_TOKEN to be set when using an Azure Blob location")
+	}
+	return nil
+}
+
+func (d *AzureBlobDestination) blobURL() string {
+	return fmt.Sprintf("%s/%s/%s?%s", d.endpoint, d.container, d.key, d.sasToken)
+}
+
+// Prepare checks the configuration and creates the local spool file the
+// archive is staged in before the upload
+func (d *AzureBlobDestination) Prepare(estimatedSize int64) (func() (io.WriteCloser, error), error) {
+	err := d.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	spoolPath, opener, err := newSpoolFile(estimatedSize)
+	if err != nil {
+		return nil, err
+	}
+	d.spoolPath = spoolPath
+
+	return opener, nil
+}
+
+// Finalize uploads the staged archive as a block blob and removes the spool file
+func (d *AzureBlobDestination) Finalize() error {
+	defer os.Remove(d.spoolPath)
+
+	spool, err := os.Open(d.spoolPath)
+	if err != nil {
+		return err
+	}
+	defer spool.Close()
+
+	stat, err := spool.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, d.blobURL(), spool)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = stat.Size()
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Uploading to %s: %s", d.Description(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Uploading to %s: unexpected status code %d (%s)",
+			d.Description(), resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// AzureBlobSource reads the imported tar from an Azure Blob Storage
+// container, staging it locally first; configured via the same environment
+// variables as AzureBlobDestination
+type AzureBlobSource struct {
+	dst *AzureBlobDestination
+}
+
+// NewAzureBlobSource constructor for a source parsed from an
+// azblob://container/key URL
+func NewAzureBlobSource(azURL string) (*AzureBlobSource, error) {
+	dst, err := NewAzureBlobDestination(azURL)
+	if err != nil {
+		return nil, err
+	}
+	return &AzureBlobSource{dst: dst}, nil
+}
+
+// Description of the source for logs and error messages
+func (s *AzureBlobSource) Description() string { return s.dst.Description() }
+
+// LocalPath downloads the blob to a temporary file
+func (s *AzureBlobSource) LocalPath() (string, func(), error) {
+	err := s.dst.validate()
+	if err != nil {
+		return "", nil, err
+	}
+
+	resp, err := s.dst.client.Get(s.dst.blobURL())
+	if err != nil {
+		return "", nil, fmt.Errorf("Downloading %s: %s", s.Description(), err)
+	}
+	defer resp.Body.Close()
+
+	return downloadToTempFile(resp, s.Description())
+}
@@ -0,0 +1,126 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureBlobDestinationURLParsing(t *testing.T) {
+	for _, invalidURL := range []string{"container/key", "azblob://", "azblob://container", "azblob://container/"} {
+		_, err := NewAzureBlobDestination(invalidURL)
+		require.Error(t, err, "expected '%s' to be rejected", invalidURL)
+		assert.Contains(t, err.Error(), "azblob://container/key")
+	}
+
+	dst, err := NewAzureBlobDestination("azblob://my-container/staging/archive.tar")
+	require.NoError(t, err)
+	assert.Equal(t, "azblob://my-container/staging/archive.tar", dst.Description())
+}
+
+func TestAzureBlobDestinationUpload(t *testing.T) {
+	var receivedBody []byte
+	var receivedReq *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		receivedBody = bs
+		receivedReq = r
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	t.Setenv("AZURE_STORAGE_ENDPOINT", server.URL)
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "?sv=2021-08-06&sig=some-signature")
+
+	dst, err := NewAzureBlobDestination("azblob://my-container/staging/archive.tar")
+	require.NoError(t, err)
+
+	opener, err := dst.Prepare(1024)
+	require.NoError(t, err)
+
+	writer, err := opener()
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("archive-contents"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	require.NoError(t, dst.Finalize())
+
+	assert.Equal(t, "archive-contents", string(receivedBody))
+	assert.Equal(t, http.MethodPut, receivedReq.Method)
+	assert.Equal(t, "/my-container/staging/archive.tar", receivedReq.URL.Path)
+	assert.Equal(t, "some-signature", receivedReq.URL.Query().Get("sig"))
+	assert.Equal(t, "BlockBlob", receivedReq.Header.Get("x-ms-blob-type"))
+}
+
+func TestAzureBlobDestinationRequiresConfiguration(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_ENDPOINT", "")
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "")
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "")
+
+	dst, err := NewAzureBlobDestination("azblob://my-container/archive.tar")
+	require.NoError(t, err)
+
+	_, err = dst.Prepare(0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AZURE_STORAGE_ACCOUNT")
+
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "some-account")
+	dst, err = NewAzureBlobDestination("azblob://my-container/archive.tar")
+	require.NoError(t, err)
+
+	_, err = dst.Prepare(0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AZURE_STORAGE_SAS_TOKEN")
+}
+
+func TestAzureBlobSourceDownload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/my-container/staging/archive.tar", r.URL.Path)
+		w.Write([]byte("archive-contents"))
+	}))
+	defer server.Close()
+
+	t.Setenv("AZURE_STORAGE_ENDPOINT", server.URL)
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "sv=2021-08-06&sig=some-signature")
+
+	src, err := NewAzureBlobSource("azblob://my-container/staging/archive.tar")
+	require.NoError(t, err)
+
+	path, cleanup, err := src.LocalPath()
+	require.NoError(t, err)
+	defer cleanup()
+
+	bs, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "archive-contents", string(bs))
+}
+
+func TestNewSourceDispatch(t *testing.T) {
+	src, err := NewSource("/tmp/archive.tar")
+	require.NoError(t, err)
+	require.IsType(t, &FileSource{}, src)
+
+	src, err = NewSource("s3://bucket/key")
+	require.NoError(t, err)
+	require.IsType(t, &S3Source{}, src)
+
+	src, err = NewSource("gs://bucket/key")
+	require.NoError(t, err)
+	require.IsType(t, &GCSSource{}, src)
+
+	src, err = NewSource("azblob://container/key")
+	require.NoError(t, err)
+	require.IsType(t, &AzureBlobSource{}, src)
+
+	_, err = NewSource("s3://missing-key")
+	require.Error(t, err)
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+)
+
+// Destination abstracts the sink an exported tar is written to, so
+// alternative storage (object storage buckets, staging areas) can be plugged
+// into the copy pipeline
+type Destination interface {
+	// Description human readable location used in logs and error messages
+	Description() string
+	// Prepare validates the destination can hold estimatedSize bytes and
+	// returns the opener used by the tar writer; the opener may be called
+	// several times when layers are written concurrently
+	Prepare(estimatedSize int64) (func() (io.WriteCloser, error), error)
+	// Finalize completes the export after the archive is fully written
+	Finalize() error
+}
+
+// Source abstracts where an imported tar is read from, so archives staged in
+// alternative storage can be plugged into the copy pipeline
+type Source interface {
+	// Description human readable location used in logs and error messages
+	Description() string
+	// LocalPath stages the archive locally if needed and returns a path the
+	// tar reader can randomly access, plus a cleanup for any staged copy
+	LocalPath() (string, func(), error)
+}
+
+// NewSource returns the source for the given tar location; object storage
+// URLs (s3://, gs://, azblob://) are staged to a local file before reading
+func NewSource(location string) (Source, error) {
+	switch {
+	case strings.HasPrefix(location, "s3://"):
+		return NewS3Source(location)
+	case strings.HasPrefix(location, "gs://"):
+		return NewGCSSource(location)
+	case strings.HasPrefix(location, "azblob://"):
+		return NewAzureBlobSource(location)
+	default:
+		return NewFileSource(location), nil
+	}
+}
+
+// FileDestination writes the exported tar to a path on the local filesystem
+type FileDestination struct {
+	path          string
+	skipDiskCheck bool
+}
+
+// NewFileDestination constructor for a destination backed by a local file
+func NewFileDestination(path string, skipDiskCheck bool) *FileDestination {
+	return &FileDestination{path: path, skipDiskCheck: skipDiskCheck}
+}
+
+// Description of the destination for logs and error messages
+func (d *FileDestination) Description() string { return d.path }
+
+// Prepare truncates the destination file and checks the containing directory
+// has enough space for the estimated archive size
+func (d *FileDestination) Prepare(estimatedSize int64) (func() (io.WriteCloser, error), error) {
+	if !d.skipDiskCheck {
+		err := util.CheckAvailableDiskSpace(filepath.Dir(d.path), estimatedSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	outputFile, err := os.Create(d.path)
+	if err != nil {
+		return nil, fmt.Errorf("Creating file '%s': %s", d.path, err)
+	}
+
+	err = outputFile.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return func() (io.WriteCloser, error) {
+		return os.OpenFile(d.path, os.O_RDWR, 0755)
+	}, nil
+}
+
+// Finalize is a no-op for file destinations; the archive is already in place
+func (d *FileDestination) Finalize() error { return nil }
+
+// FileSource reads the imported tar from a path on the local filesystem
+type FileSource struct {
+	path string
+}
+
+// NewFileSource constructor for a source backed by a local file
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+// Description of the source for logs and error messages
+func (s *FileSource) Description() string { return s.path }
+
+// LocalPath returns the path of the archive; nothing is staged
+func (s *FileSource) LocalPath() (string, func(), error) {
+	return s.path, func() {}, nil
+}
+
+// newSpoolFile creates the local file object storage destinations stage the
+// archive in before uploading, checking it has room for the estimated size
+func newSpoolFile(estimatedSize int64) (string, func() (io.WriteCloser, error), error) {
+	spool, err := ioutil.TempFile("", "imgpkg-spool-*.tar")
+	if err != nil {
+		return "", nil, fmt.Errorf("Creating spool file: %s", err)
+	}
+	err = spool.Close()
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = util.CheckAvailableDiskSpace(filepath.Dir(spool.Name()), estimatedSize)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return spool.Name(), func() (io.WriteCloser, error) {
+		return os.OpenFile(spool.Name(), os.O_RDWR, 0755)
+	}, nil
+}
+
+// downloadToTempFile stages the body of the given response in a temporary
+// file, returning its path and a cleanup removing it
+func downloadToTempFile(resp *http.Response, description string) (string, func(), error) {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return "", nil, fmt.Errorf("Downloading %s: unexpected status code %d (%s)",
+			description, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	staged, err := ioutil.TempFile("", "imgpkg-staged-*.tar")
+	if err != nil {
+		return "", nil, fmt.Errorf("Creating staging file: %s", err)
+	}
+	cleanup := func() { os.Remove(staged.Name()) }
+
+	_, err = io.Copy(staged, resp.Body)
+	if err != nil {
+		staged.Close()
+		cleanup()
+		return "", nil, fmt.Errorf("Downloading %s: %s", description, err)
+	}
+
+	err = staged.Close()
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	return staged.Name(), cleanup, nil
+}
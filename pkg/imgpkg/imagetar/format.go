@@ -0,0 +1,83 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// tarFormatFileName tar entry carrying the format metadata; tars created by
+// older imgpkg versions do not contain it and are treated as version 1
+const tarFormatFileName = "imgpkg-format.json"
+
+// currentTarFormatVersion version written into new tars. Bump it only when
+// the layout changes in a way older readers cannot handle
+const currentTarFormatVersion = 1
+
+// knownTarFeatures features this version of imgpkg understands; a tar that
+// requires a feature not listed here cannot be imported
+var knownTarFeatures = map[string]struct{}{}
+
+// tarFormat self-describing metadata embedded in the tar so that future
+// format changes fail with an explicit version error instead of an obscure
+// parse failure
+type tarFormat struct {
+	// Version format version of the tar layout
+	Version int `json:"version"`
+	// Features format capabilities a reader must understand to import
+	// this tar
+	Features []string `json:"features,omitempty"`
+}
+
+// currentTarFormat metadata describing tars written by this version of imgpkg
+func currentTarFormat() tarFormat {
+	return tarFormat{Version: currentTarFormatVersion}
+}
+
+// legacyTarFormat metadata assumed for tars that predate format versioning
+func legacyTarFormat() tarFormat {
+	return tarFormat{Version: 1}
+}
+
+// AsBytes serializes the format metadata for inclusion in the tar
+func (f tarFormat) AsBytes() ([]byte, error) {
+	return json.Marshal(f)
+}
+
+// newTarFormatFromBytes deserializes the format metadata read from the tar
+func newTarFormatFromBytes(data []byte) (tarFormat, error) {
+	var format tarFormat
+	err := json.Unmarshal(data, &format)
+	if err != nil {
+		return tarFormat{}, fmt.Errorf("Parsing tar format metadata: %s", err)
+	}
+	if format.Version < 1 {
+		return tarFormat{}, fmt.Errorf("Parsing tar format metadata: expected version to be >= 1, got %d", format.Version)
+	}
+	return format, nil
+}
+
+// Validate errors when the tar declares a version or feature this version
+// of imgpkg does not support
+func (f tarFormat) Validate() error {
+	if f.Version > currentTarFormatVersion {
+		return fmt.Errorf("Tar was created by a newer imgpkg (tar format version %d, this version supports <= %d); need an imgpkg release supporting tar format version >= %d to import it",
+			f.Version, currentTarFormatVersion, f.Version)
+	}
+
+	var unknownFeatures []string
+	for _, feature := range f.Features {
+		if _, known := knownTarFeatures[feature]; !known {
+			unknownFeatures = append(unknownFeatures, feature)
+		}
+	}
+	if len(unknownFeatures) > 0 {
+		return fmt.Errorf("Tar was created by a newer imgpkg and requires unsupported tar feature(s): %s; need a newer imgpkg release to import it",
+			strings.Join(unknownFeatures, ", "))
+	}
+
+	return nil
+}
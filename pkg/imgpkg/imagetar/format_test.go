@@ -0,0 +1,45 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarFormatRoundTrip(t *testing.T) {
+	bs, err := currentTarFormat().AsBytes()
+	require.NoError(t, err)
+
+	format, err := newTarFormatFromBytes(bs)
+	require.NoError(t, err)
+
+	assert.Equal(t, currentTarFormatVersion, format.Version)
+	assert.NoError(t, format.Validate())
+}
+
+func TestTarFormatRejectsNewerVersion(t *testing.T) {
+	err := tarFormat{Version: currentTarFormatVersion + 1}.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Tar was created by a newer imgpkg")
+	assert.Contains(t, err.Error(), "need an imgpkg release supporting tar format version >= 2")
+}
+
+func TestTarFormatRejectsUnknownFeatures(t *testing.T) {
+	err := tarFormat{Version: 1, Features: []string{"zstd-layers"}}.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported tar feature(s): zstd-layers")
+}
+
+func TestTarFormatRejectsInvalidMetadata(t *testing.T) {
+	_, err := newTarFormatFromBytes([]byte("not-json"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Parsing tar format metadata")
+
+	_, err = newTarFormatFromBytes([]byte(`{"version": 0}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected version to be >= 1")
+}
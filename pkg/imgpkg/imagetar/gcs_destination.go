@@ -0,0 +1,157 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// GCSDestination uploads the exported tar to a Google Cloud Storage bucket.
+// The archive is staged in a local spool file and uploaded on Finalize.
+//
+// Authentication uses the access token from the GOOGLE_OAUTH_ACCESS_TOKEN
+// environment variable (ex: the output of 'gcloud auth print-access-token');
+// GCS_ENDPOINT_URL points at GCS compatible storage for testing
+type GCSDestination struct {
+	bucket string
+	key    string
+
+	token    string
+	endpoint string
+
+	client    *http.Client
+	spoolPath string
+}
+
+// NewGCSDestination constructor for a destination parsed from a gs://bucket/key URL
+func NewGCSDestination(gsURL string) (*GCSDestination, error) {
+	parsedURL, err := url.Parse(gsURL)
+	if err != nil || parsedURL.Scheme != "gs" || parsedURL.Host == "" || strings.TrimPrefix(parsedURL.Path, "/") == "" {
+		return nil, fmt.Errorf("Expected GCS destination to be in the format gs://bucket/key, got '%s'", gsURL)
+	}
+
+	endpoint := os.Getenv("GCS_ENDPOINT_URL")
+	if endpoint == "" {
+		endpoint = "https://storage.googleapis.com"
+	}
+
+	return &GCSDestination{
+		bucket:   parsedURL.Host,
+		key:      strings.TrimPrefix(parsedURL.Path, "/"),
+		token:    os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"),
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   http.DefaultClient,
+	}, nil
+}
+
+// Description of the destination for logs and error messages
+func (d *GCSDestination) Description() string {
+	return fmt.Sprintf("gs://%s/%s", d.bucket, d.key)
+}
+
+// Prepare checks a token is available and creates the local spool file the
+// archive is staged in before the upload
+func (d *GCSDestination) Prepare(estimatedSize int64) (func() (io.WriteCloser, error), error) {
+	if d.token == "" {
+		return nil, fmt.Errorf("Expected GOOGLE_OAUTH_ACCESS_TOKEN to be set when using a GCS destination")
+	}
+
+	spoolPath, opener, err := newSpoolFile(estimatedSize)
+	if err != nil {
+		return nil, err
+	}
+	d.spoolPath = spoolPath
+
+	return opener, nil
+}
+
+// Finalize uploads the staged archive via the JSON API and removes the spool file
+func (d *GCSDestination) Finalize() error {
+	defer os.Remove(d.spoolPath)
+
+	spool, err := os.Open(d.spoolPath)
+	if err != nil {
+		return err
+	}
+	defer spool.Close()
+
+	stat, err := spool.Stat()
+	if err != nil {
+		return err
+	}
+
+	uploadURL := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		d.endpoint, d.bucket, url.QueryEscape(d.key))
+
+	req, err := http.NewRequest(http.MethodPost, uploadURL, spool)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = stat.Size()
+	req.Header.Set("Authorization", "Bearer "+d.token)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Uploading to %s: %s", d.Description(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Uploading to %s: unexpected status code %d (%s)",
+			d.Description(), resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// GCSSource reads the imported tar from a Google Cloud Storage bucket,
+// staging it locally first; configured via the same environment variables as
+// GCSDestination
+type GCSSource struct {
+	dst *GCSDestination
+}
+
+// NewGCSSource constructor for a source parsed from a gs://bucket/key URL
+func NewGCSSource(gsURL string) (*GCSSource, error) {
+	dst, err := NewGCSDestination(gsURL)
+	if err != nil {
+		return nil, err
+	}
+	return &GCSSource{dst: dst}, nil
+}
+
+// Description of the source for logs and error messages
+func (s *GCSSource) Description() string { return s.dst.Description() }
+
+// LocalPath downloads the archive via the JSON API to a temporary file
+func (s *GCSSource) LocalPath() (string, func(), error) {
+	if s.dst.token == "" {
+		return "", nil, fmt.Errorf("Expected GOOGLE_OAUTH_ACCESS_TOKEN to be set when using a GCS source")
+	}
+
+	downloadURL := fmt.Sprintf("%s/storage/v1/b/%s/o/%s?alt=media",
+		s.dst.endpoint, s.dst.bucket, url.PathEscape(s.dst.key))
+
+	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.dst.token)
+
+	resp, err := s.dst.client.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("Downloading %s: %s", s.Description(), err)
+	}
+	defer resp.Body.Close()
+
+	return downloadToTempFile(resp, s.Description())
+}
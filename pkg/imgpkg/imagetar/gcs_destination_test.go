@@ -0,0 +1,101 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCSDestinationURLParsing(t *testing.T) {
+	for _, invalidURL := range []string{"bucket/key", "gs://", "gs://bucket", "gs://bucket/"} {
+		_, err := NewGCSDestination(invalidURL)
+		require.Error(t, err, "expected '%s' to be rejected", invalidURL)
+		assert.Contains(t, err.Error(), "gs://bucket/key")
+	}
+
+	dst, err := NewGCSDestination("gs://my-bucket/staging/archive.tar")
+	require.NoError(t, err)
+	assert.Equal(t, "gs://my-bucket/staging/archive.tar", dst.Description())
+}
+
+func TestGCSDestinationUpload(t *testing.T) {
+	var receivedBody []byte
+	var receivedReq *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		receivedBody = bs
+		receivedReq = r
+	}))
+	defer server.Close()
+
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "some-token")
+	t.Setenv("GCS_ENDPOINT_URL", server.URL)
+
+	dst, err := NewGCSDestination("gs://my-bucket/staging/archive.tar")
+	require.NoError(t, err)
+
+	opener, err := dst.Prepare(1024)
+	require.NoError(t, err)
+
+	writer, err := opener()
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("archive-contents"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	require.NoError(t, dst.Finalize())
+
+	assert.Equal(t, "archive-contents", string(receivedBody))
+	assert.Equal(t, http.MethodPost, receivedReq.Method)
+	assert.Equal(t, "/upload/storage/v1/b/my-bucket/o", receivedReq.URL.Path)
+	assert.Equal(t, "staging/archive.tar", receivedReq.URL.Query().Get("name"))
+	assert.Equal(t, "Bearer some-token", receivedReq.Header.Get("Authorization"))
+}
+
+func TestGCSDestinationRequiresToken(t *testing.T) {
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+
+	dst, err := NewGCSDestination("gs://my-bucket/archive.tar")
+	require.NoError(t, err)
+
+	_, err = dst.Prepare(0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "GOOGLE_OAUTH_ACCESS_TOKEN")
+}
+
+func TestGCSSourceDownload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/storage/v1/b/my-bucket/o/staging%2Farchive.tar", r.URL.EscapedPath())
+		assert.Equal(t, "media", r.URL.Query().Get("alt"))
+		assert.Equal(t, "Bearer some-token", r.Header.Get("Authorization"))
+		w.Write([]byte("archive-contents"))
+	}))
+	defer server.Close()
+
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "some-token")
+	t.Setenv("GCS_ENDPOINT_URL", server.URL)
+
+	src, err := NewGCSSource("gs://my-bucket/staging/archive.tar")
+	require.NoError(t, err)
+
+	path, cleanup, err := src.LocalPath()
+	require.NoError(t, err)
+	defer cleanup()
+
+	bs, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "archive-contents", string(bs))
+
+	cleanup()
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
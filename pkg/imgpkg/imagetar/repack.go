@@ -0,0 +1,175 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
+)
+
+// RepackOpts filters applied while repackaging an existing archive
+type RepackOpts struct {
+	// IncludeRefs only keeps top level items matching one of these references
+	// (empty: keep everything)
+	IncludeRefs []string
+	// ExcludeRefs drops top level items matching one of these references
+	ExcludeRefs []string
+	// Platforms only keeps top level images whose config matches one of these
+	// os/arch pairs (ex: linux/amd64); image indexes are kept whole
+	Platforms []string
+}
+
+// Repack writes a new archive to dstPath containing the subset of the archive
+// at srcPath selected by opts, without contacting any registry
+func Repack(srcPath string, dstPath string, opts RepackOpts, writerOpts TarWriterOpts, ui goui.UI, imageLayerWriterCheck ImageLayerWriterFilter) error {
+	file := tarFile{srcPath}
+
+	format, err := TarReader{srcPath}.getFormat(file)
+	if err != nil {
+		return err
+	}
+	err = format.Validate()
+	if err != nil {
+		return err
+	}
+
+	ids, err := TarReader{srcPath}.getIdsFromManifest(file)
+	if err != nil {
+		return err
+	}
+
+	filtered := filterDescriptors(ids.Descriptors(), opts)
+	if len(filtered) == 0 {
+		return fmt.Errorf("Expected filters to select at least one image from '%s'", srcPath)
+	}
+
+	filteredBytes, err := json.Marshal(filtered)
+	if err != nil {
+		return err
+	}
+	filteredIds, err := imagedesc.NewImageRefDescriptorsFromBytes(filteredBytes)
+	if err != nil {
+		return err
+	}
+
+	outputFile, err := os.Create(dstPath)
+	if err != nil {
+		return fmt.Errorf("Creating file '%s': %s", dstPath, err)
+	}
+	err = outputFile.Close()
+	if err != nil {
+		return err
+	}
+
+	outputFileOpener := func() (io.WriteCloser, error) {
+		return os.OpenFile(dstPath, os.O_RDWR, 0755)
+	}
+
+	return NewTarWriter(repackDescriptors{ids: filteredIds, file: file}, outputFileOpener, writerOpts, ui, imageLayerWriterCheck).Write()
+}
+
+// repackDescriptors serves a filtered set of descriptors while reading layer
+// contents out of the source archive
+type repackDescriptors struct {
+	ids  *imagedesc.ImageRefDescriptors
+	file tarFile
+}
+
+func (r repackDescriptors) Descriptors() []imagedesc.ImageOrImageIndexDescriptor {
+	return r.ids.Descriptors()
+}
+
+func (r repackDescriptors) AsBytes() ([]byte, error) { return r.ids.AsBytes() }
+
+func (r repackDescriptors) FindLayer(layerTD imagedesc.ImageLayerDescriptor) (imagedesc.LayerContents, error) {
+	return r.file.FindLayer(layerTD)
+}
+
+func filterDescriptors(descs []imagedesc.ImageOrImageIndexDescriptor, opts RepackOpts) []imagedesc.ImageOrImageIndexDescriptor {
+	var result []imagedesc.ImageOrImageIndexDescriptor
+
+	for _, td := range descs {
+		var refs []string
+		var origRef string
+
+		switch {
+		case td.Image != nil:
+			refs, origRef = td.Image.Refs, td.Image.OrigRef
+		case td.ImageIndex != nil:
+			refs, origRef = td.ImageIndex.Refs, td.ImageIndex.OrigRef
+		}
+
+		if len(opts.IncludeRefs) > 0 && !refMatches(opts.IncludeRefs, refs, origRef) {
+			continue
+		}
+		if refMatches(opts.ExcludeRefs, refs, origRef) {
+			continue
+		}
+		if len(opts.Platforms) > 0 && td.Image != nil && !contains(opts.Platforms, imagePlatform(*td.Image)) {
+			continue
+		}
+
+		result = append(result, td)
+	}
+
+	return result
+}
+
+// refMatches reports whether any of the patterns matches one of the item's
+// references, either exactly or by repository
+func refMatches(patterns []string, refs []string, origRef string) bool {
+	candidates := refs
+	if origRef != "" {
+		candidates = append(candidates, origRef)
+	}
+
+	for _, pattern := range patterns {
+		for _, candidate := range candidates {
+			if pattern == candidate || pattern == repoOf(candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// repoOf strips the digest or tag from a reference
+func repoOf(ref string) string {
+	if idx := strings.Index(ref, "@"); idx != -1 {
+		ref = ref[:idx]
+	}
+	if idx := strings.LastIndex(ref, ":"); idx != -1 && !strings.Contains(ref[idx:], "/") {
+		ref = ref[:idx]
+	}
+	return ref
+}
+
+// imagePlatform derives the os/arch pair from an image's config
+func imagePlatform(td imagedesc.ImageDescriptor) string {
+	var config struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	}
+
+	err := json.Unmarshal([]byte(td.Config.Raw), &config)
+	if err != nil {
+		return ""
+	}
+	return config.OS + "/" + config.Architecture
+}
+
+func contains(items []string, item string) bool {
+	for _, candidate := range items {
+		if candidate == item {
+			return true
+		}
+	}
+	return false
+}
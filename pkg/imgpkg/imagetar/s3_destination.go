@@ -0,0 +1,244 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// S3Destination uploads the exported tar to an S3 (or S3 compatible) bucket.
+// The archive is staged in a local spool file and uploaded on Finalize, since
+// S3 requires the content length and payload hash up front.
+//
+// Credentials are taken from the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN environment variables; AWS_REGION selects the region and
+// AWS_ENDPOINT_URL points at S3 compatible storage (e.g. MinIO)
+type S3Destination struct {
+	bucket string
+	key    string
+
+	region       string
+	endpoint     string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+
+	client    *http.Client
+	spoolPath string
+}
+
+// NewS3Destination constructor for a destination parsed from an s3://bucket/key URL
+func NewS3Destination(s3URL string) (*S3Destination, error) {
+	parsedURL, err := url.Parse(s3URL)
+	if err != nil || parsedURL.Scheme != "s3" || parsedURL.Host == "" || strings.TrimPrefix(parsedURL.Path, "/") == "" {
+		return nil, fmt.Errorf("Expected S3 destination to be in the format s3://bucket/key, got '%s'", s3URL)
+	}
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3Destination{
+		bucket:       parsedURL.Host,
+		key:          strings.TrimPrefix(parsedURL.Path, "/"),
+		region:       region,
+		endpoint:     os.Getenv("AWS_ENDPOINT_URL"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		client:       http.DefaultClient,
+	}, nil
+}
+
+// Description of the destination for logs and error messages
+func (d *S3Destination) Description() string {
+	return fmt.Sprintf("s3://%s/%s", d.bucket, d.key)
+}
+
+// Prepare checks credentials are available and creates the local spool file
+// the archive is staged in before the upload
+func (d *S3Destination) Prepare(estimatedSize int64) (func() (io.WriteCloser, error), error) {
+	if d.accessKey == "" || d.secretKey == "" {
+		return nil, fmt.Errorf("Expected AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY to be set when using an S3 destination")
+	}
+
+	spoolPath, opener, err := newSpoolFile(estimatedSize)
+	if err != nil {
+		return nil, err
+	}
+	d.spoolPath = spoolPath
+
+	return opener, nil
+}
+
+// Finalize uploads the staged archive via a signed PUT and removes the spool file
+func (d *S3Destination) Finalize() error {
+	defer os.Remove(d.spoolPath)
+
+	spool, err := os.Open(d.spoolPath)
+	if err != nil {
+		return err
+	}
+	defer spool.Close()
+
+	digest := sha256.New()
+	size, err := io.Copy(digest, spool)
+	if err != nil {
+		return fmt.Errorf("Hashing archive: %s", err)
+	}
+	payloadHash := hex.EncodeToString(digest.Sum(nil))
+
+	_, err = spool.Seek(0, 0)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, d.objectURL(), spool)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	d.sign(req, payloadHash, time.Now().UTC())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Uploading to %s: %s", d.Description(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Uploading to %s: unexpected status code %d (%s)",
+			d.Description(), resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+func (d *S3Destination) objectURL() string {
+	if d.endpoint != "" {
+		return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(d.endpoint, "/"), d.bucket, d.key)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", d.bucket, d.region, d.key)
+}
+
+// sign adds an AWS signature version 4 Authorization header to the request
+func (d *S3Destination) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	if d.sessionToken != "" {
+		req.Header.Set("x-amz-security-token", d.sessionToken)
+	}
+
+	signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if d.sessionToken != "" {
+		signedHeaders = append(signedHeaders, "x-amz-security-token")
+	}
+
+	canonicalHeaders := ""
+	for _, header := range signedHeaders {
+		value := req.Header.Get(header)
+		if header == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders += header + ":" + value + "\n"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{dateStamp, d.region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := []byte("AWS4" + d.secretKey)
+	for _, part := range []string{dateStamp, d.region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		d.accessKey, scope, strings.Join(signedHeaders, ";"),
+		hex.EncodeToString(hmacSHA256(signingKey, stringToSign))))
+}
+
+// S3Source reads the imported tar from an S3 (or S3 compatible) bucket,
+// staging it locally first; configured via the same environment variables as
+// S3Destination
+type S3Source struct {
+	dst *S3Destination
+}
+
+// NewS3Source constructor for a source parsed from an s3://bucket/key URL
+func NewS3Source(s3URL string) (*S3Source, error) {
+	dst, err := NewS3Destination(s3URL)
+	if err != nil {
+		return nil, err
+	}
+	return &S3Source{dst: dst}, nil
+}
+
+// Description of the source for logs and error messages
+func (s *S3Source) Description() string { return s.dst.Description() }
+
+// LocalPath downloads the archive via a signed GET to a temporary file
+func (s *S3Source) LocalPath() (string, func(), error) {
+	if s.dst.accessKey == "" || s.dst.secretKey == "" {
+		return "", nil, fmt.Errorf("Expected AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY to be set when using an S3 source")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.dst.objectURL(), nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	s.dst.sign(req, sha256Hex(nil), time.Now().UTC())
+
+	resp, err := s.dst.client.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("Downloading %s: %s", s.Description(), err)
+	}
+	defer resp.Body.Close()
+
+	return downloadToTempFile(resp, s.Description())
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(bs []byte) string {
+	digest := sha256.Sum256(bs)
+	return hex.EncodeToString(digest[:])
+}
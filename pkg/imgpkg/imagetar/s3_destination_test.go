@@ -0,0 +1,105 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3DestinationURLParsing(t *testing.T) {
+	for _, invalidURL := range []string{"bucket/key", "s3://", "s3://bucket", "s3://bucket/"} {
+		_, err := NewS3Destination(invalidURL)
+		require.Error(t, err, "expected '%s' to be rejected", invalidURL)
+		assert.Contains(t, err.Error(), "s3://bucket/key")
+	}
+
+	dst, err := NewS3Destination("s3://my-bucket/staging/archive.tar")
+	require.NoError(t, err)
+	assert.Equal(t, "s3://my-bucket/staging/archive.tar", dst.Description())
+}
+
+func TestS3DestinationUpload(t *testing.T) {
+	var receivedBody []byte
+	var receivedReq *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		receivedBody = bs
+		receivedReq = r
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "some-access-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "some-secret-key")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ENDPOINT_URL", server.URL)
+
+	dst, err := NewS3Destination("s3://my-bucket/staging/archive.tar")
+	require.NoError(t, err)
+
+	opener, err := dst.Prepare(1024)
+	require.NoError(t, err)
+
+	writer, err := opener()
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("archive-contents"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	require.NoError(t, dst.Finalize())
+
+	assert.Equal(t, "archive-contents", string(receivedBody))
+	assert.Equal(t, http.MethodPut, receivedReq.Method)
+	assert.Equal(t, "/my-bucket/staging/archive.tar", receivedReq.URL.Path)
+
+	payloadHash := sha256.Sum256([]byte("archive-contents"))
+	assert.Equal(t, hex.EncodeToString(payloadHash[:]), receivedReq.Header.Get("x-amz-content-sha256"))
+	assert.Contains(t, receivedReq.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=some-access-key/")
+	assert.Contains(t, receivedReq.Header.Get("Authorization"), "/eu-west-1/s3/aws4_request")
+	assert.Contains(t, receivedReq.Header.Get("Authorization"), "SignedHeaders=host;x-amz-content-sha256;x-amz-date")
+}
+
+func TestS3DestinationUploadFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "access denied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "some-access-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "some-secret-key")
+	t.Setenv("AWS_ENDPOINT_URL", server.URL)
+
+	dst, err := NewS3Destination("s3://my-bucket/archive.tar")
+	require.NoError(t, err)
+
+	opener, err := dst.Prepare(0)
+	require.NoError(t, err)
+	writer, err := opener()
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	err = dst.Finalize()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status code 403")
+}
+
+func TestS3DestinationRequiresCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+
+	dst, err := NewS3Destination("s3://my-bucket/archive.tar")
+	require.NoError(t, err)
+
+	_, err = dst.Prepare(0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AWS_ACCESS_KEY_ID")
+}
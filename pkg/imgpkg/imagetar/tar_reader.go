@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
 )
 
 type TarReader struct {
@@ -20,6 +21,16 @@ func NewTarReader(path string) TarReader {
 func (r TarReader) Read() ([]imagedesc.ImageOrIndex, error) {
 	file := tarFile{r.path}
 
+	format, err := r.getFormat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	err = format.Validate()
+	if err != nil {
+		return nil, err
+	}
+
 	ids, err := r.getIdsFromManifest(file)
 	if err != nil {
 		return nil, err
@@ -28,6 +39,26 @@ func (r TarReader) Read() ([]imagedesc.ImageOrIndex, error) {
 	return imagedesc.NewDescribedReader(ids, file).Read(), nil
 }
 
+// getFormat reads the format metadata entry; tars created before format
+// versioning was introduced do not have one and are treated as version 1
+func (r TarReader) getFormat(file tarFile) (tarFormat, error) {
+	formatFile, err := file.Chunk(tarFormatFileName).Open()
+	if err != nil {
+		if _, ok := err.(util.NonRetryableError); ok {
+			return legacyTarFormat(), nil
+		}
+		return tarFormat{}, err
+	}
+	defer formatFile.Close()
+
+	formatBytes, err := ioutil.ReadAll(formatFile)
+	if err != nil {
+		return tarFormat{}, err
+	}
+
+	return newTarFormatFromBytes(formatBytes)
+}
+
 func (r TarReader) getIdsFromManifest(file tarFile) (*imagedesc.ImageRefDescriptors, error) {
 	manifestFile, err := file.Chunk("manifest.json").Open()
 	if err != nil {
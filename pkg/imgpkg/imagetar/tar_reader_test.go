@@ -0,0 +1,77 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarReaderRejectsTarFromNewerImgpkg(t *testing.T) {
+	path := writeTestTar(t, map[string][]byte{
+		tarFormatFileName: []byte(`{"version": 99}`),
+		"manifest.json":   []byte(`[]`),
+	})
+
+	_, err := NewTarReader(path).Read()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Tar was created by a newer imgpkg")
+	assert.Contains(t, err.Error(), "tar format version 99")
+}
+
+func TestTarReaderRejectsTarRequiringUnknownFeature(t *testing.T) {
+	path := writeTestTar(t, map[string][]byte{
+		tarFormatFileName: []byte(`{"version": 1, "features": ["some-future-feature"]}`),
+		"manifest.json":   []byte(`[]`),
+	})
+
+	_, err := NewTarReader(path).Read()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported tar feature(s): some-future-feature")
+}
+
+func TestTarReaderTreatsTarWithoutFormatEntryAsVersion1(t *testing.T) {
+	path := writeTestTar(t, map[string][]byte{
+		"manifest.json": []byte(`[]`),
+	})
+
+	ids, err := NewTarReader(path).Read()
+	require.NoError(t, err)
+	assert.Len(t, ids, 0)
+}
+
+func writeTestTar(t *testing.T, entries map[string][]byte) string {
+	path := filepath.Join(t.TempDir(), "test.tar")
+
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	tw := tar.NewWriter(file)
+	// Write the format entry first to mirror TarWriter's layout
+	if formatBytes, found := entries[tarFormatFileName]; found {
+		writeTestTarEntry(t, tw, tarFormatFileName, formatBytes)
+	}
+	for name, contents := range entries {
+		if name == tarFormatFileName {
+			continue
+		}
+		writeTestTarEntry(t, tw, name, contents)
+	}
+	require.NoError(t, tw.Close())
+
+	return path
+}
+
+func writeTestTarEntry(t *testing.T, tw *tar.Writer, name string, contents []byte) {
+	err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(contents))})
+	require.NoError(t, err)
+	_, err = tw.Write(contents)
+	require.NoError(t, err)
+}
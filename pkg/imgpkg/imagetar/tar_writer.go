@@ -24,10 +24,22 @@ type Logger interface {
 
 type TarWriterOpts struct {
 	Concurrency int
+
+	// MaxMemoryBytes bounds the total memory used for layer copy buffers
+	// (0 means a buffer per concurrent write)
+	MaxMemoryBytes int64
+}
+
+// TarDescriptors is the source of image descriptors and layer contents
+// written to a tarball
+type TarDescriptors interface {
+	Descriptors() []imagedesc.ImageOrImageIndexDescriptor
+	AsBytes() ([]byte, error)
+	FindLayer(imagedesc.ImageLayerDescriptor) (imagedesc.LayerContents, error)
 }
 
 type TarWriter struct {
-	ids       *imagedesc.ImageRefDescriptors
+	ids       TarDescriptors
 	dstOpener func() (io.WriteCloser, error)
 
 	dst           io.WriteCloser
@@ -37,16 +49,35 @@ type TarWriter struct {
 	opts                  TarWriterOpts
 	ui                    goui.UI
 	imageLayerWriterCheck ImageLayerWriterFilter
+	copyBuffers           *util.BufferPool
 }
 
+// copyBufferSize size of each buffer used when streaming layer contents into the tar
+const copyBufferSize int64 = 1024 * 1024
+
 // NewTarWriter constructor returning a mechanism to write image refs / layers to a tarball on disk.
-func NewTarWriter(ids *imagedesc.ImageRefDescriptors, dstOpener func() (io.WriteCloser, error), opts TarWriterOpts, ui goui.UI, imageLayerWriterCheck ImageLayerWriterFilter) *TarWriter {
+func NewTarWriter(ids TarDescriptors, dstOpener func() (io.WriteCloser, error), opts TarWriterOpts, ui goui.UI, imageLayerWriterCheck ImageLayerWriterFilter) *TarWriter {
 	return &TarWriter{ids: ids, dstOpener: dstOpener, opts: opts, ui: ui, imageLayerWriterCheck: imageLayerWriterCheck}
 }
 
 func (w *TarWriter) Write() error {
 	var err error
 
+	maxBuffers := w.opts.Concurrency
+	if maxBuffers < 1 {
+		maxBuffers = 1
+	}
+	if w.opts.MaxMemoryBytes > 0 {
+		buffersWithinLimit := int(w.opts.MaxMemoryBytes / copyBufferSize)
+		if buffersWithinLimit < 1 {
+			buffersWithinLimit = 1
+		}
+		if buffersWithinLimit < maxBuffers {
+			maxBuffers = buffersWithinLimit
+		}
+	}
+	w.copyBuffers = util.NewBufferPool(maxBuffers, copyBufferSize)
+
 	w.dst, err = w.dstOpener()
 	if err != nil {
 		return err
@@ -56,6 +87,16 @@ func (w *TarWriter) Write() error {
 	w.tf = tar.NewWriter(w.dst)
 	defer w.tf.Close()
 
+	formatBytes, err := currentTarFormat().AsBytes()
+	if err != nil {
+		return err
+	}
+
+	err = w.writeTarEntry(w.tf, tarFormatFileName, bytes.NewReader(formatBytes), int64(len(formatBytes)))
+	if err != nil {
+		return err
+	}
+
 	idsBytes, err := w.ids.AsBytes()
 	if err != nil {
 		return err
@@ -195,6 +236,11 @@ func (w *TarWriter) writeLayers() error {
 		return err
 	}
 
+	if len(w.layersToWrite) > len(writtenLayers) {
+		w.ui.BeginLinef("deduplicated %d layer references into %d unique blobs\n",
+			len(w.layersToWrite), len(writtenLayers))
+	}
+
 	if isInflatable {
 		return w.fillInLayers(writtenLayers)
 	}
@@ -297,7 +343,9 @@ func (w *TarWriter) writeTarEntry(tw *tar.Writer, path string, r io.Reader, size
 
 	t1 := time.Now()
 
-	_, err = io.Copy(tw, r)
+	buf := w.copyBuffers.Get()
+	_, err = io.CopyBuffer(tw, r, buf)
+	w.copyBuffers.Put(buf)
 	if err != nil {
 		return fmt.Errorf("Copying data: %s", err)
 	}
@@ -0,0 +1,33 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"fmt"
+)
+
+// BufferPool hands out fixed size byte buffers from a bounded pool, blocking
+// when the pool is exhausted. It bounds the total memory used for copy
+// buffers by concurrent layer writes
+type BufferPool struct {
+	ch chan []byte
+}
+
+// NewBufferPool builds a BufferPool holding maxBuffers buffers of bufferSize bytes
+func NewBufferPool(maxBuffers int, bufferSize int64) *BufferPool {
+	if maxBuffers < 1 {
+		panic(fmt.Sprintf("Expected maximum number of buffers to be >= 1, but was %d", maxBuffers))
+	}
+	ch := make(chan []byte, maxBuffers)
+	for i := 0; i < maxBuffers; i++ {
+		ch <- make([]byte, bufferSize)
+	}
+	return &BufferPool{ch}
+}
+
+// Get retrieves a buffer from the pool, blocking until one is available
+func (p *BufferPool) Get() []byte { return <-p.ch }
+
+// Put returns a buffer to the pool
+func (p *BufferPool) Put(buf []byte) { p.ch <- buf }
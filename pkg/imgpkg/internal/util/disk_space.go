@@ -0,0 +1,27 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"fmt"
+)
+
+// CheckAvailableDiskSpace errors when the filesystem containing path has fewer
+// than requiredBytes available, so callers can fail before starting a write
+// that would die mid-way with a partial artifact. On platforms where the
+// available space cannot be determined the check is skipped
+func CheckAvailableDiskSpace(path string, requiredBytes int64) error {
+	availableBytes, supported, err := availableDiskSpace(path)
+	if err != nil {
+		return fmt.Errorf("Checking available disk space in '%s': %s", path, err)
+	}
+	if !supported {
+		return nil
+	}
+
+	if uint64(requiredBytes) > availableBytes {
+		return fmt.Errorf("Expected filesystem of '%s' to have at least %d bytes available, but only %d bytes are available (hint: use --skip-disk-check to bypass this validation)", path, requiredBytes, availableBytes)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux || darwin
+// +build linux darwin
+
+package util
+
+import (
+	"syscall"
+)
+
+// availableDiskSpace returns the number of bytes available to the current user
+// on the filesystem containing path
+func availableDiskSpace(path string) (uint64, bool, error) {
+	var stat syscall.Statfs_t
+
+	err := syscall.Statfs(path, &stat)
+	if err != nil {
+		return 0, true, err
+	}
+
+	return uint64(stat.Bavail) * uint64(stat.Bsize), true, nil
+}
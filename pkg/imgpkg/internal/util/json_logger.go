@@ -0,0 +1,71 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+)
+
+// NewUIJSONLevelLogger is a UIJSONWriter constructor, wrapping a ui.UI with a
+// specific log level and emitting each message as a single JSON line
+func NewUIJSONLevelLogger(level LogLevel, ui goui.UI) *UIJSONWriter {
+	return &UIJSONWriter{
+		UI:       ui,
+		LogLevel: level,
+	}
+}
+
+// UIJSONWriter writes leveled log messages to a ui.UI as JSON lines
+type UIJSONWriter struct {
+	goui.UI
+	LogLevel LogLevel
+}
+
+// Errorf used to log error related messages
+func (l UIJSONWriter) Errorf(msg string, args ...interface{}) {
+	l.logf("error", msg, args...)
+}
+
+// Warnf used to log warning related messages
+func (l UIJSONWriter) Warnf(msg string, args ...interface{}) {
+	if l.LogLevel <= LogWarn {
+		l.logf("warning", msg, args...)
+	}
+}
+
+// Debugf used to log debug related messages
+func (l UIJSONWriter) Debugf(msg string, args ...interface{}) {
+	if l.LogLevel <= LogDebug {
+		l.logf("debug", msg, args...)
+	}
+}
+
+// Tracef used to log trace related messages
+func (l UIJSONWriter) Tracef(msg string, args ...interface{}) {
+	if l.LogLevel == LogTrace {
+		l.logf("trace", msg, args...)
+	}
+}
+
+func (l UIJSONWriter) logf(level, msg string, args ...interface{}) {
+	entry := struct {
+		Level   string `json:"level"`
+		Message string `json:"message"`
+	}{
+		Level:   level,
+		Message: strings.TrimRight(fmt.Sprintf(msg, args...), "\n"),
+	}
+
+	bs, err := json.Marshal(entry)
+	if err != nil {
+		// fall back to plain text instead of dropping the message
+		l.BeginLinef(msg, args...)
+		return
+	}
+	l.BeginLinef("%s\n", bs)
+}
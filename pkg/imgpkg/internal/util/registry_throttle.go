@@ -0,0 +1,65 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"sync"
+)
+
+// RegistryThrottle limits the number of concurrent requests sent to each
+// registry hostname, independently of the global concurrency limit
+type RegistryThrottle struct {
+	maxPerRegistry int
+	limits         map[string]int
+	throttles      map[string]Throttle
+	lock           sync.Mutex
+}
+
+// NewRegistryThrottle builds a RegistryThrottle allowing maxPerRegistry
+// concurrent requests per registry hostname
+func NewRegistryThrottle(maxPerRegistry int) *RegistryThrottle {
+	return NewRegistryThrottleWithLimits(maxPerRegistry, nil)
+}
+
+// NewRegistryThrottleWithLimits builds a RegistryThrottle allowing
+// maxPerRegistry concurrent requests per registry hostname, except for
+// hostnames present in limits which get their own maximum
+func NewRegistryThrottleWithLimits(maxPerRegistry int, limits map[string]int) *RegistryThrottle {
+	return &RegistryThrottle{
+		maxPerRegistry: maxPerRegistry,
+		limits:         limits,
+		throttles:      map[string]Throttle{},
+	}
+}
+
+// Limit returns the maximum number of concurrent requests allowed for the
+// given registry hostname
+func (r *RegistryThrottle) Limit(registry string) int {
+	if limit, found := r.limits[registry]; found {
+		return limit
+	}
+	return r.maxPerRegistry
+}
+
+// Take acquires a slot for the provided registry hostname
+func (r *RegistryThrottle) Take(registry string) {
+	r.throttleFor(registry).Take()
+}
+
+// Done releases a slot for the provided registry hostname
+func (r *RegistryThrottle) Done(registry string) {
+	r.throttleFor(registry).Done()
+}
+
+func (r *RegistryThrottle) throttleFor(registry string) Throttle {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	throttle, found := r.throttles[registry]
+	if !found {
+		throttle = NewThrottle(r.Limit(registry))
+		r.throttles[registry] = throttle
+	}
+	return throttle
+}
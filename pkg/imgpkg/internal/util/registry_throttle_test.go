@@ -0,0 +1,32 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryThrottleLimit(t *testing.T) {
+	throttle := NewRegistryThrottle(5)
+	assert.Equal(t, 5, throttle.Limit("docker.io"))
+
+	throttle = NewRegistryThrottleWithLimits(5, map[string]int{"docker.io": 2, "harbor.corp": 16})
+	assert.Equal(t, 2, throttle.Limit("docker.io"))
+	assert.Equal(t, 16, throttle.Limit("harbor.corp"))
+	assert.Equal(t, 5, throttle.Limit("quay.io"))
+}
+
+func TestRegistryThrottleTakeDonePerRegistry(t *testing.T) {
+	throttle := NewRegistryThrottleWithLimits(2, map[string]int{"slow.corp": 1})
+
+	// the single slow.corp slot must not block other registries
+	throttle.Take("slow.corp")
+	throttle.Take("fast.corp")
+	throttle.Take("fast.corp")
+	throttle.Done("fast.corp")
+	throttle.Done("fast.corp")
+	throttle.Done("slow.corp")
+}
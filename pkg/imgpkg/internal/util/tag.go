@@ -85,6 +85,39 @@ func (tagGen RepoBasedTagGenerator) GenerateTag(item imagedigest.DigestWrap, imp
 	return uploadTagRef, nil
 }
 
+// TemplateTagGenerator implements GenerateTag
+// and generates tags from a user provided template
+type TemplateTagGenerator struct {
+	Template string
+}
+
+// GenerateTag generates a tag by substituting the %algorithm%, %hex% and
+// %repo% placeholders in the template with the image digest parts and the
+// dashed original repository path
+func (tagGen TemplateTagGenerator) GenerateTag(item imagedigest.DigestWrap, importRepo regname.Repository) (regname.Tag, error) {
+	origRepoPath := ""
+	if item.OrigRef() == "" {
+		origRepoPath = strings.Split(item.RegnameDigest().Name(), "@")[0]
+	} else {
+		origRepoPath = strings.Split(item.OrigRef(), "@")[0]
+	}
+	origRepoPath = strings.Join(strings.Split(origRepoPath, "/")[1:], "-")
+	origRepoPath = strings.ReplaceAll(origRepoPath, ":", "-")
+
+	digestArr := strings.Split(item.RegnameDigest().DigestStr(), ":")
+
+	tag := tagGen.Template
+	tag = strings.ReplaceAll(tag, "%algorithm%", digestArr[0])
+	tag = strings.ReplaceAll(tag, "%hex%", digestArr[1])
+	tag = strings.ReplaceAll(tag, "%repo%", origRepoPath)
+
+	uploadTagRef, err := regname.NewTag(fmt.Sprintf("%s:%s", importRepo.Name(), tag))
+	if err != nil {
+		return regname.Tag{}, fmt.Errorf("building templated tag: %s", err)
+	}
+	return uploadTagRef, nil
+}
+
 // BuildDefaultUploadTagRef Builds a tag from the digest Algorithm and Digest
 func BuildDefaultUploadTagRef(item WithDigest, importRepo regname.Repository) (regname.Tag, error) {
 	digest, err := item.Digest()
@@ -0,0 +1,58 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"strings"
+	"testing"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedigest"
+)
+
+func TestTemplateTagGenerator(t *testing.T) {
+	importRepo, err := regname.NewRepository("dest.io/repo")
+	if err != nil {
+		t.Fatalf("Expected repo to parse: %s", err)
+	}
+
+	digestWrap := imagedigest.DigestWrap{}
+	err = digestWrap.DigestWrap("src.io/some/app@sha256:aaaabbbbccccddddaaaabbbbccccddddaaaabbbbccccddddaaaabbbbccccdddd", "")
+	if err != nil {
+		t.Fatalf("Expected digest to parse: %s", err)
+	}
+
+	tagGen := TemplateTagGenerator{Template: "%repo%-%algorithm%-%hex%"}
+	tag, err := tagGen.GenerateTag(digestWrap, importRepo)
+	if err != nil {
+		t.Fatalf("Expected tag generation to succeed: %s", err)
+	}
+
+	expectedTag := "some-app-sha256-aaaabbbbccccddddaaaabbbbccccddddaaaabbbbccccddddaaaabbbbccccdddd"
+	if tag.TagStr() != expectedTag {
+		t.Fatalf("Expected tag '%s', but was '%s'", expectedTag, tag.TagStr())
+	}
+}
+
+func TestTemplateTagGeneratorInvalidTemplate(t *testing.T) {
+	importRepo, err := regname.NewRepository("dest.io/repo")
+	if err != nil {
+		t.Fatalf("Expected repo to parse: %s", err)
+	}
+
+	digestWrap := imagedigest.DigestWrap{}
+	err = digestWrap.DigestWrap("src.io/some/app@sha256:aaaabbbbccccddddaaaabbbbccccddddaaaabbbbccccddddaaaabbbbccccdddd", "")
+	if err != nil {
+		t.Fatalf("Expected digest to parse: %s", err)
+	}
+
+	tagGen := TemplateTagGenerator{Template: "not a valid tag"}
+	_, err = tagGen.GenerateTag(digestWrap, importRepo)
+	if err == nil {
+		t.Fatalf("Expected tag generation to fail")
+	}
+	if !strings.Contains(err.Error(), "building templated tag") {
+		t.Fatalf("Expected templated tag error, but was '%s'", err)
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 
-	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
 	"sigs.k8s.io/yaml"
 )
 
@@ -38,7 +38,12 @@ func NewBundleLockFromPath(path string) (BundleLock, error) {
 func NewBundleLockFromBytes(data []byte) (BundleLock, error) {
 	var lock BundleLock
 
-	err := yaml.UnmarshalStrict(data, &lock)
+	doc, err := extractSingleYAMLDocument(data)
+	if err != nil {
+		return lock, fmt.Errorf("Unmarshaling bundle lock: %s", err)
+	}
+
+	err = yaml.UnmarshalStrict(doc, &lock)
 	if err != nil {
 		return lock, fmt.Errorf("Unmarshaling bundle lock: %s", err)
 	}
@@ -58,7 +63,7 @@ func (b BundleLock) Validate() error {
 	if b.Kind != BundleLockKind {
 		return fmt.Errorf("Validating kind: Unknown kind (known: %s)", BundleLockKind)
 	}
-	if _, err := regname.NewDigest(b.Bundle.Image); err != nil {
+	if err := ValidateDigestRef(b.Bundle.Image); err != nil {
 		return fmt.Errorf("Expected ref to be in digest form, got '%s'", b.Bundle.Image)
 	}
 	return nil
@@ -84,7 +89,7 @@ func (b BundleLock) WriteToPath(path string) error {
 		return err
 	}
 
-	err = ioutil.WriteFile(path, bs, 0600)
+	err = util.WriteFileAtomically(path, bs, 0600)
 	if err != nil {
 		return fmt.Errorf("Writing bundle config: %s", err)
 	}
@@ -4,6 +4,8 @@
 package lockconfig_test
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -42,3 +44,34 @@ another-key: invalid
 		t.Fatalf("Expected error for unknown key, got: %s", err)
 	}
 }
+
+func TestBundleLockWriteToPathIsAtomic(t *testing.T) {
+	lock := lockconfig.BundleLock{
+		LockVersion: lockconfig.LockVersion{
+			APIVersion: lockconfig.BundleLockAPIVersion,
+			Kind:       lockconfig.BundleLockKind,
+		},
+		Bundle: lockconfig.BundleRef{
+			Image: "registry.io/foo@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		},
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bundle.lock.yml")
+
+	if err := lock.WriteToPath(path); err != nil {
+		t.Fatalf("Expected write to succeed, got: %s", err)
+	}
+
+	if _, err := lockconfig.NewBundleLockFromPath(path); err != nil {
+		t.Fatalf("Expected written lockfile to be readable, got: %s", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Reading dir: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected no temporary files to be left behind, found %d entries", len(entries))
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package lockconfig
+
+import (
+	"fmt"
+	"strings"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+)
+
+// digestHexLengths hex length expected for each supported digest algorithm
+var digestHexLengths = map[string]int{
+	"sha256": 64,
+	"sha512": 128,
+}
+
+// ParseDigest splits a digest of the form '<algorithm>:<hex>' validating the
+// algorithm is supported and the hex portion has the expected length
+func ParseDigest(digest string) (string, string, error) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("Expected digest to be in the form algorithm:hex, got '%s'", digest)
+	}
+
+	algorithm, hex := parts[0], parts[1]
+	expectedLength, supported := digestHexLengths[algorithm]
+	if !supported {
+		return "", "", fmt.Errorf("Expected digest algorithm to be one of %s, got '%s'", supportedDigestAlgorithms(), algorithm)
+	}
+	if len(hex) != expectedLength {
+		return "", "", fmt.Errorf("Expected %s digest to have %d hex characters, got %d", algorithm, expectedLength, len(hex))
+	}
+	if rest := strings.TrimLeft(hex, "0123456789abcdef"); len(rest) != 0 {
+		return "", "", fmt.Errorf("Expected digest to only contain hex characters, found '%c'", rest[0])
+	}
+
+	return algorithm, hex, nil
+}
+
+// ValidateDigestRef checks imageRef points at a digest with a supported algorithm
+func ValidateDigestRef(imageRef string) error {
+	_, digest, err := splitDigestRef(imageRef)
+	if err != nil {
+		return err
+	}
+	_, _, err = ParseDigest(digest)
+	return err
+}
+
+// NormalizeDigestRef expands imageRef to its fully qualified name, preserving
+// the digest algorithm (e.g. ubuntu@sha512:... -> index.docker.io/library/ubuntu@sha512:...)
+func NormalizeDigestRef(imageRef string) (string, error) {
+	base, digest, err := splitDigestRef(imageRef)
+	if err != nil {
+		return "", err
+	}
+	if _, _, err := ParseDigest(digest); err != nil {
+		return "", err
+	}
+
+	if tag, err := regname.NewTag(base, regname.WeakValidation); err == nil {
+		base = tag.Repository.Name()
+	}
+
+	repo, err := regname.NewRepository(base, regname.WeakValidation)
+	if err != nil {
+		return "", fmt.Errorf("Parsing repository of '%s': %s", imageRef, err)
+	}
+
+	return repo.Name() + "@" + digest, nil
+}
+
+func splitDigestRef(imageRef string) (string, string, error) {
+	parts := strings.SplitN(imageRef, "@", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("Expected image reference to contain a digest, got '%s'", imageRef)
+	}
+	return parts[0], parts[1], nil
+}
+
+func supportedDigestAlgorithms() string {
+	return "[sha256, sha512]"
+}
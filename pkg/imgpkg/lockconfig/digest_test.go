@@ -0,0 +1,88 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package lockconfig_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+)
+
+func TestParseDigest(t *testing.T) {
+	t.Run("accepts sha256 digests", func(t *testing.T) {
+		algorithm, hex, err := lockconfig.ParseDigest("sha256:" + strings.Repeat("a", 64))
+		require.NoError(t, err)
+		assert.Equal(t, "sha256", algorithm)
+		assert.Equal(t, strings.Repeat("a", 64), hex)
+	})
+
+	t.Run("accepts sha512 digests", func(t *testing.T) {
+		algorithm, hex, err := lockconfig.ParseDigest("sha512:" + strings.Repeat("b", 128))
+		require.NoError(t, err)
+		assert.Equal(t, "sha512", algorithm)
+		assert.Equal(t, strings.Repeat("b", 128), hex)
+	})
+
+	t.Run("when algorithm is unknown, it errors", func(t *testing.T) {
+		_, _, err := lockconfig.ParseDigest("md5:" + strings.Repeat("a", 32))
+		require.EqualError(t, err, "Expected digest algorithm to be one of [sha256, sha512], got 'md5'")
+	})
+
+	t.Run("when hex length does not match algorithm, it errors", func(t *testing.T) {
+		_, _, err := lockconfig.ParseDigest("sha512:" + strings.Repeat("a", 64))
+		require.EqualError(t, err, "Expected sha512 digest to have 128 hex characters, got 64")
+	})
+
+	t.Run("when hex contains non hex characters, it errors", func(t *testing.T) {
+		_, _, err := lockconfig.ParseDigest("sha256:" + strings.Repeat("z", 64))
+		require.EqualError(t, err, "Expected digest to only contain hex characters, found 'z'")
+	})
+
+	t.Run("when digest has no algorithm, it errors", func(t *testing.T) {
+		_, _, err := lockconfig.ParseDigest(strings.Repeat("a", 64))
+		require.Error(t, err)
+	})
+}
+
+func TestNormalizeDigestRef(t *testing.T) {
+	t.Run("expands short hand references to fully qualified names", func(t *testing.T) {
+		ref, err := lockconfig.NormalizeDigestRef("ubuntu@sha256:" + strings.Repeat("a", 64))
+		require.NoError(t, err)
+		assert.Equal(t, "index.docker.io/library/ubuntu@sha256:"+strings.Repeat("a", 64), ref)
+	})
+
+	t.Run("preserves sha512 digests", func(t *testing.T) {
+		ref, err := lockconfig.NormalizeDigestRef("some.registry.io/img@sha512:" + strings.Repeat("c", 128))
+		require.NoError(t, err)
+		assert.Equal(t, "some.registry.io/img@sha512:"+strings.Repeat("c", 128), ref)
+	})
+
+	t.Run("drops tag when both tag and digest are present", func(t *testing.T) {
+		ref, err := lockconfig.NormalizeDigestRef("some.registry.io/img:v1@sha256:" + strings.Repeat("a", 64))
+		require.NoError(t, err)
+		assert.Equal(t, "some.registry.io/img@sha256:"+strings.Repeat("a", 64), ref)
+	})
+
+	t.Run("when reference has no digest, it errors", func(t *testing.T) {
+		_, err := lockconfig.NormalizeDigestRef("some.registry.io/img:v1")
+		require.EqualError(t, err, "Expected image reference to contain a digest, got 'some.registry.io/img:v1'")
+	})
+}
+
+func TestImagesLockAcceptsSha512Digests(t *testing.T) {
+	data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: nginx@sha512:` + strings.Repeat("d", 128) + `
+`
+
+	lock, err := lockconfig.NewImagesLockFromBytes([]byte(data))
+	require.NoError(t, err)
+	require.Len(t, lock.Images, 1)
+	assert.Equal(t, "index.docker.io/library/nginx@sha512:"+strings.Repeat("d", 128), lock.Images[0].Image)
+}
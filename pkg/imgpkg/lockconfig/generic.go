@@ -5,6 +5,7 @@ package lockconfig
 
 import (
 	"fmt"
+	"strings"
 )
 
 type LockVersion struct {
@@ -23,3 +24,44 @@ func NewLockFromPath(path string) (*BundleLock, *ImagesLock, error) {
 	}
 	return nil, nil, fmt.Errorf("Trying to read bundle or images lock file: %s", err)
 }
+
+// extractSingleYAMLDocument strips the YAML document markers that lock files
+// are written with (and that users add when hand-editing) and returns the
+// single document contained in data. Multiple documents are rejected with a
+// precise error instead of the cryptic parser failure they used to produce
+func extractSingleYAMLDocument(data []byte) ([]byte, error) {
+	var docs []string
+	var currentDoc []string
+
+	endDoc := func() {
+		for _, line := range currentDoc {
+			trimmed := strings.TrimSpace(line)
+			if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+				docs = append(docs, strings.Join(currentDoc, "\n"))
+				return
+			}
+		}
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "---" || strings.HasPrefix(line, "--- ") {
+			endDoc()
+			currentDoc = nil
+			if rest := strings.TrimPrefix(line, "---"); strings.TrimSpace(rest) != "" {
+				currentDoc = append(currentDoc, strings.TrimSpace(rest))
+			}
+			continue
+		}
+		currentDoc = append(currentDoc, line)
+	}
+	endDoc()
+
+	switch len(docs) {
+	case 0:
+		return data, nil
+	case 1:
+		return []byte(docs[0]), nil
+	default:
+		return nil, fmt.Errorf("Expected a single YAML document, found %d (hint: Remove the extra documents separated by '---')", len(docs))
+	}
+}
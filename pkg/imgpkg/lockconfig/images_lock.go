@@ -6,19 +6,34 @@ package lockconfig
 import (
 	"fmt"
 	"io/ioutil"
+	"sort"
 
-	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
 	"sigs.k8s.io/yaml"
 )
 
 const (
 	ImagesLockKind       = "ImagesLock"
 	ImagesLockAPIVersion = "imgpkg.carvel.dev/v1alpha1"
+
+	// CopyModeAnnotationKey annotation on an ImagesLock entry declaring how the
+	// image should be treated during copy
+	CopyModeAnnotationKey = "imgpkg.carvel.dev/copy"
+	// CopyModeSkip value of the copy annotation marking the image as optional,
+	// allowing it to be left out of a copy
+	CopyModeSkip = "skip"
+
+	// OriginalLocationAnnotationKey annotation recording the reference an
+	// image was relocated from, allowing consumers to fall back to the
+	// upstream registry when the mirror lacks the image
+	OriginalLocationAnnotationKey = "imgpkg.carvel.dev/original-location"
 )
 
 type ImagesLock struct {
 	LockVersion
 	Images []ImageRef `json:"images,omitempty"` // This generated yaml, but due to lib we need to use `json`
+
+	preserveOrder bool
 }
 
 type ImageRef struct {
@@ -48,7 +63,12 @@ func NewImagesLockFromPath(path string) (ImagesLock, error) {
 func NewImagesLockFromBytes(data []byte) (ImagesLock, error) {
 	var lock ImagesLock
 
-	err := yaml.UnmarshalStrict(data, &lock)
+	doc, err := extractSingleYAMLDocument(data)
+	if err != nil {
+		return lock, fmt.Errorf("Unmarshaling images lock: %s", err)
+	}
+
+	err = yaml.UnmarshalStrict(doc, &lock)
 	if err != nil {
 		return lock, fmt.Errorf("Unmarshaling images lock: %s", err)
 	}
@@ -62,16 +82,22 @@ func NewImagesLockFromBytes(data []byte) (ImagesLock, error) {
 	// i.e. if a user provides ubuntu (short hand for library/ubuntu) in the ImageLock file,
 	// downstream processing will fail when comparing if images match.
 	for i, img := range lock.Images {
-		parsedImageRefName, err := regname.NewDigest(img.Image)
+		normalizedRef, err := NormalizeDigestRef(img.Image)
 		if err != nil {
 			panic(fmt.Sprintf("Image reference (%s) is in an invalid format: %s", img.Image, err.Error()))
 		}
-		lock.Images[i].Image = parsedImageRefName.Name()
+		lock.Images[i].Image = normalizedRef
 	}
 
 	return lock, nil
 }
 
+// SetPreserveOrder keeps images in their input order when serializing instead
+// of sorting them by image reference
+func (i *ImagesLock) SetPreserveOrder(enabled bool) {
+	i.preserveOrder = enabled
+}
+
 func (i *ImagesLock) AddImageRef(ref ImageRef) {
 	for _, image := range i.Images {
 		if image.Image == ref.Image {
@@ -89,7 +115,7 @@ func (i ImagesLock) Validate() error {
 		return fmt.Errorf("Validating kind: Unknown kind (known: %s)", ImagesLockKind)
 	}
 	for _, imageRef := range i.Images {
-		if _, err := regname.NewDigest(imageRef.Image); err != nil {
+		if err := ValidateDigestRef(imageRef.Image); err != nil {
 			return fmt.Errorf("Expected ref to be in digest form, got '%s'", imageRef.Image)
 		}
 	}
@@ -108,6 +134,13 @@ func (i ImagesLock) AsBytes() ([]byte, error) {
 		image.Image = image.PrimaryLocation()
 		imgRefs = append(imgRefs, image)
 	}
+
+	// sort entries so repeated runs produce identical lock files and GitOps
+	// diffs stay stable regardless of image processing order
+	if !i.preserveOrder {
+		sort.SliceStable(imgRefs, func(a, b int) bool { return imgRefs[a].Image < imgRefs[b].Image })
+	}
+
 	updatedImagesLock := i
 	updatedImagesLock.Images = imgRefs
 
@@ -125,7 +158,7 @@ func (i ImagesLock) WriteToPath(path string) error {
 		return err
 	}
 
-	err = ioutil.WriteFile(path, bs, 0600)
+	err = util.WriteFileAtomically(path, bs, 0600)
 	if err != nil {
 		return fmt.Errorf("Writing images config: %s", err)
 	}
@@ -146,6 +179,12 @@ func (i ImageRef) DeepCopy() ImageRef {
 	}
 }
 
+// SkipOnCopy checks if the image was marked as optional via the
+// 'imgpkg.carvel.dev/copy: skip' annotation, allowing it to be left out of a copy
+func (i ImageRef) SkipOnCopy() bool {
+	return i.Annotations[CopyModeAnnotationKey] == CopyModeSkip
+}
+
 func (i ImageRef) Locations() []string {
 	if i.locations == nil {
 		return []string{i.Image}
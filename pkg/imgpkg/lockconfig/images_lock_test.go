@@ -74,3 +74,95 @@ kind: ImagesLock
 		assert.Contains(t, subject.Images[0].Locations(), "some.image.io/test@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0")
 	})
 }
+
+func TestImagesLockOrdering(t *testing.T) {
+	digest := "sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0"
+	subject := lockconfig.NewEmptyImagesLock()
+	subject.AddImageRef(lockconfig.ImageRef{Image: "registry.io/zebra@" + digest})
+	subject.AddImageRef(lockconfig.ImageRef{Image: "registry.io/apple@" + digest})
+	subject.AddImageRef(lockconfig.ImageRef{Image: "registry.io/mango@" + digest})
+
+	t.Run("entries are sorted by image ref by default", func(t *testing.T) {
+		bs, err := subject.AsBytes()
+		require.NoError(t, err)
+
+		lock, err := lockconfig.NewImagesLockFromBytes(bs)
+		require.NoError(t, err)
+		require.Len(t, lock.Images, 3)
+		assert.Equal(t, "registry.io/apple@"+digest, lock.Images[0].Image)
+		assert.Equal(t, "registry.io/mango@"+digest, lock.Images[1].Image)
+		assert.Equal(t, "registry.io/zebra@"+digest, lock.Images[2].Image)
+	})
+
+	t.Run("preserve order keeps the input order", func(t *testing.T) {
+		subject := subject // copy
+		subject.SetPreserveOrder(true)
+
+		bs, err := subject.AsBytes()
+		require.NoError(t, err)
+
+		lock, err := lockconfig.NewImagesLockFromBytes(bs)
+		require.NoError(t, err)
+		require.Len(t, lock.Images, 3)
+		assert.Equal(t, "registry.io/zebra@"+digest, lock.Images[0].Image)
+		assert.Equal(t, "registry.io/apple@"+digest, lock.Images[1].Image)
+		assert.Equal(t, "registry.io/mango@"+digest, lock.Images[2].Image)
+	})
+}
+
+func TestImagesLockYAMLDocumentHandling(t *testing.T) {
+	digest := "sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0"
+
+	t.Run("leading document marker is accepted", func(t *testing.T) {
+		data := `---
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: registry.io/img@` + digest + `
+`
+		lock, err := lockconfig.NewImagesLockFromBytes([]byte(data))
+		require.NoError(t, err)
+		require.Len(t, lock.Images, 1)
+	})
+
+	t.Run("anchors are expanded", func(t *testing.T) {
+		data := `---
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: registry.io/img@` + digest + `
+  annotations: &annotations
+    team: platform
+- image: registry.io/other@` + digest + `
+  annotations: *annotations
+`
+		lock, err := lockconfig.NewImagesLockFromBytes([]byte(data))
+		require.NoError(t, err)
+		require.Len(t, lock.Images, 2)
+		assert.Equal(t, "platform", lock.Images[1].Annotations["team"])
+	})
+
+	t.Run("multiple documents are rejected with a precise error", func(t *testing.T) {
+		data := `---
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+---
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+`
+		_, err := lockconfig.NewImagesLockFromBytes([]byte(data))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected a single YAML document, found 2")
+	})
+
+	t.Run("comment-only documents are not counted", func(t *testing.T) {
+		data := `---
+# generated by imgpkg
+---
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+`
+		_, err := lockconfig.NewImagesLockFromBytes([]byte(data))
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,457 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ociregistry implements a minimal docker V2 / OCI distribution
+// registry backed by a directory on disk, so a local registry can outlive the
+// process serving it
+package ociregistry
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Handler serves the docker V2 / OCI distribution API, storing blobs and
+// manifests under a root directory so they survive restarts
+type Handler struct {
+	rootDir string
+	lock    sync.RWMutex
+}
+
+// NewHandler builds a Handler persisting registry contents under rootDir
+func NewHandler(rootDir string) (*Handler, error) {
+	for _, dir := range []string{"blobs", "uploads", "repos"} {
+		err := os.MkdirAll(filepath.Join(rootDir, dir), 0700)
+		if err != nil {
+			return nil, fmt.Errorf("Creating storage directory: %s", err)
+		}
+	}
+	return &Handler{rootDir: rootDir}, nil
+}
+
+func (h *Handler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	elems := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(elems) == 0 || elems[0] != "v2" {
+		writeError(resp, http.StatusNotFound, "NAME_UNKNOWN", "Unknown path")
+		return
+	}
+	elems = elems[1:]
+
+	switch {
+	case len(elems) == 0 || (len(elems) == 1 && elems[0] == ""):
+		resp.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		resp.WriteHeader(http.StatusOK)
+
+	case elems[len(elems)-1] == "_catalog":
+		h.handleCatalog(resp)
+
+	case len(elems) >= 3 && elems[len(elems)-2] == "tags" && elems[len(elems)-1] == "list":
+		h.handleTagsList(resp, strings.Join(elems[:len(elems)-2], "/"))
+
+	case len(elems) >= 3 && elems[len(elems)-2] == "manifests":
+		h.handleManifest(resp, req, strings.Join(elems[:len(elems)-2], "/"), elems[len(elems)-1])
+
+	case len(elems) >= 3 && elems[len(elems)-1] == "uploads" && elems[len(elems)-2] == "blobs":
+		h.handleUpload(resp, req, strings.Join(elems[:len(elems)-2], "/"), "")
+
+	case len(elems) >= 4 && elems[len(elems)-2] == "uploads" && elems[len(elems)-3] == "blobs":
+		h.handleUpload(resp, req, strings.Join(elems[:len(elems)-3], "/"), elems[len(elems)-1])
+
+	case len(elems) >= 3 && elems[len(elems)-2] == "blobs":
+		h.handleBlob(resp, req, elems[len(elems)-1])
+
+	default:
+		writeError(resp, http.StatusNotFound, "METHOD_UNKNOWN", "Unknown path")
+	}
+}
+
+func (h *Handler) handleBlob(resp http.ResponseWriter, req *http.Request, digest string) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		writeError(resp, http.StatusMethodNotAllowed, "METHOD_UNKNOWN", "Unsupported method")
+		return
+	}
+
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	path, err := h.blobPath(digest)
+	if err != nil {
+		writeError(resp, http.StatusBadRequest, "DIGEST_INVALID", err.Error())
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		writeError(resp, http.StatusNotFound, "BLOB_UNKNOWN", "Unknown blob")
+		return
+	}
+
+	resp.Header().Set("Content-Length", fmt.Sprintf("%d", info.Size()))
+	resp.Header().Set("Docker-Content-Digest", digest)
+	resp.WriteHeader(http.StatusOK)
+
+	if req.Method == http.MethodGet {
+		file, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		defer file.Close()
+		io.Copy(resp, file)
+	}
+}
+
+func (h *Handler) handleUpload(resp http.ResponseWriter, req *http.Request, repo string, uploadID string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	switch req.Method {
+	case http.MethodPost:
+		if digest := req.URL.Query().Get("digest"); digest != "" {
+			h.finishUpload(resp, req.Body, "", repo, digest)
+			return
+		}
+
+		uploadID, err := newUploadID()
+		if err != nil {
+			writeError(resp, http.StatusInternalServerError, "BLOB_UPLOAD_UNKNOWN", err.Error())
+			return
+		}
+
+		err = ioutil.WriteFile(h.uploadPath(uploadID), nil, 0600)
+		if err != nil {
+			writeError(resp, http.StatusInternalServerError, "BLOB_UPLOAD_UNKNOWN", err.Error())
+			return
+		}
+
+		resp.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%s", repo, uploadID))
+		resp.Header().Set("Range", "0-0")
+		resp.WriteHeader(http.StatusAccepted)
+
+	case http.MethodPatch:
+		size, err := h.appendUpload(uploadID, req.Body)
+		if err != nil {
+			writeError(resp, http.StatusInternalServerError, "BLOB_UPLOAD_INVALID", err.Error())
+			return
+		}
+
+		resp.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%s", repo, uploadID))
+		resp.Header().Set("Range", fmt.Sprintf("0-%d", size-1))
+		resp.WriteHeader(http.StatusAccepted)
+
+	case http.MethodPut:
+		digest := req.URL.Query().Get("digest")
+		if digest == "" {
+			writeError(resp, http.StatusBadRequest, "DIGEST_INVALID", "Expected digest query parameter")
+			return
+		}
+		h.finishUpload(resp, req.Body, uploadID, repo, digest)
+
+	default:
+		writeError(resp, http.StatusMethodNotAllowed, "METHOD_UNKNOWN", "Unsupported method")
+	}
+}
+
+// finishUpload appends the remaining body to the upload, verifies the
+// resulting contents against digest and promotes them into blob storage
+func (h *Handler) finishUpload(resp http.ResponseWriter, body io.Reader, uploadID string, repo string, digest string) {
+	var err error
+	if uploadID == "" {
+		uploadID, err = newUploadID()
+		if err != nil {
+			writeError(resp, http.StatusInternalServerError, "BLOB_UPLOAD_UNKNOWN", err.Error())
+			return
+		}
+		err = ioutil.WriteFile(h.uploadPath(uploadID), nil, 0600)
+		if err != nil {
+			writeError(resp, http.StatusInternalServerError, "BLOB_UPLOAD_UNKNOWN", err.Error())
+			return
+		}
+	}
+
+	_, err = h.appendUpload(uploadID, body)
+	if err != nil {
+		writeError(resp, http.StatusInternalServerError, "BLOB_UPLOAD_INVALID", err.Error())
+		return
+	}
+
+	uploadPath := h.uploadPath(uploadID)
+	actualDigest, err := fileDigest(uploadPath)
+	if err != nil {
+		writeError(resp, http.StatusInternalServerError, "BLOB_UPLOAD_INVALID", err.Error())
+		return
+	}
+	if actualDigest != digest {
+		_ = os.Remove(uploadPath)
+		writeError(resp, http.StatusBadRequest, "DIGEST_INVALID", "Uploaded contents do not match digest")
+		return
+	}
+
+	blobPath, err := h.blobPath(digest)
+	if err != nil {
+		writeError(resp, http.StatusBadRequest, "DIGEST_INVALID", err.Error())
+		return
+	}
+
+	err = os.MkdirAll(filepath.Dir(blobPath), 0700)
+	if err == nil {
+		err = os.Rename(uploadPath, blobPath)
+	}
+	if err != nil {
+		writeError(resp, http.StatusInternalServerError, "BLOB_UPLOAD_INVALID", err.Error())
+		return
+	}
+
+	resp.Header().Set("Docker-Content-Digest", digest)
+	resp.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/%s", repo, digest))
+	resp.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) handleManifest(resp http.ResponseWriter, req *http.Request, repo string, reference string) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		h.lock.RLock()
+		defer h.lock.RUnlock()
+
+		digest := reference
+		if !strings.Contains(reference, ":") {
+			tagBytes, err := ioutil.ReadFile(filepath.Join(h.repoDir(repo), "tags", reference))
+			if err != nil {
+				writeError(resp, http.StatusNotFound, "MANIFEST_UNKNOWN", "Unknown tag")
+				return
+			}
+			digest = strings.TrimSpace(string(tagBytes))
+		}
+
+		manifestPath := filepath.Join(h.repoDir(repo), "manifests", strings.ReplaceAll(digest, ":", "-"))
+		contents, err := ioutil.ReadFile(manifestPath)
+		if err != nil {
+			writeError(resp, http.StatusNotFound, "MANIFEST_UNKNOWN", "Unknown manifest")
+			return
+		}
+
+		mediaType, err := ioutil.ReadFile(manifestPath + ".media-type")
+		if err != nil {
+			mediaType = []byte("application/vnd.docker.distribution.manifest.v2+json")
+		}
+
+		resp.Header().Set("Content-Type", string(mediaType))
+		resp.Header().Set("Content-Length", fmt.Sprintf("%d", len(contents)))
+		resp.Header().Set("Docker-Content-Digest", digest)
+		resp.WriteHeader(http.StatusOK)
+
+		if req.Method == http.MethodGet {
+			resp.Write(contents)
+		}
+
+	case http.MethodPut:
+		h.lock.Lock()
+		defer h.lock.Unlock()
+
+		contents, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			writeError(resp, http.StatusInternalServerError, "MANIFEST_INVALID", err.Error())
+			return
+		}
+
+		digest := fmt.Sprintf("sha256:%x", sha256.Sum256(contents))
+
+		manifestsDir := filepath.Join(h.repoDir(repo), "manifests")
+		err = os.MkdirAll(manifestsDir, 0700)
+		if err == nil {
+			manifestPath := filepath.Join(manifestsDir, strings.ReplaceAll(digest, ":", "-"))
+			err = ioutil.WriteFile(manifestPath, contents, 0600)
+			if err == nil && req.Header.Get("Content-Type") != "" {
+				err = ioutil.WriteFile(manifestPath+".media-type", []byte(req.Header.Get("Content-Type")), 0600)
+			}
+		}
+		if err == nil && !strings.Contains(reference, ":") {
+			tagsDir := filepath.Join(h.repoDir(repo), "tags")
+			err = os.MkdirAll(tagsDir, 0700)
+			if err == nil {
+				err = ioutil.WriteFile(filepath.Join(tagsDir, reference), []byte(digest), 0600)
+			}
+		}
+		if err != nil {
+			writeError(resp, http.StatusInternalServerError, "MANIFEST_INVALID", err.Error())
+			return
+		}
+
+		resp.Header().Set("Docker-Content-Digest", digest)
+		resp.WriteHeader(http.StatusCreated)
+
+	case http.MethodDelete:
+		h.lock.Lock()
+		defer h.lock.Unlock()
+
+		if !strings.Contains(reference, ":") {
+			writeError(resp, http.StatusBadRequest, "UNSUPPORTED", "Expected deletes to happen by digest")
+			return
+		}
+
+		manifestPath := filepath.Join(h.repoDir(repo), "manifests", strings.ReplaceAll(reference, ":", "-"))
+		if _, err := os.Stat(manifestPath); err != nil {
+			writeError(resp, http.StatusNotFound, "MANIFEST_UNKNOWN", "Unknown manifest")
+			return
+		}
+
+		_ = os.Remove(manifestPath)
+		_ = os.Remove(manifestPath + ".media-type")
+		h.removeTagsFor(repo, reference)
+
+		resp.WriteHeader(http.StatusAccepted)
+
+	default:
+		writeError(resp, http.StatusMethodNotAllowed, "METHOD_UNKNOWN", "Unsupported method")
+	}
+}
+
+// removeTagsFor drops every tag in repo pointing at digest
+func (h *Handler) removeTagsFor(repo string, digest string) {
+	tagsDir := filepath.Join(h.repoDir(repo), "tags")
+	entries, err := ioutil.ReadDir(tagsDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		tagPath := filepath.Join(tagsDir, entry.Name())
+		contents, err := ioutil.ReadFile(tagPath)
+		if err == nil && strings.TrimSpace(string(contents)) == digest {
+			_ = os.Remove(tagPath)
+		}
+	}
+}
+
+func (h *Handler) handleTagsList(resp http.ResponseWriter, repo string) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	entries, err := ioutil.ReadDir(filepath.Join(h.repoDir(repo), "tags"))
+	if err != nil {
+		writeError(resp, http.StatusNotFound, "NAME_UNKNOWN", "Unknown repository")
+		return
+	}
+
+	tags := []string{}
+	for _, entry := range entries {
+		tags = append(tags, entry.Name())
+	}
+	sort.Strings(tags)
+
+	writeJSON(resp, fmt.Sprintf(`{"name":%q,"tags":[%s]}`, repo, quoteJoin(tags)))
+}
+
+func (h *Handler) handleCatalog(resp http.ResponseWriter) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	reposDir := filepath.Join(h.rootDir, "repos")
+	var repos []string
+
+	filepath.Walk(reposDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() || filepath.Base(path) != "manifests" {
+			return nil
+		}
+		rel, err := filepath.Rel(reposDir, filepath.Dir(path))
+		if err == nil {
+			repos = append(repos, filepath.ToSlash(rel))
+		}
+		return filepath.SkipDir
+	})
+	sort.Strings(repos)
+
+	writeJSON(resp, fmt.Sprintf(`{"repositories":[%s]}`, quoteJoin(repos)))
+}
+
+func (h *Handler) repoDir(repo string) string {
+	return filepath.Join(h.rootDir, "repos", filepath.FromSlash(repo))
+}
+
+func (h *Handler) uploadPath(uploadID string) string {
+	return filepath.Join(h.rootDir, "uploads", uploadID)
+}
+
+// blobPath locates a blob on disk, validating the digest so it cannot address
+// paths outside of blob storage
+func (h *Handler) blobPath(digest string) (string, error) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 || parts[0] != "sha256" {
+		return "", fmt.Errorf("Expected digest '%s' to be a sha256 digest", digest)
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return "", fmt.Errorf("Expected digest '%s' to be a sha256 digest", digest)
+	}
+	return filepath.Join(h.rootDir, "blobs", parts[0], parts[1]), nil
+}
+
+func (h *Handler) appendUpload(uploadID string, body io.Reader) (int64, error) {
+	file, err := os.OpenFile(h.uploadPath(uploadID), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, body)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func newUploadID() (string, error) {
+	randomBytes := make([]byte, 16)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(randomBytes), nil
+}
+
+func fileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
+
+func quoteJoin(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = fmt.Sprintf("%q", item)
+	}
+	return strings.Join(quoted, ",")
+}
+
+func writeJSON(resp http.ResponseWriter, body string) {
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(http.StatusOK)
+	resp.Write([]byte(body))
+}
+
+func writeError(resp http.ResponseWriter, status int, code string, message string) {
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(status)
+	resp.Write([]byte(fmt.Sprintf(`{"errors":[{"code":%q,"message":%q}]}`, code, message)))
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package ociregistry_test
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/ociregistry"
+)
+
+func TestHandlerRoundTrip(t *testing.T) {
+	storageDir := t.TempDir()
+
+	handler, err := ociregistry.NewHandler(storageDir)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	pushedImg, err := random.Image(512, 2)
+	require.NoError(t, err)
+	pushedDigest, err := pushedImg.Digest()
+	require.NoError(t, err)
+
+	tagRef, err := regname.NewTag(fmt.Sprintf("%s/repo/some-image:latest", serverURL.Host))
+	require.NoError(t, err)
+
+	require.NoError(t, remote.Write(tagRef, pushedImg))
+
+	t.Run("pulling the pushed image by tag returns the same image", func(t *testing.T) {
+		pulledImg, err := remote.Image(tagRef)
+		require.NoError(t, err)
+
+		pulledDigest, err := pulledImg.Digest()
+		require.NoError(t, err)
+		assert.Equal(t, pushedDigest, pulledDigest)
+
+		layers, err := pulledImg.Layers()
+		require.NoError(t, err)
+		assert.Len(t, layers, 2)
+	})
+
+	t.Run("contents survive a restart of the registry", func(t *testing.T) {
+		server.Close()
+
+		restartedHandler, err := ociregistry.NewHandler(storageDir)
+		require.NoError(t, err)
+
+		restartedServer := httptest.NewServer(restartedHandler)
+		defer restartedServer.Close()
+
+		restartedURL, err := url.Parse(restartedServer.URL)
+		require.NoError(t, err)
+
+		restartedRef, err := regname.NewTag(fmt.Sprintf("%s/repo/some-image:latest", restartedURL.Host))
+		require.NoError(t, err)
+
+		pulledImg, err := remote.Image(restartedRef)
+		require.NoError(t, err)
+
+		pulledDigest, err := pulledImg.Digest()
+		require.NoError(t, err)
+		assert.Equal(t, pushedDigest, pulledDigest)
+	})
+
+	t.Run("lists tags and repositories", func(t *testing.T) {
+		handler, err := ociregistry.NewHandler(storageDir)
+		require.NoError(t, err)
+
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		resp, err := server.Client().Get(server.URL + "/v2/repo/some-image/tags/list")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, 200, resp.StatusCode)
+
+		catalogResp, err := server.Client().Get(server.URL + "/v2/_catalog")
+		require.NoError(t, err)
+		defer catalogResp.Body.Close()
+		assert.Equal(t, 200, catalogResp.StatusCode)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/cppforlife/go-cli-ui/ui"
@@ -20,6 +21,29 @@ import (
 type Contents struct {
 	paths         []string
 	excludedPaths []string
+	opts          ContentsOpts
+}
+
+// ContentsOpts policies applied when pushing contents as an image
+type ContentsOpts struct {
+	// FailOnConflict fail when multiple paths provide the same file instead
+	// of overlaying (later paths win)
+	FailOnConflict bool
+	// Reproducible normalize file modes and zero timestamps in the pushed
+	// layer so identical content always produces identical digests
+	Reproducible bool
+	// Estargz compress the pushed layer in the eStargz format (seekable gzip
+	// with a TOC) so the image stays lazily pullable by stargz snapshotters
+	Estargz bool
+	// IncludeVCS keep VCS and editor artifacts (.git, .svn, .DS_Store, swap
+	// files) that are excluded by default
+	IncludeVCS bool
+	// DerefSymlinks follow symlinks and embed the target's contents instead of
+	// failing the push
+	DerefSymlinks bool
+	// StrictSymlinks error when a followed symlink points outside the pushed
+	// directory
+	StrictSymlinks bool
 }
 
 type ImagesWriter interface {
@@ -31,13 +55,25 @@ func NewContents(paths []string, excludedPaths []string) Contents {
 	return Contents{paths: paths, excludedPaths: excludedPaths}
 }
 
+// NewContentsWithOpts constructor for Contents with explicit policies
+func NewContentsWithOpts(paths []string, excludedPaths []string, opts ContentsOpts) Contents {
+	return Contents{paths: paths, excludedPaths: excludedPaths, opts: opts}
+}
+
 func (i Contents) Push(uploadRef regname.Tag, labels map[string]string, writer ImagesWriter, ui ui.UI) (string, error) {
-	err := i.validate()
+	err := i.reportConflicts(ui)
 	if err != nil {
 		return "", err
 	}
 
-	tarImg := ctlimg.NewTarImage(i.paths, i.excludedPaths, InfoLog{ui})
+	newTarImage := ctlimg.NewTarImage
+	if i.opts.Reproducible {
+		newTarImage = ctlimg.NewReproducibleTarImage
+	}
+	tarImg := newTarImage(i.paths, i.excludedPaths, InfoLog{ui})
+	tarImg.SetEstargz(i.opts.Estargz)
+	tarImg.SetIncludeVCS(i.opts.IncludeVCS)
+	tarImg.SetDerefSymlinks(i.opts.DerefSymlinks, i.opts.StrictSymlinks)
 
 	img, err := tarImg.AsFileImage(labels)
 	if err != nil {
@@ -69,11 +105,37 @@ func (i Contents) Push(uploadRef regname.Tag, labels map[string]string, writer I
 	return fmt.Sprintf("%s@%s", uploadRef.Context(), digest), nil
 }
 
-func (i Contents) validate() error {
-	return i.checkRepeatedPaths()
+// reportConflicts finds paths provided by multiple inputs. In the default
+// overlay mode each conflict is reported to the user along with the input that
+// wins (later inputs win); with failOnConflict set conflicts are an error.
+func (i Contents) reportConflicts(ui ui.UI) error {
+	conflictingPaths, pathSources, err := i.findRepeatedPaths()
+	if err != nil {
+		return err
+	}
+	if len(conflictingPaths) == 0 {
+		return nil
+	}
+
+	if i.opts.FailOnConflict {
+		var repeatedPaths []string
+		for _, relPath := range conflictingPaths {
+			repeatedPaths = append(repeatedPaths, pathSources[relPath]...)
+		}
+		return fmt.Errorf("Found duplicate paths: %s", strings.Join(repeatedPaths, ", "))
+	}
+
+	for _, relPath := range conflictingPaths {
+		sources := pathSources[relPath]
+		ui.BeginLinef("Overlay: '%s' is provided by multiple inputs (%s); using '%s'\n",
+			relPath, strings.Join(sources, ", "), sources[len(sources)-1])
+	}
+	return nil
 }
 
-func (i Contents) checkRepeatedPaths() error {
+// findRepeatedPaths returns the sorted image root paths that are provided by
+// more than one input along with the contributing sources in input order
+func (i Contents) findRepeatedPaths() ([]string, map[string][]string, error) {
 	imageRootPaths := make(map[string][]string)
 	for _, flagPath := range i.paths {
 		err := filepath.Walk(flagPath, func(currPath string, info os.FileInfo, err error) error {
@@ -97,18 +159,18 @@ func (i Contents) checkRepeatedPaths() error {
 		})
 
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 	}
 
-	var repeatedPaths []string
-	for _, v := range imageRootPaths {
-		if len(v) > 1 {
-			repeatedPaths = append(repeatedPaths, v...)
+	var conflictingPaths []string
+	pathSources := map[string][]string{}
+	for relPath, sources := range imageRootPaths {
+		if len(sources) > 1 {
+			conflictingPaths = append(conflictingPaths, relPath)
+			pathSources[relPath] = sources
 		}
 	}
-	if len(repeatedPaths) > 0 {
-		return fmt.Errorf("Found duplicate paths: %s", strings.Join(repeatedPaths, ", "))
-	}
-	return nil
+	sort.Strings(conflictingPaths)
+	return conflictingPaths, pathSources, nil
 }
@@ -0,0 +1,130 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package plainimage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	ctlimg "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/image"
+)
+
+// PlatformContents input paths for a single platform of an image index
+type PlatformContents struct {
+	Platform string // format: os/architecture[/variant]
+	Paths    []string
+}
+
+// IndexContents contents pushed as one image per platform plus an OCI index
+// referencing them
+type IndexContents struct {
+	platforms     []PlatformContents
+	excludedPaths []string
+}
+
+// IndexesWriter registry writer that can also write image indexes
+type IndexesWriter interface {
+	ImagesWriter
+	WriteIndex(regname.Reference, regv1.ImageIndex) error
+}
+
+// NewIndexContents constructor for IndexContents
+func NewIndexContents(platforms []PlatformContents, excludedPaths []string) IndexContents {
+	return IndexContents{platforms: platforms, excludedPaths: excludedPaths}
+}
+
+// Push builds one image per platform, pushes them by digest, and writes an
+// OCI index referencing all of them to uploadRef
+func (i IndexContents) Push(uploadRef regname.Tag, labels map[string]string, writer IndexesWriter, ui ui.UI) (string, error) {
+	if len(i.platforms) == 0 {
+		return "", fmt.Errorf("Expected at least one platform to be provided")
+	}
+
+	index := regv1.ImageIndex(empty.Index)
+
+	for _, platformContents := range i.platforms {
+		platform, err := parsePlatform(platformContents.Platform)
+		if err != nil {
+			return "", err
+		}
+
+		ui.BeginLinef("Building image for platform '%s'\n", platformContents.Platform)
+
+		img, err := i.platformImage(platformContents, platform, labels, ui)
+		if err != nil {
+			return "", err
+		}
+		defer img.Remove()
+
+		digest, err := img.Digest()
+		if err != nil {
+			return "", err
+		}
+
+		digestRef, err := regname.NewDigest(fmt.Sprintf("%s@%s", uploadRef.Context().Name(), digest))
+		if err != nil {
+			return "", err
+		}
+
+		err = writer.WriteImage(digestRef, img)
+		if err != nil {
+			return "", fmt.Errorf("Writing '%s': %s", digestRef.Name(), err)
+		}
+
+		index = mutate.AppendManifests(index, mutate.IndexAddendum{
+			Add:        img,
+			Descriptor: regv1.Descriptor{Platform: platform},
+		})
+	}
+
+	err := writer.WriteIndex(uploadRef, index)
+	if err != nil {
+		return "", fmt.Errorf("Writing index '%s': %s", uploadRef.Name(), err)
+	}
+
+	indexDigest, err := index.Digest()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s@%s", uploadRef.Context(), indexDigest), nil
+}
+
+// platformImage builds the image for a single platform, recording the
+// platform in the image config
+func (i IndexContents) platformImage(contents PlatformContents, platform *regv1.Platform, labels map[string]string, ui ui.UI) (*ctlimg.FileImage, error) {
+	tarImg := ctlimg.NewTarImage(contents.Paths, i.excludedPaths, InfoLog{ui})
+
+	img, err := tarImg.AsFileImage(labels)
+	if err != nil {
+		return nil, err
+	}
+
+	err = img.SetPlatform(platform)
+	if err != nil {
+		img.Remove()
+		return nil, err
+	}
+
+	return img, nil
+}
+
+// parsePlatform splits a platform of the form os/architecture[/variant]
+func parsePlatform(platform string) (*regv1.Platform, error) {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("Expected platform to be in the form os/architecture[/variant], got '%s'", platform)
+	}
+
+	result := &regv1.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		result.Variant = parts[2]
+	}
+	return result, nil
+}
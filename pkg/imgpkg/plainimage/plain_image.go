@@ -12,6 +12,7 @@ import (
 	regremote "github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	ctlimg "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/image"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
 )
 
 type ImagesDescriptor interface {
@@ -114,6 +115,10 @@ func (i *PlainImage) Fetch() (regv1.Image, error) {
 		return nil, fmt.Errorf("Fetching image: %s", err)
 	}
 
+	if imagedesc.IsSchema1(imgDescriptor.MediaType) {
+		return nil, imagedesc.Schema1Error(i.unparsedRef)
+	}
+
 	if !imgDescriptor.MediaType.IsImage() {
 		i.parsedDigest = imgDescriptor.Digest.String()
 		return nil, notAnImageError{imgDescriptor.MediaType}
@@ -150,7 +155,7 @@ func (i *PlainImage) IsImage() (bool, error) {
 	return true, nil
 }
 
-func (i *PlainImage) Pull(outputPath string, ui ui.UI) error {
+func (i *PlainImage) Pull(outputPath string, ui ui.UI, opts ...ctlimg.DirImageOpt) error {
 	img, err := i.Fetch()
 	if err != nil {
 		return err
@@ -162,7 +167,7 @@ func (i *PlainImage) Pull(outputPath string, ui ui.UI) error {
 
 	ui.BeginLinef("Pulling image '%s'\n", i.DigestRef())
 
-	err = ctlimg.NewDirImage(outputPath, img, ui).AsDirectory()
+	err = ctlimg.NewDirImage(outputPath, img, ui, opts...).AsDirectory()
 	if err != nil {
 		return fmt.Errorf("Extracting image into directory: %s", err)
 	}
@@ -0,0 +1,176 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// ImagesMetadata subset of registry operations needed to evaluate rules
+type ImagesMetadata interface {
+	Image(regname.Reference) (regv1.Image, error)
+	Digest(regname.Reference) (regv1.Hash, error)
+}
+
+// Violation single rule violation found for an image
+type Violation struct {
+	Ref    string
+	Reason string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("image '%s': %s", v.Ref, v.Reason)
+}
+
+// Checker evaluates image references against a policy
+type Checker struct {
+	policy Policy
+}
+
+// NewChecker constructor for a Checker enforcing policy
+func NewChecker(policy Policy) Checker {
+	return Checker{policy: policy}
+}
+
+// Check evaluates every reference against the policy rules, returning all
+// violations found
+func (c Checker) Check(refs []string, metadata ImagesMetadata) ([]Violation, error) {
+	var violations []Violation
+
+	for _, ref := range refs {
+		parsedRef, err := regname.ParseReference(ref, regname.WeakValidation)
+		if err != nil {
+			return nil, fmt.Errorf("Parsing image reference '%s': %s", ref, err)
+		}
+
+		violations = append(violations, c.checkRegistry(ref, parsedRef)...)
+
+		moreViolations, err := c.checkSignature(ref, parsedRef, metadata)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, moreViolations...)
+
+		moreViolations, err = c.checkNotationSignature(ref, parsedRef, metadata)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, moreViolations...)
+
+		moreViolations, err = c.checkSize(ref, parsedRef, metadata)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, moreViolations...)
+	}
+
+	return violations, nil
+}
+
+func (c Checker) checkRegistry(ref string, parsedRef regname.Reference) []Violation {
+	registryHost := parsedRef.Context().RegistryStr()
+
+	for _, denied := range c.policy.Rules.DeniedRegistries {
+		if registryHost == denied {
+			return []Violation{{Ref: ref, Reason: fmt.Sprintf("registry '%s' is denied by policy", registryHost)}}
+		}
+	}
+
+	if len(c.policy.Rules.AllowedRegistries) > 0 {
+		for _, allowed := range c.policy.Rules.AllowedRegistries {
+			if registryHost == allowed {
+				return nil
+			}
+		}
+		return []Violation{{Ref: ref, Reason: fmt.Sprintf("registry '%s' is not in the allowed registries (%s)",
+			registryHost, strings.Join(c.policy.Rules.AllowedRegistries, ", "))}}
+	}
+
+	return nil
+}
+
+// checkSignature looks for the cosign signature tag associated with the
+// image's digest
+func (c Checker) checkSignature(ref string, parsedRef regname.Reference, metadata ImagesMetadata) ([]Violation, error) {
+	if !c.policy.Rules.RequireSignatures {
+		return nil, nil
+	}
+
+	digest, err := metadata.Digest(parsedRef)
+	if err != nil {
+		return nil, fmt.Errorf("Resolving digest for image reference '%s': %s", ref, err)
+	}
+
+	signatureTag, err := regname.NewTag(fmt.Sprintf("%s:%s-%s.sig", parsedRef.Context().Name(), digest.Algorithm, digest.Hex), regname.WeakValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = metadata.Digest(signatureTag)
+	if err != nil {
+		return []Violation{{Ref: ref, Reason: "no cosign signature found, but policy requires signatures"}}, nil
+	}
+	return nil, nil
+}
+
+// checkNotationSignature looks for the Notary v2 referrers fallback tag
+// associated with the image's digest. Only signature presence is verified;
+// trust policy evaluation is left to the notation toolchain
+func (c Checker) checkNotationSignature(ref string, parsedRef regname.Reference, metadata ImagesMetadata) ([]Violation, error) {
+	if !c.policy.Rules.RequireNotationSignatures {
+		return nil, nil
+	}
+
+	digest, err := metadata.Digest(parsedRef)
+	if err != nil {
+		return nil, fmt.Errorf("Resolving digest for image reference '%s': %s", ref, err)
+	}
+
+	referrersTag, err := regname.NewTag(fmt.Sprintf("%s:%s-%s", parsedRef.Context().Name(), digest.Algorithm, digest.Hex), regname.WeakValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = metadata.Digest(referrersTag)
+	if err != nil {
+		return []Violation{{Ref: ref, Reason: "no notation signature found, but policy requires notation signatures"}}, nil
+	}
+	return nil, nil
+}
+
+func (c Checker) checkSize(ref string, parsedRef regname.Reference, metadata ImagesMetadata) ([]Violation, error) {
+	if c.policy.Rules.MaxImageSizeBytes == 0 {
+		return nil, nil
+	}
+
+	img, err := metadata.Image(parsedRef)
+	if err != nil {
+		// Image indexes do not have a single size; skip the size rule for them
+		return nil, nil
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, err
+	}
+
+	var totalSize int64
+	for _, layer := range layers {
+		size, err := layer.Size()
+		if err != nil {
+			return nil, err
+		}
+		totalSize += size
+	}
+
+	if totalSize > c.policy.Rules.MaxImageSizeBytes {
+		return []Violation{{Ref: ref, Reason: fmt.Sprintf("size %d bytes exceeds the policy limit of %d bytes",
+			totalSize, c.policy.Rules.MaxImageSizeBytes)}}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package policy provides guardrails consulted before images are pushed or
+// relocated, rejecting images that violate the configured rules
+package policy
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// PolicyKind kind of the policy document
+	PolicyKind = "Policy"
+	// PolicyAPIVersion apiVersion of the policy document
+	PolicyAPIVersion = "imgpkg.carvel.dev/v1alpha1"
+)
+
+// Policy set of rules every image involved in a push or copy must satisfy
+type Policy struct {
+	APIVersion string `json:"apiVersion"` // This generated yaml, but due to lib we need to use `json`
+	Kind       string `json:"kind"`       // This generated yaml, but due to lib we need to use `json`
+	Rules      Rules  `json:"rules,omitempty"`
+}
+
+// Rules individual guardrails of a policy. Zero valued rules are not enforced
+type Rules struct {
+	// AllowedRegistries when non empty, only images hosted on one of these
+	// registries are accepted
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+	// DeniedRegistries images hosted on one of these registries are rejected
+	DeniedRegistries []string `json:"deniedRegistries,omitempty"`
+	// RequireNotationSignatures when true, images without a notation
+	// (Notary v2) signature referrer are rejected. Only signature presence is
+	// checked; evaluating notation trust policies requires the notation
+	// toolchain and is not performed by imgpkg
+	RequireNotationSignatures bool `json:"requireNotationSignatures,omitempty"`
+	// RequireSignatures when true, images without a cosign signature are rejected
+	RequireSignatures bool `json:"requireSignatures,omitempty"`
+	// MaxImageSizeBytes when non zero, images whose compressed layers exceed
+	// this size are rejected
+	MaxImageSizeBytes int64 `json:"maxImageSizeBytes,omitempty"`
+}
+
+// NewPolicyFromPath reads the policy present at path
+func NewPolicyFromPath(path string) (Policy, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Policy{}, fmt.Errorf("Reading path %s: %s", path, err)
+	}
+
+	pol, err := NewPolicyFromBytes(bs)
+	if err != nil {
+		return Policy{}, fmt.Errorf("Parsing policy %s: %s", path, err)
+	}
+	return pol, nil
+}
+
+// NewPolicyFromBytes parses the policy from data
+func NewPolicyFromBytes(data []byte) (Policy, error) {
+	var pol Policy
+
+	err := yaml.UnmarshalStrict(data, &pol)
+	if err != nil {
+		return pol, fmt.Errorf("Unmarshaling policy: %s", err)
+	}
+
+	err = pol.Validate()
+	if err != nil {
+		return pol, fmt.Errorf("Validating policy: %s", err)
+	}
+
+	return pol, nil
+}
+
+// Validate checks the policy document is of a known apiVersion and kind
+func (p Policy) Validate() error {
+	if p.APIVersion != PolicyAPIVersion {
+		return fmt.Errorf("Validating apiVersion: Unknown version (known: %s)", PolicyAPIVersion)
+	}
+	if p.Kind != PolicyKind {
+		return fmt.Errorf("Validating kind: Unknown kind (known: %s)", PolicyKind)
+	}
+	return nil
+}
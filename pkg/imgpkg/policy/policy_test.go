@@ -0,0 +1,143 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package policy_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/policy"
+)
+
+const imgDigest = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+type fakeMetadata struct {
+	images  map[string]regv1.Image
+	digests map[string]string
+}
+
+func (f fakeMetadata) Image(ref regname.Reference) (regv1.Image, error) {
+	img, found := f.images[ref.Name()]
+	if !found {
+		return nil, fmt.Errorf("image '%s' not found", ref.Name())
+	}
+	return img, nil
+}
+
+func (f fakeMetadata) Digest(ref regname.Reference) (regv1.Hash, error) {
+	digest, found := f.digests[ref.Name()]
+	if !found {
+		return regv1.Hash{}, fmt.Errorf("image '%s' not found", ref.Name())
+	}
+	return regv1.NewHash(digest)
+}
+
+func TestPolicyValidation(t *testing.T) {
+	t.Run("accepts a valid policy document", func(t *testing.T) {
+		_, err := policy.NewPolicyFromBytes([]byte(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: Policy
+rules:
+  allowedRegistries:
+  - internal.io
+`))
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects unknown kinds and unknown fields", func(t *testing.T) {
+		_, err := policy.NewPolicyFromBytes([]byte("apiVersion: imgpkg.carvel.dev/v1alpha1\nkind: NotAPolicy\n"))
+		require.Error(t, err)
+
+		_, err = policy.NewPolicyFromBytes([]byte("apiVersion: imgpkg.carvel.dev/v1alpha1\nkind: Policy\nunknownField: true\n"))
+		require.Error(t, err)
+	})
+}
+
+func TestCheckerRegistryRules(t *testing.T) {
+	t.Run("rejects images outside the allowed registries", func(t *testing.T) {
+		checker := policy.NewChecker(policy.Policy{Rules: policy.Rules{AllowedRegistries: []string{"internal.io"}}})
+
+		violations, err := checker.Check([]string{
+			"internal.io/app@" + imgDigest,
+			"public.io/app@" + imgDigest,
+		}, fakeMetadata{})
+		require.NoError(t, err)
+
+		require.Len(t, violations, 1)
+		assert.Contains(t, violations[0].String(), "public.io/app@")
+		assert.Contains(t, violations[0].String(), "not in the allowed registries")
+	})
+
+	t.Run("rejects images from denied registries", func(t *testing.T) {
+		checker := policy.NewChecker(policy.Policy{Rules: policy.Rules{DeniedRegistries: []string{"public.io"}}})
+
+		violations, err := checker.Check([]string{"public.io/app@" + imgDigest}, fakeMetadata{})
+		require.NoError(t, err)
+
+		require.Len(t, violations, 1)
+		assert.Contains(t, violations[0].Reason, "denied by policy")
+	})
+}
+
+func TestCheckerSignatureRule(t *testing.T) {
+	checker := policy.NewChecker(policy.Policy{Rules: policy.Rules{RequireSignatures: true}})
+
+	signatureTag := fmt.Sprintf("registry.io/signed:%s.sig", strings.ReplaceAll(imgDigest, ":", "-"))
+	metadata := fakeMetadata{digests: map[string]string{
+		"registry.io/signed@" + imgDigest:   imgDigest,
+		"registry.io/unsigned@" + imgDigest: imgDigest,
+		signatureTag:                        imgDigest,
+	}}
+
+	violations, err := checker.Check([]string{
+		"registry.io/signed@" + imgDigest,
+		"registry.io/unsigned@" + imgDigest,
+	}, metadata)
+	require.NoError(t, err)
+
+	require.Len(t, violations, 1)
+	assert.Contains(t, violations[0].Ref, "unsigned")
+	assert.Contains(t, violations[0].Reason, "no cosign signature found")
+}
+
+func TestCheckerNotationSignatureRule(t *testing.T) {
+	checker := policy.NewChecker(policy.Policy{Rules: policy.Rules{RequireNotationSignatures: true}})
+
+	referrersTag := fmt.Sprintf("registry.io/signed:%s", strings.ReplaceAll(imgDigest, ":", "-"))
+	metadata := fakeMetadata{digests: map[string]string{
+		"registry.io/signed@" + imgDigest:   imgDigest,
+		"registry.io/unsigned@" + imgDigest: imgDigest,
+		referrersTag:                        imgDigest,
+	}}
+
+	violations, err := checker.Check([]string{
+		"registry.io/signed@" + imgDigest,
+		"registry.io/unsigned@" + imgDigest,
+	}, metadata)
+	require.NoError(t, err)
+
+	require.Len(t, violations, 1)
+	assert.Contains(t, violations[0].Ref, "unsigned")
+	assert.Contains(t, violations[0].Reason, "no notation signature found")
+}
+
+func TestCheckerSizeRule(t *testing.T) {
+	img, err := random.Image(2048, 2)
+	require.NoError(t, err)
+
+	checker := policy.NewChecker(policy.Policy{Rules: policy.Rules{MaxImageSizeBytes: 1024}})
+	metadata := fakeMetadata{images: map[string]regv1.Image{"registry.io/big@" + imgDigest: img}}
+
+	violations, err := checker.Check([]string{"registry.io/big@" + imgDigest}, metadata)
+	require.NoError(t, err)
+
+	require.Len(t, violations, 1)
+	assert.Contains(t, violations[0].Reason, "exceeds the policy limit")
+}
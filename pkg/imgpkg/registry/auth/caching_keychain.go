@@ -0,0 +1,76 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"sync"
+	"time"
+
+	regauthn "github.com/google/go-containerregistry/pkg/authn"
+)
+
+// DefaultResolveCacheTTL how long a cached credential resolution stays valid.
+// Kept well below the lifetime of the shortest lived registry tokens (GCR
+// issues ~1h tokens) so long running commands pick up refreshed credentials
+const DefaultResolveCacheTTL = 5 * time.Minute
+
+var _ regauthn.Keychain = &CachingKeychain{}
+
+// CachingKeychain wraps a keychain and reuses credential resolutions per
+// resource for the duration of a command. Credential provider lookups and
+// token exchanges (ex: ECR, GCR) can take hundreds of milliseconds each, and
+// every image resolved in a copy hits the keychain; without the cache bundles
+// referencing many images in the same registry pay that cost per image.
+// Entries expire after a TTL so refreshed tokens are eventually picked up
+type CachingKeychain struct {
+	keychain regauthn.Keychain
+	ttl      time.Duration
+	timeFunc func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]cachedResolution
+}
+
+type cachedResolution struct {
+	auth      regauthn.Authenticator
+	expiresAt time.Time
+}
+
+// NewCachingKeychain builds a CachingKeychain around keychain with the
+// provided entry TTL
+func NewCachingKeychain(keychain regauthn.Keychain, ttl time.Duration) *CachingKeychain {
+	return &CachingKeychain{
+		keychain: keychain,
+		ttl:      ttl,
+		timeFunc: time.Now,
+		entries:  map[string]cachedResolution{},
+	}
+}
+
+// Resolve returns the cached authenticator for the resource when a fresh
+// entry exists, delegating to the wrapped keychain otherwise. Concurrent
+// misses for the same resource may resolve more than once; the last
+// resolution wins, which is safe because resolutions are deterministic
+// within a TTL window
+func (k *CachingKeychain) Resolve(res regauthn.Resource) (regauthn.Authenticator, error) {
+	key := res.String()
+
+	k.mutex.Lock()
+	entry, found := k.entries[key]
+	k.mutex.Unlock()
+	if found && k.timeFunc().Before(entry.expiresAt) {
+		return entry.auth, nil
+	}
+
+	auth, err := k.keychain.Resolve(res)
+	if err != nil {
+		return nil, err
+	}
+
+	k.mutex.Lock()
+	k.entries[key] = cachedResolution{auth: auth, expiresAt: k.timeFunc().Add(k.ttl)}
+	k.mutex.Unlock()
+
+	return auth, nil
+}
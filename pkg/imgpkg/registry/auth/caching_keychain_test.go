@@ -0,0 +1,72 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"testing"
+	"time"
+
+	regauthn "github.com/google/go-containerregistry/pkg/authn"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingKeychain struct {
+	resolveCount int
+}
+
+func (k *countingKeychain) Resolve(regauthn.Resource) (regauthn.Authenticator, error) {
+	k.resolveCount++
+	return &regauthn.Basic{Username: "user", Password: "pass"}, nil
+}
+
+func TestCachingKeychain(t *testing.T) {
+	repo, err := regname.NewRepository("registry.example.com/repo")
+	require.NoError(t, err)
+	otherRepo, err := regname.NewRepository("registry.example.com/other")
+	require.NoError(t, err)
+
+	t.Run("repeated resolutions for the same resource hit the cache", func(t *testing.T) {
+		inner := &countingKeychain{}
+		subject := NewCachingKeychain(inner, DefaultResolveCacheTTL)
+
+		for i := 0; i < 3; i++ {
+			auth, err := subject.Resolve(repo)
+			require.NoError(t, err)
+			authorization, err := auth.Authorization()
+			require.NoError(t, err)
+			assert.Equal(t, "user", authorization.Username)
+		}
+		assert.Equal(t, 1, inner.resolveCount)
+	})
+
+	t.Run("different resources are resolved separately", func(t *testing.T) {
+		inner := &countingKeychain{}
+		subject := NewCachingKeychain(inner, DefaultResolveCacheTTL)
+
+		_, err := subject.Resolve(repo)
+		require.NoError(t, err)
+		_, err = subject.Resolve(otherRepo)
+		require.NoError(t, err)
+		assert.Equal(t, 2, inner.resolveCount)
+	})
+
+	t.Run("expired entries are resolved again", func(t *testing.T) {
+		inner := &countingKeychain{}
+		subject := NewCachingKeychain(inner, DefaultResolveCacheTTL)
+
+		now := time.Now()
+		subject.timeFunc = func() time.Time { return now }
+
+		_, err := subject.Resolve(repo)
+		require.NoError(t, err)
+		assert.Equal(t, 1, inner.resolveCount)
+
+		now = now.Add(DefaultResolveCacheTTL + time.Second)
+		_, err = subject.Resolve(repo)
+		require.NoError(t, err)
+		assert.Equal(t, 2, inner.resolveCount)
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sync"
+
+	regauthn "github.com/google/go-containerregistry/pkg/authn"
+)
+
+var _ regauthn.Keychain = &ExecKeychain{}
+
+// ExecCredential JSON document an external credential helper prints to stdout
+type ExecCredential struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+// ExecKeychain implements an authn.Keychain interface by invoking an external
+// helper executable once per registry host. The helper is called with the
+// registry hostname as its only argument and is expected to print an
+// ExecCredential JSON document to stdout
+type ExecKeychain struct {
+	path string
+
+	auths    map[string]regauthn.Authenticator
+	authLock sync.Mutex
+}
+
+// NewExecKeychain builder for Exec Keychain
+func NewExecKeychain(path string) *ExecKeychain {
+	return &ExecKeychain{
+		path:  path,
+		auths: map[string]regauthn.Authenticator{},
+	}
+}
+
+// Resolve invokes the helper for the target registry host, caching the
+// returned credentials for subsequent lookups against the same host
+func (k *ExecKeychain) Resolve(target regauthn.Resource) (regauthn.Authenticator, error) {
+	k.authLock.Lock()
+	defer k.authLock.Unlock()
+
+	registryStr := target.RegistryStr()
+	if auth, found := k.auths[registryStr]; found {
+		return auth, nil
+	}
+
+	out, err := exec.Command(k.path, registryStr).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("Executing credential helper '%s' for registry '%s': %s (stderr: %s)", k.path, registryStr, err, exitErr.Stderr)
+		}
+		return nil, fmt.Errorf("Executing credential helper '%s' for registry '%s': %s", k.path, registryStr, err)
+	}
+
+	var cred ExecCredential
+	err = json.Unmarshal(out, &cred)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing credential helper '%s' output for registry '%s': %s", k.path, registryStr, err)
+	}
+
+	var auth regauthn.Authenticator
+	switch {
+	case len(cred.Username) > 0:
+		auth = &regauthn.Basic{Username: cred.Username, Password: cred.Password}
+	case len(cred.Token) > 0:
+		auth = &regauthn.Bearer{Token: cred.Token}
+	default:
+		auth = regauthn.Anonymous
+	}
+
+	k.auths[registryStr] = auth
+	return auth, nil
+}
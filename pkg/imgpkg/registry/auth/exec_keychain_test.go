@@ -0,0 +1,113 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package auth_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	regauthn "github.com/google/go-containerregistry/pkg/authn"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry/auth"
+)
+
+func TestExecKeychain(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping exec keychain test on windows")
+	}
+
+	writeHelper := func(t *testing.T, script string) string {
+		dir, err := ioutil.TempDir("", "imgpkg-exec-keychain")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+
+		path := filepath.Join(dir, "helper")
+		require.NoError(t, ioutil.WriteFile(path, []byte(script), 0700))
+		return path
+	}
+
+	resource := func(t *testing.T, image string) regauthn.Resource {
+		ref, err := regname.ParseReference(image)
+		require.NoError(t, err)
+		return ref.Context()
+	}
+
+	t.Run("when helper returns username and password, basic auth is used", func(t *testing.T) {
+		helper := writeHelper(t, `#!/bin/sh
+echo '{"username":"some-user","password":"some-password"}'
+`)
+
+		keychain := auth.NewExecKeychain(helper)
+		authenticator, err := keychain.Resolve(resource(t, "some.registry.io/some/image"))
+		require.NoError(t, err)
+
+		authorization, err := authenticator.Authorization()
+		require.NoError(t, err)
+		require.Equal(t, "some-user", authorization.Username)
+		require.Equal(t, "some-password", authorization.Password)
+	})
+
+	t.Run("when helper returns a token, bearer auth is used", func(t *testing.T) {
+		helper := writeHelper(t, `#!/bin/sh
+echo '{"token":"some-token"}'
+`)
+
+		keychain := auth.NewExecKeychain(helper)
+		authenticator, err := keychain.Resolve(resource(t, "some.registry.io/some/image"))
+		require.NoError(t, err)
+
+		authorization, err := authenticator.Authorization()
+		require.NoError(t, err)
+		require.Equal(t, "some-token", authorization.RegistryToken)
+	})
+
+	t.Run("when helper returns no credentials, anonymous auth is used", func(t *testing.T) {
+		helper := writeHelper(t, `#!/bin/sh
+echo '{}'
+`)
+
+		keychain := auth.NewExecKeychain(helper)
+		authenticator, err := keychain.Resolve(resource(t, "some.registry.io/some/image"))
+		require.NoError(t, err)
+		require.Equal(t, regauthn.Anonymous, authenticator)
+	})
+
+	t.Run("helper is invoked once per registry host", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "imgpkg-exec-keychain-count")
+		require.NoError(t, err)
+		t.Cleanup(func() { os.RemoveAll(dir) })
+
+		helper := writeHelper(t, `#!/bin/sh
+echo run >> `+filepath.Join(dir, "calls")+`
+echo '{"username":"some-user","password":"some-password"}'
+`)
+
+		keychain := auth.NewExecKeychain(helper)
+		_, err = keychain.Resolve(resource(t, "some.registry.io/some/image"))
+		require.NoError(t, err)
+		_, err = keychain.Resolve(resource(t, "some.registry.io/other/image"))
+		require.NoError(t, err)
+
+		calls, err := ioutil.ReadFile(filepath.Join(dir, "calls"))
+		require.NoError(t, err)
+		require.Equal(t, "run\n", string(calls))
+	})
+
+	t.Run("when helper fails, it errors", func(t *testing.T) {
+		helper := writeHelper(t, `#!/bin/sh
+echo 'no credentials available' >&2
+exit 1
+`)
+
+		keychain := auth.NewExecKeychain(helper)
+		_, err := keychain.Resolve(resource(t, "some.registry.io/some/image"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Executing credential helper")
+		require.Contains(t, err.Error(), "no credentials available")
+	})
+}
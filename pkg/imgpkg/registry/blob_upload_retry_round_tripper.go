@@ -0,0 +1,164 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// BlobUploadStats counts blob upload retries performed at the transport
+// level, so they can be reported after an operation finishes
+type BlobUploadStats struct {
+	lock sync.Mutex
+
+	retriedUploads int
+	resumedUploads int
+	totalAttempts  int
+}
+
+// BlobUploadStatsSnapshot point in time view of the upload retry counters
+type BlobUploadStatsSnapshot struct {
+	// RetriedUploads number of blob uploads that needed at least one retry
+	RetriedUploads int
+	// ResumedUploads number of retries that resumed from a partially
+	// acknowledged offset instead of resending the whole blob
+	ResumedUploads int
+	// TotalAttempts number of retry attempts across all blob uploads
+	TotalAttempts int
+}
+
+// NewBlobUploadStats constructor for an empty set of counters
+func NewBlobUploadStats() *BlobUploadStats { return &BlobUploadStats{} }
+
+// Snapshot returns a copy of the current counters
+func (s *BlobUploadStats) Snapshot() BlobUploadStatsSnapshot {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return BlobUploadStatsSnapshot{
+		RetriedUploads: s.retriedUploads,
+		ResumedUploads: s.resumedUploads,
+		TotalAttempts:  s.totalAttempts,
+	}
+}
+
+func (s *BlobUploadStats) noteRetried(attempts, resumed int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.retriedUploads++
+	s.resumedUploads += resumed
+	s.totalAttempts += attempts
+}
+
+// blobUploadRetryRoundTripper retries failed blob upload requests in place
+// instead of letting the failure bubble up and restart the whole image copy.
+// For chunked uploads (PATCH) it first asks the registry which offset was
+// acknowledged and resumes from there when the body can be replayed
+type blobUploadRetryRoundTripper struct {
+	inner      http.RoundTripper
+	maxRetries int
+	stats      *BlobUploadStats
+}
+
+func newBlobUploadRetryRoundTripper(inner http.RoundTripper, maxRetries int, stats *BlobUploadStats) http.RoundTripper {
+	return &blobUploadRetryRoundTripper{inner: inner, maxRetries: maxRetries, stats: stats}
+}
+
+func (rt *blobUploadRetryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isBlobUploadRequest(req) || req.GetBody == nil {
+		return rt.inner.RoundTrip(req)
+	}
+
+	resp, err := rt.inner.RoundTrip(req)
+
+	attempts := 0
+	resumed := 0
+	for attempts < rt.maxRetries && shouldRetryBlobUpload(resp, err) {
+		attempts++
+
+		var offset int64
+		if req.Method == http.MethodPatch {
+			offset = rt.acknowledgedOffset(req)
+		}
+
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			break
+		}
+		if offset > 0 {
+			_, bodyErr = io.CopyN(ioutil.Discard, body, offset)
+			if bodyErr != nil {
+				break
+			}
+			resumed++
+		}
+
+		retryReq := req.Clone(req.Context())
+		retryReq.Body = body
+		if offset > 0 && req.ContentLength > 0 {
+			retryReq.ContentLength = req.ContentLength - offset
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+		resp, err = rt.inner.RoundTrip(retryReq)
+	}
+
+	if attempts > 0 {
+		rt.stats.noteRetried(attempts, resumed)
+	}
+	return resp, err
+}
+
+// acknowledgedOffset asks the registry how much of the upload it received;
+// the upload status response carries a 'Range: 0-<offset>' header. Returns 0
+// whenever the status cannot be determined, falling back to a full resend
+func (rt *blobUploadRetryRoundTripper) acknowledgedOffset(req *http.Request) int64 {
+	statusReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, req.URL.String(), nil)
+	if err != nil {
+		return 0
+	}
+	statusReq.Header = req.Header.Clone()
+	statusReq.Header.Del("Content-Type")
+	statusReq.Header.Del("Content-Range")
+
+	resp, err := rt.inner.RoundTrip(statusReq)
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return 0
+	}
+
+	rangeParts := strings.SplitN(resp.Header.Get("Range"), "-", 2)
+	if len(rangeParts) != 2 {
+		return 0
+	}
+	lastByte, err := strconv.ParseInt(rangeParts[1], 10, 64)
+	if err != nil || lastByte < 0 {
+		return 0
+	}
+	return lastByte + 1
+}
+
+func isBlobUploadRequest(req *http.Request) bool {
+	if req.Method != http.MethodPatch && req.Method != http.MethodPut {
+		return false
+	}
+	return strings.Contains(req.URL.Path, "/blobs/uploads/")
+}
+
+func shouldRetryBlobUpload(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented
+}
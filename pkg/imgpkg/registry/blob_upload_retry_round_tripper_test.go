@@ -0,0 +1,110 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobUploadRetryResumesFromAcknowledgedOffset(t *testing.T) {
+	payload := []byte("0123456789abcdefghij")
+
+	var patchBodies [][]byte
+	statusRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPatch:
+			bs, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			patchBodies = append(patchBodies, bs)
+			if len(patchBodies) == 1 {
+				// pretend only the first 10 bytes made it
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		case http.MethodGet:
+			statusRequests++
+			w.Header().Set("Range", "0-9")
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Fatalf("Unexpected request method %s", r.Method)
+		}
+	}))
+	defer server.Close()
+
+	stats := NewBlobUploadStats()
+	client := &http.Client{Transport: newBlobUploadRetryRoundTripper(http.DefaultTransport, 3, stats)}
+
+	req, err := http.NewRequest(http.MethodPatch,
+		server.URL+"/v2/some/repo/blobs/uploads/some-upload-id", bytes.NewReader(payload))
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Len(t, patchBodies, 2)
+	assert.Equal(t, payload, patchBodies[0])
+	assert.Equal(t, payload[10:], patchBodies[1])
+	assert.Equal(t, 1, statusRequests)
+
+	snapshot := stats.Snapshot()
+	assert.Equal(t, 1, snapshot.RetriedUploads)
+	assert.Equal(t, 1, snapshot.ResumedUploads)
+	assert.Equal(t, 1, snapshot.TotalAttempts)
+}
+
+func TestBlobUploadRetryGivesUpAfterMaxRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			attempts++
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	stats := NewBlobUploadStats()
+	client := &http.Client{Transport: newBlobUploadRetryRoundTripper(http.DefaultTransport, 2, stats)}
+
+	req, err := http.NewRequest(http.MethodPatch,
+		server.URL+"/v2/some/repo/blobs/uploads/some-upload-id", bytes.NewReader([]byte("blob")))
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 2, stats.Snapshot().TotalAttempts)
+}
+
+func TestBlobUploadRetryIgnoresOtherRequests(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	stats := NewBlobUploadStats()
+	client := &http.Client{Transport: newBlobUploadRetryRoundTripper(http.DefaultTransport, 3, stats)}
+
+	resp, err := client.Get(server.URL + "/v2/some/repo/manifests/latest")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, 0, stats.Snapshot().RetriedUploads)
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// hostDialer establishes registry TCP connections honoring the configured IP
+// family, custom resolver addresses, and host aliases, for networks where the
+// system DNS cannot be trusted to reach the registry
+type hostDialer struct {
+	dialer  *net.Dialer
+	network string
+	// aliases maps registry hostnames to the IP address to dial instead of
+	// resolving them through DNS
+	aliases map[string]string
+}
+
+// newHostDialer builds a hostDialer from the resolution options in opts,
+// returning nil when none are given so the default dialer keeps being used
+func newHostDialer(dialer *net.Dialer, opts Opts) (*hostDialer, error) {
+	if opts.IPFamily == "" && len(opts.ResolverAddrs) == 0 && len(opts.HostAliases) == 0 {
+		return nil, nil
+	}
+
+	network := "tcp"
+	switch opts.IPFamily {
+	case "":
+	case "ipv4":
+		network = "tcp4"
+	case "ipv6":
+		network = "tcp6"
+	default:
+		return nil, fmt.Errorf("Expected IP family to be 'ipv4' or 'ipv6', got '%s'", opts.IPFamily)
+	}
+
+	aliases := map[string]string{}
+	for _, entry := range opts.HostAliases {
+		pieces := strings.SplitN(entry, "=", 2)
+		if len(pieces) != 2 || pieces[0] == "" {
+			return nil, fmt.Errorf("Expected host alias to be in 'host=ip' format, got '%s'", entry)
+		}
+		if net.ParseIP(pieces[1]) == nil {
+			return nil, fmt.Errorf("Expected host alias for '%s' to be a valid IP address, got '%s'", pieces[0], pieces[1])
+		}
+		aliases[pieces[0]] = pieces[1]
+	}
+
+	if len(opts.ResolverAddrs) > 0 {
+		resolverAddrs, err := normalizeResolverAddrs(opts.ResolverAddrs)
+		if err != nil {
+			return nil, err
+		}
+		resolverDialer := &net.Dialer{Timeout: dialer.Timeout}
+		dialer.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var lastErr error
+				for _, addr := range resolverAddrs {
+					conn, err := resolverDialer.DialContext(ctx, network, addr)
+					if err == nil {
+						return conn, nil
+					}
+					lastErr = err
+				}
+				return nil, lastErr
+			},
+		}
+	}
+
+	return &hostDialer{dialer: dialer, network: network, aliases: aliases}, nil
+}
+
+// normalizeResolverAddrs validates resolver addresses and appends the default
+// DNS port when only an IP is given
+func normalizeResolverAddrs(addrs []string) ([]string, error) {
+	var normalized []string
+	for _, addr := range addrs {
+		if net.ParseIP(addr) != nil {
+			normalized = append(normalized, net.JoinHostPort(addr, "53"))
+			continue
+		}
+		if host, _, err := net.SplitHostPort(addr); err == nil && net.ParseIP(host) != nil {
+			normalized = append(normalized, addr)
+			continue
+		}
+		return nil, fmt.Errorf("Expected resolver address to be an IP address with an optional port, got '%s'", addr)
+	}
+	return normalized, nil
+}
+
+// DialContext dials addr over the configured IP family, replacing aliased
+// hostnames with their explicit IP so no DNS lookup is attempted for them
+func (h *hostDialer) DialContext(ctx context.Context, _, addr string) (net.Conn, error) {
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		if ip, found := h.aliases[host]; found {
+			addr = net.JoinHostPort(ip, port)
+		}
+	}
+	return h.dialer.DialContext(ctx, h.network, addr)
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHostDialer(t *testing.T) {
+	t.Run("returns nil when no resolution controls are given", func(t *testing.T) {
+		hostDialer, err := newHostDialer(&net.Dialer{}, Opts{})
+		require.NoError(t, err)
+		assert.Nil(t, hostDialer)
+	})
+
+	t.Run("errors on an unknown IP family", func(t *testing.T) {
+		_, err := newHostDialer(&net.Dialer{}, Opts{IPFamily: "ipv5"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected IP family to be 'ipv4' or 'ipv6', got 'ipv5'")
+	})
+
+	t.Run("errors on malformed host aliases", func(t *testing.T) {
+		_, err := newHostDialer(&net.Dialer{}, Opts{HostAliases: []string{"no-separator"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected host alias to be in 'host=ip' format, got 'no-separator'")
+
+		_, err = newHostDialer(&net.Dialer{}, Opts{HostAliases: []string{"registry.corp=not-an-ip"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected host alias for 'registry.corp' to be a valid IP address, got 'not-an-ip'")
+	})
+
+	t.Run("errors on invalid resolver addresses", func(t *testing.T) {
+		_, err := newHostDialer(&net.Dialer{}, Opts{ResolverAddrs: []string{"dns.corp"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected resolver address to be an IP address with an optional port, got 'dns.corp'")
+	})
+}
+
+func TestNormalizeResolverAddrs(t *testing.T) {
+	addrs, err := normalizeResolverAddrs([]string{"10.0.0.53", "10.0.0.54:5353", "2001:db8::1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.53:53", "10.0.0.54:5353", "[2001:db8::1]:53"}, addrs)
+}
+
+func TestHostAliasBypassesDNS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	tran, err := newHTTPTransport(Opts{HostAliases: []string{"registry.invalid=127.0.0.1"}})
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: tran}
+	resp, err := client.Get("http://registry.invalid:" + port)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestHostAliasAppliesToTLSConnections(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	tran, err := newHTTPTransport(Opts{
+		VerifyCerts:             true,
+		HostAliases:             []string{"registry.invalid=127.0.0.1"},
+		InsecureSkipVerifyHosts: []string{"registry.invalid:" + port},
+	})
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: tran}
+	resp, err := client.Get("https://registry.invalid:" + port)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestIPFamilyRestrictsConnections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// the test server listens on an IPv4 loopback address
+	require.True(t, strings.HasPrefix(server.URL, "http://127.0.0.1:"))
+
+	tran, err := newHTTPTransport(Opts{IPFamily: "ipv6"})
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: tran}
+	_, err = client.Get(server.URL) //nolint:bodyclose
+	require.Error(t, err)
+
+	tran, err = newHTTPTransport(Opts{IPFamily: "ipv4"})
+	require.NoError(t, err)
+
+	client = &http.Client{Transport: tran}
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
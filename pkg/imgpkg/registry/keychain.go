@@ -20,5 +20,13 @@ func Keychain(keychainOpts auth.KeychainOpts, environFunc func() []string) (rega
 		return nil, err
 	}
 
-	return regauthn.NewMultiKeychain(auth.NewEnvKeychain(environFunc), iaasKeychain, auth.CustomRegistryKeychain{Opts: keychainOpts}), nil
+	var keychains []regauthn.Keychain
+	if keychainOpts.CredExecPath != "" {
+		keychains = append(keychains, auth.NewExecKeychain(keychainOpts.CredExecPath))
+	}
+	keychains = append(keychains, auth.NewEnvKeychain(environFunc), iaasKeychain, auth.CustomRegistryKeychain{Opts: keychainOpts})
+
+	// resolutions are cached per resource so that credential providers and
+	// token exchanges only run once per registry within a single invocation
+	return auth.NewCachingKeychain(regauthn.NewMultiKeychain(keychains...), auth.DefaultResolveCacheTTL), nil
 }
@@ -0,0 +1,18 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// offlineRoundTripper rejects every request, so commands running with
+// --offline fail immediately instead of attempting to reach a registry
+type offlineRoundTripper struct{}
+
+// RoundTrip errors on every request
+func (offlineRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("Refusing to contact '%s': network access is disabled (--offline)", req.URL.Host)
+}
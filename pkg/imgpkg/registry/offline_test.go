@@ -0,0 +1,38 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+func TestOfflineRefusesNetworkAccess(t *testing.T) {
+	reachedServer := false
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		reachedServer = true
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	subject, err := registry.NewSimpleRegistry(registry.Opts{Offline: true, Insecure: true})
+	require.NoError(t, err)
+
+	ref, err := regname.ParseReference(serverURL.Host + "/some/image:latest")
+	require.NoError(t, err)
+
+	_, err = subject.Get(ref)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "network access is disabled")
+	assert.False(t, reachedServer, "not expecting the registry to be contacted")
+}
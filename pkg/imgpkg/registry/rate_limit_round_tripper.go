@@ -0,0 +1,106 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimitRoundTripper throttles request bodies (uploads) and response
+// bodies (downloads) to a configured number of bytes per second, so large
+// relocations do not saturate constrained links. A limiter is shared across
+// all requests, enforcing a global cap rather than a per-connection one
+type rateLimitRoundTripper struct {
+	inner    http.RoundTripper
+	upload   *byteRateLimiter
+	download *byteRateLimiter
+}
+
+// newRateLimitRoundTripper wraps inner enforcing the given rates; a rate of 0
+// leaves the corresponding direction unthrottled
+func newRateLimitRoundTripper(inner http.RoundTripper, uploadBytesPerSec, downloadBytesPerSec int64) http.RoundTripper {
+	rt := &rateLimitRoundTripper{inner: inner}
+	if uploadBytesPerSec > 0 {
+		rt.upload = newByteRateLimiter(uploadBytesPerSec)
+	}
+	if downloadBytesPerSec > 0 {
+		rt.download = newByteRateLimiter(downloadBytesPerSec)
+	}
+	return rt
+}
+
+func (rt *rateLimitRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt.upload != nil && req.Body != nil {
+		req.Body = &throttledReadCloser{inner: req.Body, limiter: rt.upload}
+	}
+
+	resp, err := rt.inner.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if rt.download != nil && resp.Body != nil {
+		resp.Body = &throttledReadCloser{inner: resp.Body, limiter: rt.download}
+	}
+	return resp, nil
+}
+
+// throttledReadCloser delays reads so the average rate stays within the limiter
+type throttledReadCloser struct {
+	inner   io.ReadCloser
+	limiter *byteRateLimiter
+}
+
+func (r *throttledReadCloser) Read(p []byte) (int, error) {
+	n, err := r.inner.Read(p)
+	if n > 0 {
+		r.limiter.Throttle(n)
+	}
+	return n, err
+}
+
+func (r *throttledReadCloser) Close() error { return r.inner.Close() }
+
+// byteRateLimiter is a token bucket allowing a burst of one second worth of
+// bytes; callers exceeding the rate are put to sleep until within budget
+type byteRateLimiter struct {
+	bytesPerSec float64
+
+	lock      sync.Mutex
+	allowance float64
+	lastCheck time.Time
+}
+
+func newByteRateLimiter(bytesPerSec int64) *byteRateLimiter {
+	return &byteRateLimiter{bytesPerSec: float64(bytesPerSec), allowance: float64(bytesPerSec)}
+}
+
+// Throttle accounts for n transferred bytes, sleeping when the budget is
+// exhausted. The lock is held while sleeping so concurrent transfers share
+// the same global budget
+func (l *byteRateLimiter) Throttle(n int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	if !l.lastCheck.IsZero() {
+		l.allowance += now.Sub(l.lastCheck).Seconds() * l.bytesPerSec
+	}
+	l.lastCheck = now
+
+	if l.allowance > l.bytesPerSec {
+		l.allowance = l.bytesPerSec
+	}
+
+	l.allowance -= float64(n)
+	if l.allowance < 0 {
+		sleep := time.Duration(-l.allowance / l.bytesPerSec * float64(time.Second))
+		time.Sleep(sleep)
+		l.allowance = 0
+		l.lastCheck = time.Now()
+	}
+}
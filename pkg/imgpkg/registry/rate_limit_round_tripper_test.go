@@ -0,0 +1,75 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitRoundTripperDownload(t *testing.T) {
+	payload := bytes.Repeat([]byte("a"), 300_000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(payload)
+	}))
+	defer server.Close()
+
+	// 200KB/s with a 200KB burst: reading 300KB should take at least ~500ms
+	client := &http.Client{Transport: newRateLimitRoundTripper(http.DefaultTransport, 0, 200_000)}
+
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	bs, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Len(t, bs, len(payload))
+
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestRateLimitRoundTripperUpload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newRateLimitRoundTripper(http.DefaultTransport, 200_000, 0)}
+
+	start := time.Now()
+	resp, err := client.Post(server.URL, "application/octet-stream",
+		bytes.NewReader(bytes.Repeat([]byte("a"), 300_000)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestRateLimitRoundTripperUnlimitedByDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("a"), 1_000_000))
+	}))
+	defer server.Close()
+
+	// only the upload direction is limited, downloads are untouched
+	client := &http.Client{Transport: newRateLimitRoundTripper(http.DefaultTransport, 100, 0)}
+
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	_, err = ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,188 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// DefaultRegistriesConfPath location of the containers/image registries
+// configuration shared with podman and skopeo
+const DefaultRegistriesConfPath = "/etc/containers/registries.conf"
+
+// RegistriesConf subset of the containers/image registries.conf configuration
+// honored by imgpkg: insecure registries, pull mirrors and short-name aliases
+type RegistriesConf struct {
+	Registries []RegistryConf
+	Aliases    map[string]string
+}
+
+// RegistryConf a single [[registry]] entry from registries.conf
+type RegistryConf struct {
+	Prefix   string
+	Location string
+	Insecure bool
+	Mirrors  []string
+}
+
+// LoadRegistriesConf reads and parses the registries.conf at path. A missing
+// file is not an error; it returns a nil configuration
+func LoadRegistriesConf(path string) (*RegistriesConf, error) {
+	bs, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Reading '%s': %s", path, err)
+	}
+
+	conf, err := ParseRegistriesConf(string(bs))
+	if err != nil {
+		return nil, fmt.Errorf("Parsing '%s': %s", path, err)
+	}
+	return conf, nil
+}
+
+// ParseRegistriesConf parses the TOML subset used by registries.conf
+// ([[registry]] blocks with prefix/location/insecure, [[registry.mirror]]
+// blocks and the [aliases] table). Keys imgpkg does not honor are ignored
+func ParseRegistriesConf(contents string) (*RegistriesConf, error) {
+	conf := &RegistriesConf{Aliases: map[string]string{}}
+
+	const (
+		sectionNone = iota
+		sectionRegistry
+		sectionMirror
+		sectionAliases
+		sectionIgnored
+	)
+	section := sectionNone
+
+	for lineIdx, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		switch {
+		case line == "[[registry]]":
+			conf.Registries = append(conf.Registries, RegistryConf{})
+			section = sectionRegistry
+			continue
+		case line == "[[registry.mirror]]":
+			if len(conf.Registries) == 0 {
+				return nil, fmt.Errorf("Line %d: [[registry.mirror]] outside of a [[registry]] block", lineIdx+1)
+			}
+			section = sectionMirror
+			continue
+		case line == "[aliases]":
+			section = sectionAliases
+			continue
+		case strings.HasPrefix(line, "["):
+			// tables imgpkg does not honor (ex: [registries.search] from the v1 format)
+			section = sectionIgnored
+			continue
+		}
+
+		key, value, err := parseRegistriesConfKeyValue(line)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", lineIdx+1, err)
+		}
+
+		switch section {
+		case sectionRegistry:
+			entry := &conf.Registries[len(conf.Registries)-1]
+			switch key {
+			case "prefix":
+				entry.Prefix = value
+			case "location":
+				entry.Location = value
+			case "insecure":
+				entry.Insecure = value == "true"
+			}
+		case sectionMirror:
+			if key == "location" {
+				entry := &conf.Registries[len(conf.Registries)-1]
+				entry.Mirrors = append(entry.Mirrors, value)
+			}
+		case sectionAliases:
+			conf.Aliases[key] = value
+		}
+	}
+
+	return conf, nil
+}
+
+// parseRegistriesConfKeyValue splits a `key = value` line, unquoting string
+// values. Array values are returned verbatim and ignored by the callers
+func parseRegistriesConfKeyValue(line string) (string, string, error) {
+	pieces := strings.SplitN(line, "=", 2)
+	if len(pieces) != 2 {
+		return "", "", fmt.Errorf("Expected a 'key = value' pair, got '%s'", line)
+	}
+
+	key := strings.Trim(strings.TrimSpace(pieces[0]), `"`)
+	value := strings.TrimSpace(pieces[1])
+	if idx := strings.Index(value, " #"); idx >= 0 {
+		value = strings.TrimSpace(value[:idx])
+	}
+	value = strings.Trim(value, `"`)
+	return key, value, nil
+}
+
+// InsecureFor reports whether registries.conf marks the registry host as
+// insecure (plain HTTP or self-signed certificates)
+func (c *RegistriesConf) InsecureFor(host string) bool {
+	for _, entry := range c.Registries {
+		if !entry.Insecure {
+			continue
+		}
+		if registriesConfEntryHost(entry) == host {
+			return true
+		}
+	}
+	return false
+}
+
+// MirrorRefs returns the mirror locations to try, in configuration order,
+// before the original fully qualified reference name
+func (c *RegistriesConf) MirrorRefs(refName string) []string {
+	var mirrorRefs []string
+	for _, entry := range c.Registries {
+		prefix := entry.Prefix
+		if prefix == "" {
+			prefix = entry.Location
+		}
+		if prefix == "" {
+			continue
+		}
+		if refName != prefix && !strings.HasPrefix(refName, prefix+"/") && !strings.HasPrefix(refName, prefix+":") && !strings.HasPrefix(refName, prefix+"@") {
+			continue
+		}
+		for _, mirror := range entry.Mirrors {
+			mirrorRefs = append(mirrorRefs, mirror+refName[len(prefix):])
+		}
+	}
+	return mirrorRefs
+}
+
+// ResolveAlias resolves a short name (ex: 'fedora') to its fully qualified
+// alias from the [aliases] table
+func (c *RegistriesConf) ResolveAlias(shortName string) (string, bool) {
+	alias, found := c.Aliases[shortName]
+	return alias, found
+}
+
+// registriesConfEntryHost the registry host an entry applies to: the first
+// path component of its prefix (or location when no prefix is set)
+func registriesConfEntryHost(entry RegistryConf) string {
+	prefix := entry.Prefix
+	if prefix == "" {
+		prefix = entry.Location
+	}
+	return strings.SplitN(prefix, "/", 2)[0]
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+func TestParseRegistriesConf(t *testing.T) {
+	conf, err := registry.ParseRegistriesConf(`
+# comment
+unqualified-search-registries = ["docker.io"]
+
+[[registry]]
+prefix = "registry.corp.com"
+location = "registry.corp.com"
+insecure = true
+
+[[registry]]
+prefix = "docker.io/library"
+[[registry.mirror]]
+location = "mirror.corp.com/dockerhub"
+[[registry.mirror]]
+location = "mirror2.corp.com/dockerhub"
+
+[aliases]
+"fedora" = "registry.fedoraproject.org/fedora"
+`)
+	require.NoError(t, err)
+
+	t.Run("insecure registries are matched by host", func(t *testing.T) {
+		assert.True(t, conf.InsecureFor("registry.corp.com"))
+		assert.False(t, conf.InsecureFor("docker.io"))
+		assert.False(t, conf.InsecureFor("registry.corp.com.evil.com"))
+	})
+
+	t.Run("mirrors are rewritten by prefix in configuration order", func(t *testing.T) {
+		mirrors := conf.MirrorRefs("docker.io/library/nginx:latest")
+		assert.Equal(t, []string{
+			"mirror.corp.com/dockerhub/nginx:latest",
+			"mirror2.corp.com/dockerhub/nginx:latest",
+		}, mirrors)
+	})
+
+	t.Run("references outside every prefix have no mirrors", func(t *testing.T) {
+		assert.Empty(t, conf.MirrorRefs("gcr.io/project/image"))
+		assert.Empty(t, conf.MirrorRefs("docker.io/librarylike/image"))
+	})
+
+	t.Run("short name aliases resolve", func(t *testing.T) {
+		alias, found := conf.ResolveAlias("fedora")
+		assert.True(t, found)
+		assert.Equal(t, "registry.fedoraproject.org/fedora", alias)
+
+		_, found = conf.ResolveAlias("unknown")
+		assert.False(t, found)
+	})
+}
+
+func TestParseRegistriesConfErrors(t *testing.T) {
+	t.Run("mirror block outside a registry block", func(t *testing.T) {
+		_, err := registry.ParseRegistriesConf("[[registry.mirror]]\nlocation = \"mirror.corp.com\"\n")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "outside of a [[registry]] block")
+	})
+
+	t.Run("malformed key value pair", func(t *testing.T) {
+		_, err := registry.ParseRegistriesConf("[[registry]]\nnot-a-pair\n")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected a 'key = value' pair")
+	})
+}
+
+func TestLoadRegistriesConfMissingFile(t *testing.T) {
+	conf, err := registry.LoadRegistriesConf("/does/not/exist/registries.conf")
+	require.NoError(t, err)
+	assert.Nil(t, conf)
+}
@@ -4,13 +4,16 @@
 package registry
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"regexp"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,19 +31,67 @@ type Opts struct {
 	VerifyCerts bool
 	Insecure    bool
 
+	// InsecureSkipVerifyHosts registry hostnames for which TLS certificate
+	// verification is skipped, leaving verification enabled for other hosts
+	InsecureSkipVerifyHosts []string
+	// PinnedCerts host=path entries requiring the host to present a
+	// certificate with the same public key as the PEM certificate at path
+	PinnedCerts []string
+
 	IncludeNonDistributableLayers bool
 
-	Username string
-	Password string
-	Token    string
-	Anon     bool
+	Username     string
+	Password     string
+	Token        string
+	Anon         bool
+	CredExecPath string
 
 	ResponseHeaderTimeout time.Duration
 	RetryCount            int
 
+	MaxIdleConnsPerHost int
+	KeepAlive           time.Duration
+	DialTimeout         time.Duration
+	EnableHTTP2         bool
+
+	// IPFamily restricts registry connections to a single IP family, "ipv4"
+	// or "ipv6" (empty allows both)
+	IPFamily string
+	// ResolverAddrs DNS server addresses (ip or ip:port, port 53 assumed)
+	// used instead of the system resolver
+	ResolverAddrs []string
+	// HostAliases host=ip entries mapping registry hostnames to explicit IP
+	// addresses, bypassing DNS entirely for those hosts
+	HostAliases []string
+
+	Offline bool
+
+	// RegistriesConfPath path to a containers/image registries.conf whose
+	// insecure registries and pull mirrors are honored (empty disables)
+	RegistriesConfPath string
+
+	// MaxUploadRateBytesPerSec caps upload bandwidth across all registry
+	// requests (0 means unlimited)
+	MaxUploadRateBytesPerSec int64
+	// MaxDownloadRateBytesPerSec caps download bandwidth across all registry
+	// requests (0 means unlimited)
+	MaxDownloadRateBytesPerSec int64
+
 	EnvironFunc func() []string
 }
 
+const (
+	// DefaultMaxIdleConnsPerHost number of idle connections kept per registry host.
+	// Go's default of 2 quickly becomes a bottleneck when many images are copied in parallel
+	DefaultMaxIdleConnsPerHost = 100
+
+	// DefaultKeepAlive TCP keep-alive period for registry connections
+	DefaultKeepAlive = 30 * time.Second
+
+	// DefaultDialTimeout maximum time to establish a TCP connection to a registry
+	DefaultDialTimeout = 30 * time.Second
+)
+
 // Registry Interface to access the registry
 type Registry interface {
 	Get(reference regname.Reference) (*regremote.Descriptor, error)
@@ -53,8 +104,11 @@ type Registry interface {
 	WriteImage(reference regname.Reference, image regv1.Image) error
 	WriteIndex(reference regname.Reference, index regv1.ImageIndex) error
 	WriteTag(tag regname.Tag, taggable regremote.Taggable) error
+	WriteLayer(repo regname.Repository, layer regv1.Layer) error
 
 	ListTags(repo regname.Repository) ([]string, error)
+	CatalogRepositories(registryHost string) ([]string, error)
+	Delete(reference regname.Reference) error
 
 	CloneWithSingleAuth(imageRef regname.Tag) (Registry, error)
 }
@@ -77,6 +131,7 @@ type ImagesReaderWriter interface {
 	WriteImage(regname.Reference, regv1.Image) error
 	WriteIndex(regname.Reference, regv1.ImageIndex) error
 	WriteTag(regname.Tag, regremote.Taggable) error
+	WriteLayer(regname.Repository, regv1.Layer) error
 
 	CloneWithSingleAuth(imageRef regname.Tag) (Registry, error)
 }
@@ -90,6 +145,8 @@ type SimpleRegistry struct {
 	keychain        regauthn.Keychain
 	roundTrippers   *RoundTripperStorage
 	transportAccess *sync.Mutex
+	blobUploadStats *BlobUploadStats
+	regsConf        *RegistriesConf
 }
 
 // NewSimpleRegistry Builder for a Simple Registry
@@ -106,10 +163,11 @@ func NewSimpleRegistry(opts Opts, regOpts ...regremote.Option) (*SimpleRegistry,
 
 	keychain, err := Keychain(
 		auth.KeychainOpts{
-			Username: opts.Username,
-			Password: opts.Password,
-			Token:    opts.Token,
-			Anon:     opts.Anon,
+			Username:     opts.Username,
+			Password:     opts.Password,
+			Token:        opts.Token,
+			Anon:         opts.Anon,
+			CredExecPath: opts.CredExecPath,
 		},
 		opts.EnvironFunc,
 	)
@@ -146,12 +204,36 @@ func NewSimpleRegistry(opts Opts, regOpts ...regremote.Option) (*SimpleRegistry,
 	// Wrap the transport in something that can retry network flakes.
 	baseRoundTripper = transport.NewRetry(baseRoundTripper, transport.WithRetryBackoff(retryBackoff))
 
+	if opts.MaxUploadRateBytesPerSec > 0 || opts.MaxDownloadRateBytesPerSec > 0 {
+		baseRoundTripper = newRateLimitRoundTripper(baseRoundTripper,
+			opts.MaxUploadRateBytesPerSec, opts.MaxDownloadRateBytesPerSec)
+	}
+
+	blobUploadStats := NewBlobUploadStats()
+	if tries > 1 {
+		baseRoundTripper = newBlobUploadRetryRoundTripper(baseRoundTripper, tries-1, blobUploadStats)
+	}
+
+	if opts.Offline {
+		baseRoundTripper = offlineRoundTripper{}
+	}
+
+	var regsConf *RegistriesConf
+	if opts.RegistriesConfPath != "" {
+		regsConf, err = LoadRegistriesConf(opts.RegistriesConfPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &SimpleRegistry{
 		remoteOpts:      regRemoteOptions,
 		refOpts:         refOpts,
 		keychain:        keychain,
 		roundTrippers:   NewRoundTripperStorage(baseRoundTripper),
 		transportAccess: &sync.Mutex{},
+		blobUploadStats: blobUploadStats,
+		regsConf:        regsConf,
 	}, nil
 }
 
@@ -172,9 +254,48 @@ func (r SimpleRegistry) CloneWithSingleAuth(imageRef regname.Tag) (Registry, err
 		keychain:        keychain,
 		roundTrippers:   r.roundTrippers,
 		transportAccess: &sync.Mutex{},
+		blobUploadStats: r.blobUploadStats,
+		regsConf:        r.regsConf,
 	}, nil
 }
 
+// refOptsFor the reference options to parse refs targeting the registry host
+// with, adding regname.Insecure when registries.conf marks the host insecure
+func (r *SimpleRegistry) refOptsFor(host string) []regname.Option {
+	if r.regsConf != nil && r.regsConf.InsecureFor(host) {
+		return append([]regname.Option{regname.Insecure}, r.refOpts...)
+	}
+	return r.refOpts
+}
+
+// mirrorRefs the registries.conf mirror references to try before ref for read
+// operations, in configuration order. Mirrors that fail are skipped silently,
+// matching podman/skopeo pull-through behavior
+func (r *SimpleRegistry) mirrorRefs(ref regname.Reference) []regname.Reference {
+	if r.regsConf == nil {
+		return nil
+	}
+
+	var refs []regname.Reference
+	for _, mirrorName := range r.regsConf.MirrorRefs(ref.Name()) {
+		mirrorRef, err := regname.ParseReference(mirrorName, r.refOptsFor(strings.SplitN(mirrorName, "/", 2)[0])...)
+		if err != nil {
+			continue
+		}
+		refs = append(refs, mirrorRef)
+	}
+	return refs
+}
+
+// BlobUploadRetryStats reports how many blob uploads were retried or resumed
+// at the transport level during the lifetime of this registry
+func (r *SimpleRegistry) BlobUploadRetryStats() BlobUploadStatsSnapshot {
+	if r.blobUploadStats == nil {
+		return BlobUploadStatsSnapshot{}
+	}
+	return r.blobUploadStats.Snapshot()
+}
+
 // readOpts Returns the readOpts + the keychain
 func (r *SimpleRegistry) readOpts(ref regname.Reference) ([]regremote.Option, error) {
 	rt, err := r.transport(ref, ref.Scope(transport.PullScope))
@@ -220,7 +341,7 @@ func (r *SimpleRegistry) Get(ref regname.Reference) (*regremote.Descriptor, erro
 	if err := r.validateRef(ref); err != nil {
 		return nil, err
 	}
-	overriddenRef, err := regname.ParseReference(ref.String(), r.refOpts...)
+	overriddenRef, err := regname.ParseReference(ref.String(), r.refOptsFor(ref.Context().RegistryStr())...)
 	if err != nil {
 		return nil, err
 	}
@@ -228,6 +349,14 @@ func (r *SimpleRegistry) Get(ref regname.Reference) (*regremote.Descriptor, erro
 	if err != nil {
 		return nil, err
 	}
+
+	for _, mirrorRef := range r.mirrorRefs(overriddenRef) {
+		if mirrorOpts, err := r.readOpts(mirrorRef); err == nil {
+			if desc, err := regremote.Get(mirrorRef, mirrorOpts...); err == nil {
+				return desc, nil
+			}
+		}
+	}
 	return regremote.Get(overriddenRef, opts...)
 }
 
@@ -236,7 +365,7 @@ func (r *SimpleRegistry) Digest(ref regname.Reference) (regv1.Hash, error) {
 	if err := r.validateRef(ref); err != nil {
 		return regv1.Hash{}, err
 	}
-	overriddenRef, err := regname.ParseReference(ref.String(), r.refOpts...)
+	overriddenRef, err := regname.ParseReference(ref.String(), r.refOptsFor(ref.Context().RegistryStr())...)
 	if err != nil {
 		return regv1.Hash{}, err
 	}
@@ -245,6 +374,15 @@ func (r *SimpleRegistry) Digest(ref regname.Reference) (regv1.Hash, error) {
 	if err != nil {
 		return regv1.Hash{}, err
 	}
+
+	for _, mirrorRef := range r.mirrorRefs(overriddenRef) {
+		if mirrorOpts, err := r.readOpts(mirrorRef); err == nil {
+			if desc, err := regremote.Head(mirrorRef, mirrorOpts...); err == nil {
+				return desc.Digest, nil
+			}
+		}
+	}
+
 	desc, err := regremote.Head(overriddenRef, opts...)
 	if err != nil {
 		getDesc, err := regremote.Get(overriddenRef, opts...)
@@ -262,7 +400,7 @@ func (r *SimpleRegistry) Image(ref regname.Reference) (regv1.Image, error) {
 	if err := r.validateRef(ref); err != nil {
 		return nil, err
 	}
-	overriddenRef, err := regname.ParseReference(ref.String(), r.refOpts...)
+	overriddenRef, err := regname.ParseReference(ref.String(), r.refOptsFor(ref.Context().RegistryStr())...)
 	if err != nil {
 		return nil, err
 	}
@@ -271,6 +409,14 @@ func (r *SimpleRegistry) Image(ref regname.Reference) (regv1.Image, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	for _, mirrorRef := range r.mirrorRefs(overriddenRef) {
+		if mirrorOpts, err := r.readOpts(mirrorRef); err == nil {
+			if img, err := regremote.Image(mirrorRef, mirrorOpts...); err == nil {
+				return img, nil
+			}
+		}
+	}
 	return regremote.Image(overriddenRef, opts...)
 }
 
@@ -283,7 +429,7 @@ func (r *SimpleRegistry) MultiWrite(imageOrIndexesToUpload map[regname.Reference
 		if err := r.validateRef(ref); err != nil {
 			return err
 		}
-		overriddenRef, err := regname.ParseReference(ref.String(), r.refOpts...)
+		overriddenRef, err := regname.ParseReference(ref.String(), r.refOptsFor(ref.Context().RegistryStr())...)
 		if err != nil {
 			return err
 		}
@@ -308,7 +454,7 @@ func (r *SimpleRegistry) WriteImage(ref regname.Reference, img regv1.Image) erro
 	if err := r.validateRef(ref); err != nil {
 		return err
 	}
-	overriddenRef, err := regname.ParseReference(ref.String(), r.refOpts...)
+	overriddenRef, err := regname.ParseReference(ref.String(), r.refOptsFor(ref.Context().RegistryStr())...)
 	if err != nil {
 		return err
 	}
@@ -326,12 +472,33 @@ func (r *SimpleRegistry) WriteImage(ref regname.Reference, img regv1.Image) erro
 	return nil
 }
 
+// WriteLayer Upload a layer blob to a repository in the registry without
+// referencing it from any manifest
+func (r *SimpleRegistry) WriteLayer(repo regname.Repository, layer regv1.Layer) error {
+	overriddenRepo, err := regname.NewRepository(repo.Name(), r.refOptsFor(repo.RegistryStr())...)
+	if err != nil {
+		return err
+	}
+
+	opts, err := r.writeOpts(overriddenRepo.Tag("latest"))
+	if err != nil {
+		return err
+	}
+
+	err = regremote.WriteLayer(overriddenRepo, layer, opts...)
+	if err != nil {
+		return fmt.Errorf("Writing layer: %s", err)
+	}
+
+	return nil
+}
+
 // Index Retrieve regv1.ImageIndex struct for an Index reference
 func (r *SimpleRegistry) Index(ref regname.Reference) (regv1.ImageIndex, error) {
 	if err := r.validateRef(ref); err != nil {
 		return nil, err
 	}
-	overriddenRef, err := regname.ParseReference(ref.String(), r.refOpts...)
+	overriddenRef, err := regname.ParseReference(ref.String(), r.refOptsFor(ref.Context().RegistryStr())...)
 	if err != nil {
 		return nil, err
 	}
@@ -339,6 +506,14 @@ func (r *SimpleRegistry) Index(ref regname.Reference) (regv1.ImageIndex, error)
 	if err != nil {
 		return nil, err
 	}
+
+	for _, mirrorRef := range r.mirrorRefs(overriddenRef) {
+		if mirrorOpts, err := r.readOpts(mirrorRef); err == nil {
+			if idx, err := regremote.Index(mirrorRef, mirrorOpts...); err == nil {
+				return idx, nil
+			}
+		}
+	}
 	return regremote.Index(overriddenRef, opts...)
 }
 
@@ -347,7 +522,7 @@ func (r *SimpleRegistry) WriteIndex(ref regname.Reference, idx regv1.ImageIndex)
 	if err := r.validateRef(ref); err != nil {
 		return err
 	}
-	overriddenRef, err := regname.ParseReference(ref.String(), r.refOpts...)
+	overriddenRef, err := regname.ParseReference(ref.String(), r.refOptsFor(ref.Context().RegistryStr())...)
 	if err != nil {
 		return err
 	}
@@ -370,7 +545,7 @@ func (r *SimpleRegistry) WriteTag(ref regname.Tag, taggagle regremote.Taggable)
 	if err := r.validateRef(ref); err != nil {
 		return err
 	}
-	overriddenRef, err := regname.NewTag(ref.String(), r.refOpts...)
+	overriddenRef, err := regname.NewTag(ref.String(), r.refOptsFor(ref.Context().RegistryStr())...)
 	if err != nil {
 		return err
 	}
@@ -390,7 +565,7 @@ func (r *SimpleRegistry) WriteTag(ref regname.Tag, taggagle regremote.Taggable)
 
 // ListTags Retrieve all tags associated with a Repository
 func (r *SimpleRegistry) ListTags(repo regname.Repository) ([]string, error) {
-	overriddenRepo, err := regname.NewRepository(repo.Name(), r.refOpts...)
+	overriddenRepo, err := regname.NewRepository(repo.Name(), r.refOptsFor(repo.RegistryStr())...)
 	if err != nil {
 		return nil, err
 	}
@@ -406,6 +581,46 @@ func (r *SimpleRegistry) ListTags(repo regname.Repository) ([]string, error) {
 	return regremote.List(overriddenRepo, opts...)
 }
 
+// Delete Removes the manifest that the reference points at from the registry.
+// Most registries only accept deletes by digest
+func (r *SimpleRegistry) Delete(ref regname.Reference) error {
+	if err := r.validateRef(ref); err != nil {
+		return err
+	}
+	overriddenRef, err := regname.ParseReference(ref.String(), r.refOptsFor(ref.Context().RegistryStr())...)
+	if err != nil {
+		return err
+	}
+	opts, err := r.writeOpts(ref)
+	if err != nil {
+		return err
+	}
+	return regremote.Delete(overriddenRef, opts...)
+}
+
+// CatalogRepositories Retrieve the names of every repository hosted in a registry
+// via the _catalog API
+func (r *SimpleRegistry) CatalogRepositories(registryHost string) ([]string, error) {
+	reg, err := regname.NewRegistry(registryHost, r.refOptsFor(registryHost)...)
+	if err != nil {
+		return nil, err
+	}
+
+	// A repository reference is needed to resolve credentials for the registry
+	repoRef, err := regname.ParseReference(registryHost+"/imgpkg", r.refOptsFor(registryHost)...)
+	if err != nil {
+		return nil, err
+	}
+
+	rt, err := r.transport(repoRef, "registry:catalog:*")
+	if err != nil {
+		return nil, err
+	}
+
+	opts := append([]regremote.Option{regremote.WithAuthFromKeychain(r.keychain), regremote.WithTransport(rt)}, r.remoteOpts...)
+	return regremote.Catalog(context.Background(), reg, opts...)
+}
+
 // FirstImageExists Returns the first of the provided Image Digests that exists in the Registry
 func (r *SimpleRegistry) FirstImageExists(digests []string) (string, error) {
 	var err error
@@ -450,13 +665,54 @@ func newHTTPTransport(opts Opts) (*http.Transport, error) {
 		}
 	}
 
+	maxIdleConnsPerHost := opts.MaxIdleConnsPerHost
+	if maxIdleConnsPerHost == 0 {
+		maxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	keepAlive := opts.KeepAlive
+	if keepAlive == 0 {
+		keepAlive = DefaultKeepAlive
+	}
+	dialTimeout := opts.DialTimeout
+	if dialTimeout == 0 {
+		dialTimeout = DefaultDialTimeout
+	}
+
 	clonedDefaultTransport := http.DefaultTransport.(*http.Transport).Clone()
-	clonedDefaultTransport.ForceAttemptHTTP2 = false
+	clonedDefaultTransport.ForceAttemptHTTP2 = opts.EnableHTTP2
 	clonedDefaultTransport.ResponseHeaderTimeout = opts.ResponseHeaderTimeout
-	clonedDefaultTransport.TLSClientConfig = &tls.Config{
+	clonedDefaultTransport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	if clonedDefaultTransport.MaxIdleConns < maxIdleConnsPerHost {
+		clonedDefaultTransport.MaxIdleConns = maxIdleConnsPerHost
+	}
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: keepAlive,
+	}
+
+	dialContext := dialer.DialContext
+	hostDialer, err := newHostDialer(dialer, opts)
+	if err != nil {
+		return nil, err
+	}
+	if hostDialer != nil {
+		dialContext = hostDialer.DialContext
+	}
+	clonedDefaultTransport.DialContext = dialContext
+
+	tlsConfig := &tls.Config{
 		RootCAs:            pool,
 		InsecureSkipVerify: opts.VerifyCerts == false,
 	}
+	clonedDefaultTransport.TLSClientConfig = tlsConfig
+
+	tlsPolicy, err := newTLSHostPolicy(tlsConfig, opts.InsecureSkipVerifyHosts, opts.PinnedCerts)
+	if err != nil {
+		return nil, err
+	}
+	if tlsPolicy != nil {
+		clonedDefaultTransport.DialTLSContext = tlsPolicy.dialTLS(dialContext)
+	}
 
 	return clonedDefaultTransport, nil
 }
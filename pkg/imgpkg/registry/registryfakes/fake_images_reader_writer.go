@@ -126,6 +126,18 @@ type FakeImagesReaderWriter struct {
 	writeIndexReturnsOnCall map[int]struct {
 		result1 error
 	}
+	WriteLayerStub        func(name.Repository, v1.Layer) error
+	writeLayerMutex       sync.RWMutex
+	writeLayerArgsForCall []struct {
+		arg1 name.Repository
+		arg2 v1.Layer
+	}
+	writeLayerReturns struct {
+		result1 error
+	}
+	writeLayerReturnsOnCall map[int]struct {
+		result1 error
+	}
 	WriteTagStub        func(name.Tag, remote.Taggable) error
 	writeTagMutex       sync.RWMutex
 	writeTagArgsForCall []struct {
@@ -718,6 +730,68 @@ func (fake *FakeImagesReaderWriter) WriteIndexReturnsOnCall(i int, result1 error
 	}{result1}
 }
 
+func (fake *FakeImagesReaderWriter) WriteLayer(arg1 name.Repository, arg2 v1.Layer) error {
+	fake.writeLayerMutex.Lock()
+	ret, specificReturn := fake.writeLayerReturnsOnCall[len(fake.writeLayerArgsForCall)]
+	fake.writeLayerArgsForCall = append(fake.writeLayerArgsForCall, struct {
+		arg1 name.Repository
+		arg2 v1.Layer
+	}{arg1, arg2})
+	stub := fake.WriteLayerStub
+	fakeReturns := fake.writeLayerReturns
+	fake.recordInvocation("WriteLayer", []interface{}{arg1, arg2})
+	fake.writeLayerMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeImagesReaderWriter) WriteLayerCallCount() int {
+	fake.writeLayerMutex.RLock()
+	defer fake.writeLayerMutex.RUnlock()
+	return len(fake.writeLayerArgsForCall)
+}
+
+func (fake *FakeImagesReaderWriter) WriteLayerCalls(stub func(name.Repository, v1.Layer) error) {
+	fake.writeLayerMutex.Lock()
+	defer fake.writeLayerMutex.Unlock()
+	fake.WriteLayerStub = stub
+}
+
+func (fake *FakeImagesReaderWriter) WriteLayerArgsForCall(i int) (name.Repository, v1.Layer) {
+	fake.writeLayerMutex.RLock()
+	defer fake.writeLayerMutex.RUnlock()
+	argsForCall := fake.writeLayerArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeImagesReaderWriter) WriteLayerReturns(result1 error) {
+	fake.writeLayerMutex.Lock()
+	defer fake.writeLayerMutex.Unlock()
+	fake.WriteLayerStub = nil
+	fake.writeLayerReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeImagesReaderWriter) WriteLayerReturnsOnCall(i int, result1 error) {
+	fake.writeLayerMutex.Lock()
+	defer fake.writeLayerMutex.Unlock()
+	fake.WriteLayerStub = nil
+	if fake.writeLayerReturnsOnCall == nil {
+		fake.writeLayerReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.writeLayerReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeImagesReaderWriter) WriteTag(arg1 name.Tag, arg2 remote.Taggable) error {
 	fake.writeTagMutex.Lock()
 	ret, specificReturn := fake.writeTagReturnsOnCall[len(fake.writeTagArgsForCall)]
@@ -801,6 +875,8 @@ func (fake *FakeImagesReaderWriter) Invocations() map[string][][]interface{} {
 	defer fake.writeImageMutex.RUnlock()
 	fake.writeIndexMutex.RLock()
 	defer fake.writeIndexMutex.RUnlock()
+	fake.writeLayerMutex.RLock()
+	defer fake.writeLayerMutex.RUnlock()
 	fake.writeTagMutex.RLock()
 	defer fake.writeTagMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -0,0 +1,135 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTLSHostPolicy(t *testing.T) {
+	t.Run("returns nil when no per-host overrides are given", func(t *testing.T) {
+		policy, err := newTLSHostPolicy(nil, nil, nil)
+		require.NoError(t, err)
+		assert.Nil(t, policy)
+	})
+
+	t.Run("errors on malformed pinned certificate entries", func(t *testing.T) {
+		_, err := newTLSHostPolicy(nil, nil, []string{"no-separator"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected pinned certificate to be in 'host=path' format, got 'no-separator'")
+	})
+
+	t.Run("errors when the pinned certificate cannot be read", func(t *testing.T) {
+		_, err := newTLSHostPolicy(nil, nil, []string{"registry.corp=/does/not/exist"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Reading pinned certificate from '/does/not/exist'")
+	})
+}
+
+func TestTLSHostPolicyScopesSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	serverHost := server.Listener.Addr().String()
+
+	t.Run("skips verification only for the listed host", func(t *testing.T) {
+		tran, err := newHTTPTransport(Opts{VerifyCerts: true, InsecureSkipVerifyHosts: []string{serverHost}})
+		require.NoError(t, err)
+
+		client := &http.Client{Transport: tran}
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	})
+
+	t.Run("other hosts still fail verification", func(t *testing.T) {
+		tran, err := newHTTPTransport(Opts{VerifyCerts: true, InsecureSkipVerifyHosts: []string{"other.corp"}})
+		require.NoError(t, err)
+
+		client := &http.Client{Transport: tran}
+		_, err = client.Get(server.URL) //nolint:bodyclose
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "certificate")
+	})
+}
+
+func TestTLSHostPolicyPinnedCert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	serverHost := server.Listener.Addr().String()
+	serverCert := server.Certificate()
+
+	certPath := filepath.Join(t.TempDir(), "server.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: serverCert.Raw})
+	require.NoError(t, os.WriteFile(certPath, pemBytes, 0600))
+
+	t.Run("accepts the host presenting the pinned public key", func(t *testing.T) {
+		tran, err := newHTTPTransport(Opts{VerifyCerts: true, PinnedCerts: []string{serverHost + "=" + certPath}})
+		require.NoError(t, err)
+
+		client := &http.Client{Transport: tran}
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	})
+
+	t.Run("rejects the host when the pin does not match", func(t *testing.T) {
+		// every httptest TLS server shares one baked-in key pair, so a cert
+		// with a different key has to be generated by hand
+		otherCert := generateTestCertificate(t)
+
+		otherPath := filepath.Join(t.TempDir(), "other.pem")
+		otherPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: otherCert.Raw})
+		require.NoError(t, os.WriteFile(otherPath, otherPem, 0600))
+
+		tran, err := newHTTPTransport(Opts{VerifyCerts: true, PinnedCerts: []string{serverHost + "=" + otherPath}})
+		require.NoError(t, err)
+
+		client := &http.Client{Transport: tran}
+		_, err = client.Get(server.URL) //nolint:bodyclose
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match the pinned public key")
+	})
+}
+
+func generateTestCertificate(t *testing.T) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "other.corp"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
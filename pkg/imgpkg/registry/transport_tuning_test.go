@@ -0,0 +1,37 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPTransportTuning(t *testing.T) {
+	t.Run("applies high-throughput defaults when no overrides are provided", func(t *testing.T) {
+		tran, err := newHTTPTransport(Opts{})
+		require.NoError(t, err)
+
+		assert.Equal(t, DefaultMaxIdleConnsPerHost, tran.MaxIdleConnsPerHost)
+		assert.GreaterOrEqual(t, tran.MaxIdleConns, DefaultMaxIdleConnsPerHost)
+		assert.False(t, tran.ForceAttemptHTTP2)
+		assert.NotNil(t, tran.DialContext)
+	})
+
+	t.Run("honors overrides", func(t *testing.T) {
+		tran, err := newHTTPTransport(Opts{
+			MaxIdleConnsPerHost: 7,
+			KeepAlive:           10 * time.Second,
+			DialTimeout:         5 * time.Second,
+			EnableHTTP2:         true,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, 7, tran.MaxIdleConnsPerHost)
+		assert.True(t, tran.ForceAttemptHTTP2)
+	})
+}
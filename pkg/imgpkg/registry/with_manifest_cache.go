@@ -0,0 +1,233 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"sync"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+)
+
+var _ Registry = &WithManifestCache{}
+
+// NewRegistryWithManifestCache wraps reg, caching manifest fetches for digest
+// references for the duration of a single invocation. Digest contents are
+// immutable, so reads of images referenced multiple times (e.g. by several
+// nested bundles) only hit the registry once
+func NewRegistryWithManifestCache(reg Registry, logger util.UIWithLevels) *WithManifestCache {
+	return &WithManifestCache{
+		delegate:    reg,
+		logger:      logger,
+		descriptors: map[string]*remote.Descriptor{},
+		images:      map[string]regv1.Image{},
+		indexes:     map[string]regv1.ImageIndex{},
+		digests:     map[string]regv1.Hash{},
+	}
+}
+
+// WithManifestCache Implements Registry interface, deduplicating manifest
+// reads by digest
+type WithManifestCache struct {
+	delegate Registry
+	logger   util.UIWithLevels
+
+	lock        sync.Mutex
+	hits        int
+	misses      int
+	descriptors map[string]*remote.Descriptor
+	images      map[string]regv1.Image
+	indexes     map[string]regv1.ImageIndex
+	digests     map[string]regv1.Hash
+}
+
+// cacheKey returns a cache key for digest references. Tag references are not
+// cached since what they point at can change
+func cacheKey(reference regname.Reference) (string, bool) {
+	if digest, ok := reference.(regname.Digest); ok {
+		return digest.Name(), true
+	}
+	return "", false
+}
+
+// recordLookup updates the hit/miss counters and reports them
+func (c *WithManifestCache) recordLookup(key string, hit bool) {
+	c.lock.Lock()
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	hits, misses := c.hits, c.misses
+	c.lock.Unlock()
+
+	outcome := "miss"
+	if hit {
+		outcome = "hit"
+	}
+	c.logger.Debugf("Manifest cache %s for '%s' (hits: %d, misses: %d)\n", outcome, key, hits, misses)
+}
+
+// Get Retrieve Image descriptor for an Image reference
+func (c *WithManifestCache) Get(reference regname.Reference) (*remote.Descriptor, error) {
+	key, cacheable := cacheKey(reference)
+	if cacheable {
+		c.lock.Lock()
+		desc, found := c.descriptors[key]
+		c.lock.Unlock()
+		c.recordLookup(key, found)
+		if found {
+			return desc, nil
+		}
+	}
+
+	desc, err := c.delegate.Get(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		c.lock.Lock()
+		c.descriptors[key] = desc
+		c.lock.Unlock()
+	}
+	return desc, nil
+}
+
+// Digest Retrieve the Digest for an Image reference. Since Digest doubles as
+// an existence check, a lookup is only cached after it succeeded
+func (c *WithManifestCache) Digest(reference regname.Reference) (regv1.Hash, error) {
+	key, cacheable := cacheKey(reference)
+	if cacheable {
+		c.lock.Lock()
+		digest, found := c.digests[key]
+		c.lock.Unlock()
+		c.recordLookup(key, found)
+		if found {
+			return digest, nil
+		}
+	}
+
+	digest, err := c.delegate.Digest(reference)
+	if err != nil {
+		return regv1.Hash{}, err
+	}
+
+	if cacheable {
+		c.lock.Lock()
+		c.digests[key] = digest
+		c.lock.Unlock()
+	}
+	return digest, nil
+}
+
+// Index Retrieve regv1.ImageIndex struct for an Index reference
+func (c *WithManifestCache) Index(reference regname.Reference) (regv1.ImageIndex, error) {
+	key, cacheable := cacheKey(reference)
+	if cacheable {
+		c.lock.Lock()
+		index, found := c.indexes[key]
+		c.lock.Unlock()
+		c.recordLookup(key, found)
+		if found {
+			return index, nil
+		}
+	}
+
+	index, err := c.delegate.Index(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		c.lock.Lock()
+		c.indexes[key] = index
+		c.lock.Unlock()
+	}
+	return index, nil
+}
+
+// Image Retrieve the regv1.Image struct for an Image reference
+func (c *WithManifestCache) Image(reference regname.Reference) (regv1.Image, error) {
+	key, cacheable := cacheKey(reference)
+	if cacheable {
+		c.lock.Lock()
+		image, found := c.images[key]
+		c.lock.Unlock()
+		c.recordLookup(key, found)
+		if found {
+			return image, nil
+		}
+	}
+
+	image, err := c.delegate.Image(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		c.lock.Lock()
+		c.images[key] = image
+		c.lock.Unlock()
+	}
+	return image, nil
+}
+
+// FirstImageExists Returns the first of the provided Image Digests that exists in the Registry
+func (c *WithManifestCache) FirstImageExists(digests []string) (string, error) {
+	return c.delegate.FirstImageExists(digests)
+}
+
+// MultiWrite Upload multiple Images in Parallel to the Registry
+func (c *WithManifestCache) MultiWrite(imageOrIndexesToUpload map[regname.Reference]remote.Taggable, concurrency int, updatesCh chan regv1.Update) error {
+	return c.delegate.MultiWrite(imageOrIndexesToUpload, concurrency, updatesCh)
+}
+
+// WriteImage Upload Image to registry
+func (c *WithManifestCache) WriteImage(reference regname.Reference, image regv1.Image) error {
+	return c.delegate.WriteImage(reference, image)
+}
+
+// WriteIndex Uploads the Index manifest to the registry
+func (c *WithManifestCache) WriteIndex(reference regname.Reference, index regv1.ImageIndex) error {
+	return c.delegate.WriteIndex(reference, index)
+}
+
+// WriteTag Tag the referenced Image
+func (c *WithManifestCache) WriteTag(tag regname.Tag, taggable remote.Taggable) error {
+	return c.delegate.WriteTag(tag, taggable)
+}
+
+// WriteLayer Upload a layer blob to a repository in the registry
+func (c *WithManifestCache) WriteLayer(repo regname.Repository, layer regv1.Layer) error {
+	return c.delegate.WriteLayer(repo, layer)
+}
+
+// ListTags Retrieve all tags associated with a Repository
+func (c *WithManifestCache) ListTags(repo regname.Repository) ([]string, error) {
+	return c.delegate.ListTags(repo)
+}
+
+// CatalogRepositories Retrieve the names of every repository hosted in a registry
+func (c *WithManifestCache) CatalogRepositories(registryHost string) ([]string, error) {
+	return c.delegate.CatalogRepositories(registryHost)
+}
+
+// Delete Removes the manifest that the reference points at from the registry
+func (c *WithManifestCache) Delete(ref regname.Reference) error {
+	return c.delegate.Delete(ref)
+}
+
+// CloneWithSingleAuth Clones the provided registry replacing the Keychain with a Keychain that can only authenticate
+// the image provided. The clone starts with an empty cache
+func (c *WithManifestCache) CloneWithSingleAuth(imageRef regname.Tag) (Registry, error) {
+	delegate, err := c.delegate.CloneWithSingleAuth(imageRef)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRegistryWithManifestCache(delegate, c.logger), nil
+}
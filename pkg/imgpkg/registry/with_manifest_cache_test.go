@@ -0,0 +1,74 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry_test
+
+import (
+	"testing"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+// countingRegistry Registry stub that counts manifest reads
+type countingRegistry struct {
+	registry.Registry
+
+	getCalls    int
+	imageCalls  int
+	digestCalls int
+}
+
+func (c *countingRegistry) Get(regname.Reference) (*remote.Descriptor, error) {
+	c.getCalls++
+	return &remote.Descriptor{}, nil
+}
+
+func (c *countingRegistry) Image(regname.Reference) (regv1.Image, error) {
+	c.imageCalls++
+	return nil, nil
+}
+
+func (c *countingRegistry) Digest(regname.Reference) (regv1.Hash, error) {
+	c.digestCalls++
+	return regv1.Hash{Algorithm: "sha256", Hex: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}, nil
+}
+
+func TestManifestCacheDeduplicatesDigestReads(t *testing.T) {
+	delegate := &countingRegistry{}
+	subject := registry.NewRegistryWithManifestCache(delegate, util.NewUILevelLogger(util.LogWarn, goui.NewNoopUI()))
+
+	digestRef, err := regname.NewDigest("registry.example.com/repo@sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := subject.Get(digestRef)
+		require.NoError(t, err)
+		_, err = subject.Digest(digestRef)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, delegate.getCalls)
+	assert.Equal(t, 1, delegate.digestCalls)
+}
+
+func TestManifestCacheDoesNotCacheTagReads(t *testing.T) {
+	delegate := &countingRegistry{}
+	subject := registry.NewRegistryWithManifestCache(delegate, util.NewUILevelLogger(util.LogWarn, goui.NewNoopUI()))
+
+	tagRef, err := regname.NewTag("registry.example.com/repo:latest")
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := subject.Image(tagRef)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, delegate.imageCalls)
+}
@@ -71,11 +71,26 @@ func (w *WithProgress) WriteTag(tag regname.Tag, taggable remote.Taggable) error
 	return w.delegate.WriteTag(tag, taggable)
 }
 
+// WriteLayer Upload a layer blob to a repository in the registry
+func (w *WithProgress) WriteLayer(repo regname.Repository, layer regv1.Layer) error {
+	return w.delegate.WriteLayer(repo, layer)
+}
+
 // ListTags Retrieve all tags associated with a Repository
 func (w *WithProgress) ListTags(repo regname.Repository) ([]string, error) {
 	return w.delegate.ListTags(repo)
 }
 
+// CatalogRepositories Retrieve the names of every repository hosted in a registry
+func (w *WithProgress) CatalogRepositories(registryHost string) ([]string, error) {
+	return w.delegate.CatalogRepositories(registryHost)
+}
+
+// Delete Removes the manifest that the reference points at from the registry
+func (w *WithProgress) Delete(ref regname.Reference) error {
+	return w.delegate.Delete(ref)
+}
+
 // CloneWithSingleAuth Clones the provided registry replacing the Keychain with a Keychain that can only authenticate
 // the image provided
 // A Registry need to be provided as the first parameter or the function will panic
@@ -0,0 +1,223 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package rekor implements a minimal client for the Rekor transparency log
+// API, covering the lookups needed to verify that signed material for a
+// digest was recorded in the log
+package rekor
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// DefaultURL public good instance of Rekor operated by the Sigstore project
+const DefaultURL = "https://rekor.sigstore.dev"
+
+// certIssuerExtensionOID x509 extension recording the OIDC issuer that
+// authenticated the certificate identity (Fulcio)
+var certIssuerExtensionOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+
+// Client talks to a single Rekor instance
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// Entry a single transparency log entry relevant to a searched hash
+type Entry struct {
+	UUID           string
+	LogIndex       int64
+	IntegratedTime time.Time
+
+	// Certificates signing certificates recorded with the entry, when the
+	// entry was created with certificate based signing (ex: cosign keyless)
+	Certificates []*x509.Certificate
+}
+
+// NewClient constructor for a Client against the given Rekor base URL
+func NewClient(baseURL string) Client {
+	return Client{baseURL: baseURL, httpClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// SearchByHash returns the UUIDs of every log entry referencing the given
+// digest (format: sha256:...)
+func (c Client) SearchByHash(digest string) ([]string, error) {
+	reqBody, err := json.Marshal(map[string]string{"hash": digest})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Post(c.baseURL+"/api/v1/index/retrieve", "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("Searching Rekor for '%s': %s", digest, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Searching Rekor for '%s': unexpected status %d", digest, resp.StatusCode)
+	}
+
+	var uuids []string
+	err = json.NewDecoder(resp.Body).Decode(&uuids)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing Rekor search response: %s", err)
+	}
+
+	return uuids, nil
+}
+
+// GetEntry fetches and parses a single log entry by UUID
+func (c Client) GetEntry(uuid string) (Entry, error) {
+	resp, err := c.httpClient.Get(c.baseURL + "/api/v1/log/entries/" + uuid)
+	if err != nil {
+		return Entry{}, fmt.Errorf("Fetching Rekor entry '%s': %s", uuid, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Entry{}, fmt.Errorf("Fetching Rekor entry '%s': unexpected status %d", uuid, resp.StatusCode)
+	}
+
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return parseEntry(uuid, bs)
+}
+
+// parseEntry decodes the log entry response (a map keyed by UUID) and
+// extracts the signing certificates embedded in the entry body
+func parseEntry(uuid string, bs []byte) (Entry, error) {
+	var entries map[string]struct {
+		Body           string `json:"body"`
+		IntegratedTime int64  `json:"integratedTime"`
+		LogIndex       int64  `json:"logIndex"`
+	}
+	err := json.Unmarshal(bs, &entries)
+	if err != nil {
+		return Entry{}, fmt.Errorf("Parsing Rekor entry '%s': %s", uuid, err)
+	}
+
+	rawEntry, found := entries[uuid]
+	if !found {
+		// some deployments key the response by the full entry ID; take the
+		// single entry present
+		for _, e := range entries {
+			rawEntry = e
+			found = true
+			break
+		}
+	}
+	if !found {
+		return Entry{}, fmt.Errorf("Parsing Rekor entry '%s': entry not present in response", uuid)
+	}
+
+	entry := Entry{
+		UUID:           uuid,
+		LogIndex:       rawEntry.LogIndex,
+		IntegratedTime: time.Unix(rawEntry.IntegratedTime, 0).UTC(),
+	}
+
+	bodyBytes, err := base64.StdEncoding.DecodeString(rawEntry.Body)
+	if err != nil {
+		return Entry{}, fmt.Errorf("Decoding Rekor entry '%s' body: %s", uuid, err)
+	}
+
+	var body struct {
+		Spec struct {
+			Signature struct {
+				PublicKey struct {
+					Content string `json:"content"`
+				} `json:"publicKey"`
+			} `json:"signature"`
+		} `json:"spec"`
+	}
+	err = json.Unmarshal(bodyBytes, &body)
+	if err != nil {
+		return Entry{}, fmt.Errorf("Parsing Rekor entry '%s' body: %s", uuid, err)
+	}
+
+	if body.Spec.Signature.PublicKey.Content != "" {
+		keyBytes, err := base64.StdEncoding.DecodeString(body.Spec.Signature.PublicKey.Content)
+		if err != nil {
+			return Entry{}, fmt.Errorf("Decoding Rekor entry '%s' public key: %s", uuid, err)
+		}
+		entry.Certificates = parseCertificates(keyBytes)
+	}
+
+	return entry, nil
+}
+
+// parseCertificates extracts every x509 certificate from the PEM blocks in
+// bs; plain public keys (non certificate signing) yield no certificates
+func parseCertificates(bs []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		block, rest := pem.Decode(bs)
+		if block == nil {
+			break
+		}
+		bs = rest
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, cert)
+	}
+	return certs
+}
+
+// MatchesIdentity checks if any certificate in the entry was issued to the
+// given identity (an email address or URI subject alternative name),
+// optionally constrained to the given OIDC issuer
+func (e Entry) MatchesIdentity(identity string, issuer string) bool {
+	for _, cert := range e.Certificates {
+		if !certHasIdentity(cert, identity) {
+			continue
+		}
+		if issuer != "" && certIssuer(cert) != issuer {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func certHasIdentity(cert *x509.Certificate, identity string) bool {
+	for _, email := range cert.EmailAddresses {
+		if email == identity {
+			return true
+		}
+	}
+	for _, uri := range cert.URIs {
+		if uri.String() == identity {
+			return true
+		}
+	}
+	return false
+}
+
+// certIssuer returns the OIDC issuer recorded by Fulcio in the certificate,
+// or an empty string when the extension is not present
+func certIssuer(cert *x509.Certificate) string {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(certIssuerExtensionOID) {
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package rekor_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/rekor"
+)
+
+func TestSearchByHash(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/index/retrieve", r.URL.Path)
+		require.Equal(t, http.MethodPost, r.Method)
+
+		bs, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(bs), "sha256:aaaa")
+
+		w.Write([]byte(`["uuid-1", "uuid-2"]`))
+	}))
+	defer server.Close()
+
+	uuids, err := rekor.NewClient(server.URL).SearchByHash("sha256:aaaa")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"uuid-1", "uuid-2"}, uuids)
+}
+
+func TestSearchByHashUnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := rekor.NewClient(server.URL).SearchByHash("sha256:aaaa")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unexpected status 500")
+}
+
+func TestGetEntry(t *testing.T) {
+	certPEM, _ := generateTestCert(t, "dev@example.com", "https://issuer.example.com")
+
+	body := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"signature": map[string]interface{}{
+				"publicKey": map[string]interface{}{
+					"content": base64.StdEncoding.EncodeToString(certPEM),
+				},
+			},
+		},
+	}
+	bodyBytes, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/log/entries/uuid-1", r.URL.Path)
+
+		resp := map[string]interface{}{
+			"uuid-1": map[string]interface{}{
+				"body":           base64.StdEncoding.EncodeToString(bodyBytes),
+				"integratedTime": 1650000000,
+				"logIndex":       42,
+			},
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer server.Close()
+
+	entry, err := rekor.NewClient(server.URL).GetEntry("uuid-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "uuid-1", entry.UUID)
+	assert.Equal(t, int64(42), entry.LogIndex)
+	assert.Equal(t, time.Unix(1650000000, 0).UTC(), entry.IntegratedTime)
+	require.Len(t, entry.Certificates, 1)
+	assert.Equal(t, []string{"dev@example.com"}, entry.Certificates[0].EmailAddresses)
+}
+
+func TestMatchesIdentity(t *testing.T) {
+	_, cert := generateTestCert(t, "dev@example.com", "https://issuer.example.com")
+	entry := rekor.Entry{Certificates: []*x509.Certificate{cert}}
+
+	assert.True(t, entry.MatchesIdentity("dev@example.com", ""))
+	assert.True(t, entry.MatchesIdentity("dev@example.com", "https://issuer.example.com"))
+	assert.False(t, entry.MatchesIdentity("other@example.com", ""))
+	assert.False(t, entry.MatchesIdentity("dev@example.com", "https://other-issuer.example.com"))
+}
+
+func TestMatchesIdentityWithoutCertificates(t *testing.T) {
+	entry := rekor.Entry{}
+	assert.False(t, entry.MatchesIdentity("dev@example.com", ""))
+}
+
+// generateTestCert builds a self-signed certificate carrying the given email
+// identity and the Fulcio OIDC issuer extension
+func generateTestCert(t *testing.T, email string, issuer string) ([]byte, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber:   big.NewInt(1),
+		Subject:        pkix.Name{CommonName: "test"},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		EmailAddresses: []string{email},
+		ExtraExtensions: []pkix.Extension{{
+			Id:    asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1},
+			Value: []byte(issuer),
+		}},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), cert
+}
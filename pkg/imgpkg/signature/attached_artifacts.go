@@ -0,0 +1,110 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package signature
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"golang.org/x/sync/errgroup"
+)
+
+// attachedTagSuffixes tag suffixes probed for artifacts attached to an image:
+// cosign signatures, attestations and SBOMs plus the suffix-less referrers
+// fallback tag used by notation (Notary v2) and other ORAS based tools
+var attachedTagSuffixes = []string{".sig", ".att", ".sbom", ""}
+
+// AttachedArtifacts fetcher for artifacts attached to images via the tag
+// schemes understood by cosign and notation, so that mirrors interoperate
+// with clusters enforcing signature policies
+type AttachedArtifacts struct {
+	registry    DigestReader
+	concurrency int
+}
+
+// NewAttachedArtifacts constructs the attached artifacts fetcher
+func NewAttachedArtifacts(reg DigestReader, concurrency int) *AttachedArtifacts {
+	return &AttachedArtifacts{registry: reg, concurrency: concurrency}
+}
+
+// Fetch Retrieve the available attached artifacts associated with the images provided
+func (a *AttachedArtifacts) Fetch(images *imageset.UnprocessedImageRefs) (*imageset.UnprocessedImageRefs, error) {
+	artifacts := imageset.NewUnprocessedImageRefs()
+	var imgs []lockconfig.ImageRef
+	for _, ref := range images.All() {
+		imgs = append(imgs, lockconfig.ImageRef{
+			Image: ref.DigestRef,
+		})
+	}
+	imagesRefs, err := a.FetchForImageRefs(imgs)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range imagesRefs {
+		artifacts.Add(imageset.UnprocessedImageRef{
+			DigestRef: ref.Image,
+			Tag:       ref.Annotations["tag"],
+		})
+	}
+
+	return artifacts, nil
+}
+
+// FetchForImageRefs Retrieve the available attached artifacts associated with the images provided
+func (a *AttachedArtifacts) FetchForImageRefs(images []lockconfig.ImageRef) ([]lockconfig.ImageRef, error) {
+	lock := &sync.Mutex{}
+	var artifacts []lockconfig.ImageRef
+
+	throttle := util.NewThrottle(a.concurrency)
+	var wg errgroup.Group
+
+	for _, ref := range images {
+		ref := ref //copy
+		wg.Go(func() error {
+			imgDigest, err := name.NewDigest(ref.PrimaryLocation())
+			if err != nil {
+				return fmt.Errorf("Parsing '%s': %s", ref.Image, err)
+			}
+
+			for _, suffix := range attachedTagSuffixes {
+				tag := strings.ReplaceAll(imgDigest.DigestStr(), ":", "-") + suffix
+				tagRef, err := name.NewTag(imgDigest.Repository.Name() + ":" + tag)
+				if err != nil {
+					return fmt.Errorf("Building attached artifact tag for '%s': %s", imgDigest.Name(), err)
+				}
+
+				throttle.Take()
+				artifactDigest, err := a.registry.Digest(tagRef)
+				throttle.Done()
+				if err != nil {
+					if transportErr, ok := err.(*transport.Error); ok {
+						if transportErr.StatusCode == http.StatusNotFound {
+							continue
+						}
+					}
+					return fmt.Errorf("Fetching attached artifact '%s': %s", tagRef.Name(), err)
+				}
+
+				lock.Lock()
+				artifacts = append(artifacts, lockconfig.ImageRef{
+					Image:       imgDigest.Digest(artifactDigest.String()).Name(),
+					Annotations: map[string]string{"tag": tag},
+				})
+				lock.Unlock()
+			}
+			return nil
+		})
+	}
+
+	err := wg.Wait()
+
+	return artifacts, err
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package signature_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestAttachedArtifacts_Fetch(t *testing.T) {
+	t.Run("it finds cosign signature, attestation and notation referrer tags", func(t *testing.T) {
+		logger := &helpers.Logger{}
+		regBuilder := helpers.NewFakeRegistry(t, logger)
+		img := regBuilder.WithRandomImage("some-image")
+		digestHex := strings.Split(img.Digest, ":")[1]
+
+		sigImg := regBuilder.WithRandomImage("some-image")
+		sigImg.Tag = fmt.Sprintf("sha256-%s.sig", digestHex)
+		attImg := regBuilder.WithRandomImage("some-image")
+		attImg.Tag = fmt.Sprintf("sha256-%s.att", digestHex)
+		refImg := regBuilder.WithRandomImage("some-image")
+		refImg.Tag = fmt.Sprintf("sha256-%s", digestHex)
+
+		reg := regBuilder.Build()
+		defer regBuilder.CleanUp()
+
+		subject := signature.NewAttachedArtifacts(reg, 1)
+
+		images := imageset.NewUnprocessedImageRefs()
+		images.Add(imageset.UnprocessedImageRef{DigestRef: img.RefDigest})
+
+		artifacts, err := subject.Fetch(images)
+		require.NoError(t, err)
+		require.Len(t, artifacts.All(), 3)
+
+		digestByTag := map[string]string{}
+		for _, artifact := range artifacts.All() {
+			digestByTag[artifact.Tag] = artifact.DigestRef
+		}
+
+		assert.Contains(t, digestByTag[fmt.Sprintf("sha256-%s.sig", digestHex)], sigImg.Digest)
+		assert.Contains(t, digestByTag[fmt.Sprintf("sha256-%s.att", digestHex)], attImg.Digest)
+		assert.Contains(t, digestByTag[fmt.Sprintf("sha256-%s", digestHex)], refImg.Digest)
+	})
+
+	t.Run("it finds no artifacts when none of the tags exist", func(t *testing.T) {
+		logger := &helpers.Logger{}
+		regBuilder := helpers.NewFakeRegistry(t, logger)
+		img := regBuilder.WithRandomImage("some-image")
+		reg := regBuilder.Build()
+		defer regBuilder.CleanUp()
+
+		subject := signature.NewAttachedArtifacts(reg, 1)
+
+		images := imageset.NewUnprocessedImageRefs()
+		images.Add(imageset.UnprocessedImageRef{DigestRef: img.RefDigest})
+
+		artifacts, err := subject.Fetch(images)
+		require.NoError(t, err)
+		assert.Len(t, artifacts.All(), 0)
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tofu records the digests that references resolved to on first use,
+// so later operations can detect when a tag was silently repointed upstream
+package tofu
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/internal/util"
+)
+
+// EnvStorePath overrides the default trust store location when set
+const EnvStorePath = "IMGPKG_TOFU_PATH"
+
+// Store is a local trust-on-first-use digest store backed by a JSON file
+// mapping references to the digest they resolved to when first seen
+type Store struct {
+	path    string
+	digests map[string]string
+}
+
+// NewStore loads the trust store at path; a missing file yields an empty store
+func NewStore(path string) (*Store, error) {
+	store := &Store{path: path, digests: map[string]string{}}
+
+	bs, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Reading trust store '%s': %s", path, err)
+	}
+
+	err = json.Unmarshal(bs, &store.digests)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing trust store '%s': %s", path, err)
+	}
+	return store, nil
+}
+
+// DefaultPath returns the IMGPKG_TOFU_PATH env var when set, falling back to
+// .imgpkg/trusted-digests.json in the user's home directory
+func DefaultPath() (string, error) {
+	if path := os.Getenv(EnvStorePath); path != "" {
+		return path, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("Locating home directory for trust store: %s", err)
+	}
+	return filepath.Join(homeDir, ".imgpkg", "trusted-digests.json"), nil
+}
+
+// Path returns the location of the file backing the store
+func (s *Store) Path() string {
+	return s.path
+}
+
+// TrustedDigest returns the digest recorded for ref on first use, if any
+func (s *Store) TrustedDigest(ref string) (string, bool) {
+	digest, found := s.digests[ref]
+	return digest, found
+}
+
+// Record remembers the digest ref resolved to and persists the store. The
+// first recorded digest for a reference is kept; later calls are no-ops
+func (s *Store) Record(ref string, digest string) error {
+	if _, found := s.digests[ref]; found {
+		return nil
+	}
+	s.digests[ref] = digest
+
+	err := os.MkdirAll(filepath.Dir(s.path), 0700)
+	if err != nil {
+		return fmt.Errorf("Creating trust store directory: %s", err)
+	}
+
+	bs, err := json.MarshalIndent(s.digests, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = util.WriteFileAtomically(s.path, bs, 0600)
+	if err != nil {
+		return fmt.Errorf("Writing trust store '%s': %s", s.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2022 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package tofu
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreRecordsFirstDigestOnly(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "trusted-digests.json")
+
+	store, err := NewStore(storePath)
+	require.NoError(t, err)
+
+	_, found := store.TrustedDigest("repo.io/img:v1")
+	assert.False(t, found)
+
+	require.NoError(t, store.Record("repo.io/img:v1", "sha256:aaaa"))
+	require.NoError(t, store.Record("repo.io/img:v1", "sha256:bbbb"))
+
+	digest, found := store.TrustedDigest("repo.io/img:v1")
+	assert.True(t, found)
+	assert.Equal(t, "sha256:aaaa", digest)
+
+	// A reloaded store sees the persisted entry
+	store, err = NewStore(storePath)
+	require.NoError(t, err)
+
+	digest, found = store.TrustedDigest("repo.io/img:v1")
+	assert.True(t, found)
+	assert.Equal(t, "sha256:aaaa", digest)
+}
+
+func TestDefaultPathHonorsEnvOverride(t *testing.T) {
+	t.Setenv(EnvStorePath, "/some/store.json")
+
+	path, err := DefaultPath()
+	require.NoError(t, err)
+	assert.Equal(t, "/some/store.json", path)
+}
@@ -21,7 +21,7 @@ import (
 )
 
 type Assertion struct {
-	T                    *testing.T
+	T                    testing.TB
 	logger               *Logger
 	signatureKeyLocation string
 }
@@ -14,7 +14,7 @@ import (
 )
 
 type Assets struct {
-	T              *testing.T
+	T              testing.TB
 	CreatedFolders []string
 }
 
@@ -14,11 +14,11 @@ import (
 
 type BundleFactory struct {
 	assets       *Assets
-	t            *testing.T
+	t            testing.TB
 	bundleFolder string
 }
 
-func NewBundleDir(t *testing.T, assets *Assets) BundleFactory {
+func NewBundleDir(t testing.TB, assets *Assets) BundleFactory {
 	return BundleFactory{assets: assets, t: t}
 }
 
@@ -24,7 +24,7 @@ type Env struct {
 	cleanupFuncs   []func()
 }
 
-func BuildEnv(t *testing.T) *Env {
+func BuildEnv(t testing.TB) *Env {
 	t.Helper()
 	imgpkgPath := os.Getenv("IMGPKG_BINARY")
 	if imgpkgPath == "" {
@@ -56,7 +56,7 @@ func BuildEnv(t *testing.T) *Env {
 	return &env
 }
 
-func (e *Env) UpdateT(t *testing.T) {
+func (e *Env) UpdateT(t testing.TB) {
 	e.BundleFactory.t = t
 	e.Assert.T = t
 	e.Assets.T = t
@@ -74,7 +74,7 @@ func (e *Env) Cleanup() {
 	}
 }
 
-func (e Env) Validate(t *testing.T) {
+func (e Env) Validate(t testing.TB) {
 	t.Helper()
 	var errStrs []string
 
@@ -39,13 +39,13 @@ import (
 type FakeTestRegistryBuilder struct {
 	images          map[string]*ImageOrImageIndexWithTarPath
 	server          *httptest.Server
-	t               *testing.T
+	t               testing.TB
 	auth            authn.Authenticator
 	logger          *Logger
 	originalHandler http.Handler
 }
 
-func NewFakeRegistry(t *testing.T, logger *Logger) *FakeTestRegistryBuilder {
+func NewFakeRegistry(t testing.TB, logger *Logger) *FakeTestRegistryBuilder {
 	r := &FakeTestRegistryBuilder{images: map[string]*ImageOrImageIndexWithTarPath{}, t: t, logger: logger}
 	r.server = httptest.NewServer(regregistry.New(regregistry.Logger(log.New(io.Discard, "", 0))))
 
@@ -693,7 +693,7 @@ type ImageOrImageIndexWithTarPath struct {
 	Image        v1.Image
 	ImageIndex   v1.ImageIndex
 	path         string
-	t            *testing.T
+	t            testing.TB
 	RefDigest    string
 	Digest       string
 	Tag          string
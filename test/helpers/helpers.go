@@ -13,7 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func CompareFiles(t *testing.T, path1, path2 string) {
+func CompareFiles(t testing.TB, path1, path2 string) {
 	t.Helper()
 	path1Bs, err := ioutil.ReadFile(path1)
 	require.NoError(t, err, "reading path1")
@@ -42,7 +42,7 @@ kind: ImagesLock
 const ImageFile = "images.yml"
 const BundleFile = "bundle.yml"
 
-func ExtractDigest(t *testing.T, out string) string {
+func ExtractDigest(t testing.TB, out string) string {
 	t.Helper()
 	match := regexp.MustCompile("@(sha256:[0123456789abcdef]{64})").FindStringSubmatch(out)
 	require.Len(t, match, 2)
@@ -27,7 +27,7 @@ import (
 
 type ImageFactory struct {
 	Assets               *Assets
-	T                    *testing.T
+	T                    testing.TB
 	signatureKeyLocation string
 	logger               *Logger
 }
@@ -14,7 +14,7 @@ import (
 )
 
 type Imgpkg struct {
-	T          *testing.T
+	T          testing.TB
 	L          Logger
 	ImgpkgPath string
 }